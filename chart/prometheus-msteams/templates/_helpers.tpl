@@ -80,12 +80,8 @@ Create the card template to use.
 {{- define "app.customTemplates.config" -}}
 {{- $result := list -}}
 {{- range $i, $c := . -}}
-{{- $d := (dict 
-  "request_path" $c.request_path 
-  "template_file" (printf "/etc/template/custom_card_%d.tmpl" $i) 
-  "webhook_url" $c.webhook_url "escape_underscores"
-  (hasKey $c "escape_underscores" | ternary $c.escape_underscores nil)
-  ) -}}
+{{- /* Pass every connector option through, only the template file is remapped to the mounted path. */ -}}
+{{- $d := merge (dict "template_file" (printf "/etc/template/custom_card_%d.tmpl" $i)) (omit $c "template_file") -}}
 {{- $result = append $result $d -}}
 {{- end }}
 {{- $result | toYaml -}}
@@ -0,0 +1,240 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// goField is a JSON field of a struct in the checked package.
+type goField struct {
+	name    string
+	version string
+	kind    string
+}
+
+// goPackage is the subset of a Go package needed to compare it with the
+// schema: its structs, its other named types and the types passed to
+// RegisterType.
+type goPackage struct {
+	structs    map[string]*ast.StructType
+	types      map[string]ast.Expr // non-struct type name -> its definition
+	registered map[string]string   // "type" value -> Go type name
+}
+
+func parsePackage(dir string) (*goPackage, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	p := &goPackage{
+		structs:    map[string]*ast.StructType{},
+		types:      map[string]ast.Expr{},
+		registered: map[string]string{},
+	}
+	fset := token.NewFileSet()
+	for _, f := range files {
+		if strings.HasSuffix(f, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, f, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.TypeSpec:
+				if st, ok := n.Type.(*ast.StructType); ok {
+					p.structs[n.Name.Name] = st
+				} else {
+					p.types[n.Name.Name] = n.Type
+				}
+			case *ast.CallExpr:
+				p.inspectRegisterType(n)
+			}
+			return true
+		})
+	}
+	return p, nil
+}
+
+// inspectRegisterType records calls of the form RegisterType("X", T{}).
+func (p *goPackage) inspectRegisterType(call *ast.CallExpr) {
+	fn, ok := call.Fun.(*ast.Ident)
+	if !ok || fn.Name != "RegisterType" || len(call.Args) != 2 {
+		return
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return
+	}
+	comp, ok := call.Args[1].(*ast.CompositeLit)
+	if !ok {
+		return
+	}
+	ident, ok := comp.Type.(*ast.Ident)
+	if !ok {
+		return
+	}
+	name, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return
+	}
+	p.registered[name] = ident.Name
+}
+
+// fields returns the JSON fields of a struct, including those of embedded
+// structs.
+func (p *goPackage) fields(name string) map[string]goField {
+	out := map[string]goField{}
+	st, ok := p.structs[name]
+	if !ok {
+		return out
+	}
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			embedded := f.Type
+			if star, ok := embedded.(*ast.StarExpr); ok {
+				embedded = star.X
+			}
+			if ident, ok := embedded.(*ast.Ident); ok {
+				for k, v := range p.fields(ident.Name) {
+					out[k] = v
+				}
+			}
+			continue
+		}
+		if f.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tag := reflect.StructTag(raw)
+		jsonName, _, _ := strings.Cut(tag.Get("json"), ",")
+		if jsonName == "" || jsonName == "-" {
+			continue
+		}
+		out[jsonName] = goField{name: jsonName, version: tag.Get("version"), kind: p.kind(f.Type, nil)}
+	}
+	return out
+}
+
+// The JSON kinds compared by the check. kindAny matches every kind.
+const (
+	kindAny    = "any"
+	kindObject = "an object"
+	kindArray  = "an array"
+	kindString = "a string"
+	kindBool   = "a boolean"
+	kindNumber = "a number"
+)
+
+// kind returns the JSON kind of the values of the Go type expr. seen guards
+// against recursive type definitions.
+func (p *goPackage) kind(expr ast.Expr, seen map[string]bool) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return p.kind(t.X, seen)
+	case *ast.ArrayType:
+		return kindArray
+	case *ast.MapType, *ast.StructType:
+		return kindObject
+	case *ast.Ident:
+		switch t.Name {
+		case "string":
+			return kindString
+		case "bool":
+			return kindBool
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64":
+			return kindNumber
+		}
+		if _, ok := p.structs[t.Name]; ok {
+			return kindObject
+		}
+		if def, ok := p.types[t.Name]; ok && !seen[t.Name] {
+			if seen == nil {
+				seen = map[string]bool{}
+			}
+			seen[t.Name] = true
+			return p.kind(def, seen)
+		}
+	}
+	// Interfaces, any and the types of other packages, such as
+	// json.RawMessage, may hold any kind.
+	return kindAny
+}
+
+// checkPackage compares the schema with the Go package in dir and returns
+// one line per difference, in a stable order.
+func checkPackage(s *schemaSet, dir string) ([]string, error) {
+	pkg, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	m := newModel(s)
+
+	var drift []string
+	for _, name := range sortedKeys(s.defs) {
+		if m.bases[name] || strings.HasPrefix(name, implementationsOf) || m.enums[name] != nil {
+			continue
+		}
+
+		goName := goIdentifier(name)
+		if typeName, ok := m.typed[name]; ok {
+			registered, ok := pkg.registered[typeName]
+			if !ok {
+				drift = append(drift, fmt.Sprintf("%s: type is not registered (since %s)", name, orUnknown(s.since[name])))
+				continue
+			}
+			goName = registered
+		}
+		if _, ok := pkg.structs[goName]; !ok {
+			drift = append(drift, fmt.Sprintf("%s: no Go struct %s (since %s)", name, goName, orUnknown(s.since[name])))
+			continue
+		}
+
+		have := pkg.fields(goName)
+		for _, f := range m.fields(name) {
+			got, ok := have[f.jsonName]
+			switch {
+			case !ok:
+				drift = append(drift, fmt.Sprintf("%s.%s: missing from %s (since %s)", name, f.jsonName, goName, orUnknown(f.version)))
+			case got.version != "" && f.version != "" && got.version != f.version:
+				drift = append(drift, fmt.Sprintf("%s.%s: version tag %s, schema says %s", name, f.jsonName, got.version, f.version))
+			case got.kind != kindAny && f.kind != kindAny && got.kind != f.kind:
+				drift = append(drift, fmt.Sprintf("%s.%s: %s in %s, schema says %s", name, f.jsonName, got.kind, goName, f.kind))
+			}
+		}
+	}
+	return drift, nil
+}
+
+func orUnknown(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	return v
+}
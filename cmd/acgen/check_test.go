@@ -0,0 +1,46 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPackage(t *testing.T) {
+	s, err := loadSchemas("testdata/schemas/*.json")
+	require.NoError(t, err)
+
+	drift, err := checkPackage(s, "testdata/pkg")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Container: type is not registered (since 1.2)",
+		"FactSet.isVisible: version tag 1.3, schema says 1.2",
+		"TextBlock.isVisible: version tag 1.3, schema says 1.2",
+		"TextBlock.maxLines: missing from TextBlock (since 1.2)",
+		"TextBlock.wrap: a string in TextBlock, schema says a boolean",
+	}, drift)
+}
+
+func TestRun(t *testing.T) {
+	assert.ErrorContains(t, run(nil), "expected a command")
+	assert.ErrorContains(t, run([]string{"icons"}), "-in is required")
+	assert.ErrorContains(t, run([]string{"nope", "-in", "x"}), "unknown command")
+	assert.ErrorContains(t, run([]string{"check", "-in", "testdata/schemas/*.json", "-pkg", "testdata/pkg"}), "5 differences")
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+)
+
+// generateIcons emits the Symbol type and one constant per icon name read
+// from r. Each non-empty line not starting with '#' holds an icon name and
+// an optional Go identifier suffix, for names that do not follow the
+// "Symbol" + capitalised name rule (e.g. "CardUi CardUI").
+func generateIcons(r io.Reader, pkgName string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	fmt.Fprintf(&buf, `
+// Code generated by acgen. DO NOT EDIT.
+
+// Package %[1]s provides Go structs and helper functions for working
+// with Microsoft Adaptive Cards.
+package %[1]s
+
+// Symbol represents an icon symbol that can be used in Microsoft Teams adaptive cards.
+type Symbol string
+
+// Symbol represents an icon symbol that can be used in Microsoft Teams adaptive cards.
+const (
+`, pkgName)
+
+	seen := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected 'name [GoName]', got %q", n, line)
+		}
+		name := fields[0]
+		ident := exportName(name)
+		if len(fields) == 2 {
+			ident = fields[1]
+		}
+		if prev, ok := seen[ident]; ok {
+			return nil, fmt.Errorf("line %d: Symbol%s already declared on line %d", n, ident, prev)
+		}
+		seen[ident] = n
+		fmt.Fprintf(&buf, "\tSymbol%s Symbol = %q\n", ident, name)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	buf.WriteString(")\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// exportName upper-cases the first letter of s.
+func exportName(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIconsUpToDate fails when pkg/adaptivecards/icons.go was edited by hand
+// or icons.txt changed without running go generate.
+func TestIconsUpToDate(t *testing.T) {
+	f, err := os.Open("../../pkg/adaptivecards/icons.txt")
+	require.NoError(t, err)
+	defer f.Close() //nolint:errcheck
+
+	got, err := generateIcons(f, "adaptivecards")
+	require.NoError(t, err)
+
+	want, err := os.ReadFile("../../pkg/adaptivecards/icons.go")
+	require.NoError(t, err)
+	if !bytes.Equal(want, got) {
+		t.Fatal("icons.go is out of date, run go generate ./pkg/adaptivecards")
+	}
+}
+
+func TestGenerateIcons(t *testing.T) {
+	src, err := generateIcons(strings.NewReader("# comment\n\nmsWord\nCardUi CardUI\n"), "icons")
+	require.NoError(t, err)
+	assert.Contains(t, string(src), "// Code generated by acgen. DO NOT EDIT.")
+	assert.Contains(t, string(src), "package icons")
+	assert.Contains(t, string(src), `SymbolMsWord Symbol = "msWord"`)
+	assert.Contains(t, string(src), `SymbolCardUI Symbol = "CardUi"`)
+}
+
+func TestGenerateIconsErrors(t *testing.T) {
+	_, err := generateIcons(strings.NewReader("add\nAdd\n"), "icons")
+	assert.ErrorContains(t, err, "SymbolAdd already declared on line 1")
+
+	_, err = generateIcons(strings.NewReader("add Add extra\n"), "icons")
+	assert.ErrorContains(t, err, "line 1")
+}
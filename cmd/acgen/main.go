@@ -0,0 +1,143 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command acgen generates Go code for the adaptivecards package.
+//
+// Usage:
+//
+//	acgen icons  -in icons.txt -out icons.go
+//	acgen schema -in 'testdata/schemas/adaptive-card-*.json' -out schema_gen.go
+//	acgen check  -in 'pkg/adaptivecards/testdata/schemas/adaptive-card-*.json' -pkg pkg/adaptivecards
+//
+// The icons command emits the Symbol constants from a list of Fluent icon
+// names. The schema command emits the element, action and input structs,
+// their JSON methods, RegisterType calls and enum constants from one or more
+// versions of the Adaptive Cards JSON schema. The check command reports
+// definitions and properties of the schema that are missing from, or have a
+// different version in, the hand-written package.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const header = `/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "acgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected a command: icons, schema or check")
+	}
+
+	fs := flag.NewFlagSet("acgen "+args[0], flag.ContinueOnError)
+	in := fs.String("in", "", "Input file, or a glob of schema files.")
+	out := fs.String("out", "", "Output Go file.")
+	pkg := fs.String("pkg", ".", "Package directory to check against the schema.")
+	pkgName := fs.String("package", "adaptivecards", "Package name of the generated code.")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	switch args[0] {
+	case "icons":
+		f, err := os.Open(*in) //nolint:gosec
+		if err != nil {
+			return err
+		}
+		defer f.Close() //nolint:errcheck
+		src, err := generateIcons(f, *pkgName)
+		if err != nil {
+			return err
+		}
+		return writeOutput(*out, src)
+
+	case "schema":
+		s, err := loadSchemas(*in)
+		if err != nil {
+			return err
+		}
+		src, err := generateSchema(s, *pkgName)
+		if err != nil {
+			return err
+		}
+		return writeOutput(*out, src)
+
+	case "check":
+		s, err := loadSchemas(*in)
+		if err != nil {
+			return err
+		}
+		drift, err := checkPackage(s, *pkg)
+		if err != nil {
+			return err
+		}
+		for _, d := range drift {
+			fmt.Println(d)
+		}
+		if len(drift) > 0 {
+			return fmt.Errorf("%d differences between the schema and %s", len(drift), *pkg)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func writeOutput(path string, src []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(filepath.Clean(path), src, 0o644) //nolint:gosec
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,566 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	definitionsRef     = "#/definitions/"
+	implementationsOf  = "ImplementationsOf."
+	extendablePrefix   = "Extendable."
+	explorerURLPattern = "https://adaptivecards.io/explorer/%s.html"
+)
+
+// node is a JSON schema node as used by the Adaptive Cards schema, which is
+// generated by typed-schema. Definitions and properties share this shape.
+type node struct {
+	Description string           `json:"description,omitempty"`
+	Type        any              `json:"type,omitempty"`
+	Ref         string           `json:"$ref,omitempty"`
+	Properties  map[string]*node `json:"properties,omitempty"`
+	Required    []string         `json:"required,omitempty"`
+	AllOf       []*node          `json:"allOf,omitempty"`
+	AnyOf       []*node          `json:"anyOf,omitempty"`
+	Items       *node            `json:"items,omitempty"`
+	Enum        []any            `json:"enum,omitempty"`
+	Default     any              `json:"default,omitempty"`
+	Version     string           `json:"version,omitempty"`
+}
+
+type schemaDoc struct {
+	Definitions map[string]*node `json:"definitions"`
+}
+
+// schemaSet is the newest schema plus, for every definition and property,
+// the oldest schema version it appears in.
+type schemaSet struct {
+	defs  map[string]*node
+	since map[string]string
+}
+
+var schemaVersionRe = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// loadSchemas reads every schema matching glob. The version of a schema is
+// taken from its file name, e.g. adaptive-card-1.5.0.json.
+func loadSchemas(glob string) (*schemaSet, error) {
+	files, err := filepath.Glob(glob)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no schema matches %s", glob)
+	}
+
+	type versioned struct {
+		version string
+		major   int
+		minor   int
+		doc     schemaDoc
+	}
+	var docs []versioned
+	for _, f := range files {
+		b, err := os.ReadFile(f) //nolint:gosec
+		if err != nil {
+			return nil, err
+		}
+		var v versioned
+		if err := json.Unmarshal(b, &v.doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+		if m := schemaVersionRe.FindStringSubmatch(filepath.Base(f)); m != nil {
+			v.major, _ = strconv.Atoi(m[1])
+			v.minor, _ = strconv.Atoi(m[2])
+			v.version = m[1] + "." + m[2]
+		}
+		docs = append(docs, v)
+	}
+	sort.SliceStable(docs, func(i, j int) bool {
+		if docs[i].major != docs[j].major {
+			return docs[i].major < docs[j].major
+		}
+		return docs[i].minor < docs[j].minor
+	})
+
+	s := &schemaSet{since: map[string]string{}}
+	for _, d := range docs {
+		for name, def := range d.doc.Definitions {
+			s.record(name, def.Version, d.version)
+			for prop, p := range def.Properties {
+				s.record(name+"."+prop, p.Version, d.version)
+			}
+		}
+	}
+	s.defs = docs[len(docs)-1].doc.Definitions
+	return s, nil
+}
+
+// record keeps the first version seen for key. An explicit "version" in the
+// schema wins over the version of the file it was found in.
+func (s *schemaSet) record(key, explicit, fileVersion string) {
+	if explicit != "" {
+		s.since[key] = explicit
+		return
+	}
+	if _, ok := s.since[key]; !ok && fileVersion != "" {
+		s.since[key] = fileVersion
+	}
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, definitionsRef)
+}
+
+// model is the Go view of a schema, computed once and shared by the
+// generator and the drift check.
+type model struct {
+	s          *schemaSet
+	interfaces map[string][]string // interface name -> definition names
+	memberOf   map[string][]string // definition name -> interface names
+	enums      map[string][]string // definition name -> values
+	bases      map[string]bool     // definitions only used through allOf
+	typed      map[string]string   // definition name -> "type" value
+}
+
+func newModel(s *schemaSet) *model {
+	m := &model{
+		s:          s,
+		interfaces: map[string][]string{},
+		memberOf:   map[string][]string{},
+		enums:      map[string][]string{},
+		bases:      map[string]bool{},
+		typed:      map[string]string{},
+	}
+	for _, name := range sortedKeys(s.defs) {
+		def := s.defs[name]
+		switch {
+		case strings.HasPrefix(name, implementationsOf):
+			iface := strings.TrimPrefix(name, implementationsOf)
+			for _, member := range def.AnyOf {
+				if ref := memberRef(member); ref != "" {
+					m.interfaces[iface] = append(m.interfaces[iface], ref)
+					m.memberOf[ref] = append(m.memberOf[ref], iface)
+				}
+			}
+		case strings.HasPrefix(name, extendablePrefix):
+			m.bases[name] = true
+		case len(def.Properties) == 0 && enumValues(def) != nil:
+			m.enums[name] = enumValues(def)
+		}
+		for _, parent := range def.AllOf {
+			if parent.Ref != "" {
+				m.bases[refName(parent.Ref)] = true
+			}
+		}
+		if t, ok := def.Properties["type"]; ok && len(t.Enum) == 1 {
+			if v, ok := t.Enum[0].(string); ok {
+				m.typed[name] = v
+			}
+		}
+	}
+	// Abstract definitions named like an interface ("Element", "Action")
+	// are bases even if nothing extends them directly.
+	for iface := range m.interfaces {
+		if _, ok := m.s.defs[iface]; ok {
+			m.bases[iface] = true
+		}
+	}
+	return m
+}
+
+// memberRef returns the definition an ImplementationsOf entry points to.
+func memberRef(n *node) string {
+	if n.Ref != "" {
+		return refName(n.Ref)
+	}
+	for _, a := range n.AllOf {
+		if a.Ref != "" {
+			return refName(a.Ref)
+		}
+	}
+	return ""
+}
+
+// enumValues returns the string values of an enum definition, which is
+// either a plain enum or an anyOf of an enum and a case-insensitive pattern.
+func enumValues(n *node) []string {
+	src := n.Enum
+	if src == nil {
+		for _, a := range n.AnyOf {
+			if a.Enum != nil {
+				src = a.Enum
+				break
+			}
+		}
+	}
+	if src == nil {
+		return nil
+	}
+	values := make([]string, 0, len(src))
+	for _, v := range src {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// field is a flattened struct field of a definition.
+type field struct {
+	goName   string
+	jsonName string
+	goType   string
+	required bool
+	version  string
+	owner    string
+	// kind is the JSON kind of the values, compared by the drift check.
+	kind string
+}
+
+// fields returns the properties of a definition and of all its bases,
+// ordered by JSON name. The "type" property is left out as it is injected
+// by SmartMarshalFromJSON.
+func (m *model) fields(name string) []field {
+	seen := map[string]bool{}
+	var out []field
+	var walk func(name string)
+	walk = func(name string) {
+		def, ok := m.s.defs[name]
+		if !ok {
+			return
+		}
+		required := map[string]bool{}
+		for _, r := range def.Required {
+			required[r] = true
+		}
+		for _, prop := range sortedKeys(def.Properties) {
+			if prop == "type" || seen[prop] {
+				continue
+			}
+			seen[prop] = true
+			p := def.Properties[prop]
+			out = append(out, field{
+				goName:   goIdentifier(prop),
+				jsonName: prop,
+				goType:   m.goType(p),
+				required: required[prop],
+				version:  m.s.since[name+"."+prop],
+				owner:    name,
+				kind:     m.kind(p),
+			})
+		}
+		for _, parent := range def.AllOf {
+			if parent.Ref != "" {
+				walk(refName(parent.Ref))
+			}
+		}
+	}
+	walk(name)
+	sort.Slice(out, func(i, j int) bool { return out[i].jsonName < out[j].jsonName })
+	return out
+}
+
+// goType maps a schema property to a Go type.
+func (m *model) goType(p *node) string {
+	if p.Ref != "" {
+		ref := refName(p.Ref)
+		if strings.HasPrefix(ref, implementationsOf) {
+			return goIdentifier(strings.TrimPrefix(ref, implementationsOf))
+		}
+		if m.bases[ref] {
+			return "any"
+		}
+		if _, ok := m.s.defs[ref]; ok {
+			return "*" + goIdentifier(ref)
+		}
+		return "any"
+	}
+	if len(p.AnyOf) > 0 {
+		// A single interface or struct wrapped in anyOf keeps its type,
+		// mixed alternatives (e.g. fallback) become any.
+		var types []string
+		for _, a := range p.AnyOf {
+			types = append(types, m.goType(a))
+		}
+		for _, t := range types[1:] {
+			if t != types[0] {
+				return "any"
+			}
+		}
+		return types[0]
+	}
+	switch t := p.Type.(type) {
+	case string:
+		switch t {
+		case "string":
+			return "string"
+		case "boolean":
+			// Keep an explicit false when the default is true.
+			if d, ok := p.Default.(bool); ok && d {
+				return "*bool"
+			}
+			return "bool"
+		case "number":
+			return "float64"
+		case "integer":
+			return "int"
+		case "array":
+			if p.Items == nil {
+				return "[]any"
+			}
+			return "[]" + strings.TrimPrefix(m.goType(p.Items), "*")
+		}
+	}
+	if len(p.Enum) > 0 {
+		return "string"
+	}
+	return "any"
+}
+
+// kind returns the JSON kind of the values of a schema property, kindAny
+// when it accepts several kinds.
+func (m *model) kind(p *node) string {
+	if p.Ref != "" {
+		ref := refName(p.Ref)
+		def, ok := m.s.defs[ref]
+		switch {
+		case !ok || strings.HasPrefix(ref, implementationsOf) || m.bases[ref]:
+			return kindAny
+		case m.enums[ref] != nil:
+			return kindString
+		case len(def.Properties) > 0:
+			return kindObject
+		}
+		return m.kind(def)
+	}
+	if len(p.AnyOf) > 0 {
+		k := m.kind(p.AnyOf[0])
+		for _, a := range p.AnyOf[1:] {
+			if m.kind(a) != k {
+				return kindAny
+			}
+		}
+		return k
+	}
+	if t, ok := p.Type.(string); ok {
+		switch t {
+		case "string":
+			return kindString
+		case "boolean":
+			return kindBool
+		case "number", "integer":
+			return kindNumber
+		case "array":
+			return kindArray
+		case "object":
+			return kindObject
+		}
+	}
+	if len(p.Enum) > 0 {
+		return kindString
+	}
+	return kindAny
+}
+
+var (
+	identSplitRe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+	wordRe       = regexp.MustCompile(`[A-Z]?[a-z0-9]+|[A-Z]+(?:[^a-z]|$)`)
+	initialisms  = map[string]string{
+		"Id":   "ID",
+		"Ids":  "IDs",
+		"Url":  "URL",
+		"Urls": "URLs",
+		"Uri":  "URI",
+		"Rtl":  "RTL",
+		"Json": "JSON",
+		"Html": "HTML",
+	}
+)
+
+// goIdentifier turns a schema name ("Action.OpenUrl", "grid.area",
+// "$schema") into an exported Go identifier ("ActionOpenURL", "GridArea",
+// "Schema").
+func goIdentifier(name string) string {
+	var b strings.Builder
+	for _, part := range identSplitRe.Split(name, -1) {
+		if part == "" {
+			continue
+		}
+		for _, w := range wordRe.FindAllString(exportName(part), -1) {
+			if i, ok := initialisms[w]; ok {
+				w = i
+			}
+			b.WriteString(w)
+		}
+	}
+	return b.String()
+}
+
+// generateSchema emits the Go code for all definitions of s.
+func generateSchema(s *schemaSet, pkgName string) ([]byte, error) {
+	m := newModel(s)
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	fmt.Fprintf(&buf, "\n// Code generated by acgen. DO NOT EDIT.\n\npackage %s\n", pkgName)
+
+	for _, iface := range sortedKeys(m.interfaces) {
+		goName := goIdentifier(iface)
+		fmt.Fprintf(&buf, "\n// %s is implemented by %s.\ntype %s interface {\n\tis%s()\n}\n",
+			goName, strings.Join(goNames(m.interfaces[iface]), ", "), goName, goName)
+	}
+
+	for _, name := range sortedKeys(m.enums) {
+		goName := goIdentifier(name)
+		fmt.Fprintf(&buf, "\n%s\ntype %s string\n\n// %s values.\nconst (\n",
+			docComment(goName, s.defs[name].Description, ""), goName, goName)
+		for _, v := range m.enums[name] {
+			fmt.Fprintf(&buf, "\t%s%s %s = %q\n", goName, goIdentifier(v), goName, v)
+		}
+		buf.WriteString(")\n")
+	}
+
+	for _, name := range sortedKeys(s.defs) {
+		if m.bases[name] || strings.HasPrefix(name, implementationsOf) || m.enums[name] != nil {
+			continue
+		}
+		if err := m.writeStruct(&buf, name); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteString("\nfunc init() {\n")
+	for _, name := range sortedKeys(m.typed) {
+		if m.bases[name] {
+			continue
+		}
+		fmt.Fprintf(&buf, "\tRegisterType(%q, %s{})\n", m.typed[name], goIdentifier(name))
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+func (m *model) writeStruct(buf *bytes.Buffer, name string) error {
+	def := m.s.defs[name]
+	goName := goIdentifier(name)
+	typeName, typed := m.typed[name]
+
+	url := ""
+	if typed {
+		url = fmt.Sprintf(explorerURLPattern, typeName)
+	}
+	fmt.Fprintf(buf, "\n// region %s\n\n%s\ntype %s struct {\n", goName, docComment(goName, def.Description, url), goName)
+
+	polymorphic := false
+	for _, f := range m.fields(name) {
+		tag := f.jsonName
+		if !f.required {
+			tag += ",omitempty"
+		}
+		tags := fmt.Sprintf("json:%q", tag)
+		if f.version != "" {
+			tags += fmt.Sprintf(" version:%q", f.version)
+		}
+		fmt.Fprintf(buf, "\t%s %s `%s`\n", f.goName, f.goType, tags)
+		if m.isPolymorphic(f.goType) {
+			polymorphic = true
+		}
+	}
+	buf.WriteString("}\n")
+
+	recv := strings.ToLower(goName[:1])
+	for _, iface := range m.memberOf[name] {
+		fmt.Fprintf(buf, "\nfunc (%s %s) is%s() {}\n", recv, goName, goIdentifier(iface))
+	}
+	if typed {
+		fmt.Fprintf(buf, `
+// MarshalJSON ensures that the "type" field is included when marshaling %[3]s
+// %[1]s to JSON.
+func (%[2]s %[1]s) MarshalJSON() ([]byte, error) {
+	return SmartMarshalFromJSON(%[2]s)
+}
+`, goName, recv, article(goName))
+	}
+	if typed || polymorphic {
+		fmt.Fprintf(buf, `
+// UnmarshalJSON unmarshals %[3]s %[1]s, resolving its polymorphic fields.
+func (%[2]s *%[1]s) UnmarshalJSON(data []byte) error {
+	return SmartUnmarshalJSON(data, %[2]s)
+}
+`, goName, recv, article(goName))
+	}
+	fmt.Fprintf(buf, "\n// endregion %s\n", goName)
+	return nil
+}
+
+// isPolymorphic reports whether t holds one of the generated interfaces.
+func (m *model) isPolymorphic(t string) bool {
+	t = strings.TrimPrefix(t, "[]")
+	for iface := range m.interfaces {
+		if t == goIdentifier(iface) {
+			return true
+		}
+	}
+	return false
+}
+
+func goNames(defs []string) []string {
+	out := make([]string, len(defs))
+	for i, d := range defs {
+		out[i] = goIdentifier(d)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// docComment renders the doc comment of a generated type: the explorer URL
+// for typed definitions, the first sentence of the description otherwise.
+func docComment(goName, description, url string) string {
+	if url != "" {
+		return fmt.Sprintf("// %s - %s", goName, url)
+	}
+	description = strings.TrimSpace(description)
+	if i := strings.Index(description, ". "); i >= 0 {
+		description = description[:i+1]
+	}
+	if description == "" {
+		return fmt.Sprintf("// %s is generated from the Adaptive Cards schema.", goName)
+	}
+	return fmt.Sprintf("// %s - %s", goName, description)
+}
+
+func article(word string) string {
+	if strings.ContainsRune("AEIOU", rune(word[0])) {
+		return "an"
+	}
+	return "a"
+}
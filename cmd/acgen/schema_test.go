@@ -0,0 +1,97 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSchema(t *testing.T) {
+	s, err := loadSchemas("testdata/schemas/*.json")
+	require.NoError(t, err)
+
+	got, err := generateSchema(s, "fixture")
+	require.NoError(t, err)
+
+	want, err := os.ReadFile("testdata/schema_gen.golden")
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got), "regenerate with: go run . schema -in 'testdata/schemas/*.json' -package fixture -out testdata/schema_gen.golden")
+}
+
+func TestLoadSchemasVersions(t *testing.T) {
+	s, err := loadSchemas("testdata/schemas/*.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.0", s.since["TextBlock"])
+	assert.Equal(t, "1.0", s.since["TextBlock.text"])
+	assert.Equal(t, "1.2", s.since["TextBlock.maxLines"])
+	assert.Equal(t, "1.2", s.since["Container"])
+	// An explicit "version" wins over the file the property was found in.
+	assert.Equal(t, "1.2", s.since["Extendable.Element.isVisible"])
+}
+
+func TestLoadSchemasNoMatch(t *testing.T) {
+	_, err := loadSchemas("testdata/schemas/*.yaml")
+	assert.ErrorContains(t, err, "no schema matches")
+}
+
+func TestGoIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"TextBlock":             "TextBlock",
+		"Action.OpenUrl":        "ActionOpenURL",
+		"Action.OpenUrlDialog":  "ActionOpenURLDialog",
+		"Input.ChoiceSet":       "InputChoiceSet",
+		"id":                    "ID",
+		"grid.area":             "GridArea",
+		"isVisible":             "IsVisible",
+		"$schema":               "Schema",
+		"rtl":                   "RTL",
+		"targetInputIds":        "TargetInputIDs",
+		"ImplementationsOf.Foo": "ImplementationsOfFoo",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, goIdentifier(in), in)
+	}
+}
+
+// TestPackageMatchesSchema compares pkg/adaptivecards with the official
+// schemas checked in by "make schemas". It fails when SOURCE pins a commit
+// but the schemas are missing, and is skipped until they are first checked
+// in.
+func TestPackageMatchesSchema(t *testing.T) {
+	const dir = "../../pkg/adaptivecards/testdata/schemas"
+	files, _ := filepath.Glob(dir + "/adaptive-card-*.json")
+	if len(files) == 0 {
+		if _, err := os.Stat(dir + "/SOURCE"); err == nil {
+			t.Fatalf("%s/SOURCE is set but no schema is checked in, run make schemas", dir)
+		}
+		t.Skip("no schemas checked in, run make schemas")
+	}
+	s, err := loadSchemas(dir + "/adaptive-card-*.json")
+	require.NoError(t, err)
+
+	drift, err := checkPackage(s, "../../pkg/adaptivecards")
+	require.NoError(t, err)
+	for _, d := range drift {
+		t.Error(d)
+	}
+}
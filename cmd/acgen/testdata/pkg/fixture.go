@@ -0,0 +1,36 @@
+package fixture
+
+func init() {
+	RegisterType("Action.OpenUrl", ActionOpenURL{})
+	RegisterType("FactSet", FactSet{})
+	RegisterType("TextBlock", TextBlock{})
+}
+
+type Common struct {
+	ID        string `json:"id,omitempty" version:"1.0"`
+	IsVisible *bool  `json:"isVisible,omitempty" version:"1.3"`
+	Spacing   string `json:"spacing,omitempty" version:"1.0"`
+	Fallback  any    `json:"fallback,omitempty" version:"1.2"`
+}
+
+type ActionOpenURL struct {
+	ID    string `json:"id,omitempty"`
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url"`
+}
+
+type Fact struct {
+	Title string `json:"title" version:"1.0"`
+	Value string `json:"value" version:"1.0"`
+}
+
+type FactSet struct {
+	*Common
+	Facts []Fact `json:"facts" version:"1.0"`
+}
+
+type TextBlock struct {
+	*Common
+	Text string `json:"text" version:"1.0"`
+	Wrap string `json:"wrap,omitempty" version:"1.0"`
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stakater/prometheus-msteams/pkg/ack"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+const (
+	// actionsTimeout is the timeout of the requests to the JWKS,
+	// Alertmanager and Grafana.
+	actionsTimeout = 10 * time.Second
+	// defaultSilenceDuration is how long the acknowledged alerts are
+	// silenced without a silence duration.
+	defaultSilenceDuration = 4 * time.Hour
+)
+
+// ActionsConfig configures the callback endpoint of the Acknowledge and
+// Assign to me buttons of the connectors with acknowledge set. Teams sends
+// the presses to the messaging endpoint of the bot with the app ID, which
+// must be https://<host>/api/v1/actions.
+type ActionsConfig struct {
+	// AppID is the Microsoft app ID of the bot, the audience of the tokens.
+	AppID string `yaml:"app_id"`
+	// JWKSURL and Issuer override those of the Bot Framework.
+	JWKSURL string `yaml:"jwks_url"`
+	Issuer  string `yaml:"issuer"`
+	// StoreFile persists the acknowledgements, kept in memory if empty.
+	StoreFile string `yaml:"store_file"`
+	// Retention is how long the acknowledgements are kept,
+	// ack.DefaultRetention if 0.
+	Retention time.Duration `yaml:"retention"`
+	// Silence silences the acknowledged alerts, if set.
+	Silence *SilenceConfig `yaml:"silence"`
+	// Annotation annotates the actions in Grafana, if set.
+	Annotation *AnnotationConfig `yaml:"annotation"`
+}
+
+// SilenceConfig configures the silences of the acknowledged alerts.
+type SilenceConfig struct {
+	AlertmanagerURL string `yaml:"alertmanager_url"`
+	// Duration is how long the alerts are silenced, 4h if 0.
+	Duration time.Duration `yaml:"duration"`
+}
+
+// AnnotationConfig configures the Grafana annotations of the actions.
+type AnnotationConfig struct {
+	GrafanaURL string `yaml:"grafana_url"`
+	// TokenFile holds the token of a Grafana service account allowed to
+	// write annotations.
+	TokenFile string `yaml:"token_file"`
+}
+
+// setupActions creates the handler of the actions of tc and its store, or
+// returns nil if tc has no actions.
+func setupActions(cfg Config, tc PromTeamsConfig, logger *utility.Logger) (*ack.Handler, *ack.Store, error) {
+	ac := tc.Actions
+	if ac == nil {
+		return nil, nil, nil
+	}
+	client := &http.Client{Timeout: actionsTimeout}
+	verifier, err := ack.NewVerifier(ac.JWKSURL, ac.Issuer, ac.AppID, client)
+	if err != nil {
+		return nil, nil, fmt.Errorf("actions: %w", err)
+	}
+	if ac.Retention < 0 {
+		return nil, nil, fmt.Errorf("actions: the retention must not be negative, got %s", ac.Retention)
+	}
+
+	var opts []ack.HandlerOption
+	if s := ac.Silence; s != nil {
+		if s.AlertmanagerURL == "" {
+			return nil, nil, errors.New("actions: the silence alertmanager_url is required")
+		}
+		d := s.Duration
+		if d == 0 {
+			d = defaultSilenceDuration
+		}
+		if d < 0 {
+			return nil, nil, fmt.Errorf("actions: the silence duration must not be negative, got %s", d)
+		}
+		opts = append(opts, ack.WithSilences(ack.Alertmanager{URL: s.AlertmanagerURL, HTTP: client}, d))
+	}
+	if a := ac.Annotation; a != nil {
+		if a.GrafanaURL == "" {
+			return nil, nil, errors.New("actions: the annotation grafana_url is required")
+		}
+		var token string
+		if a.TokenFile != "" {
+			b, err := os.ReadFile(filepath.Clean(a.TokenFile))
+			if err != nil {
+				return nil, nil, fmt.Errorf("actions: %w", err)
+			}
+			token = strings.TrimSpace(string(b))
+		}
+		opts = append(opts, ack.WithAnnotations(ack.Grafana{URL: a.GrafanaURL, Token: token, HTTP: client}))
+	}
+
+	localizer, err := card.NewLocalizer(cfg.Locale, cfg.Timezone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("actions: %w", err)
+	}
+	store, err := ack.NewStore(ac.StoreFile, ac.Retention)
+	if err != nil {
+		return nil, nil, fmt.Errorf("actions: %w", err)
+	}
+	return ack.NewHandler(verifier, store, localizer, logger, opts...), store, nil
+}
+
+// setupActionsAPI serves the actions of h on POST /api/v1/actions.
+func setupActionsAPI(e *echo.Echo, h *ack.Handler) {
+	e.POST("/api/v1/actions", echo.WrapHandler(h))
+}
+
+// setupAcknowledge wraps converter with the Acknowledge and Assign to me
+// buttons if acknowledge is set, which requires the actions of tc.
+func setupAcknowledge(acknowledge bool, tc PromTeamsConfig, localizer *card.Localizer, converter card.Converter) (card.Converter, error) {
+	if !acknowledge {
+		return converter, nil
+	}
+	if tc.Actions == nil {
+		return nil, errors.New("acknowledge requires the actions to be configured")
+	}
+	return card.NewActionsMiddleware(localizer, converter), nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSetupActions(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret\n"), 0o600))
+
+	var tc PromTeamsConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+actions:
+  app_id: 00000000-0000-0000-0000-000000000001
+  store_file: `+filepath.Join(dir, "acks.jsonl")+`
+  retention: 72h
+  silence:
+    alertmanager_url: http://alertmanager:9093
+    duration: 2h
+  annotation:
+    grafana_url: http://grafana:3000
+    token_file: `+tokenFile+`
+`), &tc))
+	require.NotNil(t, tc.Actions)
+	assert.Equal(t, 72*time.Hour, tc.Actions.Retention)
+	assert.Equal(t, 2*time.Hour, tc.Actions.Silence.Duration)
+
+	logger := setupLogger(Config{LogFormat: "fmt"})
+	h, store, err := setupActions(Config{}, tc, logger)
+	require.NoError(t, err)
+	require.NotNil(t, h)
+	defer store.Close()
+	assert.FileExists(t, filepath.Join(dir, "acks.jsonl"))
+
+	// The endpoint rejects the requests without a token.
+	e := echo.New()
+	setupActionsAPI(e, h)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/actions", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	h, store, err = setupActions(Config{}, PromTeamsConfig{}, logger)
+	require.NoError(t, err)
+	assert.Nil(t, h)
+	assert.Nil(t, store)
+}
+
+func TestSetupActionsInvalid(t *testing.T) {
+	logger := setupLogger(Config{LogFormat: "fmt"})
+	tests := map[string]ActionsConfig{
+		"no app id":        {},
+		"retention":        {AppID: "app", Retention: -time.Hour},
+		"no alertmanager":  {AppID: "app", Silence: &SilenceConfig{}},
+		"silence duration": {AppID: "app", Silence: &SilenceConfig{AlertmanagerURL: "http://am", Duration: -time.Hour}},
+		"no grafana":       {AppID: "app", Annotation: &AnnotationConfig{}},
+		"token file":       {AppID: "app", Annotation: &AnnotationConfig{GrafanaURL: "http://grafana", TokenFile: "missing"}},
+	}
+	for name, ac := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := setupActions(Config{}, PromTeamsConfig{Actions: &ac}, logger)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSetupAcknowledge(t *testing.T) {
+	tmpl, err := card.ParseTemplateFile("../../default-message-workflow-card.tmpl")
+	require.NoError(t, err)
+	converter := card.NewTemplatedCardCreator(tmpl, false, setupLogger(Config{LogFormat: "fmt"}))
+
+	same, err := setupAcknowledge(false, PromTeamsConfig{}, nil, converter)
+	require.NoError(t, err)
+	assert.Equal(t, converter, same)
+
+	_, err = setupAcknowledge(true, PromTeamsConfig{}, nil, converter)
+	assert.Error(t, err)
+
+	acked, err := setupAcknowledge(true, PromTeamsConfig{Actions: &ActionsConfig{AppID: "app"}}, card.DefaultLocalizer(), converter)
+	require.NoError(t, err)
+	got, err := acked.Convert(context.Background(), webhook.Message{Data: &template.Data{
+		Status: "firing",
+		Alerts: template.Alerts{{Status: "firing", Labels: template.KV{"alertname": "HighLoad"}, Fingerprint: "1"}},
+	}})
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	require.IsType(t, &adaptivecards.ActionSet{}, body[len(body)-1])
+}
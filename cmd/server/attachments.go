@@ -0,0 +1,56 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// AttachmentsConfig adds to the cards of a connector a card per alert, after
+// the summary card, when a notification has several alerts.
+type AttachmentsConfig struct {
+	// Layout is how Teams shows the cards: carousel (the default) or list.
+	Layout adaptivecards.AttachmentLayout `yaml:"layout"`
+	// MaxBytes is the maximum size of the payloads, card.DefaultMaxPayload
+	// if 0. The cards of the alerts not fitting are left out and logged,
+	// and the notification fails if the summary card does not fit.
+	MaxBytes int `yaml:"max_bytes"`
+}
+
+// setupAttachments wraps converter with the attachments of cfg, if any,
+// logging with logger the cards of the alerts left out.
+func setupAttachments(cfg *AttachmentsConfig, logger *utility.Logger, converter card.Converter) (card.Converter, error) {
+	if cfg == nil {
+		return converter, nil
+	}
+	layout := cfg.Layout
+	switch layout {
+	case "":
+		layout = adaptivecards.AttachmentLayoutCarousel
+	case adaptivecards.AttachmentLayoutCarousel, adaptivecards.AttachmentLayoutList:
+	default:
+		return nil, fmt.Errorf("unknown attachments layout '%s', expected carousel or list", cfg.Layout)
+	}
+	if cfg.MaxBytes < 0 {
+		return nil, fmt.Errorf("attachments max_bytes must not be negative, got %d", cfg.MaxBytes)
+	}
+	return card.NewAttachmentsMiddleware(layout, cfg.MaxBytes, logger, converter), nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSetupAttachments(t *testing.T) {
+	var c ConnectorWithCustomTemplate
+	require.NoError(t, yaml.Unmarshal([]byte(`
+request_path: /carousel
+attachments:
+  max_bytes: 20000
+`), &c))
+	require.NotNil(t, c.Attachments)
+	assert.Equal(t, 20000, c.Attachments.MaxBytes)
+
+	tmpl, err := card.ParseTemplateFile("../../default-message-workflow-card.tmpl")
+	require.NoError(t, err)
+	logger := setupLogger(Config{LogFormat: "fmt"})
+	converter, err := setupAttachments(c.Attachments, logger, card.NewTemplatedCardCreator(tmpl, false, logger))
+	require.NoError(t, err)
+
+	got, err := converter.Convert(context.Background(), webhook.Message{Data: &template.Data{
+		Status: "firing",
+		Alerts: template.Alerts{
+			{Status: "firing", Labels: template.KV{"alertname": "HighLoad"}, Fingerprint: "1"},
+			{Status: "firing", Labels: template.KV{"alertname": "DiskFull"}, Fingerprint: "2"},
+		},
+	}})
+	require.NoError(t, err)
+	assert.Len(t, got.Attachments, 3)
+	assert.Equal(t, adaptivecards.AttachmentLayoutCarousel, got.AttachmentLayout)
+
+	same, err := setupAttachments(nil, logger, converter)
+	require.NoError(t, err)
+	assert.Equal(t, converter, same)
+
+	_, err = setupAttachments(&AttachmentsConfig{Layout: "grid"}, logger, converter)
+	assert.EqualError(t, err, "unknown attachments layout 'grid', expected carousel or list")
+	_, err = setupAttachments(&AttachmentsConfig{MaxBytes: -1}, logger, converter)
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/stakater/prometheus-msteams/pkg/audit"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// defaultDeliveriesLimit is the number of entries returned by
+// /api/v1/deliveries without a limit parameter.
+const defaultDeliveriesLimit = 100
+
+// setupAudit creates the delivery audit log, or returns nil if disabled.
+func setupAudit(cfg Config, logger *utility.Logger) (*audit.Log, error) {
+	if cfg.AuditSize <= 0 {
+		return nil, nil
+	}
+	return audit.New(cfg.AuditSize, cfg.AuditFile, logger)
+}
+
+// setupAuditAPI serves the entries of l on GET /api/v1/deliveries.
+func setupAuditAPI(e *echo.Echo, l *audit.Log) {
+	e.GET("/api/v1/deliveries", func(c *echo.Context) error {
+		f, err := parseDeliveriesFilter(c.Request().URL.Query())
+		if err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+		return c.JSON(http.StatusOK, map[string][]audit.Entry{"deliveries": l.Query(f)})
+	})
+}
+
+// parseDeliveriesFilter parses the query parameters route, status,
+// delivered, since, until, filter and limit.
+func parseDeliveriesFilter(q url.Values) (audit.Filter, error) {
+	f := audit.Filter{
+		Route:  q.Get("route"),
+		Status: q.Get("status"),
+		Limit:  defaultDeliveriesLimit,
+	}
+	if v := q.Get("delivered"); v != "" {
+		delivered, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid delivered %q: %w", v, err)
+		}
+		f.Delivered = &delivered
+	}
+	var err error
+	if f.Since, err = parseTimeParam(q, "since"); err != nil {
+		return f, err
+	}
+	if f.Until, err = parseTimeParam(q, "until"); err != nil {
+		return f, err
+	}
+	for _, v := range q["filter"] {
+		matchers, err := labels.ParseMatchers(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid filter %q: %w", v, err)
+		}
+		f.Matchers = append(f.Matchers, matchers...)
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+			return f, fmt.Errorf("invalid limit %q", v)
+		}
+	}
+	return f, nil
+}
+
+// parseTimeParam parses an RFC 3339 time or a duration before now.
+func parseTimeParam(q url.Values, name string) (time.Time, error) {
+	v := q.Get(name)
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q, expected an RFC 3339 time or a duration", name, v)
+	}
+	return t, nil
+}
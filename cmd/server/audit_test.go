@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stakater/prometheus-msteams/pkg/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupAudit(t *testing.T) {
+	l, err := setupAudit(Config{AuditSize: 0}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, l)
+
+	l, err = setupAudit(Config{AuditSize: 10, AuditFile: filepath.Join(t.TempDir(), "deliveries.jsonl")}, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, l)
+	assert.NoError(t, l.Close())
+}
+
+func TestParseDeliveriesFilter(t *testing.T) {
+	f, err := parseDeliveriesFilter(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, audit.Filter{Limit: defaultDeliveriesLimit}, f)
+
+	before := time.Now()
+	f, err = parseDeliveriesFilter(url.Values{
+		"route":     {"/alertmanager"},
+		"status":    {"firing"},
+		"delivered": {"false"},
+		"since":     {"1h"},
+		"until":     {"2026-03-14T03:12:00Z"},
+		"filter":    {`{severity="critical"}`, `alertname=~"High.*"`},
+		"limit":     {"0"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/alertmanager", f.Route)
+	assert.Equal(t, "firing", f.Status)
+	require.NotNil(t, f.Delivered)
+	assert.False(t, *f.Delivered)
+	assert.WithinDuration(t, before.Add(-time.Hour), f.Since, time.Second)
+	assert.Equal(t, time.Date(2026, 3, 14, 3, 12, 0, 0, time.UTC), f.Until)
+	assert.Len(t, f.Matchers, 2)
+	assert.Zero(t, f.Limit)
+
+	for _, q := range []url.Values{
+		{"delivered": {"maybe"}},
+		{"since": {"yesterday"}},
+		{"until": {"2026-03-14"}},
+		{"filter": {`{severity}`}},
+		{"limit": {"-1"}},
+		{"limit": {"ten"}},
+	} {
+		_, err := parseDeliveriesFilter(q)
+		assert.Error(t, err, q)
+	}
+}
+
+func TestSetupAuditAPI(t *testing.T) {
+	l, err := audit.New(10, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, l.Record(audit.Entry{Route: "/a", ResponseCode: http.StatusOK}))
+	require.NoError(t, l.Record(audit.Entry{Route: "/b", ResponseCode: http.StatusBadRequest}))
+
+	e := echo.New()
+	setupAuditAPI(e, l)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/api/v1/deliveries?delivered=false")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Deliveries []audit.Entry `json:"deliveries"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Deliveries, 1)
+	assert.Equal(t, "/b", body.Deliveries[0].Route)
+
+	rec = get("/api/v1/deliveries?route=/c")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"deliveries": []}`, rec.Body.String())
+
+	rec = get("/api/v1/deliveries?limit=-1")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
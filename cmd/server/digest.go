@@ -0,0 +1,75 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// setupDigests wraps the services of the routes of the templated connectors
+// with a digest configured with a service.DigestService. The digests are
+// flushed with t, and those posted at the end of their window are canceled
+// with ctx.
+func setupDigests(ctx context.Context, tc PromTeamsConfig, routes []transport.Route, t *service.Tracker, logger *utility.Logger) error {
+	digests := map[string]*DigestConfig{}
+	for _, c := range tc.ConnectorsWithCustomTemplates {
+		if c.Digest != nil {
+			digests[c.RequestPath] = c.Digest
+		}
+	}
+	for i := range routes {
+		dc, ok := digests[routes[i].RequestPath]
+		if !ok {
+			continue
+		}
+		cfg, err := digestConfig(dc)
+		if err != nil {
+			return fmt.Errorf("request_path '%s': %w", routes[i].RequestPath, err)
+		}
+		d, err := service.NewDigestService(ctx, routes[i].RequestPath, cfg, routes[i].Service, logger, service.WithDigestTracker(t))
+		if err != nil {
+			return fmt.Errorf("request_path '%s': %w", routes[i].RequestPath, err)
+		}
+		routes[i].Service = d
+	}
+	return nil
+}
+
+func digestConfig(dc *DigestConfig) (service.DigestConfig, error) {
+	if dc.Window <= 0 {
+		return service.DigestConfig{}, fmt.Errorf("digest window must be positive, got %s", dc.Window)
+	}
+	cfg := service.DigestConfig{
+		Window:           dc.Window,
+		MaxNotifications: dc.MaxNotifications,
+		Top:              dc.Top,
+	}
+	for _, b := range dc.Bypass {
+		matchers, err := labels.ParseMatchers(b)
+		if err != nil {
+			return service.DigestConfig{}, fmt.Errorf("invalid digest bypass '%s': %w", b, err)
+		}
+		cfg.Bypass = append(cfg.Bypass, matchers)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupDigests(t *testing.T) {
+	logger := setupLogger(Config{LogFormat: "fmt"})
+	tc := PromTeamsConfig{ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{
+		{RequestPath: "/digest", Digest: &DigestConfig{Window: time.Minute, Bypass: []string{`{severity="critical"}`}}},
+		{RequestPath: "/plain"},
+	}}
+	next := slowService{}
+	routes := []transport.Route{{RequestPath: "/digest", Service: next}, {RequestPath: "/plain", Service: next}}
+
+	require.NoError(t, setupDigests(context.Background(), tc, routes, service.NewTracker(), logger))
+	assert.IsType(t, &service.DigestService{}, routes[0].Service)
+	assert.Equal(t, next, routes[1].Service)
+}
+
+func TestSetupDigestsInvalid(t *testing.T) {
+	logger := setupLogger(Config{LogFormat: "fmt"})
+	tests := map[string]*DigestConfig{
+		"no window":      {},
+		"invalid bypass": {Window: time.Minute, Bypass: []string{`{severity}`}},
+	}
+	for name, dc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc := PromTeamsConfig{ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{{RequestPath: "/digest", Digest: dc}}}
+			routes := []transport.Route{{RequestPath: "/digest", Service: slowService{}}}
+			err := setupDigests(context.Background(), tc, routes, service.NewTracker(), logger)
+			assert.ErrorContains(t, err, "request_path '/digest'")
+		})
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/health"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// Names of the readiness checks.
+const (
+	configCheck         = "config"
+	templateCheckPrefix = "template:"
+	dnsCheckPrefix      = "dns:"
+)
+
+// healthCheckTimeout bounds the checks run by a request to /-/ready.
+const healthCheckTimeout = 5 * time.Second
+
+// setupHealth serves the reports of liveness on /-/healthy and of readiness
+// on /-/ready, with status 503 if a check failed.
+func setupHealth(e *echo.Echo, liveness, readiness *health.Checker) {
+	handler := func(checker *health.Checker) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), healthCheckTimeout)
+			defer cancel()
+			r := checker.Run(ctx)
+			if !r.OK() {
+				return c.JSON(http.StatusServiceUnavailable, r)
+			}
+			return c.JSON(http.StatusOK, r)
+		}
+	}
+	for path, checker := range map[string]*health.Checker{"/-/healthy": liveness, "/-/ready": readiness} {
+		e.GET(path, handler(checker))
+		e.HEAD(path, handler(checker))
+	}
+}
+
+// checkTemplates records in readiness whether the converters, of the
+// default template under "" and of the templated connectors by request
+// path, render the sample alert of card.SampleMessage. The checks are
+// pending until the sample is rendered, once, in the background: the
+// probes report the recorded results. The returned channel is closed once
+// every check completed.
+func checkTemplates(converters map[string]card.Converter, logger *utility.Logger, readiness *health.Checker) <-chan struct{} {
+	requestPaths := slices.Sorted(maps.Keys(converters))
+	names := make([]string, len(requestPaths))
+	for i, p := range requestPaths {
+		names[i] = templateCheckPrefix + p
+		if p == "" {
+			names[i] = templateCheckPrefix + "default"
+		}
+		readiness.Set(names[i], health.ErrPending)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i, p := range requestPaths {
+			_, err := converters[p].Convert(context.Background(), card.SampleMessage())
+			if err != nil {
+				logger.Warn("message", "template failed to render the sample alert", "check", names[i], "err", err)
+			}
+			readiness.Set(names[i], err)
+		}
+	}()
+	return done
+}
+
+// addDNSChecks adds to readiness a check resolving each webhook host of the
+// connectors.
+func addDNSChecks(cfg Config, tc PromTeamsConfig, r health.Resolver, readiness *health.Checker) {
+	for _, host := range webhookHosts(cfg, tc) {
+		readiness.Add(dnsCheckPrefix+host, health.DNSCheck(r, host))
+	}
+}
+
+// webhookHosts returns the sorted hosts of the webhook URLs of the
+// connectors. The hosts of dynamic webhooks are not known in advance.
+func webhookHosts(cfg Config, tc PromTeamsConfig) []string {
+	var urls []string
+	if cfg.RequestURI != "" {
+		urls = append(urls, cfg.TeamsWebhookURL)
+	}
+	for _, c := range tc.Connectors {
+		for _, webhook := range c {
+			urls = append(urls, webhook)
+		}
+	}
+	for _, c := range tc.ConnectorsWithCustomTemplates {
+		urls = append(urls, c.WebhookURL)
+	}
+
+	seen := map[string]bool{}
+	var hosts []string
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Hostname() == "" || seen[parsed.Hostname()] {
+			continue
+		}
+		seen[parsed.Hostname()] = true
+		hosts = append(hosts, parsed.Hostname())
+	}
+	sort.Strings(hosts)
+	return hosts
+}
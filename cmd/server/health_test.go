@@ -0,0 +1,137 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupHealth(t *testing.T) {
+	liveness, readiness := health.NewChecker(), health.NewChecker()
+	readiness.Set(configCheck, health.ErrPending)
+
+	e := echo.New()
+	setupHealth(e, liveness, readiness)
+	request := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	rec := request(http.MethodGet, "/-/healthy")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, rec.Body.String())
+
+	rec = request(http.MethodGet, "/-/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status": "failed", "checks": {"config": "pending"}}`, rec.Body.String())
+	assert.Equal(t, http.StatusServiceUnavailable, request(http.MethodHead, "/-/ready").Code)
+
+	readiness.Set(configCheck, nil)
+	rec = request(http.MethodGet, "/-/ready")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status": "ok", "checks": {"config": "ok"}}`, rec.Body.String())
+	assert.Equal(t, http.StatusOK, request(http.MethodHead, "/-/ready").Code)
+}
+
+func TestCheckTemplates(t *testing.T) {
+	broken := filepath.Join(t.TempDir(), "broken.tmpl")
+	require.NoError(t, os.WriteFile(broken, []byte(`{{ define "teams.card" }}{"type": "message", {{ end }}`), 0o600))
+
+	cfg := Config{
+		TemplateFile: "../../default-message-workflow-card.tmpl",
+		Locale:       "en",
+		Timezone:     "UTC",
+	}
+	tc := PromTeamsConfig{ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{
+		{RequestPath: "/ok", TemplateFile: "../../default-message-workflow-card.tmpl"},
+		{RequestPath: "/broken", TemplateFile: broken},
+	}}
+	logger := setupLogger(Config{LogFormat: "fmt"})
+	converters := map[string]card.Converter{}
+	for _, p := range []string{"", "/ok", "/broken"} {
+		converter, err := previewConverter(cfg, tc, p, logger)
+		require.NoError(t, err)
+		converters[p] = converter
+	}
+	readiness := health.NewChecker()
+
+	done := checkTemplates(converters, logger, readiness)
+	// The checks are recorded, pending or completed, before checkTemplates
+	// returns.
+	assert.Len(t, readiness.Run(context.Background()).Checks, 3)
+	<-done
+
+	r := readiness.Run(context.Background())
+	assert.False(t, r.OK())
+	assert.Equal(t, "ok", r.Checks["template:default"])
+	assert.Equal(t, "ok", r.Checks["template:/ok"])
+	assert.NotEqual(t, "ok", r.Checks["template:/broken"])
+}
+
+type fakeResolver map[string]bool
+
+func (r fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if !r[host] {
+		return nil, errors.New("no such host")
+	}
+	return []string{"192.0.2.1"}, nil
+}
+
+func TestAddDNSChecks(t *testing.T) {
+	cfg := Config{
+		RequestURI:      "/alertmanager",
+		TeamsWebhookURL: "https://a.webhook.office.com/webhookb2/x",
+	}
+	tc := PromTeamsConfig{
+		Connectors: []map[string]string{
+			{"/b": "https://b.environment.api.powerplatform.com/powerautomate/x"},
+			{"/a2": "https://a.webhook.office.com/webhookb2/y"},
+		},
+		ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{
+			{RequestPath: "/c", WebhookURL: "https://c.example.com/x"},
+		},
+	}
+	assert.Equal(t, []string{
+		"a.webhook.office.com",
+		"b.environment.api.powerplatform.com",
+		"c.example.com",
+	}, webhookHosts(cfg, tc))
+	assert.Empty(t, webhookHosts(Config{TeamsWebhookURL: cfg.TeamsWebhookURL}, PromTeamsConfig{}))
+
+	readiness := health.NewChecker()
+	addDNSChecks(cfg, tc, fakeResolver{"a.webhook.office.com": true, "c.example.com": true}, readiness)
+
+	r := readiness.Run(context.Background())
+	assert.Equal(t, map[string]string{
+		"dns:a.webhook.office.com":                "ok",
+		"dns:b.environment.api.powerplatform.com": "no such host",
+		"dns:c.example.com":                       "ok",
+	}, r.Checks)
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/stakater/prometheus-msteams/pkg/input"
+)
+
+// InputConfig selects the format of the requests of a connector.
+type InputConfig struct {
+	// Format is alertmanager, the default, grafana or json.
+	Format string `yaml:"format"`
+	// Mapping maps the payloads of the json format to alerts.
+	Mapping *input.Mapping `yaml:"mapping"`
+}
+
+// inputDecoder returns the decoder of the requests of a connector, nil for
+// Alertmanager.
+func inputDecoder(c *InputConfig) (input.Decoder, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return input.New(c.Format, c.Mapping)
+}
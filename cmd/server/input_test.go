@@ -0,0 +1,68 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stakater/prometheus-msteams/pkg/input"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestInputDecoder(t *testing.T) {
+	var tc PromTeamsConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+connectors_with_custom_templates:
+  - request_path: /grafana
+    input:
+      format: grafana
+  - request_path: /ci
+    input:
+      format: json
+      mapping:
+        alerts: builds
+        status: result
+        labels_from: tags
+        payload_labels:
+          alertname: pipeline
+  - request_path: /alertmanager
+  - request_path: /invalid
+    input:
+      format: json
+`), &tc))
+
+	d, err := inputDecoder(tc.ConnectorsWithCustomTemplates[0].Input)
+	require.NoError(t, err)
+	assert.Equal(t, input.Grafana{}, d)
+
+	d, err = inputDecoder(tc.ConnectorsWithCustomTemplates[1].Input)
+	require.NoError(t, err)
+	wm, _, err := d.Decode([]byte(`{"pipeline": "deploy", "builds": [{"result": "failed", "tags": {"env": "prod"}}]}`))
+	require.NoError(t, err)
+	require.Len(t, wm.Alerts, 1)
+	assert.Equal(t, "deploy", wm.Alerts[0].Labels["alertname"])
+	assert.Equal(t, "prod", wm.Alerts[0].Labels["env"])
+
+	d, err = inputDecoder(tc.ConnectorsWithCustomTemplates[2].Input)
+	require.NoError(t, err)
+	assert.Nil(t, d)
+
+	_, err = inputDecoder(tc.ConnectorsWithCustomTemplates[3].Input)
+	assert.ErrorContains(t, err, "requires a mapping")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"gopkg.in/yaml.v3"
+)
+
+// LinkConfig is a link button of the cards of a connector, written as the
+// name of a built-in link or as a mapping with its title and url templates.
+type LinkConfig struct {
+	Name  string `yaml:"-"`
+	Title string `yaml:"title"`
+	URL   string `yaml:"url"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *LinkConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		l.Name = value.Value
+		return nil
+	}
+	type plain LinkConfig
+	return value.Decode((*plain)(l))
+}
+
+// setupLinks wraps converter with the link buttons of links, if any.
+func setupLinks(links []LinkConfig, localizer *card.Localizer, converter card.Converter) (card.Converter, error) {
+	if len(links) == 0 {
+		return converter, nil
+	}
+	templates := make([]card.LinkTemplate, 0, len(links))
+	for _, l := range links {
+		if l.Name == "" {
+			templates = append(templates, card.LinkTemplate{Title: l.Title, URL: l.URL})
+			continue
+		}
+		lt, ok := card.BuiltinLinks[l.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown link '%s', expected runbook, dashboard, alertmanager or graph", l.Name)
+		}
+		templates = append(templates, lt)
+	}
+	ls, err := card.NewLinks(templates, localizer)
+	if err != nil {
+		return nil, err
+	}
+	return card.NewLinksMiddleware(ls, converter), nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSetupLinks(t *testing.T) {
+	var c ConnectorWithCustomTemplate
+	require.NoError(t, yaml.Unmarshal([]byte(`
+request_path: /links
+links:
+  - runbook
+  - title: Logs
+    url: https://logs.example.com/?namespace={{ .Labels.namespace }}
+`), &c))
+	require.Len(t, c.Links, 2)
+	assert.Equal(t, LinkConfig{Name: "runbook"}, c.Links[0])
+	assert.Equal(t, "Logs", c.Links[1].Title)
+
+	tmpl, err := card.ParseTemplateFile("../../default-message-workflow-card.tmpl")
+	require.NoError(t, err)
+	converter, err := setupLinks(c.Links, nil, card.NewTemplatedCardCreator(tmpl, false, setupLogger(Config{LogFormat: "fmt"})))
+	require.NoError(t, err)
+
+	got, err := converter.Convert(context.Background(), webhook.Message{Data: &template.Data{
+		Status: "firing",
+		Alerts: template.Alerts{{
+			Status:      "firing",
+			Labels:      template.KV{"alertname": "HighLoad", "namespace": "shop"},
+			Annotations: template.KV{"runbook_url": "https://runbooks.example.com/high-load"},
+		}},
+	}})
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	set, ok := body[len(body)-1].(*adaptivecards.ActionSet)
+	require.True(t, ok)
+	require.Len(t, set.Actions, 2)
+	assert.Equal(t, "https://runbooks.example.com/high-load", set.Actions[0].(*adaptivecards.ActionOpenURL).URL)
+	assert.Equal(t, "https://logs.example.com/?namespace=shop", set.Actions[1].(*adaptivecards.ActionOpenURL).URL)
+
+	_, err = setupLinks([]LinkConfig{{Name: "wiki"}}, nil, converter)
+	assert.EqualError(t, err, "unknown link 'wiki', expected runbook, dashboard, alertmanager or graph")
+}
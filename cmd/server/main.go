@@ -34,20 +34,20 @@ import (
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/pkg/errors"
 
-	ocprometheus "contrib.go.opencensus.io/exporter/prometheus"
 	"github.com/labstack/echo/v5"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stakater/prometheus-msteams/pkg/audit"
 	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/health"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stakater/prometheus-msteams/pkg/relabel"
 	"github.com/stakater/prometheus-msteams/pkg/service"
 	"github.com/stakater/prometheus-msteams/pkg/transport"
 	"github.com/stakater/prometheus-msteams/pkg/utility"
 	"github.com/stakater/prometheus-msteams/pkg/version"
 
-	"contrib.go.opencensus.io/exporter/jaeger"
-	"go.opencensus.io/plugin/ochttp"
-	"go.opencensus.io/stats/view"
-	"go.opencensus.io/tag"
-	"go.opencensus.io/trace"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	_ "net/http/pprof" //nolint: gosec
 
@@ -63,6 +63,15 @@ type PromTeamsConfig struct {
 	// The value is the Teams webhook url.
 	Connectors                    []map[string]string           `yaml:"connectors"`
 	ConnectorsWithCustomTemplates []ConnectorWithCustomTemplate `yaml:"connectors_with_custom_templates"`
+	// Theme overrides the styles of card.DefaultTheme for all the
+	// connectors.
+	Theme *card.Theme `yaml:"theme"`
+	// TemplateDirs hold the templates, such as partials, available to the
+	// templates of all the connectors: a directory provides its *.tmpl
+	// files, and the other entries are globs.
+	TemplateDirs []string `yaml:"template_dirs"`
+	// Actions configures the callback endpoint of the acknowledge buttons.
+	Actions *ActionsConfig `yaml:"actions"`
 }
 
 // ConnectorWithCustomTemplate .
@@ -71,6 +80,55 @@ type ConnectorWithCustomTemplate struct {
 	TemplateFile      string `yaml:"template_file"`
 	WebhookURL        string `yaml:"webhook_url"`
 	EscapeUnderscores bool   `yaml:"escape_underscores"`
+	// MarkdownEscapeChars overrides the global -markdown-escape-chars flag
+	// for this connector.
+	MarkdownEscapeChars string `yaml:"markdown_escape_chars"`
+	// Template is the name of the template rendering the cards,
+	// card.DefaultEntryTemplate if empty. TemplateFile may be empty if one of
+	// the template_dirs defines it.
+	Template string `yaml:"template"`
+	// TemplateFormat overrides the global -template-format flag for this
+	// connector.
+	TemplateFormat string `yaml:"template_format"`
+	// Locale and Timezone override the global -locale and -timezone flags
+	// for this connector.
+	Locale   string `yaml:"locale"`
+	Timezone string `yaml:"timezone"`
+	// Digest buffers the notifications of the connector and posts them as
+	// one summary card.
+	Digest *DigestConfig `yaml:"digest"`
+	// Schedules mute the notifications of the connector during their time
+	// intervals.
+	Schedules []ScheduleConfig `yaml:"schedules"`
+	// Pipeline holds the middlewares of the connector, the first one being
+	// the outermost. Defaults to logging.
+	Pipeline []MiddlewareConfig `yaml:"pipeline"`
+	// RelabelConfigs and AnnotationRelabelConfigs rewrite the labels and the
+	// annotations of the alerts before anything else.
+	RelabelConfigs           relabel.Configs `yaml:"relabel_configs"`
+	AnnotationRelabelConfigs relabel.Configs `yaml:"annotation_relabel_configs"`
+	// Input selects the format of the requests, Alertmanager webhook
+	// messages by default.
+	Input *InputConfig `yaml:"input"`
+	// Links are the link buttons added to the cards.
+	Links []LinkConfig `yaml:"links"`
+	// Attachments adds a card per alert to the cards, if set.
+	Attachments *AttachmentsConfig `yaml:"attachments"`
+	// Acknowledge adds the Acknowledge and Assign to me buttons to the
+	// cards. It requires the actions to be configured.
+	Acknowledge bool `yaml:"acknowledge"`
+	// Theme overrides the styles of the global theme for this connector.
+	Theme *card.Theme `yaml:"theme"`
+}
+
+// DigestConfig configures the digest of a connector.
+type DigestConfig struct {
+	Window           time.Duration `yaml:"window"`
+	MaxNotifications int           `yaml:"max_notifications"`
+	Top              int           `yaml:"top"`
+	// Bypass holds the label matchers, e.g. {severity="critical"}, of the
+	// alerts posted right away.
+	Bypass []string `yaml:"bypass"`
 }
 
 func parseTeamsConfigFile(f string) (PromTeamsConfig, error) {
@@ -115,6 +173,15 @@ func validateWebhook(workflowType service.WebhookType, u string) error {
 
 //nolint:gocyclo
 func main() {
+	// Render subcommand
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		if err := runRender(os.Args[2:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Parse configuration and flags
 	cfg, err := parseFlags()
 	if err != nil {
@@ -131,8 +198,13 @@ func main() {
 	// Setup logger
 	logger := setupLogger(cfg)
 
+	// Readiness fails until the config and templates are loaded.
+	liveness, readiness := health.NewChecker(), health.NewChecker()
+	readiness.Set(configCheck, health.ErrPending)
+
 	// Setup tracer
-	if err := setupTracer(cfg, logger); err != nil {
+	shutdownTracer, err := setupTracer(cfg, logger)
+	if err != nil {
 		logger.Err(err)
 		os.Exit(1)
 	}
@@ -147,32 +219,97 @@ func main() {
 		}
 	}
 
+	// Setup Prometheus exporter
+	recorder, shutdownMeter, err := setupMeterProvider(stdprometheus.DefaultRegisterer)
+	if err != nil {
+		logger.Err(err)
+		os.Exit(1)
+	}
+
+	// Setup audit log
+	auditLog, err := setupAudit(cfg, logger)
+	if err != nil {
+		logger.Err(err)
+		os.Exit(1)
+	}
+
+	// Setup actions
+	actions, ackStore, err := setupActions(cfg, tc, logger)
+	if err != nil {
+		logger.Err(err)
+		os.Exit(1)
+	}
+
 	// Setup converter
-	defaultConverter, err := setupConverter(cfg, logger)
+	theme, err := connectorTheme(tc, nil)
+	if err != nil {
+		logger.Err(err)
+		os.Exit(1)
+	}
+	defaultConverter, err := setupConverter(cfg, logger, recorder, card.WithTheme(theme), card.WithTemplateDirs(tc.TemplateDirs...))
 	if err != nil {
 		logger.Err(err)
 		os.Exit(1)
 	}
 
 	// Setup HTTP client
-	httpClient := setupHTTPClient(cfg)
+	httpClient := setupHTTPClient(cfg, recorder)
 
 	// Setup routes
-	routes, dRoutes, err := setupRoutes(cfg, tc, logger, defaultConverter, httpClient)
+	routes, dRoutes, converters, err := setupRoutes(cfg, tc, logger, recorder, auditLog, defaultConverter, httpClient)
 	if err != nil {
 		logger.Err(err)
 		os.Exit(1)
 	}
 
-	// Setup Prometheus exporter
-	pe, err := setupPrometheusExporter(logger)
+	// Track the notifications in flight for the shutdown. Requests get a
+	// context canceled once the grace period of the shutdown is over.
+	tracker := service.NewTracker()
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	if err := setupDigests(requestsCtx, tc, routes, tracker, logger); err != nil {
+		logger.Err(err)
+		os.Exit(1)
+	}
+	schedules, err := setupSchedules(requestsCtx, tc, routes, tracker, logger)
 	if err != nil {
 		logger.Err(err)
 		os.Exit(1)
 	}
+	setupRelabeling(tc, routes, logger)
+	if cfg.Async {
+		if err := setupQueues(requestsCtx, cfg, routes, dRoutes, tracker, recorder, logger); err != nil {
+			logger.Err(err)
+			os.Exit(1)
+		}
+	} else {
+		trackRoutes(tracker, routes, dRoutes)
+	}
+
+	// Setup readiness checks. The templates are checked once the server
+	// is started.
+	if cfg.ReadyCheckDNS {
+		addDNSChecks(cfg, tc, net.DefaultResolver, readiness)
+	}
+	readiness.Set(configCheck, nil)
 
 	// Setup server
-	handler := setupServer(logger, routes, dRoutes, tc, pe)
+	handler := setupServer(logger, routes, dRoutes, tc, promhttp.Handler())
+	setupHealth(handler, liveness, readiness)
+	if auditLog != nil {
+		setupAuditAPI(handler, auditLog)
+	}
+	setupSchedulesAPI(handler, schedules)
+	if actions != nil {
+		setupActionsAPI(handler, actions)
+	}
+
+	// Setup preview
+	if cfg.EnablePreview {
+		if err := setupPreview(handler, cfg, tc, logger); err != nil {
+			logger.Err(err)
+			os.Exit(1)
+		}
+	}
 
 	// Setup run group
 	var g run.Group
@@ -181,6 +318,7 @@ func main() {
 			Addr:              cfg.HTTPAddr,
 			Handler:           handler,
 			ReadHeaderTimeout: 30 * time.Second,
+			BaseContext:       func(net.Listener) context.Context { return requestsCtx },
 		}
 		g.Add(
 			func() error {
@@ -194,86 +332,26 @@ func main() {
 				return srv.ListenAndServe()
 			},
 			func(error) {
-				if err != http.ErrServerClosed {
-					if err := srv.Shutdown(context.Background()); err != nil {
-						logger.Err(err)
-					}
-				}
+				shutdownServer(&srv, cancelRequests, tracker, readiness, cfg.ShutdownDelay, cfg.ShutdownGracePeriod, logger)
 			},
 		)
 	}
+	checkTemplates(converters, logger, readiness)
 	{
 		g.Add(run.SignalHandler(context.Background(), syscall.SIGINT, syscall.SIGTERM))
 	}
 	logger.Info("exit", g.Run())
-}
 
-func ocviews() []*view.View {
-	clientKeys := []tag.Key{
-		ochttp.KeyClientMethod, ochttp.KeyClientStatus, ochttp.KeyClientHost, ochttp.KeyClientPath,
-	}
-	serverKeys := []tag.Key{
-		ochttp.StatusCode, ochttp.Method, ochttp.Path,
-	}
-	return []*view.View{
-		// HTTP client metrics.
-		{
-			Name:        "http/client/sent_bytes",
-			Measure:     ochttp.ClientSentBytes,
-			Aggregation: view.Distribution(1024, 2048, 4096, 16384, 65536, 262144, 1048576, 4194304),
-			Description: "Total bytes sent in request body (not including headers), by HTTP method and response status",
-			TagKeys:     clientKeys,
-		},
-		{
-			Name:        "http/client/received_bytes",
-			Measure:     ochttp.ClientReceivedBytes,
-			Aggregation: view.Distribution(1024, 2048, 4096, 16384, 65536, 262144, 1048576, 4194304),
-			Description: "Total bytes received in response bodies (not including headers but including error responses with bodies), by HTTP method and response status",
-			TagKeys:     clientKeys,
-		},
-		{
-			Name:        "http/client/roundtrip_latency",
-			Measure:     ochttp.ClientRoundtripLatency,
-			Aggregation: view.Distribution(1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30),
-			Description: "End-to-end latency, by HTTP method and response status",
-			TagKeys:     clientKeys,
-		},
-		{
-			Name:        "http/client/completed_count",
-			Measure:     ochttp.ClientRoundtripLatency,
-			Aggregation: view.Count(),
-			Description: "Count of completed requests, by HTTP method and response status",
-			TagKeys:     clientKeys,
-		},
-		// HTTP server metrics.
-		{
-			Name:        "http/server/request_count",
-			Description: "Count of HTTP requests started",
-			Measure:     ochttp.ServerRequestCount,
-			Aggregation: view.Count(),
-			TagKeys:     serverKeys,
-		},
-		{
-			Name:        "http/server/request_bytes",
-			Description: "Size distribution of HTTP request body",
-			Measure:     ochttp.ServerRequestBytes,
-			Aggregation: view.Distribution(1024, 2048, 4096, 16384, 65536, 262144, 1048576, 4194304),
-			TagKeys:     serverKeys,
-		},
-		{
-			Name:        "http/server/response_bytes",
-			Description: "Size distribution of HTTP response body",
-			Measure:     ochttp.ServerResponseBytes,
-			Aggregation: view.Distribution(1024, 2048, 4096, 16384, 65536, 262144, 1048576, 4194304),
-			TagKeys:     serverKeys,
-		},
-		{
-			Name:        "http/server/latency",
-			Description: "Latency distribution of HTTP requests",
-			Measure:     ochttp.ServerLatency,
-			Aggregation: view.Distribution(1, 2, 3, 4, 5, 6, 8, 10, 13, 16, 20, 25, 30),
-			TagKeys:     serverKeys,
-		},
+	for _, shutdown := range []shutdownFunc{shutdownTracer, shutdownMeter} {
+		if err := shutdown(context.Background()); err != nil {
+			logger.Err(err)
+		}
+	}
+	if err := auditLog.Close(); err != nil {
+		logger.Err(err)
+	}
+	if err := ackStore.Close(); err != nil {
+		logger.Err(err)
 	}
 }
 
@@ -323,11 +401,21 @@ type Config struct {
 	DebugLogs                     bool
 	JaegerTrace                   bool
 	JaegerAgentAddr               string
+	OTLPTrace                     bool
+	OTLPEndpoint                  string
+	OTLPProtocol                  string
+	OTLPInsecure                  bool
 	HTTPAddr                      string
 	RequestURI                    string
 	TeamsWebhookURL               string
 	TemplateFile                  string
 	EscapeUnderscores             bool
+	MarkdownEscapeChars           string
+	TemplateFormat                string
+	TemplateMaxOutput             int
+	TemplateTimeout               time.Duration
+	Locale                        string
+	Timezone                      string
 	ConfigFile                    string
 	HTTPClientIdleConnTimeout     time.Duration
 	HTTPClientTLSHandshakeTimeout time.Duration
@@ -336,6 +424,16 @@ type Config struct {
 	RetryMax                      int
 	ValidateWebhookURL            bool
 	WebhookType                   service.WebhookType
+	EnablePreview                 bool
+	PreviewHostConfig             string
+	AuditSize                     int
+	AuditFile                     string
+	ReadyCheckDNS                 bool
+	ShutdownDelay                 time.Duration
+	ShutdownGracePeriod           time.Duration
+	Async                         bool
+	AsyncQueueSize                int
+	AsyncWorkers                  int
 }
 
 func parseFlags() (Config, error) {
@@ -344,13 +442,23 @@ func parseFlags() (Config, error) {
 		promVersion                   = fs.Bool("version", false, "Print the version")
 		logFormat                     = fs.String("log-format", "json", "json|fmt")
 		debugLogs                     = fs.Bool("debug", false, "Set log level to debug mode.")
-		jaegerTrace                   = fs.Bool("jaeger-trace", false, "Send traces to Jaeger.")
-		jaegerAgentAddr               = fs.String("jaeger-agent", "localhost:6831", "Jaeger agent endpoint")
+		jaegerTrace                   = fs.Bool("jaeger-trace", false, "Deprecated: use -otlp-trace.")
+		jaegerAgentAddr               = fs.String("jaeger-agent", "", "Deprecated: ignored, use -otlp-endpoint.")
+		otlpTrace                     = fs.Bool("otlp-trace", false, "Export traces with OTLP.")
+		otlpEndpoint                  = fs.String("otlp-endpoint", "", "OTLP collector host:port. Defaults to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317 (grpc), localhost:4318 (http).")
+		otlpProtocol                  = fs.String("otlp-protocol", otlpProtocolGRPC, "OTLP transport protocol (grpc|http).")
+		otlpInsecure                  = fs.Bool("otlp-insecure", false, "Connect to the OTLP collector without TLS.")
 		httpAddr                      = fs.String("http-addr", ":2000", "HTTP listen address.")
 		requestURI                    = fs.String("teams-request-uri", "", "The default request URI path where Prometheus will post to.")
 		teamsWebhookURL               = fs.String("teams-incoming-webhook-url", "", "The default Microsoft Teams webhook connector.")
 		templateFile                  = fs.String("template-file", "", "The Microsoft Teams Message Card template file.")
-		escapeUnderscores             = fs.Bool("auto-escape-underscores", true, "Automatically replace all '_' with '\\_' from texts in the alert.")
+		escapeUnderscores             = fs.Bool("auto-escape-underscores", true, "Escape the '_' with a '\\' in the texts of the cards, except in the URLs.")
+		markdownEscapeChars           = fs.String("markdown-escape-chars", "", "The markdown characters escaped with a '\\' in the texts of the cards, e.g. '_*~'.")
+		templateFormat                = fs.String("template-format", string(card.FormatJSON), "The format of the output of the card templates (json|yaml).")
+		templateMaxOutput             = fs.Int("template-max-output", card.DefaultMaxOutput, "The maximum size in bytes of the output of a card template.")
+		templateTimeout               = fs.Duration("template-timeout", card.DefaultTimeout, "The maximum duration of the execution of a card template.")
+		locale                        = fs.String("locale", card.DefaultLocale, "The default locale of rendered cards (en|de|fr|es|nl).")
+		timezone                      = fs.String("timezone", card.DefaultTimezone, "The default IANA timezone of dates in rendered cards.")
 		configFile                    = fs.String("config-file", "", "The connectors configuration file.")
 		httpClientIdleConnTimeout     = fs.Duration("idle-conn-timeout", 90*time.Second, "The HTTP client idle connection timeout duration.")
 		httpClientTLSHandshakeTimeout = fs.Duration("tls-handshake-timeout", 30*time.Second, "The HTTP client TLS handshake timeout.")
@@ -358,6 +466,16 @@ func parseFlags() (Config, error) {
 		insecureSkipVerify            = fs.Bool("insecure-skip-verify", false, "Disable validation of the server certificate.")
 		retryMax                      = fs.Int("max-retry-count", 3, "The retry maximum for sending requests to the webhook")
 		validateWebhookURL            = fs.Bool("validate-webhook-url", false, "Enforce strict validation of webhook url")
+		enablePreview                 = fs.Bool("enable-preview", false, "Serve the /preview debug endpoint rendering Alertmanager payloads to HTML.")
+		auditSize                     = fs.Int("audit-size", 1000, "The number of deliveries kept in the audit log served on /api/v1/deliveries. 0 disables the audit log.")
+		auditFile                     = fs.String("audit-file", "", "Persist the audit log to this file.")
+		readyCheckDNS                 = fs.Bool("ready-check-dns", false, "Fail /-/ready if a webhook host of the connectors does not resolve.")
+		async                         = fs.Bool("async", false, "Answer 202 to Alertmanager once a notification is queued and deliver it in the background.")
+		asyncQueueSize                = fs.Int("async-queue-size", 1000, "The number of notifications queued per route with -async. Requests get 503 when the queue is full.")
+		asyncWorkers                  = fs.Int("async-workers", 4, "The number of workers delivering the queued notifications of a route with -async.")
+		shutdownDelay                 = fs.Duration("shutdown-delay", 0, "The time to keep accepting requests on shutdown after /-/ready fails, before closing the listener.")
+		shutdownGracePeriod           = fs.Duration("shutdown-grace-period", 25*time.Second, "The time to wait for in-flight deliveries on shutdown before abandoning them.")
+		previewHostConfig             = fs.String("preview-host-config", "", "Host config JSON file used by /preview. Defaults to the Microsoft Teams light theme.")
 	)
 
 	if err := ff.Parse(fs, os.Args[1:], ff.WithEnvVarNoPrefix()); err != nil {
@@ -374,11 +492,21 @@ func parseFlags() (Config, error) {
 		DebugLogs:                     *debugLogs,
 		JaegerTrace:                   *jaegerTrace,
 		JaegerAgentAddr:               *jaegerAgentAddr,
+		OTLPTrace:                     *otlpTrace,
+		OTLPEndpoint:                  *otlpEndpoint,
+		OTLPProtocol:                  *otlpProtocol,
+		OTLPInsecure:                  *otlpInsecure,
 		HTTPAddr:                      *httpAddr,
 		RequestURI:                    *requestURI,
 		TeamsWebhookURL:               *teamsWebhookURL,
 		TemplateFile:                  *templateFile,
 		EscapeUnderscores:             *escapeUnderscores,
+		MarkdownEscapeChars:           *markdownEscapeChars,
+		TemplateFormat:                *templateFormat,
+		TemplateMaxOutput:             *templateMaxOutput,
+		TemplateTimeout:               *templateTimeout,
+		Locale:                        *locale,
+		Timezone:                      *timezone,
 		ConfigFile:                    *configFile,
 		HTTPClientIdleConnTimeout:     *httpClientIdleConnTimeout,
 		HTTPClientTLSHandshakeTimeout: *httpClientTLSHandshakeTimeout,
@@ -387,6 +515,16 @@ func parseFlags() (Config, error) {
 		RetryMax:                      *retryMax,
 		ValidateWebhookURL:            *validateWebhookURL,
 		WebhookType:                   service.Workflow,
+		EnablePreview:                 *enablePreview,
+		PreviewHostConfig:             *previewHostConfig,
+		AuditSize:                     *auditSize,
+		AuditFile:                     *auditFile,
+		ReadyCheckDNS:                 *readyCheckDNS,
+		ShutdownDelay:                 *shutdownDelay,
+		ShutdownGracePeriod:           *shutdownGracePeriod,
+		Async:                         *async,
+		AsyncQueueSize:                *asyncQueueSize,
+		AsyncWorkers:                  *asyncWorkers,
 	}, nil
 }
 
@@ -396,57 +534,184 @@ func setupLogger(cfg Config) *utility.Logger {
 	return logger
 }
 
-func setupTracer(cfg Config, logger *utility.Logger) error {
-	if !cfg.JaegerTrace {
-		return nil
-	}
-
-	logger.Log("message", "jaeger tracing enabled")
-
-	je, err := jaeger.NewExporter(
-		jaeger.Options{
-			AgentEndpoint: cfg.JaegerAgentAddr,
-			ServiceName:   "prometheus-msteams",
-		},
-	)
+// setupConverter returns the converter of the default template, parsed with
+// the localizer of the flags and opts.
+func setupConverter(cfg Config, logger *utility.Logger, m *metrics.Recorder, opts ...card.TemplateOption) (card.Converter, error) {
+	localizer, err := card.NewLocalizer(cfg.Locale, cfg.Timezone)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	trace.RegisterExporter(je)
-	trace.ApplyConfig(
-		trace.Config{
-			DefaultSampler: trace.AlwaysSample(),
-		},
-	)
-	return nil
-}
-
-func setupConverter(cfg Config, logger *utility.Logger) (card.Converter, error) {
-	tmpl, err := card.ParseTemplateFile(cfg.TemplateFile)
+	opts = append([]card.TemplateOption{card.WithLocalizer(localizer)}, opts...)
+	converter, err := newTemplatedConverter(defaultTemplate(cfg), logger, opts...)
 	if err != nil {
 		return nil, err
 	}
-	converter := card.NewTemplatedCardCreator(tmpl, cfg.EscapeUnderscores, logger)
 	converter = card.NewCreatorLoggingMiddleware(
 		logger.With(
 			"template_file", cfg.TemplateFile,
 			"escaped_underscores", cfg.EscapeUnderscores,
+			"locale", localizer.Locale(),
+			"timezone", localizer.Location().String(),
 		),
 		converter,
 	)
+	converter = card.NewCreatorMetricsMiddleware(m, defaultTemplate(cfg).name(), converter)
+	return converter, nil
+}
+
+// templateConfig is how a connector renders its cards.
+type templateConfig struct {
+	File              string
+	Entry             string
+	EscapeUnderscores bool
+	MarkdownChars     string
+	Format            string
+	MaxOutput         int
+	Timeout           time.Duration
+}
+
+// name returns the name of the template in the logs and the metrics.
+func (t templateConfig) name() string {
+	if t.File == "" {
+		return t.Entry
+	}
+	return filepath.Base(t.File)
+}
+
+// defaultTemplate returns the templateConfig of the flags.
+func defaultTemplate(cfg Config) templateConfig {
+	return templateConfig{
+		File:              cfg.TemplateFile,
+		EscapeUnderscores: cfg.EscapeUnderscores,
+		MarkdownChars:     cfg.MarkdownEscapeChars,
+		Format:            cfg.TemplateFormat,
+		MaxOutput:         cfg.TemplateMaxOutput,
+		Timeout:           cfg.TemplateTimeout,
+	}
+}
+
+// connectorTemplate returns the templateConfig of a templated connector.
+func connectorTemplate(c ConnectorWithCustomTemplate, cfg Config) templateConfig {
+	return templateConfig{
+		File:              c.TemplateFile,
+		Entry:             c.Template,
+		EscapeUnderscores: c.EscapeUnderscores,
+		MarkdownChars:     connectorMarkdownChars(c, cfg),
+		Format:            connectorTemplateFormat(c, cfg),
+		MaxOutput:         cfg.TemplateMaxOutput,
+		Timeout:           cfg.TemplateTimeout,
+	}
+}
+
+// newTemplatedConverter parses the template file of t with opts and the
+// limits of t, and returns the Converter rendering it.
+func newTemplatedConverter(t templateConfig, logger *utility.Logger, opts ...card.TemplateOption) (card.Converter, error) {
+	format, err := card.ParseFormat(t.Format)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, card.WithMaxOutput(t.MaxOutput), card.WithTimeout(t.Timeout), card.WithRequiredTemplate(t.Entry))
+	tmpl, err := card.ParseTemplateFile(t.File, opts...)
+	if err != nil {
+		return nil, err
+	}
+	converter := card.NewTemplatedCardCreator(tmpl, t.EscapeUnderscores, logger, card.WithEntryTemplate(t.Entry), card.WithFormat(format))
+	if t.MarkdownChars != "" {
+		converter = card.NewMarkdownEscapeMiddleware(t.MarkdownChars, converter)
+	}
 	return converter, nil
 }
 
-func setupHTTPClient(cfg Config) *http.Client {
+// newDefaultConverter returns the converter of the default template t,
+// rendered with the locale of cfg and the global theme of tc.
+func newDefaultConverter(t templateConfig, cfg Config, tc PromTeamsConfig, logger *utility.Logger) (card.Converter, error) {
+	localizer, err := card.NewLocalizer(cfg.Locale, cfg.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	theme, err := connectorTheme(tc, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newTemplatedConverter(t, logger, card.WithLocalizer(localizer), card.WithTheme(theme), card.WithTemplateDirs(tc.TemplateDirs...))
+}
+
+// connectorConverter returns the converter of a templated connector: its
+// template, rendered with its locale and theme, then its acknowledge
+// buttons, links and attachments. The routes, /preview, render and the
+// readiness checks all render the cards of the connectors with it.
+func connectorConverter(c ConnectorWithCustomTemplate, cfg Config, tc PromTeamsConfig, logger *utility.Logger) (card.Converter, *card.Localizer, error) {
+	localizer, err := card.NewLocalizer(connectorLocale(c, cfg))
+	if err != nil {
+		return nil, nil, err
+	}
+	theme, err := connectorTheme(tc, &c)
+	if err != nil {
+		return nil, nil, err
+	}
+	converter, err := newTemplatedConverter(connectorTemplate(c, cfg), logger, card.WithLocalizer(localizer), card.WithTheme(theme), card.WithTemplateDirs(tc.TemplateDirs...))
+	if err != nil {
+		return nil, nil, err
+	}
+	converter, err = setupAcknowledge(c.Acknowledge, tc, localizer, converter)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The links are added to the card of each alert of the attachments.
+	converter, err = setupLinks(c.Links, localizer, converter)
+	if err != nil {
+		return nil, nil, err
+	}
+	converter, err = setupAttachments(c.Attachments, logger, converter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return converter, localizer, nil
+}
+
+// connectorLocale returns the locale and timezone of a templated connector,
+// falling back to the global flags.
+func connectorLocale(c ConnectorWithCustomTemplate, cfg Config) (string, string) {
+	locale, timezone := cfg.Locale, cfg.Timezone
+	if c.Locale != "" {
+		locale = c.Locale
+	}
+	if c.Timezone != "" {
+		timezone = c.Timezone
+	}
+	return locale, timezone
+}
+
+// connectorMarkdownChars returns the markdown characters escaped by a
+// templated connector, falling back to the global flag.
+func connectorMarkdownChars(c ConnectorWithCustomTemplate, cfg Config) string {
+	if c.MarkdownEscapeChars != "" {
+		return c.MarkdownEscapeChars
+	}
+	return cfg.MarkdownEscapeChars
+}
+
+// connectorTemplateFormat returns the format of the template of a templated
+// connector, falling back to the global flag.
+func connectorTemplateFormat(c ConnectorWithCustomTemplate, cfg Config) string {
+	if c.TemplateFormat != "" {
+		return c.TemplateFormat
+	}
+	return cfg.TemplateFormat
+}
+
+func setupHTTPClient(cfg Config, m *metrics.Recorder) *http.Client {
 	retryClient := retryablehttp.NewClient()
 	if !cfg.DebugLogs {
 		retryClient.Logger = nil
 	}
 	retryClient.RetryMax = cfg.RetryMax
+	retryClient.RequestLogHook = func(_ retryablehttp.Logger, req *http.Request, attempt int) {
+		m.DeliveryAttempt(req.Context(), metrics.RouteFromContext(req.Context()), attempt)
+	}
 	retryClient.HTTPClient = &http.Client{
-		Transport: &ochttp.Transport{
-			Base: &http.Transport{
+		Transport: otelhttp.NewTransport(
+			&http.Transport{
 				Proxy: http.ProxyFromEnvironment,
 				DialContext: (&net.Dialer{
 					Timeout:   30 * time.Second,
@@ -458,12 +723,15 @@ func setupHTTPClient(cfg Config) *http.Client {
 				ExpectContinueTimeout: 1 * time.Second,
 				TLSClientConfig:       &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}, //nolint: gosec
 			},
-		},
+		),
 	}
 	return retryClient.StandardClient()
 }
 
-func setupRoutes(cfg Config, tc PromTeamsConfig, logger *utility.Logger, defaultConverter card.Converter, httpClient *http.Client) ([]transport.Route, []transport.DynamicRoute, error) {
+// setupRoutes returns the routes of the connectors, the dynamic route, and
+// the converters rendering the cards of the default template, under "", and
+// of the templated connectors, by request path.
+func setupRoutes(cfg Config, tc PromTeamsConfig, logger *utility.Logger, m *metrics.Recorder, al *audit.Log, defaultConverter card.Converter, httpClient *http.Client) ([]transport.Route, []transport.DynamicRoute, map[string]card.Converter, error) {
 	var routes []transport.Route
 	var dRoutes []transport.DynamicRoute
 
@@ -477,6 +745,14 @@ func setupRoutes(cfg Config, tc PromTeamsConfig, logger *utility.Logger, default
 		)
 	}
 
+	// The connectors without a template post with the default converter.
+	webhookService := func(webhookURL string) (service.Service, error) {
+		if err := validateWebhook(cfg.WebhookType, webhookURL); cfg.ValidateWebhookURL && err != nil {
+			return nil, err
+		}
+		return service.NewSimpleService(defaultConverter, httpClient, webhookURL, cfg.WebhookType, service.WithMetrics(m), service.WithAudit(al)), nil
+	}
+
 	// Dynamic uri handler: webhook uri is retrieved from request.URL
 	var dr transport.DynamicRoute
 	dr.RequestPath = "/_dynamicwebhook/*"
@@ -495,33 +771,38 @@ func setupRoutes(cfg Config, tc PromTeamsConfig, logger *utility.Logger, default
 			return nil, err
 		}
 
-		var s service.Service
-		s = service.NewSimpleService(defaultConverter, httpClient, webhook, cfg.WebhookType)
-		s = service.NewLoggingService(logger, s)
-		return s, nil
+		s := service.NewSimpleService(defaultConverter, httpClient, webhook, cfg.WebhookType, service.WithMetrics(m), service.WithAudit(al))
+		return setupPipeline(nil, s, service.MiddlewareEnv{
+			Route:   dr.RequestPath,
+			Logger:  logger,
+			Webhook: webhookService,
+		})
 	}
 	dRoutes = append(dRoutes, dr)
 
-	configRoutes, err := connectorsFromConfig(tc, cfg, logger, defaultConverter, httpClient)
+	configRoutes, err := connectorsFromConfig(tc, cfg, logger, m, al, defaultConverter, httpClient, webhookService)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	routes = append(routes, configRoutes...)
 
-	templateRoutes, err := connectorsFromTemplate(tc, cfg, logger, httpClient)
+	templateRoutes, converters, err := connectorsFromTemplate(tc, cfg, logger, m, al, httpClient)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	routes = append(routes, templateRoutes...)
+	converters[""] = defaultConverter
 
 	if err := checkDuplicateRequestPath(routes); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
-	return routes, dRoutes, nil
+	return routes, dRoutes, converters, nil
 }
 
-func connectorsFromConfig(tc PromTeamsConfig, cfg Config, logger *utility.Logger, defaultConverter card.Converter, httpClient *http.Client) ([]transport.Route, error) {
+// connectorsFromConfig returns the routes of the connectors without a
+// template. They go through the default pipeline.
+func connectorsFromConfig(tc PromTeamsConfig, cfg Config, logger *utility.Logger, m *metrics.Recorder, al *audit.Log, defaultConverter card.Converter, httpClient *http.Client, webhookService func(string) (service.Service, error)) ([]transport.Route, error) {
 	var routes []transport.Route
 	// Connectors from config file.
 	for _, c := range tc.Connectors {
@@ -533,75 +814,92 @@ func connectorsFromConfig(tc PromTeamsConfig, cfg Config, logger *utility.Logger
 
 			var r transport.Route
 			r.RequestPath = uri
-			r.Service = service.NewSimpleService(defaultConverter, httpClient, webhook, cfg.WebhookType)
-			r.Service = service.NewLoggingService(logger, r.Service)
+			r.Service = service.NewSimpleService(defaultConverter, httpClient, webhook, cfg.WebhookType, service.WithMetrics(m), service.WithAudit(al))
+			r.Service, err = setupPipeline(nil, r.Service, service.MiddlewareEnv{
+				Route:   uri,
+				Logger:  logger,
+				Webhook: webhookService,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("request_path '%s': %w", uri, err)
+			}
 			routes = append(routes, r)
 		}
 	}
 	return routes, nil
 }
 
-func connectorsFromTemplate(tc PromTeamsConfig, cfg Config, logger *utility.Logger, httpClient *http.Client) ([]transport.Route, error) {
+// connectorsFromTemplate returns the routes of the templated connectors and
+// their converters by request path.
+func connectorsFromTemplate(tc PromTeamsConfig, cfg Config, logger *utility.Logger, m *metrics.Recorder, al *audit.Log, httpClient *http.Client) ([]transport.Route, map[string]card.Converter, error) {
 	var routes []transport.Route
+	converters := map[string]card.Converter{}
 	// Connectors with custom template files.
 	for _, c := range tc.ConnectorsWithCustomTemplates {
 		if len(c.RequestPath) == 0 {
-			return nil, fmt.Errorf("one of the 'templated_connectors' is missing a 'request_path'")
+			return nil, nil, fmt.Errorf("one of the 'templated_connectors' is missing a 'request_path'")
 		}
 		if len(c.WebhookURL) == 0 {
-			return nil, fmt.Errorf("the webhook_url is required for request_path '%s'", c.RequestPath)
+			return nil, nil, fmt.Errorf("the webhook_url is required for request_path '%s'", c.RequestPath)
 		}
 		err := validateWebhook(cfg.WebhookType, c.WebhookURL)
 		if cfg.ValidateWebhookURL && err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		if len(c.TemplateFile) == 0 {
-			return nil, fmt.Errorf("the template_file is required for request_path '%s'", c.RequestPath)
+		if len(c.TemplateFile) == 0 && len(c.Template) == 0 {
+			return nil, nil, fmt.Errorf("the template_file or the template is required for request_path '%s'", c.RequestPath)
 		}
 
-		tmpl, err := card.ParseTemplateFile(c.TemplateFile)
+		converter, localizer, err := connectorConverter(c, cfg, tc, logger)
 		if err != nil {
-			return nil, err
+			return nil, nil, fmt.Errorf("request_path '%s': %w", c.RequestPath, err)
 		}
-
-		converter := card.NewTemplatedCardCreator(tmpl, c.EscapeUnderscores, logger)
+		t := connectorTemplate(c, cfg)
 		converter = card.NewCreatorLoggingMiddleware(
 			logger.With(
 				"template_file", c.TemplateFile,
+				"template", t.Entry,
 				"escaped_underscores", c.EscapeUnderscores,
+				"locale", localizer.Locale(),
+				"timezone", localizer.Location().String(),
 			),
 			converter,
 		)
+		converter = card.NewCreatorMetricsMiddleware(m, t.name(), converter)
+		converters[c.RequestPath] = converter
+
+		webhookService := func(webhookURL string) (service.Service, error) {
+			if err := validateWebhook(cfg.WebhookType, webhookURL); cfg.ValidateWebhookURL && err != nil {
+				return nil, err
+			}
+			return service.NewSimpleService(converter, httpClient, webhookURL, cfg.WebhookType, service.WithMetrics(m), service.WithAudit(al)), nil
+		}
 
 		var r transport.Route
 		r.RequestPath = c.RequestPath
-		r.Service = service.NewSimpleService(converter, httpClient, c.WebhookURL, cfg.WebhookType)
-		r.Service = service.NewLoggingService(logger, r.Service)
+		r.Service = service.NewSimpleService(converter, httpClient, c.WebhookURL, cfg.WebhookType, service.WithMetrics(m), service.WithAudit(al))
+		r.Service, err = setupPipeline(c.Pipeline, r.Service, service.MiddlewareEnv{
+			Route:   c.RequestPath,
+			Logger:  logger,
+			Webhook: webhookService,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("request_path '%s': %w", c.RequestPath, err)
+		}
+		r.Decoder, err = inputDecoder(c.Input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("request_path '%s': %w", c.RequestPath, err)
+		}
 		routes = append(routes, r)
 	}
-	return routes, nil
-}
-
-func setupPrometheusExporter(_ *utility.Logger) (*ocprometheus.Exporter, error) {
-	pe, err := ocprometheus.NewExporter(
-		ocprometheus.Options{
-			Registry: stdprometheus.DefaultRegisterer.(*stdprometheus.Registry),
-		},
-	)
-	if err != nil {
-		return nil, err
-	}
-	if err := view.Register(ocviews()...); err != nil {
-		return nil, err
-	}
-	return pe, nil
+	return routes, converters, nil
 }
 
-func setupServer(logger *utility.Logger, routes []transport.Route, dRoutes []transport.DynamicRoute, tc PromTeamsConfig, pe *ocprometheus.Exporter) *echo.Echo {
+func setupServer(logger *utility.Logger, routes []transport.Route, dRoutes []transport.DynamicRoute, tc PromTeamsConfig, metrics http.Handler) *echo.Echo {
 	// Main app.
 	handler := transport.NewServer(logger.GetLogger(), routes, dRoutes)
 	// Prometheus metrics.
-	handler.GET("/metrics", echo.WrapHandler(pe))
+	handler.GET("/metrics", echo.WrapHandler(metrics))
 	// Pprof.
 	handler.GET("/debug/pprof/*", echo.WrapHandler(http.DefaultServeMux))
 	// Config.
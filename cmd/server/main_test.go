@@ -16,16 +16,23 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	_ "net/http/pprof"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
-	ocprometheus "contrib.go.opencensus.io/exporter/prometheus"
+	"github.com/labstack/echo/v5"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stakater/prometheus-msteams/pkg/card"
 	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
 	"github.com/stakater/prometheus-msteams/pkg/transport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,11 +62,21 @@ func TestParseFlagsDefaults(t *testing.T) {
 	assert.Equal(t, "json", cfg.LogFormat)
 	assert.False(t, cfg.DebugLogs)
 	assert.False(t, cfg.JaegerTrace)
+	assert.False(t, cfg.OTLPTrace)
+	assert.Equal(t, "grpc", cfg.OTLPProtocol)
 	assert.Equal(t, ":2000", cfg.HTTPAddr)
 	assert.Equal(t, service.Workflow, cfg.WebhookType)
 	assert.True(t, cfg.EscapeUnderscores)
 	assert.Equal(t, 3, cfg.RetryMax)
 	assert.False(t, cfg.ValidateWebhookURL)
+	assert.Equal(t, "en", cfg.Locale)
+	assert.Equal(t, "UTC", cfg.Timezone)
+	assert.Equal(t, 1000, cfg.AuditSize)
+	assert.False(t, cfg.ReadyCheckDNS)
+	assert.Equal(t, 25*time.Second, cfg.ShutdownGracePeriod)
+	assert.False(t, cfg.Async)
+	assert.Equal(t, 1000, cfg.AsyncQueueSize)
+	assert.Equal(t, 4, cfg.AsyncWorkers)
 }
 
 func TestParseFlagsWorkflowWebhookUsesCorrectTemplate(t *testing.T) {
@@ -118,7 +135,7 @@ func TestSetupHTTPClient(t *testing.T) {
 		InsecureSkipVerify:            false,
 	}
 
-	client := setupHTTPClient(cfg)
+	client := setupHTTPClient(cfg, nil)
 	assert.NotNil(t, client)
 	assert.NotNil(t, client.Transport)
 }
@@ -163,54 +180,100 @@ func TestSetupHTTPClientInsecureSkipVerify(t *testing.T) {
 		InsecureSkipVerify:            true,
 	}
 
-	client := setupHTTPClient(cfg)
+	client := setupHTTPClient(cfg, nil)
 	assert.NotNil(t, client)
 	assert.NotNil(t, client.Transport)
 }
 
-func TestSetupTracerEnabled(t *testing.T) {
+func TestSetupConverterValidTemplate(t *testing.T) {
 	cfg := Config{
-		JaegerTrace:     true,
-		JaegerAgentAddr: "localhost:6831",
+		TemplateFile:      "../../default-message-workflow-card.tmpl",
+		EscapeUnderscores: true,
 	}
 	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
 
-	err := setupTracer(cfg, logger)
+	converter, err := setupConverter(cfg, logger, nil)
 	assert.NoError(t, err)
+	assert.NotNil(t, converter)
 }
 
-func TestSetupTracerDisabled(t *testing.T) {
+func TestSetupConverterInvalidTemplate(t *testing.T) {
 	cfg := Config{
-		JaegerTrace: false,
+		TemplateFile:      "./nonexistent-template.tmpl",
+		EscapeUnderscores: false,
 	}
 	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
 
-	err := setupTracer(cfg, logger)
-	assert.NoError(t, err)
+	converter, err := setupConverter(cfg, logger, nil)
+	assert.Error(t, err)
+	assert.Nil(t, converter)
 }
 
-func TestSetupConverterValidTemplate(t *testing.T) {
+func TestSetupConverterMarkdownEscapeChars(t *testing.T) {
+	cfg := Config{
+		TemplateFile:        "../../default-message-workflow-card.tmpl",
+		EscapeUnderscores:   true,
+		MarkdownEscapeChars: "%",
+	}
+	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
+	wm, err := testutils.ParseWebhookJSONFromFile("../../test/data/prom_post_request.json")
+	require.NoError(t, err)
+
+	converter, err := setupConverter(cfg, logger, nil)
+	require.NoError(t, err)
+	c, err := converter.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	b, err := json.Marshal(c)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `with 23.28\\%.](http://docker.for.mac.host.internal:9093)`)
+	assert.Contains(t, string(b), `high\\_memory\\_load`)
+
+	tc := ConnectorWithCustomTemplate{MarkdownEscapeChars: "*"}
+	assert.Equal(t, "*", connectorMarkdownChars(tc, cfg))
+	assert.Equal(t, "%", connectorMarkdownChars(ConnectorWithCustomTemplate{}, cfg))
+}
+
+func TestSetupConverterTemplateLimits(t *testing.T) {
 	cfg := Config{
 		TemplateFile:      "../../default-message-workflow-card.tmpl",
-		EscapeUnderscores: true,
+		TemplateMaxOutput: 100,
 	}
 	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
+	wm, err := testutils.ParseWebhookJSONFromFile("../../test/data/prom_post_request.json")
+	require.NoError(t, err)
 
-	converter, err := setupConverter(cfg, logger)
-	assert.NoError(t, err)
-	assert.NotNil(t, converter)
+	converter, err := setupConverter(cfg, logger, nil)
+	require.NoError(t, err)
+	_, err = converter.Convert(context.Background(), wm)
+	assert.ErrorIs(t, err, card.ErrOutputTooLarge)
+
+	cfg.TemplateMaxOutput = 0
+	converter, err = setupConverter(cfg, logger, nil)
+	require.NoError(t, err)
+	_, err = converter.Convert(context.Background(), wm)
+	assert.NoError(t, err, "the default limits apply")
 }
 
-func TestSetupConverterInvalidTemplate(t *testing.T) {
+func TestSetupConverterTemplateFormat(t *testing.T) {
 	cfg := Config{
-		TemplateFile:      "./nonexistent-template.tmpl",
-		EscapeUnderscores: false,
+		TemplateFile:   "../../default-message-workflow-card.tmpl",
+		TemplateFormat: "toml",
 	}
 	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
+	_, err := setupConverter(cfg, logger, nil)
+	assert.ErrorContains(t, err, "unknown template format")
 
-	converter, err := setupConverter(cfg, logger)
-	assert.Error(t, err)
-	assert.Nil(t, converter)
+	// JSON is valid YAML.
+	cfg.TemplateFormat = "yaml"
+	converter, err := setupConverter(cfg, logger, nil)
+	require.NoError(t, err)
+	wm, err := testutils.ParseWebhookJSONFromFile("../../test/data/prom_post_request.json")
+	require.NoError(t, err)
+	_, err = converter.Convert(context.Background(), wm)
+	require.NoError(t, err)
+
+	assert.Equal(t, "json", connectorTemplateFormat(ConnectorWithCustomTemplate{TemplateFormat: "json"}, cfg))
+	assert.Equal(t, "yaml", connectorTemplateFormat(ConnectorWithCustomTemplate{}, cfg))
 }
 
 func TestParseTeamsConfigFileInvalidPath(t *testing.T) {
@@ -231,6 +294,11 @@ func TestParseTeamsConfigFileValidConfig(t *testing.T) {
 	// Check templated connector
 	assert.Equal(t, "/custom", config.ConnectorsWithCustomTemplates[0].RequestPath)
 	assert.True(t, config.ConnectorsWithCustomTemplates[0].EscapeUnderscores)
+	assert.Equal(t, &DigestConfig{
+		Window:           15 * time.Minute,
+		MaxNotifications: 20,
+		Bypass:           []string{`{severity="critical"}`},
+	}, config.ConnectorsWithCustomTemplates[0].Digest)
 }
 
 func TestParseTeamsConfigFileEmptyFile(t *testing.T) {
@@ -248,37 +316,9 @@ func TestParseTeamsConfigFileEmptyFile(t *testing.T) {
 	assert.Empty(t, config.ConnectorsWithCustomTemplates)
 }
 
-func TestSetupTracer(t *testing.T) {
-	cfg := Config{
-		LogFormat: "json",
-		DebugLogs: false,
-	}
-	logger := setupLogger(cfg)
-
-	err := setupTracer(cfg, logger)
-	require.NoError(t, err)
-}
-
-func TestSetupPrometheusExporter(t *testing.T) {
-	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
-
-	exporter, err := setupPrometheusExporter(logger)
-	require.NoError(t, err)
-	assert.NotNil(t, exporter)
-}
-
 func TestSetupServer(t *testing.T) {
 	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
 
-	// Don't call setupPrometheusExporter here to avoid duplicate view registration
-	// Just create a mock exporter for testing purposes
-	pe, err := ocprometheus.NewExporter(
-		ocprometheus.Options{
-			Registry: prometheus.NewRegistry(), // Use new registry to avoid conflicts
-		},
-	)
-	require.NoError(t, err)
-
 	routes := []transport.Route{
 		{
 			RequestPath: "/test",
@@ -287,7 +327,7 @@ func TestSetupServer(t *testing.T) {
 	dRoutes := []transport.DynamicRoute{}
 	tc := PromTeamsConfig{}
 
-	handler := setupServer(logger, routes, dRoutes, tc, pe)
+	handler := setupServer(logger, routes, dRoutes, tc, promhttp.HandlerFor(prometheus.NewRegistry(), promhttp.HandlerOpts{}))
 	assert.NotNil(t, handler)
 }
 
@@ -303,12 +343,12 @@ func TestSetupRoutesWithConnectors(t *testing.T) {
 
 	tc := PromTeamsConfig{}
 	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
-	converter, err := setupConverter(cfg, logger)
+	converter, err := setupConverter(cfg, logger, nil)
 	require.NoError(t, err)
 
-	httpClient := setupHTTPClient(cfg)
+	httpClient := setupHTTPClient(cfg, nil)
 
-	routes, dRoutes, err := setupRoutes(cfg, tc, logger, converter, httpClient)
+	routes, dRoutes, _, err := setupRoutes(cfg, tc, logger, nil, nil, converter, httpClient)
 	require.NoError(t, err)
 
 	assert.GreaterOrEqual(t, len(routes), 1)
@@ -331,18 +371,53 @@ func TestSetupRoutesWithConfigFile(t *testing.T) {
 	}
 
 	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
-	converter, err := setupConverter(cfg, logger)
+	converter, err := setupConverter(cfg, logger, nil)
 	require.NoError(t, err)
 
-	httpClient := setupHTTPClient(cfg)
+	httpClient := setupHTTPClient(cfg, nil)
 
-	routes, dRoutes, err := setupRoutes(cfg, tc, logger, converter, httpClient)
+	routes, dRoutes, _, err := setupRoutes(cfg, tc, logger, nil, nil, converter, httpClient)
 	require.NoError(t, err)
 
 	assert.Len(t, routes, 2)  // Two connectors from config
 	assert.Len(t, dRoutes, 1) // Dynamic route
 }
 
+func TestSetupRoutesUsesDefaultPipeline(t *testing.T) {
+	saved := defaultPipeline
+	defer func() { defaultPipeline = saved }()
+	defaultPipeline = []MiddlewareConfig{{Name: "unknown"}}
+
+	cfg := Config{WebhookType: service.Workflow}
+	tc := PromTeamsConfig{Connectors: []map[string]string{{"/path1": "https://example.com/webhook"}}}
+	logger := setupLogger(Config{LogFormat: "json"})
+	converter, err := setupConverter(Config{TemplateFile: "../../default-message-workflow-card.tmpl"}, logger, nil)
+	require.NoError(t, err)
+
+	_, _, _, err = setupRoutes(cfg, tc, logger, nil, nil, converter, http.DefaultClient)
+	assert.ErrorContains(t, err, "request_path '/path1': unknown middleware 'unknown'")
+
+	_, dRoutes, _, err := setupRoutes(cfg, PromTeamsConfig{}, logger, nil, nil, converter, http.DefaultClient)
+	require.NoError(t, err)
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/_dynamicwebhook/example.com/webhook", nil)
+	_, err = dRoutes[0].ServiceGenerator(e.NewContext(req, httptest.NewRecorder()))
+	assert.ErrorContains(t, err, "unknown middleware 'unknown'")
+}
+
+func TestSetupRoutesUndefinedEntryTemplate(t *testing.T) {
+	cfg := Config{WebhookType: service.Workflow}
+	tc := PromTeamsConfig{ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{{
+		RequestPath:  "/alertmanager",
+		TemplateFile: "../../default-message-workflow-card.tmpl",
+		Template:     "missing.card",
+		WebhookURL:   "https://example.com/webhook",
+	}}}
+	logger := setupLogger(Config{LogFormat: "json"})
+	_, _, _, err := setupRoutes(cfg, tc, logger, nil, nil, nil, http.DefaultClient)
+	assert.ErrorContains(t, err, `template "missing.card" is not defined`)
+}
+
 func TestSetupRoutesWithTemplatedConnectors(t *testing.T) {
 	cfg := Config{
 		TemplateFile:       "../../default-message-workflow-card.tmpl",
@@ -363,18 +438,85 @@ func TestSetupRoutesWithTemplatedConnectors(t *testing.T) {
 	}
 
 	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
-	converter, err := setupConverter(cfg, logger)
+	converter, err := setupConverter(cfg, logger, nil)
 	require.NoError(t, err)
 
-	httpClient := setupHTTPClient(cfg)
+	httpClient := setupHTTPClient(cfg, nil)
 
-	routes, dRoutes, err := setupRoutes(cfg, tc, logger, converter, httpClient)
+	routes, dRoutes, _, err := setupRoutes(cfg, tc, logger, nil, nil, converter, httpClient)
 	require.NoError(t, err)
 
 	assert.Len(t, routes, 1)  // One templated connector
 	assert.Len(t, dRoutes, 1) // Dynamic route
 }
 
+func TestSetupRoutesWithTemplatedConnectorsLocale(t *testing.T) {
+	cfg := Config{
+		TemplateFile: "../../default-message-workflow-card.tmpl",
+		WebhookType:  service.Workflow,
+	}
+	url := "https://custom1.cd.environment.api.powerplatform.com/powerautomate/automations/direct/workflows/id1/triggers/manual/paths/invoke?api-version=1&sp=%2Ftriggers%2Fmanual%2Frun&sv=1.0&sig=customtoken"
+
+	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
+	httpClient := setupHTTPClient(cfg, nil)
+
+	tc := PromTeamsConfig{
+		ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{
+			{
+				RequestPath:  "/de",
+				TemplateFile: "../../default-message-workflow-card.tmpl",
+				WebhookURL:   url,
+				Locale:       "de",
+				Timezone:     "Europe/Berlin",
+			},
+		},
+	}
+	routes, _, err := connectorsFromTemplate(tc, cfg, logger, nil, nil, httpClient)
+	require.NoError(t, err)
+	assert.Len(t, routes, 1)
+
+	tc.ConnectorsWithCustomTemplates[0].Timezone = "Not/AZone"
+	_, _, err = connectorsFromTemplate(tc, cfg, logger, nil, nil, httpClient)
+	assert.Error(t, err)
+}
+
+func TestSetupRoutesWithTemplateDirs(t *testing.T) {
+	cfg := Config{
+		TemplateFile: "../../default-message-workflow-card.tmpl",
+		WebhookType:  service.Workflow,
+	}
+	url := "https://custom1.cd.environment.api.powerplatform.com/powerautomate/automations/direct/workflows/id1/triggers/manual/paths/invoke?api-version=1&sp=%2Ftriggers%2Fmanual%2Frun&sv=1.0&sig=customtoken"
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "compact.tmpl"), []byte(`{{ define "teams.compact" }}
+{"type": "message", "attachments": [{"contentType": "application/vnd.microsoft.card.adaptive", "content": {
+  "type": "AdaptiveCard", "version": "1.5", "body": [{{ template "teams.header" . }}]
+}}]}
+{{ end }}`), 0o600))
+
+	logger := setupLogger(Config{LogFormat: "json", DebugLogs: false})
+	httpClient := setupHTTPClient(cfg, nil)
+
+	tc := PromTeamsConfig{
+		TemplateDirs: []string{dir},
+		ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{
+			{RequestPath: "/compact", Template: "teams.compact", WebhookURL: url},
+		},
+	}
+	routes, _, err := connectorsFromTemplate(tc, cfg, logger, nil, nil, httpClient)
+	require.NoError(t, err)
+	assert.Len(t, routes, 1)
+
+	converter, err := previewConverter(cfg, tc, "/compact", logger)
+	require.NoError(t, err)
+	c, err := converter.Convert(context.Background(), card.SampleMessage())
+	require.NoError(t, err)
+	assert.Len(t, c.Attachments[0].Content.Body, 3)
+
+	tc.ConnectorsWithCustomTemplates[0].Template = ""
+	_, _, err = connectorsFromTemplate(tc, cfg, logger, nil, nil, httpClient)
+	assert.EqualError(t, err, "the template_file or the template is required for request_path '/compact'")
+}
+
 func TestValidateWebhookValidWorkflow(t *testing.T) {
 	url := "https://test.cd.environment.api.powerplatform.com/powerautomate/automations/direct/workflows/testid/triggers/manual/paths/invoke?api-version=1&sp=%2Ftriggers%2Fmanual%2Frun&sv=1.0&sig=testtoken"
 	err := validateWebhook(service.Workflow, url)
@@ -0,0 +1,59 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"gopkg.in/yaml.v3"
+)
+
+// MiddlewareConfig is a middleware of the pipeline of a connector, written
+// as its name or as a mapping with its name and config.
+type MiddlewareConfig struct {
+	Name   string    `yaml:"name"`
+	Config yaml.Node `yaml:"config"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (m *MiddlewareConfig) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		m.Name = value.Value
+		return nil
+	}
+	type plain MiddlewareConfig
+	return value.Decode((*plain)(m))
+}
+
+// defaultPipeline is the pipeline of the connectors without one.
+var defaultPipeline = []MiddlewareConfig{{Name: "logging"}}
+
+// setupPipeline wraps next with the middlewares of pipeline, or of
+// defaultPipeline if empty.
+func setupPipeline(pipeline []MiddlewareConfig, next service.Service, env service.MiddlewareEnv) (service.Service, error) {
+	if len(pipeline) == 0 {
+		pipeline = defaultPipeline
+	}
+	specs := make([]service.MiddlewareSpec, 0, len(pipeline))
+	for _, m := range pipeline {
+		spec := service.MiddlewareSpec{Name: m.Name}
+		if m.Config.Kind != 0 {
+			spec.Decode = m.Config.Decode
+		}
+		specs = append(specs, spec)
+	}
+	return service.NewPipeline(specs, next, env)
+}
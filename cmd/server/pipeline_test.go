@@ -0,0 +1,80 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMiddlewareConfigUnmarshalYAML(t *testing.T) {
+	var c ConnectorWithCustomTemplate
+	require.NoError(t, yaml.Unmarshal([]byte(`
+pipeline:
+  - logging
+  - name: dedup
+    config:
+      window: 10m
+`), &c))
+
+	require.Len(t, c.Pipeline, 2)
+	assert.Equal(t, "logging", c.Pipeline[0].Name)
+	assert.Zero(t, c.Pipeline[0].Config.Kind)
+	assert.Equal(t, "dedup", c.Pipeline[1].Name)
+	assert.Equal(t, yaml.MappingNode, c.Pipeline[1].Config.Kind)
+}
+
+func TestConnectorsFromTemplatePipeline(t *testing.T) {
+	cfg := Config{WebhookType: service.Workflow}
+	url := "https://custom1.cd.environment.api.powerplatform.com/powerautomate/automations/direct/workflows/id1/triggers/manual/paths/invoke?api-version=1&sp=%2Ftriggers%2Fmanual%2Frun&sv=1.0&sig=customtoken"
+	logger := setupLogger(Config{LogFormat: "fmt"})
+	httpClient := setupHTTPClient(cfg, nil)
+
+	var c ConnectorWithCustomTemplate
+	require.NoError(t, yaml.Unmarshal([]byte(`
+request_path: /dev
+template_file: ../../default-message-workflow-card.tmpl
+pipeline:
+  - name: filter
+    config:
+      exclude: ['{severity="info"}']
+  - name: fanout
+    config:
+      webhook_urls: ["`+url+`"]
+  - logging
+`), &c))
+	c.WebhookURL = url
+	tc := PromTeamsConfig{ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{c}}
+	routes, _, err := connectorsFromTemplate(tc, cfg, logger, nil, nil, httpClient)
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+
+	tc.ConnectorsWithCustomTemplates[0].Pipeline = append(tc.ConnectorsWithCustomTemplates[0].Pipeline, MiddlewareConfig{Name: "unknown"})
+	_, _, err = connectorsFromTemplate(tc, cfg, logger, nil, nil, httpClient)
+	assert.EqualError(t, err, "request_path '/dev': unknown middleware 'unknown'")
+
+	// The webhooks of fanout are validated like the webhook_url.
+	cfg.ValidateWebhookURL = true
+	tc.ConnectorsWithCustomTemplates[0].Pipeline = []MiddlewareConfig{{Name: "fanout"}}
+	require.NoError(t, yaml.Unmarshal([]byte(`webhook_urls: ["https://invalid.com"]`), &tc.ConnectorsWithCustomTemplates[0].Pipeline[0].Config))
+	_, _, err = connectorsFromTemplate(tc, cfg, logger, nil, nil, httpClient)
+	assert.ErrorContains(t, err, "unexpected format")
+}
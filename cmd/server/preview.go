@@ -0,0 +1,224 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v5"
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/preview"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// maxPreviewBytes caps the size of the webhook messages posted to /preview.
+const maxPreviewBytes = 1 << 20
+
+// runRender implements the "render" subcommand. It converts an Alertmanager
+// payload, read from the file given as argument or from stdin, with a
+// template and writes the card JSON, or an HTML preview with --html. With
+// -request-path, it renders the card of a templated connector of
+// -config-file as the server does.
+func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var (
+		fs                = flag.NewFlagSet("prometheus-msteams render", flag.ContinueOnError)
+		asHTML            = fs.Bool("html", false, "Write an HTML preview instead of the card JSON.")
+		templateFile      = fs.String("template-file", "./default-message-workflow-card.tmpl", "The Microsoft Teams Message Card template file.")
+		escapeUnderscores = fs.Bool("auto-escape-underscores", true, "Escape the '_' with a '\\' in the texts of the cards, except in the URLs.")
+		entry             = fs.String("template", card.DefaultEntryTemplate, "The name of the template rendering the card.")
+		format            = fs.String("template-format", string(card.FormatJSON), "The format of the output of the template (json|yaml).")
+		templateDirs      = fs.String("template-dirs", "", "Comma separated directories or globs of templates available to the template file.")
+		markdownChars     = fs.String("markdown-escape-chars", "", "The markdown characters escaped with a '\\' in the texts of the card, e.g. '_*~'.")
+		locale            = fs.String("locale", card.DefaultLocale, "The locale of the rendered card (en|de|fr|es|nl).")
+		timezone          = fs.String("timezone", card.DefaultTimezone, "The IANA timezone of dates in the rendered card.")
+		hostConfig        = fs.String("host-config", "", "Host config JSON file used with --html. Defaults to the Microsoft Teams light theme.")
+		configFile        = fs.String("config-file", "", "The connectors config file, for its theme, template dirs and templated connectors.")
+		requestPath       = fs.String("request-path", "", "Render with the templated connector of this request path of -config-file instead of the template flags.")
+		out               = fs.String("out", "", "Write to this file instead of stdout.")
+	)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: prometheus-msteams render [flags] [alert.json]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var payload []byte
+	var err error
+	switch fs.Arg(0) {
+	case "", "-":
+		payload, err = io.ReadAll(stdin)
+	default:
+		payload, err = os.ReadFile(filepath.Clean(fs.Arg(0)))
+	}
+	if err != nil {
+		return err
+	}
+	var msg webhook.Message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("failed to parse webhook message: %w", err)
+	}
+
+	var tc PromTeamsConfig
+	if *configFile != "" {
+		if tc, err = parseTeamsConfigFile(*configFile); err != nil {
+			return err
+		}
+	}
+	if *templateDirs != "" {
+		tc.TemplateDirs = append(tc.TemplateDirs, strings.Split(*templateDirs, ",")...)
+	}
+	cfg := Config{
+		TemplateFile:        *templateFile,
+		EscapeUnderscores:   *escapeUnderscores,
+		MarkdownEscapeChars: *markdownChars,
+		TemplateFormat:      *format,
+		TemplateMaxOutput:   card.DefaultMaxOutput,
+		TemplateTimeout:     card.DefaultTimeout,
+		Locale:              *locale,
+		Timezone:            *timezone,
+	}
+	logger := utility.NewLogger(utility.LogFormatFmt, false)
+	var converter card.Converter
+	if *requestPath != "" {
+		converter, err = previewConverter(cfg, tc, *requestPath, logger)
+	} else {
+		t := defaultTemplate(cfg)
+		t.Entry = *entry
+		converter, err = newDefaultConverter(t, cfg, tc, logger)
+	}
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if *asHTML {
+		hc, err := loadHostConfig(*hostConfig)
+		if err != nil {
+			return err
+		}
+		issues, err := renderPreview(context.Background(), &buf, converter, hc, msg)
+		if err != nil {
+			return err
+		}
+		for _, i := range issues {
+			fmt.Fprintln(stderr, "warning:", i)
+		}
+	} else {
+		c, err := converter.Convert(context.Background(), msg)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+
+	if *out == "" {
+		_, err = stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(filepath.Clean(*out), buf.Bytes(), 0o644) //nolint:gosec
+}
+
+// renderPreview converts msg and writes the HTML preview of the card to w.
+func renderPreview(ctx context.Context, w io.Writer, converter card.Converter, hc preview.HostConfig, msg webhook.Message) ([]preview.Issue, error) {
+	c, err := converter.Convert(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	return preview.NewRenderer(hc).RenderWorkflowCard(w, c)
+}
+
+func loadHostConfig(f string) (preview.HostConfig, error) {
+	if f == "" {
+		return preview.TeamsHostConfig(), nil
+	}
+	return preview.LoadHostConfig(f)
+}
+
+// setupPreview adds the /preview debug endpoint. It renders the Alertmanager
+// payload of a POST request to HTML with the default template, or with the
+// converter of the connector given by the request_path query parameter. The
+// template is parsed on every request, so changes show up without a restart.
+// The number of issues found is returned in the X-Preview-Issues header.
+func setupPreview(e *echo.Echo, cfg Config, tc PromTeamsConfig, logger *utility.Logger) error {
+	hc, err := loadHostConfig(cfg.PreviewHostConfig)
+	if err != nil {
+		return err
+	}
+	logger.Info("message", "preview endpoint enabled", "path", "/preview")
+
+	e.POST("/preview", func(c *echo.Context) error {
+		converter, err := previewConverter(cfg, tc, c.QueryParam("request_path"), logger)
+		if err != nil {
+			return c.String(http.StatusBadRequest, err.Error())
+		}
+
+		var msg webhook.Message
+		body := http.MaxBytesReader(c.Response(), c.Request().Body, maxPreviewBytes)
+		if err := json.NewDecoder(body).Decode(&msg); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return c.String(http.StatusRequestEntityTooLarge, fmt.Sprintf("the webhook message exceeds %d bytes", tooLarge.Limit))
+			}
+			return c.String(http.StatusBadRequest, fmt.Sprintf("failed to parse webhook message: %s", err))
+		}
+
+		var buf bytes.Buffer
+		issues, err := renderPreview(c.Request().Context(), &buf, converter, hc, msg)
+		if err != nil {
+			return c.String(http.StatusUnprocessableEntity, err.Error())
+		}
+		c.Response().Header().Set("X-Preview-Issues", strconv.Itoa(len(issues)))
+		return c.HTMLBlob(http.StatusOK, buf.Bytes())
+	})
+	return nil
+}
+
+// previewConverter returns the converter of the connector of requestPath,
+// or of the default template when requestPath is empty, as the routes build
+// it.
+func previewConverter(cfg Config, tc PromTeamsConfig, requestPath string, logger *utility.Logger) (card.Converter, error) {
+	if requestPath == "" {
+		return newDefaultConverter(defaultTemplate(cfg), cfg, tc, logger)
+	}
+	for _, c := range tc.ConnectorsWithCustomTemplates {
+		if c.RequestPath != requestPath {
+			continue
+		}
+		converter, _, err := connectorConverter(c, cfg, tc, logger)
+		return converter, err
+	}
+	return nil, fmt.Errorf("no templated connector for request_path '%s'", requestPath)
+}
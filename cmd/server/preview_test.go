@@ -0,0 +1,168 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPayload  = "../../test/data/prom_post_request.json"
+	testTemplate = "../../default-message-workflow-card.tmpl"
+)
+
+func TestRunRenderJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := runRender([]string{"-template-file", testTemplate, testPayload}, nil, &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Contains(t, stdout.String(), `"type": "message"`)
+	assert.Contains(t, stdout.String(), `"type": "AdaptiveCard"`)
+	assert.Empty(t, stderr.String())
+}
+
+func TestRunRenderHTML(t *testing.T) {
+	payload, err := os.ReadFile(testPayload)
+	require.NoError(t, err)
+	out := filepath.Join(t.TempDir(), "preview.html")
+
+	var stdout, stderr bytes.Buffer
+	err = runRender([]string{"--html", "-template-file", testTemplate, "-locale", "de", "-out", out}, bytes.NewReader(payload), &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Empty(t, stdout.String())
+
+	html, err := os.ReadFile(out) //nolint:gosec
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "<!DOCTYPE html>")
+	assert.Contains(t, string(html), "Prometheus-Alarm")
+}
+
+func TestRunRenderConfigFile(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(f, []byte(`
+theme:
+  severities:
+    warning:
+      icon: Bug
+connectors_with_custom_templates:
+  - request_path: /dev
+    template_file: `+testTemplate+`
+    webhook_url: https://example.com
+    links:
+      - title: Custom link
+        url: https://example.com/custom
+`), 0o600))
+
+	// The default template gets the global theme.
+	var stdout, stderr bytes.Buffer
+	err := runRender([]string{"-template-file", testTemplate, "-config-file", f, testPayload}, nil, &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Contains(t, stdout.String(), `"icon": "Bug"`)
+	assert.NotContains(t, stdout.String(), "Custom link")
+
+	// A templated connector gets its links too.
+	stdout.Reset()
+	err = runRender([]string{"-config-file", f, "-request-path", "/dev", testPayload}, nil, &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Contains(t, stdout.String(), `"icon": "Bug"`)
+	assert.Contains(t, stdout.String(), "Custom link")
+
+	err = runRender([]string{"-config-file", f, "-request-path", "/unknown", testPayload}, nil, &stdout, &stderr)
+	assert.ErrorContains(t, err, "no templated connector")
+}
+
+func TestRunRenderErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	err := runRender([]string{"-template-file", testTemplate}, strings.NewReader("{"), &stdout, &stderr)
+	assert.ErrorContains(t, err, "failed to parse webhook message")
+
+	err = runRender([]string{"-template-file", "./nonexistent.tmpl", testPayload}, nil, &stdout, &stderr)
+	assert.Error(t, err)
+
+	err = runRender([]string{"--html", "-template-file", testTemplate, "-host-config", "./nonexistent.json", testPayload}, nil, &stdout, &stderr)
+	assert.Error(t, err)
+
+	err = runRender([]string{"-config-file", "./nonexistent.yml", testPayload}, nil, &stdout, &stderr)
+	assert.Error(t, err)
+
+	err = runRender([]string{"-unknown"}, nil, &stdout, &stderr)
+	assert.Error(t, err)
+}
+
+func TestSetupPreview(t *testing.T) {
+	payload, err := os.ReadFile(testPayload)
+	require.NoError(t, err)
+
+	cfg := Config{TemplateFile: testTemplate, Locale: "en", Timezone: "UTC"}
+	tc := PromTeamsConfig{
+		ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{
+			{RequestPath: "/german", TemplateFile: testTemplate, WebhookURL: "https://example.com", Locale: "de"},
+			{RequestPath: "/ack", TemplateFile: testTemplate, WebhookURL: "https://example.com", Acknowledge: true},
+		},
+	}
+	e := echo.New()
+	require.NoError(t, setupPreview(e, cfg, tc, utility.NewLogger(utility.LogFormatFmt, false)))
+
+	post := func(target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/preview", string(payload))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	assert.NotEmpty(t, rec.Header().Get("X-Preview-Issues"))
+	assert.Contains(t, rec.Body.String(), "Prometheus Alert")
+
+	rec = post("/preview?request_path=/german", string(payload))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Prometheus-Alarm")
+
+	// The connectors are previewed with their whole converter, which
+	// requires the actions for the acknowledge buttons.
+	rec = post("/preview?request_path=/ack", string(payload))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "acknowledge requires the actions")
+
+	rec = post("/preview?request_path=/unknown", string(payload))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "no templated connector")
+
+	rec = post("/preview", "{")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = post("/preview", `{"receiver": "`+strings.Repeat("x", maxPreviewBytes)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestSetupPreviewInvalidHostConfig(t *testing.T) {
+	cfg := Config{PreviewHostConfig: "./nonexistent.json"}
+	assert.Error(t, setupPreview(echo.New(), cfg, PromTeamsConfig{}, utility.NewLogger(utility.LogFormatFmt, false)))
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// setupQueues gives each route a delivery queue of cfg.AsyncQueueSize
+// notifications posted by cfg.AsyncWorkers workers, which stop once ctx is
+// done. The queued notifications are added to t.
+func setupQueues(ctx context.Context, cfg Config, routes []transport.Route, dRoutes []transport.DynamicRoute, t *service.Tracker, m *metrics.Recorder, logger *utility.Logger) error {
+	newQueue := func(route string) (*service.Queue, error) {
+		return service.NewQueue(ctx, route, cfg.AsyncQueueSize, cfg.AsyncWorkers, logger,
+			service.WithQueueMetrics(m),
+			service.WithQueueTracker(t),
+		)
+	}
+	var err error
+	for i := range routes {
+		if routes[i].Queue, err = newQueue(routes[i].RequestPath); err != nil {
+			return err
+		}
+	}
+	for i := range dRoutes {
+		if dRoutes[i].Queue, err = newQueue(dRoutes[i].RequestPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupQueues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := setupLogger(Config{LogFormat: "fmt"})
+	routes := []transport.Route{{RequestPath: "/a"}, {RequestPath: "/b"}}
+	dRoutes := []transport.DynamicRoute{{RequestPath: "/_dynamicwebhook/*"}}
+
+	require.NoError(t, setupQueues(ctx, Config{AsyncQueueSize: 10, AsyncWorkers: 2}, routes, dRoutes, service.NewTracker(), nil, logger))
+	assert.NotNil(t, routes[0].Queue)
+	assert.NotNil(t, routes[1].Queue)
+	assert.NotSame(t, routes[0].Queue, routes[1].Queue)
+	assert.NotNil(t, dRoutes[0].Queue)
+
+	routes = []transport.Route{{RequestPath: "/a"}}
+	assert.Error(t, setupQueues(ctx, Config{AsyncQueueSize: 0, AsyncWorkers: 2}, routes, nil, service.NewTracker(), nil, logger))
+}
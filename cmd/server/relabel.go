@@ -0,0 +1,42 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// setupRelabeling wraps the services of the routes of the templated
+// connectors with relabel configs with a relabeling service, so that the
+// schedules, digests and pipelines get the relabeled alerts.
+func setupRelabeling(tc PromTeamsConfig, routes []transport.Route, logger *utility.Logger) {
+	connectors := map[string]ConnectorWithCustomTemplate{}
+	for _, c := range tc.ConnectorsWithCustomTemplates {
+		if len(c.RelabelConfigs) > 0 || len(c.AnnotationRelabelConfigs) > 0 {
+			connectors[c.RequestPath] = c
+		}
+	}
+	for i := range routes {
+		c, ok := connectors[routes[i].RequestPath]
+		if !ok {
+			continue
+		}
+		routes[i].Service = service.NewRelabelService(c.RelabelConfigs, c.AnnotationRelabelConfigs, logger.With("route", c.RequestPath), routes[i].Service)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stakater/prometheus-msteams/pkg/relabel"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSetupRelabeling(t *testing.T) {
+	var tc PromTeamsConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+connectors_with_custom_templates:
+  - request_path: /relabeled
+    relabel_configs:
+      - action: drop
+        source_labels: [alertname]
+        regex: Watchdog
+    annotation_relabel_configs:
+      - source_labels: [__label_alertname]
+        target_label: runbook
+        replacement: https://runbooks.example.com/$1
+  - request_path: /plain
+`), &tc))
+	c := tc.ConnectorsWithCustomTemplates[0]
+	require.Len(t, c.RelabelConfigs, 1)
+	assert.Equal(t, relabel.Drop, c.RelabelConfigs[0].Action)
+	require.Len(t, c.AnnotationRelabelConfigs, 1)
+	assert.Equal(t, relabel.Replace, c.AnnotationRelabelConfigs[0].Action)
+
+	routes := []transport.Route{{RequestPath: "/relabeled", Service: slowService{}}, {RequestPath: "/plain", Service: slowService{}}}
+	setupRelabeling(tc, routes, setupLogger(Config{LogFormat: "fmt"}))
+	assert.NotEqual(t, slowService{}, routes[0].Service)
+	assert.Equal(t, slowService{}, routes[1].Service)
+
+	err := yaml.Unmarshal([]byte(`
+connectors_with_custom_templates:
+  - request_path: /invalid
+    relabel_configs:
+      - action: replace
+`), &tc)
+	assert.ErrorContains(t, err, "requires a target_label")
+
+	err = yaml.Unmarshal([]byte(`
+connectors_with_custom_templates:
+  - request_path: /empty
+    annotation_relabel_configs:
+      -
+`), &tc)
+	assert.ErrorContains(t, err, "relabel config 0 is empty")
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// ScheduleConfig configures a schedule of a connector.
+type ScheduleConfig struct {
+	Name string `yaml:"name"`
+	// Action is drop, delay or digest.
+	Action string `yaml:"action"`
+	// Matchers holds the label matchers, e.g. {severity=~"warning|info"}, of
+	// the alerts muted. All the alerts are muted if empty.
+	Matchers []string `yaml:"matchers"`
+	// TimeIntervals use the syntax of the Alertmanager time intervals.
+	TimeIntervals []timeinterval.TimeInterval `yaml:"time_intervals"`
+}
+
+// setupSchedules wraps the services of the routes of the templated connectors
+// with schedules with a service.ScheduleService, and returns them. The held
+// notifications are flushed with t, and those posted once their schedule
+// ends are canceled with ctx.
+func setupSchedules(ctx context.Context, tc PromTeamsConfig, routes []transport.Route, t *service.Tracker, logger *utility.Logger) ([]*service.ScheduleService, error) {
+	configs := map[string][]ScheduleConfig{}
+	for _, c := range tc.ConnectorsWithCustomTemplates {
+		if len(c.Schedules) > 0 {
+			configs[c.RequestPath] = c.Schedules
+		}
+	}
+	var out []*service.ScheduleService
+	for i := range routes {
+		scs, ok := configs[routes[i].RequestPath]
+		if !ok {
+			continue
+		}
+		schedules, err := schedulesConfig(scs)
+		if err != nil {
+			return nil, fmt.Errorf("request_path '%s': %w", routes[i].RequestPath, err)
+		}
+		s, err := service.NewScheduleService(ctx, routes[i].RequestPath, schedules, routes[i].Service, logger, service.WithScheduleTracker(t))
+		if err != nil {
+			return nil, fmt.Errorf("request_path '%s': %w", routes[i].RequestPath, err)
+		}
+		routes[i].Service = s
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func schedulesConfig(scs []ScheduleConfig) ([]service.Schedule, error) {
+	schedules := make([]service.Schedule, 0, len(scs))
+	for _, sc := range scs {
+		s := service.Schedule{
+			Name:      sc.Name,
+			Action:    service.ScheduleAction(sc.Action),
+			Intervals: sc.TimeIntervals,
+		}
+		for _, m := range sc.Matchers {
+			matchers, err := labels.ParseMatchers(m)
+			if err != nil {
+				return nil, fmt.Errorf("invalid matchers '%s' of schedule '%s': %w", m, sc.Name, err)
+			}
+			s.Matchers = append(s.Matchers, matchers)
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+// setupSchedulesAPI serves the current state of schedules on
+// GET /api/v1/schedules.
+func setupSchedulesAPI(e *echo.Echo, schedules []*service.ScheduleService) {
+	e.GET("/api/v1/schedules", func(c *echo.Context) error {
+		now := time.Now()
+		states := []service.ScheduleState{}
+		for _, s := range schedules {
+			states = append(states, s.States(now)...)
+		}
+		return c.JSON(http.StatusOK, map[string][]service.ScheduleState{"schedules": states})
+	})
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const schedulesConfigYAML = `
+connectors_with_custom_templates:
+  - request_path: /dev
+    schedules:
+      - name: nights
+        action: delay
+        matchers:
+          - '{severity=~"warning|info"}'
+        time_intervals:
+          - times:
+              - start_time: "00:00"
+                end_time: "24:00"
+            location: Europe/Berlin
+      - name: never
+        action: drop
+        time_intervals:
+          - years: ["2000"]
+  - request_path: /prod
+`
+
+func TestSetupSchedules(t *testing.T) {
+	var tc PromTeamsConfig
+	require.NoError(t, yaml.Unmarshal([]byte(schedulesConfigYAML), &tc))
+	require.Len(t, tc.ConnectorsWithCustomTemplates[0].Schedules, 2)
+	nights := tc.ConnectorsWithCustomTemplates[0].Schedules[0]
+	require.Len(t, nights.TimeIntervals, 1)
+	assert.Equal(t, "Europe/Berlin", nights.TimeIntervals[0].Location.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	routes := []transport.Route{{RequestPath: "/dev", Service: slowService{}}, {RequestPath: "/prod", Service: slowService{}}}
+	schedules, err := setupSchedules(ctx, tc, routes, service.NewTracker(), setupLogger(Config{LogFormat: "fmt"}))
+	require.NoError(t, err)
+	require.Len(t, schedules, 1)
+	assert.Same(t, schedules[0], routes[0].Service)
+	assert.Equal(t, slowService{}, routes[1].Service)
+
+	e := echo.New()
+	setupSchedulesAPI(e, schedules)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/schedules", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var got map[string][]service.ScheduleState
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, []service.ScheduleState{
+		{Route: "/dev", Name: "nights", Action: service.ScheduleDelay, Active: true},
+		{Route: "/dev", Name: "never", Action: service.ScheduleDrop},
+	}, got["schedules"])
+}
+
+func TestSetupSchedulesInvalid(t *testing.T) {
+	tests := map[string]ScheduleConfig{
+		"invalid matchers": {Name: "a", Action: "drop", Matchers: []string{`{severity}`}},
+		"invalid action":   {Name: "a", Action: "mute"},
+	}
+	for name, sc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc := PromTeamsConfig{ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{{RequestPath: "/dev", Schedules: []ScheduleConfig{sc}}}}
+			routes := []transport.Route{{RequestPath: "/dev", Service: slowService{}}}
+			_, err := setupSchedules(context.Background(), tc, routes, service.NewTracker(), setupLogger(Config{LogFormat: "fmt"}))
+			assert.ErrorContains(t, err, "request_path '/dev'")
+		})
+	}
+}
+
+func TestSetupSchedulesAPIEmpty(t *testing.T) {
+	e := echo.New()
+	setupSchedulesAPI(e, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/schedules", nil))
+	assert.JSONEq(t, `{"schedules": []}`, rec.Body.String())
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v5"
+	"github.com/stakater/prometheus-msteams/pkg/health"
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// shutdownCheck is the readiness check failing once the server shuts down.
+const shutdownCheck = "shutdown"
+
+var errShuttingDown = errors.New("shutting down")
+
+// abandonTimeout bounds the wait for the abandoned deliveries to return
+// after their contexts are canceled, so that they are audited before exit.
+const abandonTimeout = 5 * time.Second
+
+// flushTimeout bounds the flush of the buffered digests on shutdown, which
+// does not share the grace period of the in-flight deliveries.
+const flushTimeout = 5 * time.Second
+
+// trackRoutes wraps the services of routes and dRoutes with t.
+func trackRoutes(t *service.Tracker, routes []transport.Route, dRoutes []transport.DynamicRoute) {
+	for i := range routes {
+		routes[i].Service = service.NewTrackingService(t, routes[i].Service)
+	}
+	for i := range dRoutes {
+		generate := dRoutes[i].ServiceGenerator
+		dRoutes[i].ServiceGenerator = func(c *echo.Context) (service.Service, error) {
+			s, err := generate(c)
+			if err != nil || s == nil {
+				return s, err
+			}
+			return service.NewTrackingService(t, s), nil
+		}
+	}
+}
+
+// shutdownServer marks the server not ready and keeps accepting requests
+// for delay, so that the load balancers see the failed readiness before the
+// listener closes. It then stops accepting requests and waits up to grace for
+// the in-flight and queued deliveries and the buffered digests. The deliveries
+// still pending after grace are logged as abandoned and returned, and their
+// contexts canceled with cancelRequests.
+func shutdownServer(srv *http.Server, cancelRequests context.CancelFunc, t *service.Tracker, readiness *health.Checker, delay, grace time.Duration, logger *utility.Logger) []service.InFlight {
+	readiness.Set(shutdownCheck, errShuttingDown)
+	logger.Info("message", "shutting down", "in_flight", len(t.InFlight()), "delay", delay, "grace_period", grace)
+	time.Sleep(delay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	drainErr := srv.Shutdown(ctx)
+	if drainErr == nil {
+		// Queued notifications are posted after their request returned.
+		drainErr = t.Wait(ctx)
+	}
+	// Buffered digests are posted without waiting for their window, even
+	// when the drain timed out.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancelFlush()
+	flushErr := t.Flush(flushCtx)
+	if errors.Join(drainErr, flushErr) == nil {
+		return nil
+	}
+	for _, err := range []error{drainErr, flushErr} {
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			logger.Err(err)
+		}
+	}
+
+	abandoned := t.InFlight()
+	for _, n := range abandoned {
+		logger.Warn(
+			"message", "notification abandoned",
+			"route", n.Route,
+			"receiver", n.Receiver,
+			"group_key", n.GroupKey,
+			"alerts", strings.Join(n.Alerts, ","),
+			"in_flight_for", time.Since(n.Since),
+		)
+	}
+	cancelRequests()
+	if err := srv.Close(); err != nil {
+		logger.Err(err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), abandonTimeout)
+	defer cancel()
+	if err := t.Wait(ctx); err != nil {
+		logger.Err(err, "message", "abandoned notifications did not return")
+	}
+	return abandoned
+}
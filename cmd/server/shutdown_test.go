@@ -0,0 +1,207 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/health"
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowService posts after delay, or fails when its context is canceled.
+type slowService struct {
+	started  chan struct{}
+	delay    time.Duration
+	canceled chan struct{}
+}
+
+func (s slowService) Post(ctx context.Context, _ webhook.Message) ([]service.PostResponse, error) {
+	s.started <- struct{}{}
+	select {
+	case <-time.After(s.delay):
+		return nil, nil
+	case <-ctx.Done():
+		close(s.canceled)
+		return nil, ctx.Err()
+	}
+}
+
+// startTrackedServer serves s on /alertmanager and posts a notification to
+// it, returning once s received it.
+func startTrackedServer(t *testing.T, s slowService) (*http.Server, context.CancelFunc, *service.Tracker) {
+	tracker := service.NewTracker()
+	routes := []transport.Route{{RequestPath: "/alertmanager", Service: s}}
+	trackRoutes(tracker, routes, nil)
+
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	srv := &http.Server{
+		Handler:           transport.NewServer(setupLogger(Config{LogFormat: "fmt"}).GetLogger(), routes, nil),
+		ReadHeaderTimeout: time.Second,
+		BaseContext:       func(net.Listener) context.Context { return requestsCtx },
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv.Addr = l.Addr().String()
+	go func() { _ = srv.Serve(l) }()
+
+	body := `{"version": "4", "receiver": "teams", "groupKey": "{}:{alertname=\"HighLoad\"}", "alerts": [{"fingerprint": "abc"}]}`
+	go func() {
+		resp, err := http.Post("http://"+l.Addr().String()+"/alertmanager", "application/json", strings.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	select {
+	case <-s.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the notification did not reach the service")
+	}
+	return srv, cancelRequests, tracker
+}
+
+func TestShutdownServerDrains(t *testing.T) {
+	s := slowService{started: make(chan struct{}), delay: 50 * time.Millisecond, canceled: make(chan struct{})}
+	srv, cancelRequests, tracker := startTrackedServer(t, s)
+	defer cancelRequests()
+	readiness := health.NewChecker()
+
+	abandoned := shutdownServer(srv, cancelRequests, tracker, readiness, 0, 5*time.Second, setupLogger(Config{LogFormat: "fmt"}))
+
+	assert.Empty(t, abandoned)
+	assert.Empty(t, tracker.InFlight())
+	assert.Equal(t, "shutting down", readiness.Run(context.Background()).Checks[shutdownCheck])
+}
+
+func TestShutdownServerDelay(t *testing.T) {
+	s := slowService{started: make(chan struct{}, 2), canceled: make(chan struct{})}
+	srv, cancelRequests, tracker := startTrackedServer(t, s)
+	defer cancelRequests()
+	readiness := health.NewChecker()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		shutdownServer(srv, cancelRequests, tracker, readiness, 200*time.Millisecond, 5*time.Second, setupLogger(Config{LogFormat: "fmt"}))
+	}()
+	require.Eventually(t, func() bool { return !readiness.Run(context.Background()).OK() }, time.Second, time.Millisecond)
+
+	// Requests are still accepted while the server is not ready.
+	resp, err := http.Post("http://"+srv.Addr+"/alertmanager", "application/json", strings.NewReader(`{"version": "4", "receiver": "teams", "groupKey": "{}:{alertname=\"HighLoad\"}", "alerts": [{"fingerprint": "def"}]}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, s.started, 1)
+	<-done
+}
+
+func TestShutdownServerAbandons(t *testing.T) {
+	s := slowService{started: make(chan struct{}), delay: time.Hour, canceled: make(chan struct{})}
+	srv, cancelRequests, tracker := startTrackedServer(t, s)
+	readiness := health.NewChecker()
+
+	abandoned := shutdownServer(srv, cancelRequests, tracker, readiness, 0, 50*time.Millisecond, setupLogger(Config{LogFormat: "fmt"}))
+
+	require.Len(t, abandoned, 1)
+	assert.Equal(t, "/alertmanager", abandoned[0].Route)
+	assert.Equal(t, "teams", abandoned[0].Receiver)
+	assert.Equal(t, `{}:{alertname="HighLoad"}`, abandoned[0].GroupKey)
+	assert.Equal(t, []string{"abc"}, abandoned[0].Alerts)
+	select {
+	case <-s.canceled:
+	default:
+		t.Fatal("the context of the abandoned notification was not canceled")
+	}
+	assert.Empty(t, tracker.InFlight())
+}
+
+func TestShutdownServerAbandonsQueued(t *testing.T) {
+	tracker := service.NewTracker()
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	routes := []transport.Route{{RequestPath: "/alertmanager"}}
+	require.NoError(t, setupQueues(requestsCtx, Config{AsyncQueueSize: 10, AsyncWorkers: 1}, routes, nil, tracker, nil, setupLogger(Config{LogFormat: "fmt"})))
+
+	s := slowService{started: make(chan struct{}), delay: time.Hour, canceled: make(chan struct{})}
+	queued := slowService{started: make(chan struct{}, 1), delay: time.Hour, canceled: make(chan struct{})}
+	msg := func(groupKey string) webhook.Message {
+		return webhook.Message{Data: &template.Data{}, GroupKey: groupKey}
+	}
+	require.NoError(t, routes[0].Queue.Enqueue(context.Background(), s, msg("first")))
+	<-s.started
+	require.NoError(t, routes[0].Queue.Enqueue(context.Background(), queued, msg("queued")))
+
+	srv := &http.Server{ReadHeaderTimeout: time.Second}
+	abandoned := shutdownServer(srv, cancelRequests, tracker, health.NewChecker(), 0, 50*time.Millisecond, setupLogger(Config{LogFormat: "fmt"}))
+
+	var groupKeys []string
+	for _, n := range abandoned {
+		groupKeys = append(groupKeys, n.GroupKey)
+	}
+	assert.Equal(t, []string{"first", "queued"}, groupKeys)
+	assert.Empty(t, tracker.InFlight())
+	// The queued notification is posted with a canceled context.
+	<-queued.canceled
+}
+
+func TestShutdownServerFlushesDigests(t *testing.T) {
+	tracker := service.NewTracker()
+	s := slowService{started: make(chan struct{}, 1), canceled: make(chan struct{})}
+	tc := PromTeamsConfig{ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{
+		{RequestPath: "/alertmanager", Digest: &DigestConfig{Window: time.Hour}},
+	}}
+	routes := []transport.Route{{RequestPath: "/alertmanager", Service: s}}
+	require.NoError(t, setupDigests(context.Background(), tc, routes, tracker, setupLogger(Config{LogFormat: "fmt"})))
+
+	_, err := routes[0].Service.Post(context.Background(), webhook.Message{Data: &template.Data{Alerts: template.Alerts{{Fingerprint: "abc"}}}})
+	require.NoError(t, err)
+	assert.Empty(t, s.started)
+
+	srv := &http.Server{ReadHeaderTimeout: time.Second}
+	abandoned := shutdownServer(srv, func() {}, tracker, health.NewChecker(), 0, 5*time.Second, setupLogger(Config{LogFormat: "fmt"}))
+
+	assert.Empty(t, abandoned)
+	assert.Len(t, s.started, 1)
+}
+
+func TestShutdownServerFlushesDigestsAfterTimeout(t *testing.T) {
+	s := slowService{started: make(chan struct{}), delay: time.Hour, canceled: make(chan struct{})}
+	srv, cancelRequests, tracker := startTrackedServer(t, s)
+
+	digested := slowService{started: make(chan struct{}, 1), canceled: make(chan struct{})}
+	tc := PromTeamsConfig{ConnectorsWithCustomTemplates: []ConnectorWithCustomTemplate{
+		{RequestPath: "/digest", Digest: &DigestConfig{Window: time.Hour}},
+	}}
+	routes := []transport.Route{{RequestPath: "/digest", Service: digested}}
+	require.NoError(t, setupDigests(context.Background(), tc, routes, tracker, setupLogger(Config{LogFormat: "fmt"})))
+	_, err := routes[0].Service.Post(context.Background(), webhook.Message{Data: &template.Data{Alerts: template.Alerts{{Fingerprint: "abc"}}}})
+	require.NoError(t, err)
+
+	abandoned := shutdownServer(srv, cancelRequests, tracker, health.NewChecker(), 0, 50*time.Millisecond, setupLogger(Config{LogFormat: "fmt"}))
+
+	assert.Len(t, abandoned, 1)
+	assert.Len(t, digested.started, 1)
+}
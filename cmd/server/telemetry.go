@@ -0,0 +1,153 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stakater/prometheus-msteams/pkg/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+const (
+	serviceName = "prometheus-msteams"
+
+	otlpProtocolGRPC = "grpc"
+	otlpProtocolHTTP = "http"
+)
+
+// shutdownFunc flushes and stops a telemetry provider.
+type shutdownFunc func(context.Context) error
+
+func noopShutdown(context.Context) error { return nil }
+
+func newResource(ctx context.Context) (*resource.Resource, error) {
+	// Attributes from OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES are
+	// detected last so they override the defaults.
+	return resource.New(ctx,
+		resource.WithAttributes(
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(version.VERSION),
+		),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+}
+
+// setupTracer installs the W3C trace context propagator and, when tracing is
+// enabled, a tracer provider exporting spans with OTLP. The returned function
+// flushes the pending spans.
+func setupTracer(cfg Config, logger *utility.Logger) (shutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.JaegerTrace {
+		logger.Warn("message", "-jaeger-trace is deprecated, use -otlp-trace; Jaeger accepts OTLP on port 4317")
+	}
+	if cfg.JaegerAgentAddr != "" {
+		logger.Warn("message", "-jaeger-agent is ignored, the Jaeger agent protocol is no longer supported; use -otlp-endpoint")
+	}
+	if !cfg.OTLPTrace && !cfg.JaegerTrace {
+		return noopShutdown, nil
+	}
+
+	ctx := context.Background()
+	exporter, err := newOTLPExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := newResource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+
+	logger.Log("message", "otlp tracing enabled", "protocol", cfg.OTLPProtocol, "endpoint", cfg.OTLPEndpoint)
+	return tp.Shutdown, nil
+}
+
+// newOTLPExporter creates the span exporter of cfg.OTLPProtocol. Options not
+// set by flags are read by the exporter from the standard OTEL_EXPORTER_OTLP_*
+// environment variables.
+func newOTLPExporter(ctx context.Context, cfg Config) (*otlptrace.Exporter, error) {
+	switch cfg.OTLPProtocol {
+	case otlpProtocolGRPC, "":
+		var opts []otlptracegrpc.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case otlpProtocolHTTP:
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.OTLPEndpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown otlp protocol %q, expected %s or %s", cfg.OTLPProtocol, otlpProtocolGRPC, otlpProtocolHTTP)
+	}
+}
+
+// setupMeterProvider installs a meter provider whose metrics are collected
+// by reg, and returns the recorder of the alert, card and delivery metrics.
+func setupMeterProvider(reg stdprometheus.Registerer) (*metrics.Recorder, shutdownFunc, error) {
+	exporter, err := otelprometheus.New(otelprometheus.WithRegisterer(reg))
+	if err != nil {
+		return nil, nil, err
+	}
+	res, err := newResource(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(exporter),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(mp)
+
+	recorder, err := metrics.NewRecorder(mp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return recorder, mp.Shutdown, nil
+}
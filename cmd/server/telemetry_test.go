@@ -0,0 +1,162 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stakater/prometheus-msteams/pkg/service"
+	"github.com/stakater/prometheus-msteams/pkg/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+// restoreGlobalTelemetry resets the global OpenTelemetry providers changed
+// by a test.
+func restoreGlobalTelemetry(t *testing.T) {
+	tp, mp, prop := otel.GetTracerProvider(), otel.GetMeterProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		if otel.GetTracerProvider() != tp {
+			otel.SetTracerProvider(tp)
+		}
+		if otel.GetMeterProvider() != mp {
+			otel.SetMeterProvider(mp)
+		}
+		if otel.GetTextMapPropagator() != prop {
+			otel.SetTextMapPropagator(prop)
+		}
+	})
+}
+
+func shutdownWithin(t *testing.T, shutdown shutdownFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, shutdown(ctx))
+}
+
+func TestSetupTracerDisabled(t *testing.T) {
+	restoreGlobalTelemetry(t)
+	logger := setupLogger(Config{LogFormat: "json"})
+
+	shutdown, err := setupTracer(Config{}, logger)
+	require.NoError(t, err)
+	shutdownWithin(t, shutdown)
+
+	// W3C trace context is propagated even when spans are not exported.
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+}
+
+func TestSetupTracerOTLP(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"grpc", Config{OTLPTrace: true, OTLPProtocol: "grpc", OTLPEndpoint: "localhost:4317", OTLPInsecure: true}},
+		{"http", Config{OTLPTrace: true, OTLPProtocol: "http", OTLPEndpoint: "localhost:4318", OTLPInsecure: true}},
+		{"default protocol", Config{OTLPTrace: true}},
+		{"deprecated jaeger flags", Config{JaegerTrace: true, JaegerAgentAddr: "localhost:6831"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreGlobalTelemetry(t)
+			logger := setupLogger(Config{LogFormat: "json"})
+
+			shutdown, err := setupTracer(tt.cfg, logger)
+			require.NoError(t, err)
+			shutdownWithin(t, shutdown)
+		})
+	}
+}
+
+func TestSetupTracerUnknownProtocol(t *testing.T) {
+	restoreGlobalTelemetry(t)
+	logger := setupLogger(Config{LogFormat: "json"})
+
+	_, err := setupTracer(Config{OTLPTrace: true, OTLPProtocol: "udp"}, logger)
+	assert.ErrorContains(t, err, `unknown otlp protocol "udp"`)
+}
+
+// TestSetupMeterProvider documents the Prometheus names of the metrics;
+// README.md maps the HTTP metrics to the names of the former OpenCensus views.
+func TestSetupMeterProvider(t *testing.T) {
+	restoreGlobalTelemetry(t)
+	reg := prometheus.NewRegistry()
+
+	recorder, shutdown, err := setupMeterProvider(reg)
+	require.NoError(t, err)
+	defer shutdownWithin(t, shutdown)
+
+	teams := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("1"))
+	}))
+	defer teams.Close()
+
+	logger := setupLogger(Config{LogFormat: "json"})
+	converter, err := setupConverter(Config{TemplateFile: testTemplate}, logger, recorder)
+	require.NoError(t, err)
+	client := setupHTTPClient(Config{HTTPClientMaxIdleConn: 1}, recorder)
+	s := service.NewSimpleService(converter, client, teams.URL, service.Workflow, service.WithMetrics(recorder))
+	handler := transport.NewServer(logger.GetLogger(), []transport.Route{{Service: s, RequestPath: "/alertmanager"}}, nil)
+
+	body, err := os.ReadFile(testPayload)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alertmanager", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	labels := map[string]map[string]string{}
+	for _, f := range families {
+		labels[f.GetName()] = map[string]string{}
+		for _, l := range f.GetMetric()[0].GetLabel() {
+			labels[f.GetName()][l.GetName()] = l.GetValue()
+		}
+	}
+
+	for _, name := range []string{
+		"http_server_request_duration_seconds",
+		"http_server_request_body_size_bytes",
+		"http_server_response_body_size_bytes",
+		"http_client_request_duration_seconds",
+		"http_client_request_body_size_bytes",
+		"prometheus_msteams_alerts_received_total",
+		"prometheus_msteams_cards_rendered_total",
+		"prometheus_msteams_card_render_duration_seconds",
+		"prometheus_msteams_card_size_bytes",
+		"prometheus_msteams_delivery_attempts_total",
+		"prometheus_msteams_deliveries_total",
+		"prometheus_msteams_alert_delivery_delay_seconds",
+	} {
+		assert.Contains(t, labels, name)
+	}
+	assert.Equal(t, "/alertmanager", labels["http_server_request_duration_seconds"]["http_route"])
+	assert.Equal(t, "POST", labels["http_server_request_duration_seconds"]["http_request_method"])
+	assert.Equal(t, "200", labels["http_client_request_duration_seconds"]["http_response_status_code"])
+	assert.Equal(t, "/alertmanager", labels["prometheus_msteams_deliveries_total"]["route"])
+	assert.Equal(t, "2xx", labels["prometheus_msteams_deliveries_total"]["status_class"])
+	assert.Equal(t, "/alertmanager", labels["prometheus_msteams_delivery_attempts_total"]["route"])
+	assert.Equal(t, "default-message-workflow-card.tmpl", labels["prometheus_msteams_cards_rendered_total"]["template"])
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/stakater/prometheus-msteams/pkg/card"
+)
+
+// connectorTheme returns card.DefaultTheme overridden with the global theme
+// of tc, then with the theme of the connector c, which may be nil.
+func connectorTheme(tc PromTeamsConfig, c *ConnectorWithCustomTemplate) (card.Theme, error) {
+	t := card.DefaultTheme
+	if tc.Theme != nil {
+		t = t.Merge(*tc.Theme)
+	}
+	if c != nil && c.Theme != nil {
+		t = t.Merge(*c.Theme)
+	}
+	if err := t.Validate(); err != nil {
+		return card.Theme{}, fmt.Errorf("theme: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConnectorTheme(t *testing.T) {
+	var tc PromTeamsConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`
+theme:
+  severities:
+    page:
+      color: Attention
+      container_style: attention
+      icon: Alert
+  resolved:
+    icon: Checkmark
+connectors_with_custom_templates:
+  - request_path: /dev
+    theme:
+      severities:
+        page:
+          color: Warning
+  - request_path: /invalid
+    theme:
+      default:
+        icon: NoSuchIcon
+`), &tc))
+
+	global, err := connectorTheme(tc, nil)
+	require.NoError(t, err)
+	assert.Equal(t, adaptivecards.ColorAttention, global.Style("firing", "page").Color)
+	assert.Equal(t, adaptivecards.Symbol("Checkmark"), global.Style("resolved", "").Icon)
+	assert.Equal(t, card.DefaultTheme.Style("firing", "critical"), global.Style("firing", "critical"))
+
+	dev, err := connectorTheme(tc, &tc.ConnectorsWithCustomTemplates[0])
+	require.NoError(t, err)
+	assert.Equal(t, adaptivecards.ColorWarning, dev.Style("firing", "page").Color)
+	assert.Equal(t, adaptivecards.ContainerStyleAttention, dev.Style("firing", "page").ContainerStyle)
+	assert.Equal(t, adaptivecards.Symbol("Checkmark"), dev.Style("resolved", "").Icon)
+
+	_, err = connectorTheme(tc, &tc.ConnectorsWithCustomTemplates[1])
+	assert.EqualError(t, err, "theme: default: unknown icon 'NoSuchIcon'")
+
+	def, err := connectorTheme(PromTeamsConfig{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, card.DefaultTheme, def)
+}
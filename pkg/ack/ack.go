@@ -0,0 +1,244 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ack handles the Acknowledge and Assign to me buttons of the cards:
+// it verifies the invoke activities sent by the Bot Framework, records the
+// acknowledgements and answers with the updated card.
+package ack
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRetention is how long a Store keeps the acknowledgements unless
+// another retention is given.
+const DefaultRetention = 7 * 24 * time.Hour
+
+// Ack is the acknowledgement or the assignment, depending on its verb, of an
+// alert by a user.
+type Ack struct {
+	Fingerprint string    `json:"fingerprint"`
+	Verb        string    `json:"verb"`
+	UserID      string    `json:"userId"`
+	UserName    string    `json:"userName"`
+	Time        time.Time `json:"time"`
+	// SilenceID is the ID of the silence created with the acknowledgement,
+	// if any.
+	SilenceID string `json:"silenceId,omitempty"`
+}
+
+type key struct {
+	fingerprint string
+	verb        string
+}
+
+// Store keeps the latest Ack of each verb of the alerts, optionally
+// persisted to a file holding one JSON Ack per line. The acks older than the
+// retention are dropped. A Store is safe for concurrent use.
+type Store struct {
+	mu        sync.Mutex
+	acks      map[key]Ack
+	retention time.Duration
+	now       func() time.Time
+
+	file  *os.File
+	path  string
+	lines int
+}
+
+// NewStore creates a Store keeping the acks for retention, DefaultRetention
+// if not positive. If path is not empty, the acks found in the file are
+// loaded and new acks appended to it; the file is compacted when it holds
+// twice as many acks as the Store.
+func NewStore(path string, retention time.Duration) (*Store, error) {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	s := &Store{acks: map[key]Ack{}, retention: retention, now: time.Now, path: path}
+	if path == "" {
+		return s, nil
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		var a Ack
+		if err := json.Unmarshal(sc.Bytes(), &a); err != nil {
+			return fmt.Errorf("%s:%d: %w", s.path, n, err)
+		}
+		s.add(a)
+	}
+	return sc.Err()
+}
+
+// compact drops the expired acks, rewrites the file with the others and
+// reopens it for appending.
+func (s *Store) compact() error {
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return err
+		}
+		s.file = nil
+	}
+	s.expire()
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	for _, a := range s.ordered() {
+		if err := enc.Encode(a); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	s.lines = len(s.acks)
+	return err
+}
+
+func (s *Store) add(a Ack) {
+	k := key{a.Fingerprint, a.Verb}
+	if prev, ok := s.acks[k]; ok && prev.Time.After(a.Time) {
+		return
+	}
+	s.acks[k] = a
+}
+
+func (s *Store) expire() {
+	cutoff := s.now().Add(-s.retention)
+	for k, a := range s.acks {
+		if a.Time.Before(cutoff) {
+			delete(s.acks, k)
+		}
+	}
+}
+
+// ordered returns the acks from the oldest to the newest.
+func (s *Store) ordered() []Ack {
+	acks := make([]Ack, 0, len(s.acks))
+	for _, a := range s.acks {
+		acks = append(acks, a)
+	}
+	sortAcks(acks)
+	return acks
+}
+
+// sortAcks sorts acks from the oldest to the newest.
+func sortAcks(acks []Ack) {
+	sort.Slice(acks, func(i, j int) bool {
+		if !acks[i].Time.Equal(acks[j].Time) {
+			return acks[i].Time.Before(acks[j].Time)
+		}
+		if acks[i].Fingerprint != acks[j].Fingerprint {
+			return acks[i].Fingerprint < acks[j].Fingerprint
+		}
+		return acks[i].Verb < acks[j].Verb
+	})
+}
+
+// Record adds acks, replacing the previous acks of their alerts with the
+// same verbs.
+func (s *Store) Record(acks ...Ack) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range acks {
+		s.add(a)
+	}
+	if s.file == nil {
+		return nil
+	}
+	for _, a := range acks {
+		b, err := json.Marshal(a)
+		if err != nil {
+			return err
+		}
+		if _, err := s.file.Write(append(b, '\n')); err != nil {
+			return err
+		}
+		s.lines++
+	}
+	if s.lines >= 2*len(s.acks) {
+		return s.compact()
+	}
+	return nil
+}
+
+// Get returns the acks of the alert with the given fingerprint, the oldest
+// first, without the expired ones.
+func (s *Store) Get(fingerprint string) []Ack {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-s.retention)
+	var acks []Ack
+	for k, a := range s.acks {
+		if k.fingerprint == fingerprint && !a.Time.Before(cutoff) {
+			acks = append(acks, a)
+		}
+	}
+	sortAcks(acks)
+	return acks
+}
+
+// Close closes the file of the Store.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ack
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// t0 is recent for the acks to be kept by the stores reloaded from files.
+var t0 = time.Now().UTC().Truncate(time.Minute).Add(-time.Hour)
+
+func ack(i int, fingerprint, verb, user string) Ack {
+	return Ack{
+		Fingerprint: fingerprint,
+		Verb:        verb,
+		UserID:      user,
+		UserName:    user,
+		Time:        t0.Add(time.Duration(i) * time.Minute),
+	}
+}
+
+func lines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	n := 0
+	for sc := bufio.NewScanner(f); sc.Scan(); {
+		n++
+	}
+	return n
+}
+
+func TestStoreRecord(t *testing.T) {
+	s, err := NewStore("", 0)
+	require.NoError(t, err)
+	s.now = func() time.Time { return t0 }
+
+	require.NoError(t, s.Record(ack(0, "f1", "acknowledge", "alice"), ack(1, "f1", "assign", "bob")))
+	require.NoError(t, s.Record(ack(2, "f1", "acknowledge", "carol"), ack(0, "f2", "acknowledge", "alice")))
+	// An older ack does not replace a newer one.
+	require.NoError(t, s.Record(ack(-1, "f1", "assign", "dave")))
+
+	assert.Equal(t, []Ack{ack(1, "f1", "assign", "bob"), ack(2, "f1", "acknowledge", "carol")}, s.Get("f1"))
+	assert.Equal(t, []Ack{ack(0, "f2", "acknowledge", "alice")}, s.Get("f2"))
+	assert.Empty(t, s.Get("f3"))
+	assert.NoError(t, s.Close())
+}
+
+func TestStoreRetention(t *testing.T) {
+	s, err := NewStore("", time.Hour)
+	require.NoError(t, err)
+	now := t0
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Record(ack(0, "f1", "acknowledge", "alice"), ack(30, "f1", "assign", "bob")))
+	now = t0.Add(75 * time.Minute)
+	assert.Equal(t, []Ack{ack(30, "f1", "assign", "bob")}, s.Get("f1"))
+}
+
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acks.jsonl")
+	s, err := NewStore(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Record(ack(0, "f1", "acknowledge", "alice")))
+	require.NoError(t, s.Record(ack(1, "f2", "assign", "bob")))
+	require.NoError(t, s.Close())
+
+	s, err = NewStore(path, 0)
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, []Ack{ack(0, "f1", "acknowledge", "alice")}, s.Get("f1"))
+	assert.Equal(t, []Ack{ack(1, "f2", "assign", "bob")}, s.Get("f2"))
+}
+
+func TestStoreCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acks.jsonl")
+	s, err := NewStore(path, 0)
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Record(ack(0, "f1", "acknowledge", "alice"), ack(0, "f2", "acknowledge", "alice")))
+	assert.Equal(t, 2, lines(t, path))
+	require.NoError(t, s.Record(ack(1, "f1", "acknowledge", "bob")))
+	assert.Equal(t, 3, lines(t, path))
+	// The fourth line holds twice as many acks as the store.
+	require.NoError(t, s.Record(ack(2, "f1", "acknowledge", "carol")))
+	assert.Equal(t, 2, lines(t, path))
+
+	require.NoError(t, s.Record(ack(3, "f2", "assign", "dave")))
+	assert.Equal(t, 3, lines(t, path))
+	assert.Equal(t, []Ack{ack(2, "f1", "acknowledge", "carol")}, s.Get("f1"))
+}
+
+func TestStoreExpiredOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acks.jsonl")
+	s, err := NewStore(path, 0)
+	require.NoError(t, err)
+	require.NoError(t, s.Record(
+		Ack{Fingerprint: "f1", Verb: "acknowledge", Time: time.Now().Add(-2 * time.Hour)},
+		Ack{Fingerprint: "f2", Verb: "acknowledge", Time: time.Now()},
+	))
+	require.NoError(t, s.Close())
+
+	s, err = NewStore(path, time.Hour)
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, 1, lines(t, path))
+	assert.Empty(t, s.Get("f1"))
+	assert.Len(t, s.Get("f2"), 1)
+}
+
+func TestStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acks.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{}\nnot json\n"), 0o600))
+	_, err := NewStore(path, 0)
+	assert.ErrorContains(t, err, "acks.jsonl:2")
+}
+
+func TestStoreCloseNil(t *testing.T) {
+	var s *Store
+	assert.NoError(t, s.Close())
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ack
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// The keys and the issuer of the tokens of the Bot Framework.
+const (
+	BotFrameworkJWKSURL = "https://login.botframework.com/v1/.well-known/keys"
+	BotFrameworkIssuer  = "https://api.botframework.com"
+)
+
+const (
+	// keysTTL is how long the keys are cached. The Bot Framework rotates
+	// them at most daily.
+	keysTTL = 24 * time.Hour
+	// minRefresh is the minimum time between two fetches of the keys, for
+	// the tokens signed with an unknown key and after a failed fetch.
+	minRefresh = time.Minute
+	// fetchTimeout is the maximum duration of a fetch of the keys.
+	fetchTimeout = 10 * time.Second
+	// leeway is the clock skew accepted on the times of the tokens.
+	leeway = 5 * time.Minute
+	// maxKeysBytes caps the size of the JWKS documents read.
+	maxKeysBytes = 1 << 20
+)
+
+// ErrUnauthorized is returned for the requests without a valid token.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Verifier verifies the JWT of the requests of the Bot Framework: signed
+// with RS256 by one of the keys of the JWKS at its URL, endorsed for the
+// channel of the activity, issued by its issuer for its audience, the
+// Microsoft app ID of the bot.
+type Verifier struct {
+	jwksURL  string
+	issuer   string
+	audience string
+	client   *http.Client
+
+	// fetches runs one fetch of the keys at a time, shared by the callers.
+	fetches singleflight.Group
+
+	mu   sync.Mutex
+	keys map[string]publicKey
+	// fetched is the time of the last fetch of the keys, attempted the
+	// time of the last attempt and fetchErr its error, if any.
+	fetched, attempted time.Time
+	fetchErr           error
+}
+
+// publicKey is a key of the JWKS with the channels it is endorsed for.
+type publicKey struct {
+	key          *rsa.PublicKey
+	endorsements []string
+}
+
+// NewVerifier creates a Verifier of the tokens issued for audience. The
+// jwksURL and the issuer default to those of the Bot Framework, and client
+// to http.DefaultClient.
+func NewVerifier(jwksURL, issuer, audience string, client *http.Client) (*Verifier, error) {
+	if audience == "" {
+		return nil, errors.New("the app ID of the bot is required")
+	}
+	if jwksURL == "" {
+		jwksURL = BotFrameworkJWKSURL
+	}
+	if issuer == "" {
+		issuer = BotFrameworkIssuer
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Verifier{jwksURL: jwksURL, issuer: issuer, audience: audience, client: client}, nil
+}
+
+// Verify verifies the bearer token of the Authorization header authorization
+// of an activity of the channel channelID and returns its claims. A key
+// with endorsements must be endorsed for the channel, as the keys of the
+// Bot Framework are. The errors wrap ErrUnauthorized, except those of the
+// fetch of the keys.
+func (v *Verifier) Verify(ctx context.Context, authorization, channelID string) (jwt.MapClaims, error) {
+	raw, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok || raw == "" {
+		return nil, fmt.Errorf("%w: no bearer token", ErrUnauthorized)
+	}
+	var keyErr error
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.key(ctx, kid)
+		if err != nil {
+			keyErr = err
+			return nil, err
+		}
+		if len(key.endorsements) > 0 && !slices.Contains(key.endorsements, channelID) {
+			return nil, fmt.Errorf("key %q is not endorsed for the channel %q", kid, channelID)
+		}
+		return key.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	)
+	if keyErr != nil {
+		return nil, keyErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+	return claims, nil
+}
+
+// key returns the key kid, fetching the keys when they are stale or do not
+// have it, at most once per minRefresh. A stale key is used while the keys
+// cannot be fetched.
+func (v *Verifier) key(ctx context.Context, kid string) (publicKey, error) {
+	v.mu.Lock()
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetched) > keysTTL
+	refresh := time.Since(v.attempted) > minRefresh
+	fetchErr := v.fetchErr
+	v.mu.Unlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+	if refresh {
+		fetchErr = v.refresh(ctx)
+		v.mu.Lock()
+		if k, found := v.keys[kid]; found {
+			key, ok = k, true
+		}
+		v.mu.Unlock()
+	}
+	switch {
+	case ok:
+		return key, nil
+	case fetchErr != nil:
+		return publicKey{}, fmt.Errorf("failed to fetch the keys: %w", fetchErr)
+	}
+	return publicKey{}, fmt.Errorf("%w: unknown key %q", ErrUnauthorized, kid)
+}
+
+// refresh fetches the keys, unless another caller fetched them less than
+// minRefresh ago, and returns the error of the last fetch. The fetch is
+// shared by the concurrent callers and goes on when ctx is canceled.
+func (v *Verifier) refresh(ctx context.Context) error {
+	ch := v.fetches.DoChan("keys", func() (any, error) {
+		v.mu.Lock()
+		recent, err := time.Since(v.attempted) <= minRefresh, v.fetchErr
+		v.mu.Unlock()
+		if recent {
+			return nil, err
+		}
+
+		fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), fetchTimeout)
+		defer cancel()
+		keys, err := v.fetch(fctx)
+
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.attempted, v.fetchErr = time.Now(), err
+		if err == nil {
+			v.keys, v.fetched = keys, v.attempted
+		}
+		return nil, err
+	})
+	select {
+	case res := <-ch:
+		return res.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwk is a key of a JWKS. The Bot Framework adds the IDs of the channels
+// the key is endorsed for.
+type jwk struct {
+	Kty          string   `json:"kty"`
+	Kid          string   `json:"kid"`
+	N            string   `json:"n"`
+	E            string   `json:"e"`
+	Endorsements []string `json:"endorsements,omitempty"`
+}
+
+// fetch returns the RSA keys of the JWKS by ID.
+func (v *Verifier) fetch(ctx context.Context) (map[string]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %d", v.jwksURL, resp.StatusCode)
+	}
+	var set jwks
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxKeysBytes)).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := map[string]publicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid exponent: %w", k.Kid, err)
+		}
+		keys[k.Kid] = publicKey{
+			key:          &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())},
+			endorsements: k.Endorsements,
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s has no RSA keys", v.jwksURL)
+	}
+	return keys, nil
+}
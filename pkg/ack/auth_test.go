@@ -0,0 +1,278 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ack
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://api.botframework.test"
+	testAudience = "00000000-0000-0000-0000-000000000001"
+)
+
+// jwksServer serves the public keys of its private keys as a JWKS.
+type jwksServer struct {
+	*httptest.Server
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PrivateKey
+	// endorsements are the channels the keys are endorsed for, if any.
+	endorsements map[string][]string
+	fetches      int
+	fail         bool
+}
+
+func newJWKSServer(t *testing.T, kids ...string) *jwksServer {
+	t.Helper()
+	s := &jwksServer{keys: map[string]*rsa.PrivateKey{}, endorsements: map[string][]string{}}
+	for _, kid := range kids {
+		s.addKey(t, kid)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		if s.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var set jwks
+		for kid, k := range s.keys {
+			set.Keys = append(set.Keys, jwk{
+				Kty:          "RSA",
+				Kid:          kid,
+				N:            base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+				E:            base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+				Endorsements: s.endorsements[kid],
+			})
+		}
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) addKey(t *testing.T, kid string) {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[kid] = k
+}
+
+// sign returns the token of claims signed with the key kid.
+func (s *jwksServer) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = kid
+	raw, err := tok.SignedString(s.keys[kid])
+	require.NoError(t, err)
+	return "Bearer " + raw
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": testIssuer,
+		"aud": testAudience,
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+	}
+}
+
+func newTestVerifier(t *testing.T, s *jwksServer) *Verifier {
+	t.Helper()
+	v, err := NewVerifier(s.URL, testIssuer, testAudience, s.Client())
+	require.NoError(t, err)
+	return v
+}
+
+func TestNewVerifier(t *testing.T) {
+	_, err := NewVerifier("", "", "", nil)
+	assert.Error(t, err)
+
+	v, err := NewVerifier("", "", testAudience, nil)
+	require.NoError(t, err)
+	assert.Equal(t, BotFrameworkJWKSURL, v.jwksURL)
+	assert.Equal(t, BotFrameworkIssuer, v.issuer)
+	assert.Equal(t, http.DefaultClient, v.client)
+}
+
+func TestVerify(t *testing.T) {
+	s := newJWKSServer(t, "k1")
+	v := newTestVerifier(t, s)
+
+	claims, err := v.Verify(context.Background(), s.sign(t, "k1", validClaims()), "msteams")
+	require.NoError(t, err)
+	assert.Equal(t, testIssuer, claims["iss"])
+
+	// The keys are cached.
+	_, err = v.Verify(context.Background(), s.sign(t, "k1", validClaims()), "msteams")
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.fetches)
+}
+
+func TestVerifyInvalid(t *testing.T) {
+	s := newJWKSServer(t, "k1")
+	v := newTestVerifier(t, s)
+
+	claims := func(k string, v any) jwt.MapClaims {
+		c := validClaims()
+		if v == nil {
+			delete(c, k)
+		} else {
+			c[k] = v
+		}
+		return c
+	}
+	hs256, err := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims()).SignedString([]byte("secret"))
+	require.NoError(t, err)
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"no header":      "",
+		"no bearer":      "Basic dXNlcjpwYXNz",
+		"empty bearer":   "Bearer ",
+		"malformed":      "Bearer not.a.token",
+		"wrong audience": s.sign(t, "k1", claims("aud", "another-app")),
+		"wrong issuer":   s.sign(t, "k1", claims("iss", "https://example.com")),
+		"expired":        s.sign(t, "k1", claims("exp", time.Now().Add(-time.Hour).Unix())),
+		"no expiration":  s.sign(t, "k1", claims("exp", nil)),
+		"not yet valid":  s.sign(t, "k1", claims("nbf", time.Now().Add(time.Hour).Unix())),
+		"HS256":          "Bearer " + hs256,
+		"none":           "Bearer " + none,
+	}
+	for name, authorization := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), authorization, "msteams")
+			assert.ErrorIs(t, err, ErrUnauthorized)
+		})
+	}
+}
+
+func TestVerifyOtherKey(t *testing.T) {
+	s := newJWKSServer(t, "k1")
+	v := newTestVerifier(t, s)
+	other := newJWKSServer(t, "k1")
+
+	_, err := v.Verify(context.Background(), other.sign(t, "k1", validClaims()), "msteams")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestVerifyUnknownKey(t *testing.T) {
+	s := newJWKSServer(t, "k1")
+	v := newTestVerifier(t, s)
+	_, err := v.Verify(context.Background(), s.sign(t, "k1", validClaims()), "msteams")
+	require.NoError(t, err)
+
+	// The keys were just fetched: the token is rejected without a fetch.
+	s.addKey(t, "k2")
+	_, err = v.Verify(context.Background(), s.sign(t, "k2", validClaims()), "msteams")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	assert.Equal(t, 1, s.fetches)
+
+	// A minute later the rotated keys are fetched.
+	v.attempted = v.attempted.Add(-2 * minRefresh)
+	_, err = v.Verify(context.Background(), s.sign(t, "k2", validClaims()), "msteams")
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.fetches)
+}
+
+func TestVerifyStaleKeys(t *testing.T) {
+	s := newJWKSServer(t, "k1")
+	v := newTestVerifier(t, s)
+	_, err := v.Verify(context.Background(), s.sign(t, "k1", validClaims()), "msteams")
+	require.NoError(t, err)
+
+	// The stale keys are used while they cannot be fetched.
+	s.fail = true
+	v.fetched = v.fetched.Add(-2 * keysTTL)
+	v.attempted = v.fetched
+	_, err = v.Verify(context.Background(), s.sign(t, "k1", validClaims()), "msteams")
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.fetches)
+}
+
+func TestVerifyFetchError(t *testing.T) {
+	s := newJWKSServer(t, "k1")
+	s.fail = true
+	v := newTestVerifier(t, s)
+
+	_, err := v.Verify(context.Background(), s.sign(t, "k1", validClaims()), "msteams")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+
+	// The failed fetch is not retried before minRefresh.
+	_, err = v.Verify(context.Background(), s.sign(t, "k1", validClaims()), "msteams")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnauthorized)
+	assert.Equal(t, 1, s.fetches)
+
+	s.fail = false
+	v.attempted = v.attempted.Add(-2 * minRefresh)
+	_, err = v.Verify(context.Background(), s.sign(t, "k1", validClaims()), "msteams")
+	require.NoError(t, err)
+	assert.Equal(t, 2, s.fetches)
+}
+
+func TestVerifyConcurrentFetch(t *testing.T) {
+	s := newJWKSServer(t, "k1")
+	v := newTestVerifier(t, s)
+	token := s.sign(t, "k1", validClaims())
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			_, err := v.Verify(context.Background(), token, "msteams")
+			assert.NoError(t, err)
+		})
+	}
+	wg.Wait()
+	assert.Equal(t, 1, s.fetches)
+}
+
+func TestVerifyEndorsements(t *testing.T) {
+	s := newJWKSServer(t, "k1")
+	s.endorsements["k1"] = []string{"msteams", "webchat"}
+	v := newTestVerifier(t, s)
+	token := s.sign(t, "k1", validClaims())
+
+	_, err := v.Verify(context.Background(), token, "webchat")
+	require.NoError(t, err)
+	_, err = v.Verify(context.Background(), token, "slack")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = v.Verify(context.Background(), token, "")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
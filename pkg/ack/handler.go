@@ -0,0 +1,272 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// maxActivityBytes caps the size of the activities read.
+const maxActivityBytes = 1 << 20
+
+// Activity is the part of a Bot Framework activity read by the Handler.
+type Activity struct {
+	Type       string `json:"type"`
+	Name       string `json:"name"`
+	ChannelID  string `json:"channelId"`
+	ServiceURL string `json:"serviceUrl"`
+	From       struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		AADObjectID string `json:"aadObjectId"`
+	} `json:"from"`
+	Value struct {
+		Action struct {
+			Type string          `json:"type"`
+			Verb string          `json:"verb"`
+			Data json.RawMessage `json:"data"`
+		} `json:"action"`
+	} `json:"value"`
+}
+
+// invokeResponse is the body of the response to an adaptiveCard/action
+// invoke activity.
+type invokeResponse struct {
+	StatusCode int    `json:"statusCode"`
+	Type       string `json:"type"`
+	Value      any    `json:"value"`
+}
+
+type invokeError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// HandlerOption configures a Handler.
+type HandlerOption func(*Handler)
+
+// WithSilences silences the acknowledged alerts in am for d.
+func WithSilences(am Alertmanager, d time.Duration) HandlerOption {
+	return func(h *Handler) {
+		h.alertmanager = &am
+		h.silence = d
+	}
+}
+
+// WithAnnotations annotates the acknowledgements and the assignments in g.
+func WithAnnotations(g Grafana) HandlerOption {
+	return func(h *Handler) {
+		h.grafana = &g
+	}
+}
+
+// Handler serves the invoke activities sent by the Bot Framework when a user
+// presses an Action.Execute button of card.Actions. It records an Ack per
+// alert of the card and answers with the card updated with the latest
+// acknowledgement and assignment. The failures of the silences and the
+// annotations are logged without failing the action.
+type Handler struct {
+	verifier     *Verifier
+	store        *Store
+	localizer    *card.Localizer
+	logger       *utility.Logger
+	alertmanager *Alertmanager
+	silence      time.Duration
+	grafana      *Grafana
+	now          func() time.Time
+}
+
+// NewHandler creates a Handler authenticating the requests with v, recording
+// the acks in s and rendering the cards in the locale of l.
+func NewHandler(v *Verifier, s *Store, l *card.Localizer, logger *utility.Logger, opts ...HandlerOption) *Handler {
+	if l == nil {
+		l = card.DefaultLocalizer()
+	}
+	h := &Handler{
+		verifier:  v,
+		store:     s,
+		localizer: l,
+		logger:    logger.WithPrefix("package", "ack", "component", "handler"),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	// The activity is decoded before the token is verified, as its keys
+	// are endorsed for the channel of the activity.
+	var a Activity
+	decodeErr := json.NewDecoder(io.LimitReader(r.Body, maxActivityBytes)).Decode(&a)
+	claims, err := h.verifier.Verify(r.Context(), r.Header.Get("Authorization"), a.ChannelID)
+	if err != nil {
+		h.logger.Err(err)
+		if errors.Is(err, ErrUnauthorized) {
+			w.WriteHeader(http.StatusUnauthorized)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		return
+	}
+	if decodeErr != nil {
+		http.Error(w, fmt.Sprintf("invalid activity: %v", decodeErr), http.StatusBadRequest)
+		return
+	}
+	// The token is only valid for the service URL it was issued for.
+	if u, ok := claims["serviceurl"].(string); ok && u != a.ServiceURL {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if a.Type != "invoke" || a.Name != "adaptiveCard/action" {
+		// The other activities, e.g. the installation of the bot, are
+		// acknowledged and ignored.
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	res := h.invoke(r, a)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		h.logger.Err(err)
+	}
+}
+
+// invoke handles the adaptiveCard/action activity a.
+func (h *Handler) invoke(r *http.Request, a Activity) invokeResponse {
+	action := a.Value.Action
+	if action.Type != "Action.Execute" || (action.Verb != card.VerbAcknowledge && action.Verb != card.VerbAssign) {
+		return errorResponse(http.StatusBadRequest, fmt.Sprintf("unsupported action %s %q", action.Type, action.Verb))
+	}
+	var d card.ActionData
+	if err := json.Unmarshal(action.Data, &d); err != nil || len(d.Fingerprints) == 0 {
+		return errorResponse(http.StatusBadRequest, "the action has no alerts")
+	}
+
+	userID := a.From.AADObjectID
+	if userID == "" {
+		userID = a.From.ID
+	}
+	now := h.now()
+	// Each alert gets a silence matching all its labels, as the common
+	// labels of a group may match the other alerts of the same rules.
+	silences := map[string]string{}
+	if action.Verb == card.VerbAcknowledge && h.alertmanager != nil {
+		for _, fp := range d.Fingerprints {
+			id, err := h.alertmanager.Silence(r.Context(), d.Labels[fp], now, now.Add(h.silence), a.From.Name, "Acknowledged in Microsoft Teams")
+			if err != nil {
+				h.logger.Err(fmt.Errorf("failed to silence the alert: %w", err), "fingerprint", fp)
+				continue
+			}
+			silences[fp] = id
+		}
+	}
+	acks := make([]Ack, 0, len(d.Fingerprints))
+	for _, fp := range d.Fingerprints {
+		acks = append(acks, Ack{
+			Fingerprint: fp,
+			Verb:        action.Verb,
+			UserID:      userID,
+			UserName:    a.From.Name,
+			Time:        now,
+			SilenceID:   silences[fp],
+		})
+	}
+	if err := h.store.Record(acks...); err != nil {
+		h.logger.Err(fmt.Errorf("failed to record the acks: %w", err))
+		return errorResponse(http.StatusInternalServerError, "the action could not be recorded")
+	}
+	if h.grafana != nil {
+		text := fmt.Sprintf("%s: %s %s", d.Title, h.localizer.Translate(factKeys[action.Verb]), a.From.Name)
+		if _, err := h.grafana.Annotate(r.Context(), now, []string{"prometheus-msteams", action.Verb}, text); err != nil {
+			h.logger.Err(fmt.Errorf("failed to annotate the action: %w", err), "fingerprints", d.Fingerprints)
+		}
+	}
+	h.logger.Info("verb", action.Verb, "user", a.From.Name, "fingerprints", d.Fingerprints)
+
+	return invokeResponse{
+		StatusCode: http.StatusOK,
+		Type:       "application/vnd.microsoft.card.adaptive",
+		Value:      h.card(d),
+	}
+}
+
+// factKeys are the localization keys of the facts of the verbs.
+var factKeys = map[string]string{
+	card.VerbAcknowledge: "ack.acknowledgedBy",
+	card.VerbAssign:      "ack.assignedTo",
+}
+
+// card returns the card of the alerts of d with their latest
+// acknowledgement and assignment, and the buttons.
+func (h *Handler) card(d card.ActionData) adaptivecards.AdaptiveCard {
+	latest := map[string]Ack{}
+	for _, fp := range d.Fingerprints {
+		for _, a := range h.store.Get(fp) {
+			if prev, ok := latest[a.Verb]; !ok || a.Time.After(prev.Time) {
+				latest[a.Verb] = a
+			}
+		}
+	}
+	var facts []adaptivecards.Fact
+	for _, verb := range []string{card.VerbAcknowledge, card.VerbAssign} {
+		a, ok := latest[verb]
+		if !ok {
+			continue
+		}
+		facts = append(facts, adaptivecards.Fact{
+			Title: h.localizer.Translate(factKeys[verb]),
+			Value: fmt.Sprintf("%s (%s)", a.UserName, h.localizer.FormatDate(a.Time)),
+		})
+	}
+
+	title := d.Title
+	if title == "" {
+		title = h.localizer.Translate("alert.title")
+	}
+	size, weight := adaptivecards.FontSizeMedium, adaptivecards.FontWeightBolder
+	return adaptivecards.AdaptiveCard{
+		Version: "1.5",
+		Body: []adaptivecards.Element{
+			&adaptivecards.TextBlock{Text: &title, Size: &size, Weight: &weight, Wrap: true},
+			&adaptivecards.FactSet{Facts: facts},
+			card.Actions(&d, h.localizer),
+		},
+	}
+}
+
+func errorResponse(code int, message string) invokeResponse {
+	return invokeResponse{
+		StatusCode: code,
+		Type:       "application/vnd.microsoft.error",
+		Value:      invokeError{Code: http.StatusText(code), Message: message},
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ack
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testServiceURL = "https://smba.trafficmanager.net/emea/"
+
+type handlerTest struct {
+	jwks    *jwksServer
+	store   *Store
+	handler *Handler
+}
+
+func newHandlerTest(t *testing.T, opts ...HandlerOption) *handlerTest {
+	t.Helper()
+	s := newJWKSServer(t, "k1")
+	store, err := NewStore("", 0)
+	require.NoError(t, err)
+	h := NewHandler(newTestVerifier(t, s), store, nil, utility.NewLogger(utility.LogFormatJSON, false), opts...)
+	h.now = func() time.Time { return t0 }
+	return &handlerTest{jwks: s, store: store, handler: h}
+}
+
+func activity(verb, user string) string {
+	return `{
+		"type": "invoke",
+		"name": "adaptiveCard/action",
+		"channelId": "msteams",
+		"serviceUrl": "` + testServiceURL + `",
+		"from": {"id": "29:` + user + `", "name": "` + user + `", "aadObjectId": "aad-` + user + `"},
+		"value": {"action": {
+			"type": "Action.Execute",
+			"verb": "` + verb + `",
+			"data": {
+				"fingerprints": ["f1", "f2"],
+				"labels": {"f1": {"alertname": "Down", "instance": "a"}, "f2": {"alertname": "Down", "instance": "b"}},
+				"title": "API down"
+			}
+		}}
+	}`
+}
+
+// invoke posts body with a token and returns the response and its decoded
+// body, if any.
+func (ht *handlerTest) invoke(t *testing.T, body string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	claims := validClaims()
+	claims["serviceurl"] = testServiceURL
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/actions", strings.NewReader(body))
+	req.Header.Set("Authorization", ht.jwks.sign(t, "k1", claims))
+	rec := httptest.NewRecorder()
+	ht.handler.ServeHTTP(rec, req)
+	if rec.Body.Len() == 0 || rec.Header().Get("Content-Type") != "application/json" {
+		return rec, nil
+	}
+	var res map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	return rec, res
+}
+
+func facts(t *testing.T, res map[string]any) []any {
+	t.Helper()
+	body := res["value"].(map[string]any)["body"].([]any)
+	require.Len(t, body, 3)
+	assert.Equal(t, "API down", body[0].(map[string]any)["text"])
+	assert.Equal(t, "ActionSet", body[2].(map[string]any)["type"])
+	fs, _ := body[1].(map[string]any)["facts"].([]any)
+	return fs
+}
+
+func TestHandlerAcknowledge(t *testing.T) {
+	ht := newHandlerTest(t)
+
+	rec, res := ht.invoke(t, activity(card.VerbAcknowledge, "alice"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, float64(http.StatusOK), res["statusCode"])
+	assert.Equal(t, "application/vnd.microsoft.card.adaptive", res["type"])
+	assert.Equal(t, []any{
+		map[string]any{"title": "Acknowledged by", "value": "alice (" + card.DefaultLocalizer().FormatDate(t0) + ")"},
+	}, facts(t, res))
+
+	for _, fp := range []string{"f1", "f2"} {
+		assert.Equal(t, []Ack{{Fingerprint: fp, Verb: card.VerbAcknowledge, UserID: "aad-alice", UserName: "alice", Time: t0}}, ht.store.Get(fp))
+	}
+
+	ht.handler.now = func() time.Time { return t0.Add(time.Minute) }
+	_, res = ht.invoke(t, activity(card.VerbAssign, "bob"))
+	assert.Len(t, facts(t, res), 2)
+}
+
+func TestHandlerSilenceAndAnnotation(t *testing.T) {
+	var silenced []silence
+	am := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var s silence
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&s))
+		silenced = append(silenced, s)
+		_, _ = fmt.Fprintf(w, `{"silenceID":"s%d"}`, len(silenced))
+	}))
+	defer am.Close()
+	var annotated []annotation
+	grafana := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a annotation
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&a))
+		annotated = append(annotated, a)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer grafana.Close()
+
+	ht := newHandlerTest(t,
+		WithSilences(Alertmanager{URL: am.URL, HTTP: am.Client()}, 2*time.Hour),
+		WithAnnotations(Grafana{URL: grafana.URL, HTTP: grafana.Client()}),
+	)
+	rec, _ := ht.invoke(t, activity(card.VerbAcknowledge, "alice"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	// Each alert is silenced with all its labels.
+	assert.Equal(t, "s1", ht.store.Get("f1")[0].SilenceID)
+	assert.Equal(t, "s2", ht.store.Get("f2")[0].SilenceID)
+	require.Len(t, silenced, 2)
+	assert.Equal(t, []matcher{{Name: "alertname", Value: "Down", IsEqual: true}, {Name: "instance", Value: "a", IsEqual: true}}, silenced[0].Matchers)
+	assert.Equal(t, []matcher{{Name: "alertname", Value: "Down", IsEqual: true}, {Name: "instance", Value: "b", IsEqual: true}}, silenced[1].Matchers)
+	assert.Equal(t, t0.Add(2*time.Hour), silenced[0].EndsAt)
+	assert.Equal(t, "alice", silenced[0].CreatedBy)
+
+	// The assignments are annotated without a silence.
+	ht.invoke(t, activity(card.VerbAssign, "bob"))
+	assert.Len(t, silenced, 2)
+	require.Len(t, annotated, 2)
+	assert.Equal(t, "API down: Acknowledged by alice", annotated[0].Text)
+	assert.Equal(t, []string{"prometheus-msteams", card.VerbAssign}, annotated[1].Tags)
+}
+
+func TestHandlerSilenceError(t *testing.T) {
+	am := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer am.Close()
+
+	// The action is recorded even though the alerts could not be silenced.
+	ht := newHandlerTest(t, WithSilences(Alertmanager{URL: am.URL, HTTP: am.Client()}, time.Hour))
+	_, res := ht.invoke(t, activity(card.VerbAcknowledge, "alice"))
+	assert.Equal(t, float64(http.StatusOK), res["statusCode"])
+	assert.Len(t, ht.store.Get("f1"), 1)
+}
+
+func TestHandlerInvalidActions(t *testing.T) {
+	ht := newHandlerTest(t)
+	tests := map[string]string{
+		"unknown verb":    activity("resolve", "alice"),
+		"not execute":     strings.Replace(activity(card.VerbAcknowledge, "alice"), "Action.Execute", "Action.Submit", 1),
+		"no fingerprints": strings.Replace(activity(card.VerbAcknowledge, "alice"), `["f1", "f2"]`, "[]", 1),
+	}
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec, res := ht.invoke(t, body)
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, float64(http.StatusBadRequest), res["statusCode"])
+			assert.Equal(t, "application/vnd.microsoft.error", res["type"])
+		})
+	}
+	assert.Empty(t, ht.store.Get("f1"))
+}
+
+func TestHandlerOtherActivities(t *testing.T) {
+	ht := newHandlerTest(t)
+	rec, res := ht.invoke(t, `{"type": "conversationUpdate", "serviceUrl": "`+testServiceURL+`"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, res)
+}
+
+func TestHandlerRejected(t *testing.T) {
+	ht := newHandlerTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/actions", nil)
+	rec := httptest.NewRecorder()
+	ht.handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/actions", strings.NewReader(activity(card.VerbAcknowledge, "alice")))
+	rec = httptest.NewRecorder()
+	ht.handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The token was issued for another service URL.
+	claims := validClaims()
+	claims["serviceurl"] = "https://attacker.example.com/"
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/actions", strings.NewReader(activity(card.VerbAcknowledge, "alice")))
+	req.Header.Set("Authorization", ht.jwks.sign(t, "k1", claims))
+	rec = httptest.NewRecorder()
+	ht.handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec, _ = ht.invoke(t, "{")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Empty(t, ht.store.Get("f1"))
+}
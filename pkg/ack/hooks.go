@@ -0,0 +1,140 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxResponseBytes caps the size of the responses of Alertmanager and
+// Grafana read.
+const maxResponseBytes = 1 << 20
+
+// Alertmanager creates silences with the API of Alertmanager at URL.
+type Alertmanager struct {
+	URL  string
+	HTTP *http.Client
+}
+
+type matcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+type silence struct {
+	Matchers  []matcher `json:"matchers"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	CreatedBy string    `json:"createdBy"`
+	Comment   string    `json:"comment"`
+}
+
+// Silence silences the alerts with the given labels from start to end and
+// returns the ID of the silence.
+func (a Alertmanager) Silence(ctx context.Context, labels map[string]string, start, end time.Time, createdBy, comment string) (string, error) {
+	if len(labels) == 0 {
+		return "", errors.New("no labels to silence")
+	}
+	s := silence{StartsAt: start, EndsAt: end, CreatedBy: createdBy, Comment: comment}
+	for name, value := range labels {
+		s.Matchers = append(s.Matchers, matcher{Name: name, Value: value, IsEqual: true})
+	}
+	sort.Slice(s.Matchers, func(i, j int) bool { return s.Matchers[i].Name < s.Matchers[j].Name })
+
+	var res struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := postJSON(ctx, a.HTTP, strings.TrimSuffix(a.URL, "/")+"/api/v2/silences", "", s, &res); err != nil {
+		return "", err
+	}
+	return res.SilenceID, nil
+}
+
+// Grafana creates annotations with the API of Grafana at URL, authenticated
+// with Token, a service account token.
+type Grafana struct {
+	URL   string
+	Token string
+	HTTP  *http.Client
+}
+
+type annotation struct {
+	Time int64    `json:"time"`
+	Tags []string `json:"tags"`
+	Text string   `json:"text"`
+}
+
+// Annotate adds an organization annotation at t and returns its ID.
+func (g Grafana) Annotate(ctx context.Context, t time.Time, tags []string, text string) (string, error) {
+	var res struct {
+		ID int64 `json:"id"`
+	}
+	a := annotation{Time: t.UnixMilli(), Tags: tags, Text: text}
+	if err := postJSON(ctx, g.HTTP, strings.TrimSuffix(g.URL, "/")+"/api/annotations", g.Token, a, &res); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(res.ID, 10), nil
+}
+
+// postJSON posts the JSON of body to url, with the bearer token if not
+// empty, and decodes the JSON response into res.
+func postJSON(ctx context.Context, client *http.Client, url, token string, body, res any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d: %s", url, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, res); err != nil {
+		return fmt.Errorf("%s returned an invalid response: %w", url, err)
+	}
+	return nil
+}
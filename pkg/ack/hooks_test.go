@@ -0,0 +1,100 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ack
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertmanagerSilence(t *testing.T) {
+	var got silence
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v2/silences", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"silenceID":"s1"}`))
+	}))
+	defer srv.Close()
+
+	end := t0.Add(4 * time.Hour)
+	am := Alertmanager{URL: srv.URL + "/", HTTP: srv.Client()}
+	id, err := am.Silence(context.Background(), map[string]string{"job": "api", "alertname": "Down"}, t0, end, "alice", "acked")
+	require.NoError(t, err)
+	assert.Equal(t, "s1", id)
+	assert.Equal(t, silence{
+		Matchers: []matcher{
+			{Name: "alertname", Value: "Down", IsEqual: true},
+			{Name: "job", Value: "api", IsEqual: true},
+		},
+		StartsAt:  t0,
+		EndsAt:    end,
+		CreatedBy: "alice",
+		Comment:   "acked",
+	}, got)
+}
+
+func TestAlertmanagerSilenceErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad matchers", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	am := Alertmanager{URL: srv.URL, HTTP: srv.Client()}
+
+	_, err := am.Silence(context.Background(), nil, t0, t0, "alice", "")
+	assert.Error(t, err)
+
+	_, err = am.Silence(context.Background(), map[string]string{"job": "api"}, t0, t0, "alice", "")
+	assert.ErrorContains(t, err, "returned 400: bad matchers")
+}
+
+func TestGrafanaAnnotate(t *testing.T) {
+	var got annotation
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/annotations", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"id":42,"message":"Annotation added"}`))
+	}))
+	defer srv.Close()
+
+	g := Grafana{URL: srv.URL, Token: "token", HTTP: srv.Client()}
+	id, err := g.Annotate(context.Background(), t0, []string{"prometheus-msteams"}, "Down: acknowledged by alice")
+	require.NoError(t, err)
+	assert.Equal(t, "42", id)
+	assert.Equal(t, annotation{Time: t0.UnixMilli(), Tags: []string{"prometheus-msteams"}, Text: "Down: acknowledged by alice"}, got)
+}
+
+func TestGrafanaAnnotateInvalidResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html>`))
+	}))
+	defer srv.Close()
+
+	g := Grafana{URL: srv.URL, HTTP: srv.Client()}
+	_, err := g.Annotate(context.Background(), t0, nil, "")
+	assert.ErrorContains(t, err, "invalid response")
+}
@@ -21,7 +21,6 @@ package adaptivecards
 import (
 	"encoding/json"
 	"fmt"
-	"reflect"
 	"strings"
 
 	"github.com/stakater/prometheus-msteams/pkg/utility"
@@ -39,7 +38,7 @@ var logger *utility.Logger
 
 // init registers all known Element and Action types for dynamic unmarshaling
 func init() {
-	typeRegistry["AdaptiveCard"] = reflect.TypeFor[AdaptiveCard]()
+	RegisterType("AdaptiveCard", AdaptiveCard{})
 
 	// Register all Element types
 	RegisterType("ActionSet", ActionSet{})
@@ -48,18 +47,24 @@ func init() {
 	RegisterType("Container", Container{})
 	RegisterType("ColumnSet", ColumnSet{})
 	RegisterType("Column", Column{})
+	RegisterType("CompoundButton", CompoundButton{})
 	RegisterType("FactSet", FactSet{})
 	RegisterType("Icon", Icon{})
 	RegisterType("Image", Image{})
 	RegisterType("ImageSet", ImageSet{})
 	RegisterType("Media", Media{})
+	RegisterType("ProgressBar", ProgressBar{})
+	RegisterType("ProgressRing", ProgressRing{})
 	RegisterType("Rating", Rating{})
 	RegisterType("RichTextBlock", RichTextBlock{})
 	RegisterType("Table", Table{})
+	RegisterType("TableRow", TableRow{})
+	RegisterType("TableCell", TableCell{})
 	RegisterType("TextBlock", TextBlock{})
 
 	// Register all Input types
 	RegisterType("Input.ChoiceSet", InputChoiceSet{})
+	RegisterType("Data.Query", DataQuery{})
 	RegisterType("Input.Date", InputDate{})
 	RegisterType("Input.Number", InputNumber{})
 	RegisterType("Input.Rating", InputRating{})
@@ -100,6 +105,7 @@ func init() {
 	RegisterType("Layout.Flow", LayoutFlow{})
 	RegisterType("Layout.AreaGrid", LayoutAreaGrid{})
 	RegisterType("StringResource", StringResource{})
+	RegisterType("mention", Mention{})
 
 	if logger == nil {
 		logger = utility.NewLogger(utility.LogFormatJSON, true)
@@ -118,7 +124,7 @@ type Common struct {
 	HorizontalAlignment *HorizontalAlignment `json:"horizontalAlignment,omitempty" version:"1.0"`
 	ID                  string               `json:"id,omitempty" version:"1.0"`
 	IsSortKey           bool                 `json:"isSortKey,omitempty" version:"1.5"`
-	IsVisible           bool                 `json:"isVisible,omitempty" version:"1.2"`
+	IsVisible           *bool                `json:"isVisible,omitempty" version:"1.2"`
 	IsVisibleDynamic    bool                 `json:"isVisible.dynamic,omitempty" version:"1.5"`
 	Key                 string               `json:"key,omitempty" version:"1.0"`
 	Lang                string               `json:"lang,omitempty" version:"1.1"`
@@ -435,11 +441,19 @@ func (c *Column) UnmarshalJSON(data []byte) error {
 // which can display a button with multiple pieces of information and an optional icon.
 type CompoundButton struct {
 	*Common
-	Badge        *Badge  `json:"badge,omitempty" version:"1.5"`
-	Description  string  `json:"description,omitempty" version:"1.5"`
-	Icon         *Symbol `json:"icon,omitempty" version:"1.5"`
-	SelectAction Action  `json:"selectAction,omitempty" version:"1.1"`
-	Title        string  `json:"title,omitempty" version:"1.5"`
+	Badge        *Badge    `json:"badge,omitempty" version:"1.5"`
+	Description  string    `json:"description,omitempty" version:"1.5"`
+	Icon         *IconInfo `json:"icon,omitempty" version:"1.5"`
+	SelectAction Action    `json:"selectAction,omitempty" version:"1.1"`
+	Title        string    `json:"title,omitempty" version:"1.5"`
+}
+
+// IconInfo defines the icon of a CompoundButton.
+type IconInfo struct {
+	Name  Symbol     `json:"name" version:"1.5"`
+	Size  *IconSize  `json:"size,omitempty" version:"1.5"`
+	Style *IconStyle `json:"style,omitempty" version:"1.5"`
+	Color Colors     `json:"color,omitempty" version:"1.5"`
 }
 
 func (c CompoundButton) isElement() {}
@@ -550,7 +564,7 @@ func (f *Fact) UnmarshalJSON(data []byte) error {
 // Icon - https://adaptivecards.io/explorer/Icon.html
 type Icon struct {
 	*Common
-	URL          string     `json:"url"`
+	URL          string     `json:"url,omitempty"`
 	Color        Colors     `json:"color,omitempty" version:"1.5"`
 	Name         *Symbol    `json:"name,omitempty" version:"1.5"`
 	SelectAction Action     `json:"selectAction,omitempty" version:"1.5"`
@@ -580,7 +594,7 @@ func (i *Icon) UnmarshalJSON(data []byte) error {
 // Image - https://adaptivecards.io/explorer/Image.html
 type Image struct {
 	*Common
-	AllowExpand                bool                  `json:"allowExpand,omitempty" verson:"1.2"`
+	AllowExpand                bool                  `json:"allowExpand,omitempty" version:"1.2"`
 	AltText                    string                `json:"altText,omitempty" version:"1.0"`
 	BackgroundColor            string                `json:"backgroundColor,omitempty" version:"1.1"`
 	FitMode                    *ImageFit             `json:"fitMode,omitempty" version:"1.2"`
@@ -615,7 +629,7 @@ func (i *Image) UnmarshalJSON(data []byte) error {
 // TeamsImageProperties defines Microsoft Teams-specific properties for
 // Image elements in Adaptive Cards.
 type TeamsImageProperties struct {
-	AllowExpand bool   `json:"allowExpand,omitempty" verson:"1.2"`
+	AllowExpand bool   `json:"allowExpand,omitempty" version:"1.2"`
 	Key         string `json:"key,omitempty" version:"1.0"`
 }
 
@@ -684,15 +698,8 @@ type CaptionSource struct {
 	Label    string `json:"label"`
 }
 
-// MarshalJSON ensures that the "type" field is included when marshaling a
-// CaptionSource to JSON.
-func (c CaptionSource) MarshalJSON() ([]byte, error) {
-	return SmartMarshalFromJSON(c)
-	// return marshalWithType(c, "CaptionSource")
-}
-
-// UnmarshalJSON ensures that the "type" field is validated when unmarshaling
-// JSON into a CaptionSource struct.
+// UnmarshalJSON decodes a CaptionSource. It has no "type" field, so it is
+// marshaled as a plain struct.
 func (c *CaptionSource) UnmarshalJSON(data []byte) error {
 	return SmartUnmarshalJSON(data, c)
 }
@@ -707,15 +714,8 @@ type MediaSource struct {
 	URL      string `json:"url"`
 }
 
-// MarshalJSON ensures that the "type" field is included when marshaling a
-// MediaSource to JSON.
-func (m MediaSource) MarshalJSON() ([]byte, error) {
-	return SmartMarshalFromJSON(m)
-	// return marshalWithType(m, "MediaSource")
-}
-
-// UnmarshalJSON ensures that the "type" field is validated when unmarshaling
-// JSON into a MediaSource struct.
+// UnmarshalJSON decodes a MediaSource. It has no "type" field, so it is
+// marshaled as a plain struct.
 func (m *MediaSource) UnmarshalJSON(data []byte) error {
 	return SmartUnmarshalJSON(data, m)
 }
@@ -792,7 +792,7 @@ type Rating struct {
 	Max   int          `json:"max,omitempty" version:"1.5"`
 	Size  *RatingSize  `json:"size,omitempty" version:"1.5"`
 	Style *RatingStyle `json:"style,omitempty" version:"1.5"`
-	Value int          `json:"value,omitempty" version:"1.5"`
+	Value float64      `json:"value,omitempty" version:"1.5"`
 }
 
 func (c Rating) isElement() {}
@@ -921,7 +921,7 @@ type IconRun struct {
 	GridArea         string  `json:"grid.area,omitempty" version:"1.5"`
 	ID               string  `json:"id,omitempty" version:"1.0"`
 	IsSortKey        bool    `json:"isSortKey,omitempty" version:"1.5"`
-	IsVisible        bool    `json:"isVisible,omitempty" version:"1.2"`
+	IsVisible        *bool   `json:"isVisible,omitempty" version:"1.2"`
 	IsVisibleDynamic bool    `json:"isVisible.dynamic,omitempty" version:"1.5"`
 	Key              string  `json:"key,omitempty" version:"1.0"`
 	Lang             string  `json:"lang,omitempty" version:"1.1"`
@@ -957,7 +957,7 @@ type ImageRun struct {
 	GridArea         string      `json:"grid.area,omitempty" version:"1.5"`
 	ID               string      `json:"id,omitempty" version:"1.0"`
 	IsSortKey        bool        `json:"isSortKey,omitempty" version:"1.5"`
-	IsVisible        bool        `json:"isVisible,omitempty" version:"1.2"`
+	IsVisible        *bool       `json:"isVisible,omitempty" version:"1.2"`
 	IsVisibleDynamic bool        `json:"isVisible.dynamic,omitempty" version:"1.5"`
 	Key              string      `json:"key,omitempty" version:"1.0"`
 	Lang             string      `json:"lang,omitempty" version:"1.1"`
@@ -1025,15 +1025,8 @@ type TableColumnDefinition struct {
 	Key                            string               `json:"key,omitempty" version:"1.0"`
 }
 
-// MarshalJSON ensures that the "type" field is included when marshaling a
-// TableColumnDefinition to JSON.
-func (t TableColumnDefinition) MarshalJSON() ([]byte, error) {
-	return SmartMarshalFromJSON(t)
-	// return marshalWithType(t, "TableColumnDefinition")
-}
-
-// UnmarshalJSON ensures that the "type" field is validated when unmarshaling
-// JSON into a TableColumnDefinition struct.
+// UnmarshalJSON decodes a TableColumnDefinition. It has no "type" field, so it is
+// marshaled as a plain struct.
 func (t *TableColumnDefinition) UnmarshalJSON(data []byte) error {
 	return SmartUnmarshalJSON(data, t)
 }
@@ -1419,6 +1412,10 @@ type TargetElement struct {
 	IsVisible *bool  `json:"isVisible,omitempty"`
 }
 
+// targetElement has the fields of TargetElement without its JSON methods, so
+// it can be marshaled and unmarshaled as an object without recursion.
+type targetElement TargetElement
+
 // MarshalJSON allows TargetElement to be marshaled as either a simple string
 // (elementId) or an object with elementId and isVisible properties.
 func (t TargetElement) MarshalJSON() ([]byte, error) {
@@ -1426,23 +1423,23 @@ func (t TargetElement) MarshalJSON() ([]byte, error) {
 	if t.IsVisible == nil {
 		return json.Marshal(t.ElementID)
 	}
-
-	return SmartMarshalFromJSON(t)
-	// return marshalWithType(t, "TargetElement")
+	return json.Marshal(targetElement(t))
 }
 
 // UnmarshalJSON allows TargetElement to be unmarshaled from either a simple string
 // (elementId) or an object with elementId and isVisible properties.
 func (t *TargetElement) UnmarshalJSON(data []byte) error {
-	err := json.Unmarshal(data, &t.ElementID) // Try unmarshaling as a simple string first
-	if err == nil {
+	var elementID string
+	if err := json.Unmarshal(data, &elementID); err == nil {
+		*t = TargetElement{ElementID: elementID}
 		return nil
 	}
-	err = json.Unmarshal(data, &t) // If that fails, try unmarshaling as an object
-	if err != nil {
+	var te targetElement
+	if err := json.Unmarshal(data, &te); err != nil {
 		return fmt.Errorf("failed to unmarshal TargetElement: %w", err)
 	}
-	return SmartUnmarshalJSON(data, t)
+	*t = TargetElement(te)
+	return nil
 }
 
 // endregion TargetElement
@@ -1611,9 +1608,9 @@ type InputChoiceSet struct {
 	MinColumnWidth     string            `json:"minColumnWidth,omitempty" version:"1.5"`
 	Placeholder        string            `json:"placeholder,omitempty"`
 	Style              *ChoiceInputStyle `json:"style,omitempty"`
-	UseMultipleColumns any               `json:"useMultipleColumns"`
+	UseMultipleColumns any               `json:"useMultipleColumns,omitempty"`
 	Value              string            `json:"value,omitempty"`
-	ValueChangedAction Action            `json:"valueChangedAction"`
+	ValueChangedAction Action            `json:"valueChangedAction,omitempty"`
 	Wrap               bool              `json:"wrap,omitempty" version:"1.2"`
 }
 
@@ -1640,15 +1637,8 @@ type InputChoice struct {
 	Value string `json:"value"`
 }
 
-// MarshalJSON ensures that the "type" field is included when marshaling an
-// InputChoice to JSON.
-func (i InputChoice) MarshalJSON() ([]byte, error) {
-	return SmartMarshalFromJSON(i)
-	// return marshalWithType(i, "Input.Choice")
-}
-
-// UnmarshalJSON ensures that the "type" field is validated when unmarshaling
-// JSON into an InputChoice struct.
+// UnmarshalJSON decodes an InputChoice. It has no "type" field, so it is
+// marshaled as a plain struct.
 func (i *InputChoice) UnmarshalJSON(data []byte) error {
 	return SmartUnmarshalJSON(data, i)
 }
@@ -1695,7 +1685,7 @@ type InputDate struct {
 	LabelWidth         any            `json:"labelWidth,omitempty" version:"1.6"`
 	Placeholder        string         `json:"placeholder,omitempty"`
 	Value              string         `json:"value,omitempty"`
-	ValueChangedAction Action         `json:"valueChangedAction"`
+	ValueChangedAction Action         `json:"valueChangedAction,omitempty"`
 }
 
 func (i InputDate) isElement() {}
@@ -1720,16 +1710,16 @@ func (i *InputDate) UnmarshalJSON(data []byte) error {
 // InputNumber - https://adaptivecards.io/explorer/Input.Number.html
 type InputNumber struct {
 	*Common
-	Max                float64        `json:"max,omitempty"`
-	Min                float64        `json:"min,omitempty"`
+	Max                *float64       `json:"max,omitempty"`
+	Min                *float64       `json:"min,omitempty"`
 	ErrorMessage       string         `json:"errorMessage,omitempty" version:"1.3"`
 	IsRequired         bool           `json:"isRequired,omitempty" version:"1.3"`
 	Label              string         `json:"label,omitempty" version:"1.3"`
 	LabelPosition      *LabelPosition `json:"labelPosition,omitempty" version:"1.6"`
 	LabelWidth         any            `json:"labelWidth,omitempty" version:"1.6"`
 	Placeholder        string         `json:"placeholder,omitempty"`
-	Value              float64        `json:"value,omitempty"`
-	ValueChangedAction Action         `json:"valueChangedAction"`
+	Value              *float64       `json:"value,omitempty"`
+	ValueChangedAction Action         `json:"valueChangedAction,omitempty"`
 }
 
 func (i InputNumber) isElement() {}
@@ -1765,9 +1755,11 @@ type InputRating struct {
 	LabelWidth         any            `json:"labelWidth,omitempty" version:"1.6"`
 	Placeholder        string         `json:"placeholder,omitempty"`
 	Value              float64        `json:"value,omitempty"`
-	ValueChangedAction Action         `json:"valueChangedAction"`
+	ValueChangedAction Action         `json:"valueChangedAction,omitempty"`
 }
 
+func (i InputRating) isElement() {}
+
 // MarshalJSON ensures that the "type" field is included when marshaling an
 // InputRating to JSON.
 func (i InputRating) MarshalJSON() ([]byte, error) {
@@ -1800,7 +1792,7 @@ type InputText struct {
 	LabelWidth         any             `json:"labelWidth,omitempty" version:"1.6"`
 	Placeholder        string          `json:"placeholder,omitempty"`
 	Value              string          `json:"value,omitempty"`
-	ValueChangedAction Action          `json:"valueChangedAction"`
+	ValueChangedAction Action          `json:"valueChangedAction,omitempty"`
 }
 
 func (i InputText) isElement() {}
@@ -1834,7 +1826,7 @@ type InputTime struct {
 	LabelWidth         any            `json:"labelWidth,omitempty" version:"1.6"`
 	Placeholder        string         `json:"placeholder,omitempty"`
 	Value              string         `json:"value,omitempty"`
-	ValueChangedAction Action         `json:"valueChangedAction"`
+	ValueChangedAction Action         `json:"valueChangedAction,omitempty"`
 }
 
 func (i InputTime) isElement() {}
@@ -1871,7 +1863,7 @@ type InputToggle struct {
 	LabelWidth         any            `json:"labelWidth,omitempty" version:"1.6"`
 	Placeholder        string         `json:"placeholder,omitempty"`
 	Value              string         `json:"value,omitempty"`
-	ValueChangedAction Action         `json:"valueChangedAction"`
+	ValueChangedAction Action         `json:"valueChangedAction,omitempty"`
 }
 
 func (i InputToggle) isElement() {}
@@ -1916,15 +1908,8 @@ type Refresh struct {
 	UserIDs []string       `json:"userIds,omitempty"`
 }
 
-// MarshalJSON ensures that the "type" field is included when marshaling a
-// Refresh to JSON.
-func (r Refresh) MarshalJSON() ([]byte, error) {
-	return SmartMarshalFromJSON(r)
-	// return marshalWithType(r, "Refresh")
-}
-
-// UnmarshalJSON ensures that the "type" field is validated when unmarshaling
-// JSON into a Refresh struct.
+// UnmarshalJSON decodes a Refresh. It has no "type" field, so it is
+// marshaled as a plain struct.
 func (r *Refresh) UnmarshalJSON(data []byte) error {
 	return SmartUnmarshalJSON(data, r)
 }
@@ -1953,15 +1938,8 @@ type TokenExchangeResource struct {
 	ProviderID string `json:"providerId"`
 }
 
-// MarshalJSON ensures that the "type" field is included when marshaling a
-// TokenExchangeResource to JSON.
-func (t TokenExchangeResource) MarshalJSON() ([]byte, error) {
-	return SmartMarshalFromJSON(t)
-	// return marshalWithType(t, "TokenExchangeResource")
-}
-
-// UnmarshalJSON ensures that the "type" field is validated when unmarshaling
-// JSON into a TokenExchangeResource struct.
+// UnmarshalJSON decodes a TokenExchangeResource. It has no "type" field, so it is
+// marshaled as a plain struct.
 func (t *TokenExchangeResource) UnmarshalJSON(data []byte) error {
 	return SmartUnmarshalJSON(data, t)
 }
@@ -1978,15 +1956,8 @@ type Authentication struct {
 	Buttons               []AuthCardButton       `json:"buttons,omitempty"`
 }
 
-// MarshalJSON ensures that the "type" field is included when marshaling an
-// Authentication to JSON.
-func (a Authentication) MarshalJSON() ([]byte, error) {
-	return SmartMarshalFromJSON(a)
-	// return marshalWithType(a, "Authentication")
-}
-
-// UnmarshalJSON ensures that the "type" field is validated when unmarshaling
-// JSON into an Authentication struct.
+// UnmarshalJSON decodes an Authentication. It has no "type" field, so it is
+// marshaled as a plain struct.
 func (a *Authentication) UnmarshalJSON(data []byte) error {
 	return SmartUnmarshalJSON(data, a)
 }
@@ -2000,15 +1971,8 @@ type Metadata struct {
 	WebURL string `json:"webUrl,omitempty"`
 }
 
-// MarshalJSON ensures that the "type" field is included when marshaling
-// Metadata to JSON.
-func (m Metadata) MarshalJSON() ([]byte, error) {
-	return SmartMarshalFromJSON(m)
-	// return marshalWithType(m, "Metadata")
-}
-
-// UnmarshalJSON ensures that the "type" field is validated when unmarshaling
-// JSON into a Metadata struct.
+// UnmarshalJSON decodes a Metadata. It has no "type" field, so it is
+// marshaled as a plain struct.
 func (m *Metadata) UnmarshalJSON(data []byte) error {
 	return SmartUnmarshalJSON(data, m)
 }
@@ -2053,12 +2017,12 @@ type MentionedEntity struct {
 
 // region Mention
 
-// Mention defines a mention of one or more entities in a message,
-// along with the text of the mention
+// Mention defines a mention of an entity in a message, along with the text
+// of the mention
 type Mention struct {
-	Mentioned []MentionedEntity `json:"mentioned,omitempty"`
-	Text      string            `json:"text,omitempty"`
-	Key       string            `json:"key,omitempty"`
+	Mentioned *MentionedEntity `json:"mentioned,omitempty"`
+	Text      string           `json:"text,omitempty"`
+	Key       string           `json:"key,omitempty"`
 }
 
 // MarshalJSON ensures that the "type" field is included when marshaling a
@@ -17,6 +17,7 @@ package adaptivecards
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -173,7 +174,7 @@ func TestAdaptiveCardExample(t *testing.T) {
 				Text: AsPtr("This course is designed to equip you with an understanding of the key principles and tools necessary for creating compelling designs. You'll gain practical experience with creative software and learn about design principles through hands-on projects that will help build your portfolio. Enroll now and start your journey to mastering the art of graphic design."),
 				Wrap: true,
 				Common: &Common{
-					IsVisible:   false,
+					IsVisible:   AsPtr(false),
 					ID:          "fullText",
 					TargetWidth: AsPtr(TargetWidthAtLeastNarrow),
 				},
@@ -203,7 +204,7 @@ func TestAdaptiveCardExample(t *testing.T) {
 					ID:          "showLess",
 					Spacing:     AsPtr(SpacingNone),
 					TargetWidth: AsPtr(TargetWidthAtLeastNarrow),
-					IsVisible:   false,
+					IsVisible:   AsPtr(false),
 				},
 				Inlines: []RichTextInline{
 					&TextRun{
@@ -573,3 +574,43 @@ func TestAdaptiveCardResources(t *testing.T) {
 func TestAdaptiveCard(t *testing.T) {
 	assert.True(t, true)
 }
+
+// benchmarkCard builds a card with n body elements, cycling through a
+// TextBlock, a FactSet and an Image.
+func benchmarkCard(n int) AdaptiveCard {
+	card := AdaptiveCard{
+		Version: "1.5",
+		Actions: []Action{
+			ActionOpenURL{CommonActionProperties: &CommonActionProperties{Title: "Open"}, URL: "https://example.com"},
+		},
+	}
+	for i := 0; i < n; i++ {
+		common := &Common{ID: fmt.Sprintf("element-%d", i), Separator: i%2 == 0}
+		switch i % 3 {
+		case 0:
+			card.Body = append(card.Body, TextBlock{Common: common, Text: AsPtr("alert firing"), Wrap: true})
+		case 1:
+			card.Body = append(card.Body, FactSet{Common: common, Facts: []Fact{
+				{Title: "severity", Value: "critical"},
+				{Title: "instance", Value: "node-1:9100"},
+			}})
+		default:
+			card.Body = append(card.Body, Image{Common: common, URL: "https://example.com/graph.png", AltText: "graph"})
+		}
+	}
+	return card
+}
+
+func BenchmarkMarshalJSON(b *testing.B) {
+	for _, n := range []int{1, 50, 500} {
+		card := benchmarkCard(n)
+		b.Run(fmt.Sprintf("elements=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := json.Marshal(card); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
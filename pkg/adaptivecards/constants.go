@@ -484,3 +484,12 @@ const (
 	PopoverPositionAbove  PopoverPosition = "Above"
 	PopoverPositionBelow  PopoverPosition = "Below"
 )
+
+// AttachmentLayout controls how the attachments of a message are shown.
+type AttachmentLayout string
+
+// AttachmentLayout controls how the attachments of a message are shown.
+const (
+	AttachmentLayoutList     AttachmentLayout = "list"
+	AttachmentLayoutCarousel AttachmentLayout = "carousel"
+)
@@ -0,0 +1,22 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adaptivecards
+
+// Symbol constants are generated from icons.txt. The structs of this package
+// are compared with the Adaptive Cards schemas of testdata/schemas, checked
+// in by "make schemas", by "make schema-check" and the acgen tests.
+//go:generate go run ../../cmd/acgen icons -in icons.txt -out icons.go
@@ -14,6 +14,8 @@ See the License for the specific language governing permissions and
 limitations under the License.
 */
 
+// Code generated by acgen. DO NOT EDIT.
+
 // Package adaptivecards provides Go structs and helper functions for working
 // with Microsoft Adaptive Cards.
 package adaptivecards
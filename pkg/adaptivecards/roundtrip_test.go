@@ -0,0 +1,109 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package adaptivecards
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleFiles returns the Adaptive Card samples used as round-trip corpus and
+// as fuzzing seeds: those of test/data/samples and the official samples of
+// test/data/samples/official, see make samples.
+func sampleFiles(tb testing.TB) []string {
+	dir := filepath.Join("..", "..", "test", "data", "samples")
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(tb, err)
+	require.NotEmpty(tb, files)
+	official, err := filepath.Glob(filepath.Join(dir, "official", "v*", "*", "*.json"))
+	require.NoError(tb, err)
+	return append(files, official...)
+}
+
+// roundTrip unmarshals data into a new T and marshals it back.
+func roundTrip[T any](data []byte) ([]byte, error) {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// TestRoundTripSamples checks that every sample survives
+// unmarshal→marshal without losing fields and that marshal→unmarshal→marshal
+// is stable.
+func TestRoundTripSamples(t *testing.T) {
+	for _, file := range sampleFiles(t) {
+		name, err := filepath.Rel(filepath.Join("..", "..", "test", "data", "samples"), file)
+		require.NoError(t, err)
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(file) //nolint:gosec
+			require.NoError(t, err)
+
+			var typ AdaptiveCardType
+			require.NoError(t, json.Unmarshal(data, &typ))
+
+			rt := roundTrip[AdaptiveCard]
+			if typ.Type == "message" {
+				rt = roundTrip[WorkflowConnectorCard]
+			}
+
+			first, err := rt(data)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(data), string(first), "unmarshal→marshal lost or changed fields")
+
+			second, err := rt(first)
+			require.NoError(t, err)
+			assert.Equal(t, string(first), string(second), "marshal→unmarshal→marshal is not stable")
+		})
+	}
+}
+
+// FuzzAdaptiveCardUnmarshalJSON checks that arbitrary input never panics and
+// that any card which unmarshals successfully round-trips to a stable form.
+func FuzzAdaptiveCardUnmarshalJSON(f *testing.F) {
+	for _, file := range sampleFiles(f) {
+		data, err := os.ReadFile(file) //nolint:gosec
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	f.Add([]byte(`{"type":"AdaptiveCard","version":"1.2","body":[{"type":"TextBlock","text":"x","fallback":"drop"}]}`))
+	f.Add([]byte(`{"type":"AdaptiveCard","version":"1.2","body":[{"type":"TextBlock","text":"x","fallback":{"type":"Unknown"}}]}`))
+	f.Add([]byte(`{"type":"AdaptiveCard","actions":[{"type":"Action.ToggleVisibility","targetElements":[{"elementId":"a"}]}]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var card AdaptiveCard
+		if err := json.Unmarshal(data, &card); err != nil {
+			return
+		}
+		first, err := json.Marshal(card)
+		if err != nil {
+			t.Fatalf("marshal after successful unmarshal: %v", err)
+		}
+		second, err := roundTrip[AdaptiveCard](first)
+		if err != nil {
+			t.Fatalf("unmarshal of marshaled card %s: %v", first, err)
+		}
+		if string(first) != string(second) {
+			t.Fatalf("round trip is not stable:\n%s\n%s", first, second)
+		}
+	})
+}
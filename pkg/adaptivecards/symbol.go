@@ -0,0 +1,45 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adaptivecards
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+//go:embed icons.txt
+var iconsTxt string
+
+// symbols holds the icon names of icons.txt.
+var symbols = sync.OnceValue(func() map[Symbol]bool {
+	m := map[Symbol]bool{}
+	for line := range strings.Lines(iconsTxt) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		m[Symbol(fields[0])] = true
+	}
+	return m
+})
+
+// Valid reports whether s is the name of an icon supported by the Icon
+// element.
+func (s Symbol) Valid() bool {
+	return symbols()[s]
+}
@@ -0,0 +1,32 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package adaptivecards
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolValid(t *testing.T) {
+	for _, s := range []Symbol{SymbolWarning, SymbolMsWord, SymbolCardUI, SymbolZoomOut} {
+		assert.True(t, s.Valid(), s)
+	}
+	for _, s := range []Symbol{"", "warning", "NoSuchIcon", "#"} {
+		assert.False(t, s.Valid(), s)
+	}
+}
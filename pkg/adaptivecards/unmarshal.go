@@ -19,21 +19,46 @@ limitations under the License.
 package adaptivecards
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // region Type Registry for Dynamic Unmarshaling
 
-// Type registries for dynamic unmarshaling
-var typeRegistry = make(map[string]reflect.Type)
+// Type registries for dynamic unmarshaling. typeNames is the reverse index
+// used when marshaling, holding the JSON encoded type name of each type.
+// Both are written by RegisterType, which must only be called from init
+// functions.
+var (
+	typeRegistry = make(map[string]reflect.Type)
+	typeNames    = make(map[reflect.Type][]byte)
+)
 
 // RegisterType registers a type with its JSON type name for dynamic unmarshaling
 func RegisterType(typeName string, example any) {
-	typeRegistry[typeName] = reflect.TypeOf(example)
+	t := reflect.TypeOf(example)
+	if prev, ok := typeRegistry[typeName]; ok && prev != t {
+		delete(typeNames, prev)
+	}
+	typeRegistry[typeName] = t
+	quoted, _ := json.Marshal(typeName)
+	typeNames[t] = quoted
+}
+
+// registeredTypeName returns the type name t was registered with.
+func registeredTypeName(t reflect.Type) (string, bool) {
+	quoted, ok := typeNames[t]
+	if !ok {
+		return "", false
+	}
+	return string(quoted[1 : len(quoted)-1]), true
 }
 
 // endregion Type Registry for Dynamic Unmarshaling
@@ -124,9 +149,9 @@ func ValidateType(raw json.RawMessage, sourceType reflect.Type) error {
 		sourceType = sourceType.Elem()
 	}
 
-	typeStr, ok := FindMapKey(typeRegistry, sourceType)
+	typeStr, ok := registeredTypeName(sourceType)
 	if !ok {
-		return fmt.Errorf("unable to find type for %T in registry! Is it registered?", sourceType)
+		return fmt.Errorf("unable to find type for %v in registry! Is it registered?", sourceType)
 	}
 	if tc.Type != "" && tc.Type != typeStr {
 		return AdaptiveCardErrorInvalid{Original: sourceType, Card: *tc}
@@ -137,121 +162,232 @@ func ValidateType(raw json.RawMessage, sourceType reflect.Type) error {
 // region Dynamic Marshaling
 
 // SmartMarshalFromJSON injects a "type" field into any struct during JSON marshaling.
-// It uses reflection to extract struct fields and build a map, avoiding the infinite
+// It writes the struct fields from a cached field plan, avoiding the infinite
 // recursion that would occur if we called json.Marshal directly on the value.
+// The "type" field comes first, followed by the fields in declaration order,
+// so the output is stable.
 func SmartMarshalFromJSON(v any) ([]byte, error) {
-	sourceValue := reflect.ValueOf(v)
-	sourceType := sourceValue.Type()
-
-	if sourceValue.Kind() == reflect.Pointer {
+	sourceType := reflect.TypeOf(v)
+	if sourceType != nil && sourceType.Kind() == reflect.Pointer {
 		sourceType = sourceType.Elem()
 	}
 
-	typeStr, ok := FindMapKey(typeRegistry, sourceType)
+	typeName, ok := typeNames[sourceType]
 	if !ok {
-		return nil, fmt.Errorf("unable to find type for %T in registry! Is it registered?", sourceType)
+		return nil, fmt.Errorf("unable to find type for %v in registry! Is it registered?", sourceType)
 	}
 
-	return marshalWithType(v, typeStr)
+	return marshalStruct(v, typeName)
 }
 
-// marshalWithType injects a "type" field into any struct during JSON marshaling.
-// It uses reflection to extract struct fields and build a map, avoiding the infinite
-// recursion that would occur if we called json.Marshal directly on the value.
+// marshalWithType injects a "type" field set to typeName into any struct
+// during JSON marshaling.
 func marshalWithType(v any, typeName string) ([]byte, error) {
-	// Create a map to hold all fields plus the type
-	m := make(map[string]any)
-	m["type"] = typeName
+	quoted, err := json.Marshal(typeName)
+	if err != nil {
+		return nil, err
+	}
+	return marshalStruct(v, quoted)
+}
+
+// fieldPlan describes how to write a single JSON field of a struct.
+type fieldPlan struct {
+	name      string
+	key       []byte // JSON encoded name followed by ':'
+	index     []int
+	depth     int
+	omitEmpty bool
+}
+
+// structPlan lists the JSON fields of a struct type in declaration order,
+// with the fields of embedded structs inlined where they are embedded.
+type structPlan struct {
+	fields []fieldPlan
+	// typeField is the index path, as for reflect.Value.FieldByIndex, of a
+	// declared "type" field, or nil if there is none. Its value takes
+	// precedence over the registered type name when it is set.
+	typeField []int
+}
+
+// structPlans caches a *structPlan per reflect.Type.
+var structPlans sync.Map
+
+func planFor(t reflect.Type) *structPlan {
+	if p, ok := structPlans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	p, _ := structPlans.LoadOrStore(t, buildStructPlan(t))
+	return p.(*structPlan)
+}
+
+func buildStructPlan(t reflect.Type) *structPlan {
+	var fields []fieldPlan
+	var walk func(t reflect.Type, index []int, depth int)
+	walk = func(t reflect.Type, index []int, depth int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldIndex := append(append([]int(nil), index...), i)
 
-	// Use reflection to extract struct fields
+			// Handle embedded structs (like *Common, *CommonActionProperties)
+			if field.Anonymous {
+				ft := field.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					walk(ft, fieldIndex, depth+1)
+				}
+				continue
+			}
+
+			jsonTag := field.Tag.Get("json")
+			name := parseJSONTag(jsonTag)
+			if name == "" {
+				continue
+			}
+			key, _ := json.Marshal(name)
+			fields = append(fields, fieldPlan{
+				name:      name,
+				key:       append(key, ':'),
+				index:     fieldIndex,
+				depth:     depth,
+				omitEmpty: strings.Contains(jsonTag[len(name):], ",omitempty"),
+			})
+		}
+	}
+	walk(t, nil, 0)
+
+	// Like encoding/json, a field shadows fields with the same name that
+	// are embedded deeper; the first one wins between equal depths.
+	winner := make(map[string]int, len(fields))
+	for i, f := range fields {
+		if w, ok := winner[f.name]; !ok || f.depth < fields[w].depth {
+			winner[f.name] = i
+		}
+	}
+	plan := &structPlan{}
+	for i, f := range fields {
+		if winner[f.name] != i {
+			continue
+		}
+		if f.name == "type" {
+			if t.FieldByIndex(f.index).Type.Kind() == reflect.String {
+				plan.typeField = f.index
+			}
+			continue
+		}
+		plan.fields = append(plan.fields, f)
+	}
+	return plan
+}
+
+// marshalBuffers holds the buffers used by marshalWithType.
+var marshalBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// marshalStruct writes a struct as a JSON object with a leading "type"
+// field set to the JSON encoded typeName.
+func marshalStruct(v any, typeName []byte) ([]byte, error) {
 	val := reflect.ValueOf(v)
-	typ := reflect.TypeOf(v)
 
 	// Handle pointer types by dereferencing
 	for val.Kind() == reflect.Pointer {
 		if val.IsNil() {
-			return json.Marshal(m)
+			return append(append([]byte(`{"type":`), typeName...), '}'), nil
 		}
 		val = val.Elem()
-		typ = typ.Elem()
 	}
 
 	// Ensure we're working with a struct
 	if val.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("marshalWithType requires a struct, got %v", val.Kind())
+		return nil, fmt.Errorf("marshalStruct requires a struct, got %v", val.Kind())
 	}
+	plan := planFor(val.Type())
 
-	// Extract all exported fields
-	for i := 0; i < val.NumField(); i++ {
-		field := typ.Field(i)
-		fieldValue := val.Field(i)
+	buf := marshalBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer marshalBuffers.Put(buf)
+	enc := json.NewEncoder(buf)
 
-		// Skip unexported fields
-		if !field.IsExported() {
-			continue
-		}
-
-		// Handle embedded structs (like *Common, *CommonActionProperties)
-		if field.Anonymous {
-			// If it's a pointer to a struct, dereference it
-			if fieldValue.Kind() == reflect.Pointer && !fieldValue.IsNil() {
-				fieldValue = fieldValue.Elem()
+	buf.WriteString(`{"type":`)
+	if plan.typeField != nil {
+		if fv, err := val.FieldByIndexErr(plan.typeField); err == nil && fv.String() != "" {
+			typeName = nil
+			if err := encodeValue(buf, enc, fv); err != nil {
+				return nil, err
 			}
+		}
+	}
+	buf.Write(typeName)
 
-			// If it's a struct, add all its fields to our map
-			if fieldValue.Kind() == reflect.Struct {
-				embeddedType := fieldValue.Type()
-				for j := 0; j < fieldValue.NumField(); j++ {
-					embeddedField := embeddedType.Field(j)
-					embeddedFieldValue := fieldValue.Field(j)
-
-					if !embeddedField.IsExported() {
-						continue
-					}
-
-					jsonTag := embeddedField.Tag.Get("json")
-					if jsonTag == "" || jsonTag == "-" {
-						continue
-					}
-
-					// Parse the json tag (format: "name,omitempty")
-					tagParts := strings.Split(jsonTag, ",")
-					fieldName := tagParts[0]
-					omitEmpty := len(tagParts) > 1 && tagParts[1] == "omitempty"
-
-					// Skip if omitempty and the field is empty
-					if omitEmpty && isEmptyValue(embeddedFieldValue) {
-						continue
-					}
-
-					m[fieldName] = embeddedFieldValue.Interface()
-				}
-			}
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		fv, err := val.FieldByIndexErr(f.index)
+		if err != nil {
+			// The field belongs to a nil embedded struct
 			continue
 		}
-
-		// Get the JSON tag for this field
-		jsonTag := field.Tag.Get("json")
-		if jsonTag == "" || jsonTag == "-" {
+		if f.omitEmpty && isEmptyValue(fv) {
 			continue
 		}
+		buf.WriteByte(',')
+		buf.Write(f.key)
+		if err := encodeValue(buf, enc, fv); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
 
-		// Parse the json tag (format: "name,omitempty")
-		tagParts := strings.Split(jsonTag, ",")
-		fieldName := tagParts[0]
-		omitEmpty := len(tagParts) > 1 && tagParts[1] == "omitempty"
+	return bytes.Clone(buf.Bytes()), nil
+}
 
-		// Skip if omitempty and the field is empty
-		if omitEmpty && isEmptyValue(fieldValue) {
-			continue
+// encodeValue appends the JSON encoding of v to buf. Booleans, integers and
+// plain strings are written directly, anything else goes through enc.
+func encodeValue(buf *bytes.Buffer, enc *json.Encoder, v reflect.Value) error {
+	if v.Type().Implements(marshalerType) {
+		return encodeWith(buf, enc, v)
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), v.Bool()))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), v.Int(), 10))
+		return nil
+	case reflect.String:
+		if s := v.String(); isPlainString(s) {
+			buf.WriteByte('"')
+			buf.WriteString(s)
+			buf.WriteByte('"')
+			return nil
 		}
+	}
+	return encodeWith(buf, enc, v)
+}
+
+var marshalerType = reflect.TypeFor[json.Marshaler]()
 
-		// Add the field to the map
-		m[fieldName] = fieldValue.Interface()
+func encodeWith(buf *bytes.Buffer, enc *json.Encoder, v reflect.Value) error {
+	if err := enc.Encode(v.Interface()); err != nil {
+		return err
 	}
+	// Encode terminates each value with a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
 
-	// Marshal the final map
-	return json.Marshal(m)
+// isPlainString reports whether s can be written as a JSON string without
+// escaping, using the same rules as encoding/json with HTML escaping.
+func isPlainString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c >= 0x80 || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			return false
+		}
+	}
+	return true
 }
 
 // isEmptyValue checks if a reflect.Value is empty (zero value)
@@ -289,25 +425,40 @@ func parseJSONTag(tag string) string {
 	return tag
 }
 
-// unmarshalFallback handles the special fallback field which can be a string, Element, or Action
+// unmarshalFallback handles the special fallback field which can be the
+// string "drop", an Element or an Action. A fallback of an unknown type, e.g.
+// an element of a newer schema, is kept as a json.RawMessage so it is
+// marshaled back unchanged.
 func unmarshalFallback(payload json.RawMessage, data *any) error {
 	// Try string first (most common case)
 	var fallbackStr string
 	if err := json.Unmarshal(payload, &fallbackStr); err == nil {
-		*data = FallbackOption(fallbackStr)
+		if !strings.EqualFold(fallbackStr, string(FallbackOptionDrop)) {
+			return fmt.Errorf("unsupported fallback option %q, only %q is allowed", fallbackStr, FallbackOptionDrop)
+		}
+		*data = FallbackOptionDrop
+		return nil
+	}
+
+	typeCheck, err := getCardType(payload)
+	if err != nil {
+		return err
+	}
+	if _, ok := typeRegistry[typeCheck.Type]; !ok {
+		*data = json.RawMessage(slices.Clone(payload))
 		return nil
 	}
 
 	// Try Element
 	var fallbackElement Element
-	if err := unmarshalMessage(payload, &fallbackElement); err == nil {
+	if err := unmarshalMessage(payload, &fallbackElement); err == nil && fallbackElement != nil {
 		*data = fallbackElement
 		return nil
 	}
 
 	// Try Action
 	var fallbackAction Action
-	if err := unmarshalMessage(payload, &fallbackAction); err == nil {
+	if err := unmarshalMessage(payload, &fallbackAction); err == nil && fallbackAction != nil {
 		*data = fallbackAction
 		return nil
 	}
@@ -556,14 +707,20 @@ var singleUnmarshalers = []struct {
 // It handles polymorphic slices like []Element, []Action, []Reference, and []Layout
 func unmarshalSliceField(rawMessages []json.RawMessage, fieldValue reflect.Value) error {
 	// Try concrete type first (optimized path)
-	if err := ValidateTypes(rawMessages, fieldValue.Type()); err == nil {
+	err := ValidateTypes(rawMessages, fieldValue.Type())
+	if err == nil {
 		return unmarshalSlice(rawMessages, fieldValue)
 	}
+	if invalid := (AdaptiveCardErrorInvalid{}); errors.As(err, &invalid) {
+		return err
+	}
 
-	// Check if element type implements any known interface
+	// Check if the element type is one of the known interfaces. Concrete
+	// element types (e.g. []Column) must not use these, as the resulting
+	// []Element is not assignable to them.
 	elemType := fieldValue.Type().Elem()
 	for _, unmarshaler := range sliceUnmarshalers {
-		if elemType.Implements(unmarshaler.interfaceType) {
+		if elemType == unmarshaler.interfaceType {
 			value, err := unmarshaler.unmarshal(rawMessages)
 			if err != nil {
 				return err
@@ -581,14 +738,18 @@ func unmarshalSliceField(rawMessages []json.RawMessage, fieldValue reflect.Value
 // It handles polymorphic fields like Element, Action, Reference, and Layout
 func unmarshalSingleField(rawMessage json.RawMessage, fieldValue reflect.Value) error {
 	// Try concrete type first (optimized path)
-	if err := ValidateType(rawMessage, fieldValue.Type()); err == nil {
+	err := ValidateType(rawMessage, fieldValue.Type())
+	if err == nil {
 		return unmarshalSingle(rawMessage, fieldValue)
 	}
+	if invalid := (AdaptiveCardErrorInvalid{}); errors.As(err, &invalid) {
+		return err
+	}
 
-	// Check if field type implements any known interface
+	// Check if the field type is one of the known interfaces
 	fieldType := fieldValue.Type()
 	for _, unmarshaler := range singleUnmarshalers {
-		if fieldType.Implements(unmarshaler.interfaceType) {
+		if fieldType == unmarshaler.interfaceType {
 			value, err := unmarshaler.unmarshal(rawMessage)
 			if err != nil {
 				return err
@@ -683,6 +844,9 @@ func unmarshalInterfaceFieldFromRaw(rawData json.RawMessage, fieldValue reflect.
 		if err := unmarshalFallback(rawData, &fallbackValue); err != nil {
 			return fmt.Errorf("failed to unmarshal fallback field %s: %w", fieldName, err)
 		}
+		if fallbackValue == nil {
+			return nil
+		}
 		fieldValue.Set(reflect.ValueOf(fallbackValue))
 		return nil
 	}
@@ -744,6 +908,13 @@ func smartUnmarshalSingleField(rawFields map[string]json.RawMessage, targetType
 		return nil
 	}
 
+	// null means "not set": leave pointers, interfaces and slices nil so the
+	// field is omitted again when marshaling.
+	if string(bytes.TrimSpace(rawData)) == "null" {
+		fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		return nil
+	}
+
 	/*
 		logger.Debug(
 			"function", "smartUnmarshalSingleField",
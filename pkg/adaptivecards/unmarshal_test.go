@@ -17,6 +17,7 @@ package adaptivecards
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -319,6 +320,57 @@ func TestSmartUnmarshalJSON_FallbackField(t *testing.T) {
 		require.True(t, ok, "card fallback should be FallbackOption")
 		assert.Equal(t, FallbackOptionDrop, fallbackOption)
 	})
+
+	t.Run("Fallback drop round trip", func(t *testing.T) {
+		cardJSON := `{"type":"AdaptiveCard","version":"1.2","body":[{"type":"TextBlock","text":"x","fallback":"drop"}]}`
+
+		var card AdaptiveCard
+		require.NoError(t, json.Unmarshal([]byte(cardJSON), &card))
+
+		out, err := json.Marshal(card)
+		require.NoError(t, err)
+		assert.JSONEq(t, cardJSON, string(out))
+	})
+
+	t.Run("Unsupported fallback string", func(t *testing.T) {
+		cardJSON := `{"type":"AdaptiveCard","body":[{"type":"TextBlock","text":"x","fallback":"hide"}]}`
+
+		var card AdaptiveCard
+		err := json.Unmarshal([]byte(cardJSON), &card)
+		assert.ErrorContains(t, err, "unsupported fallback option")
+	})
+
+	t.Run("Null fallback is ignored", func(t *testing.T) {
+		cardJSON := `{"type": "AdaptiveCard", "body": [{"type": "TextBlock", "text": "a", "fallback": null}]}`
+
+		var card AdaptiveCard
+		require.NoError(t, json.Unmarshal([]byte(cardJSON), &card))
+		require.Len(t, card.Body, 1)
+		textBlock, ok := card.Body[0].(*TextBlock)
+		require.True(t, ok)
+		assert.Nil(t, textBlock.Fallback)
+	})
+
+	t.Run("Unknown fallback is preserved", func(t *testing.T) {
+		cardJSON := `{
+			"type": "AdaptiveCard",
+			"version": "1.2",
+			"body": [
+				{"type": "TextBlock", "text": "b", "fallback": {"type": "FutureElement", "mode": "x", "items": [{"type": "TextBlock", "text": "c"}]}}
+			]
+		}`
+
+		var card AdaptiveCard
+		require.NoError(t, json.Unmarshal([]byte(cardJSON), &card))
+		require.Len(t, card.Body, 1)
+		textBlock, ok := card.Body[0].(*TextBlock)
+		require.True(t, ok)
+		assert.IsType(t, json.RawMessage{}, textBlock.Fallback)
+
+		out, err := json.Marshal(card)
+		require.NoError(t, err)
+		assert.JSONEq(t, cardJSON, string(out))
+	})
 }
 
 // TestSmartUnmarshalJSON_InputElements tests all input types
@@ -439,6 +491,110 @@ func TestSmartUnmarshalJSON_CustomStruct(t *testing.T) {
 	assert.Equal(t, "Custom item", *textBlock.Text)
 }
 
+func TestSmartMarshalFromJSON_StableOrder(t *testing.T) {
+	block := TextBlock{
+		Common: &Common{ID: "a", Separator: true},
+		Text:   AsPtr("<b>bold</b>"),
+		Wrap:   true,
+	}
+
+	data, err := json.Marshal(block)
+	require.NoError(t, err)
+	// "type" first, then the embedded Common fields and the TextBlock
+	// fields in declaration order, escaped like encoding/json.
+	assert.Equal(t, `{"type":"TextBlock","id":"a","separator":true,"wrap":true,"text":"\u003cb\u003ebold\u003c/b\u003e"}`, string(data))
+
+	for i := 0; i < 10; i++ {
+		again, err := json.Marshal(block)
+		require.NoError(t, err)
+		assert.Equal(t, data, again)
+	}
+}
+
+func TestSmartMarshalFromJSON_NilEmbedded(t *testing.T) {
+	data, err := json.Marshal(TextBlock{Text: AsPtr("x")})
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"TextBlock","text":"x"}`, string(data))
+
+	data, err = json.Marshal(ActionSubmit{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"Action.Submit"}`, string(data))
+}
+
+func TestSmartMarshalFromJSON_DeclaredTypeField(t *testing.T) {
+	data, err := json.Marshal(WorkflowConnectorCard{Attachments: []AdaptiveCardItem{}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"message","attachments":[]}`, string(data))
+
+	data, err = json.Marshal(WorkflowConnectorCard{Type: "custom", Attachments: []AdaptiveCardItem{}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"custom","attachments":[]}`, string(data))
+
+	data, err = json.Marshal(WorkflowConnectorCard{Attachments: []AdaptiveCardItem{}, AttachmentLayout: AttachmentLayoutCarousel})
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"message","attachments":[],"attachmentLayout":"carousel"}`, string(data))
+
+	var c WorkflowConnectorCard
+	require.NoError(t, json.Unmarshal(data, &c))
+	assert.Equal(t, AttachmentLayoutCarousel, c.AttachmentLayout)
+}
+
+func TestSmartMarshalFromJSON_Unregistered(t *testing.T) {
+	type unregistered struct {
+		Name string `json:"name"`
+	}
+	_, err := SmartMarshalFromJSON(unregistered{Name: "x"})
+	assert.ErrorContains(t, err, "unable to find type for adaptivecards.unregistered in registry")
+}
+
+func TestRegisterTypeReverseIndex(t *testing.T) {
+	type first struct{}
+	type second struct{}
+	t.Cleanup(func() {
+		delete(typeRegistry, "ReverseIndexTest")
+		delete(typeNames, reflect.TypeFor[first]())
+		delete(typeNames, reflect.TypeFor[second]())
+	})
+
+	RegisterType("ReverseIndexTest", first{})
+	name, ok := registeredTypeName(reflect.TypeFor[first]())
+	require.True(t, ok)
+	assert.Equal(t, "ReverseIndexTest", name)
+
+	// Registering another type under the same name drops the old entry
+	RegisterType("ReverseIndexTest", second{})
+	_, ok = registeredTypeName(reflect.TypeFor[first]())
+	assert.False(t, ok)
+	name, ok = registeredTypeName(reflect.TypeFor[second]())
+	require.True(t, ok)
+	assert.Equal(t, "ReverseIndexTest", name)
+}
+
+func TestBuildStructPlan(t *testing.T) {
+	type Inner struct {
+		Key   string `json:"key,omitempty"`
+		Other int    `json:"other"`
+	}
+	type outer struct {
+		*Inner
+		Type    string `json:"type"`
+		Key     string `json:"key"`
+		Ignored string `json:"-"`
+		hidden  string //nolint:unused
+	}
+
+	plan := buildStructPlan(reflect.TypeFor[outer]())
+	var names []string
+	for _, f := range plan.fields {
+		names = append(names, f.name)
+	}
+	// The outer "key" shadows the embedded one, "type" is handled apart.
+	assert.Equal(t, []string{"other", "key"}, names)
+	assert.Equal(t, []int{1}, plan.typeField)
+	assert.False(t, plan.fields[1].omitEmpty)
+	assert.Same(t, planFor(reflect.TypeFor[outer]()), planFor(reflect.TypeFor[outer]()))
+}
+
 // Benchmark comparing SmartUnmarshalJSON vs manual UnmarshalJSON
 func BenchmarkSmartUnmarshalJSON(b *testing.B) {
 	cardJSON := []byte(`{
@@ -54,6 +54,9 @@ func (a *AdaptiveCardItem) UnmarshalJSON(data []byte) error {
 type WorkflowConnectorCard struct {
 	Type        string             `json:"type"`
 	Attachments []AdaptiveCardItem `json:"attachments"`
+	// AttachmentLayout is how the hosts supporting it show the attachments,
+	// a list by default.
+	AttachmentLayout AttachmentLayout `json:"attachmentLayout,omitempty"`
 }
 
 // MarshalJSON ensures that the "type" field is included when marshaling a
@@ -0,0 +1,318 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package audit keeps a bounded history of the deliveries to Teams.
+//
+// Entries identify the route by its request path pattern and never hold the
+// webhook URL, which carries the secret of the Teams connector.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// MaxResponseBody is the number of bytes of the Teams response body kept in
+// an entry.
+const MaxResponseBody = 1024
+
+// Alert identifies an alert of a delivered notification.
+type Alert struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// Entry is the record of a delivery.
+type Entry struct {
+	// Time is when the delivery ended.
+	Time     time.Time `json:"time"`
+	Route    string    `json:"route"`
+	Receiver string    `json:"receiver"`
+	GroupKey string    `json:"groupKey"`
+	// Status is the status of the notification, firing or resolved.
+	Status string  `json:"status"`
+	Alerts []Alert `json:"alerts"`
+	// CardHash is the SHA-256 of the card payload, empty if the card failed
+	// to render.
+	CardHash     string        `json:"cardHash,omitempty"`
+	ResponseCode int           `json:"responseCode"`
+	ResponseBody string        `json:"responseBody,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	Duration     time.Duration `json:"durationNs"`
+}
+
+// Delivered reports whether Teams accepted the card.
+func (e Entry) Delivered() bool {
+	return e.Error == "" && e.ResponseCode >= 200 && e.ResponseCode < 300
+}
+
+// AlertsOf returns the audit records of alerts.
+func AlertsOf(alerts template.Alerts) []Alert {
+	out := make([]Alert, 0, len(alerts))
+	for _, a := range alerts {
+		out = append(out, Alert{Fingerprint: a.Fingerprint, Status: a.Status, Labels: a.Labels})
+	}
+	return out
+}
+
+// TruncateBody returns body cut to MaxResponseBody bytes.
+func TruncateBody(body string) string {
+	if len(body) <= MaxResponseBody {
+		return body
+	}
+	return body[:MaxResponseBody] + "..."
+}
+
+// Log is a ring buffer of the latest entries, optionally persisted to a file
+// holding one JSON entry per line. A nil *Log records nothing.
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+
+	file   *os.File
+	path   string
+	lines  int
+	logger *utility.Logger
+}
+
+// New creates a Log keeping the latest size entries. If path is not empty,
+// the entries found in the file are loaded and new entries appended to it;
+// the file is compacted when it holds twice as many entries as the Log.
+// A last line which is not a valid entry, such as one cut by a crash, is
+// dropped with a warning logged with logger, which may be nil.
+func New(size int, path string, logger *utility.Logger) (*Log, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("audit log size must be positive, got %d", size)
+	}
+	l := &Log{entries: make([]Entry, size), path: path, logger: logger}
+	if path == "" {
+		return l, nil
+	}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+	if err := l.compact(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *Log) load() error {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// An invalid line fails the load unless it is the last one, which
+	// may have been cut by a crash while it was written.
+	var invalid error
+	s := bufio.NewScanner(f)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; s.Scan(); n++ {
+		if invalid != nil {
+			return invalid
+		}
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			invalid = fmt.Errorf("%s:%d: %w", l.path, n, err)
+			continue
+		}
+		l.add(e)
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if invalid != nil && l.logger != nil {
+		l.logger.Warn("message", "dropped the invalid last line of the audit file", "err", invalid)
+	}
+	return nil
+}
+
+// compact rewrites the file with the entries of the buffer and reopens it
+// for appending.
+func (l *Log) compact() error {
+	if l.file != nil {
+		if err := l.file.Close(); err != nil {
+			return err
+		}
+		l.file = nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	entries := l.ordered()
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), l.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	l.file, err = os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	l.lines = len(entries)
+	return err
+}
+
+func (l *Log) add(e Entry) {
+	l.entries[l.next] = e
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// ordered returns the entries from the oldest to the newest.
+func (l *Log) ordered() []Entry {
+	if !l.full {
+		return append([]Entry(nil), l.entries[:l.next]...)
+	}
+	return append(append([]Entry(nil), l.entries[l.next:]...), l.entries[:l.next]...)
+}
+
+// Record adds an entry, evicting the oldest one if the Log is full.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.add(e)
+	if l.file == nil {
+		return nil
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	l.lines++
+	if l.lines >= 2*len(l.entries) {
+		return l.compact()
+	}
+	return nil
+}
+
+// Close closes the file of the Log.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Filter selects entries. Zero fields match every entry.
+type Filter struct {
+	Route string
+	// Status is the notification status, firing or resolved.
+	Status string
+	// Delivered selects the delivered entries if true, the failed ones if
+	// false.
+	Delivered *bool
+	Since     time.Time
+	Until     time.Time
+	// Matchers select the entries holding an alert whose labels match all of
+	// them.
+	Matchers []*labels.Matcher
+	// Limit is the maximum number of entries returned.
+	Limit int
+}
+
+// Match reports whether e is selected by f.
+func (f Filter) Match(e Entry) bool {
+	switch {
+	case f.Route != "" && e.Route != f.Route,
+		f.Status != "" && e.Status != f.Status,
+		f.Delivered != nil && e.Delivered() != *f.Delivered,
+		!f.Since.IsZero() && e.Time.Before(f.Since),
+		!f.Until.IsZero() && e.Time.After(f.Until):
+		return false
+	}
+	if len(f.Matchers) == 0 {
+		return true
+	}
+	for _, a := range e.Alerts {
+		if matchLabels(f.Matchers, a.Labels) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchLabels(matchers []*labels.Matcher, ls map[string]string) bool {
+	for _, m := range matchers {
+		if !m.Matches(ls[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Query returns the entries selected by f, the newest first.
+func (l *Log) Query(f Filter) []Entry {
+	out := []Entry{}
+	if l == nil {
+		return out
+	}
+	l.mu.Lock()
+	entries := l.ordered()
+	l.mu.Unlock()
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if f.Limit > 0 && len(out) == f.Limit {
+			break
+		}
+		if f.Match(entries[i]) {
+			out = append(out, entries[i])
+		}
+	}
+	return out
+}
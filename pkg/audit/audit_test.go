@@ -0,0 +1,193 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2026, 3, 14, 3, 12, 0, 0, time.UTC)
+
+func entry(i int, route, status string, code int, ls map[string]string) Entry {
+	return Entry{
+		Time:         t0.Add(time.Duration(i) * time.Minute),
+		Route:        route,
+		GroupKey:     "{}:{alertname=\"test\"}",
+		Status:       status,
+		Alerts:       []Alert{{Fingerprint: "f" + string(rune('0'+i)), Status: status, Labels: ls}},
+		ResponseCode: code,
+	}
+}
+
+func timesOf(entries []Entry) []time.Time {
+	out := []time.Time{}
+	for _, e := range entries {
+		out = append(out, e.Time)
+	}
+	return out
+}
+
+func TestNewInvalidSize(t *testing.T) {
+	_, err := New(0, "", nil)
+	assert.Error(t, err)
+}
+
+func TestLogRingBuffer(t *testing.T) {
+	l, err := New(3, "", nil)
+	require.NoError(t, err)
+
+	assert.Empty(t, l.Query(Filter{}))
+	for i := 0; i < 5; i++ {
+		require.NoError(t, l.Record(entry(i, "/a", "firing", 200, nil)))
+	}
+
+	got := l.Query(Filter{})
+	assert.Equal(t, []time.Time{t0.Add(4 * time.Minute), t0.Add(3 * time.Minute), t0.Add(2 * time.Minute)}, timesOf(got))
+	assert.Len(t, l.Query(Filter{Limit: 2}), 2)
+}
+
+func TestFilterMatch(t *testing.T) {
+	critical := map[string]string{"alertname": "HighLoad", "severity": "critical"}
+	warning := map[string]string{"alertname": "HighLoad", "severity": "warning"}
+	delivered, failed := true, false
+	sev, err := labels.ParseMatchers(`{severity=~"crit.*"}`)
+	require.NoError(t, err)
+
+	l, err := New(10, "", nil)
+	require.NoError(t, err)
+	require.NoError(t, l.Record(entry(0, "/a", "firing", 200, critical)))
+	require.NoError(t, l.Record(entry(1, "/a", "resolved", 200, critical)))
+	require.NoError(t, l.Record(entry(2, "/b", "firing", 429, warning)))
+	errored := entry(3, "/b", "firing", 0, warning)
+	errored.Error = "http client failed"
+	require.NoError(t, l.Record(errored))
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int
+	}{
+		{"all", Filter{}, []int{3, 2, 1, 0}},
+		{"route", Filter{Route: "/a"}, []int{1, 0}},
+		{"status", Filter{Status: "firing"}, []int{3, 2, 0}},
+		{"delivered", Filter{Delivered: &delivered}, []int{1, 0}},
+		{"failed", Filter{Delivered: &failed}, []int{3, 2}},
+		{"since", Filter{Since: t0.Add(2 * time.Minute)}, []int{3, 2}},
+		{"until", Filter{Until: t0.Add(time.Minute)}, []int{1, 0}},
+		{"matchers", Filter{Matchers: sev}, []int{1, 0}},
+		{"combined", Filter{Route: "/a", Status: "firing", Matchers: sev}, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []time.Time{}
+			for _, i := range tt.want {
+				want = append(want, t0.Add(time.Duration(i)*time.Minute))
+			}
+			assert.Equal(t, want, timesOf(l.Query(tt.filter)))
+		})
+	}
+}
+
+func countLines(t *testing.T, path string) int {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	n := 0
+	for s := bufio.NewScanner(f); s.Scan(); {
+		n++
+	}
+	return n
+}
+
+func TestLogPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.jsonl")
+
+	l, err := New(3, path, nil)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, l.Record(entry(i, "/a", "firing", 200, map[string]string{"i": string(rune('0' + i))})))
+	}
+	// The file is compacted once it holds twice the size of the log.
+	assert.Equal(t, 5, countLines(t, path))
+	require.NoError(t, l.Record(entry(5, "/a", "firing", 200, nil)))
+	assert.Equal(t, 3, countLines(t, path))
+	require.NoError(t, l.Record(entry(6, "/a", "firing", 200, nil)))
+	require.NoError(t, l.Close())
+
+	reloaded, err := New(3, path, nil)
+	require.NoError(t, err)
+	defer reloaded.Close()
+	assert.Equal(t, l.Query(Filter{}), reloaded.Query(Filter{}))
+	assert.Equal(t, 3, countLines(t, path))
+}
+
+func TestLogPersistenceInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("{}\nnot json\n{}\n"), 0o600))
+
+	_, err := New(3, path, nil)
+	assert.ErrorContains(t, err, "deliveries.jsonl:2")
+}
+
+func TestLogPersistencePartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.jsonl")
+	b, err := json.Marshal(entry(0, "/a", "firing", 200, nil))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, append(append(b, '\n'), b[:len(b)/2]...), 0o600))
+
+	// The last line, cut by a crash, is dropped, and the file compacted.
+	l, err := New(3, path, utility.NewLogger(utility.LogFormatFmt, false))
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Len(t, l.Query(Filter{}), 1)
+	assert.Equal(t, 1, countLines(t, path))
+}
+
+func TestNilLog(t *testing.T) {
+	var l *Log
+	assert.NoError(t, l.Record(Entry{}))
+	assert.Empty(t, l.Query(Filter{}))
+	assert.NoError(t, l.Close())
+}
+
+func TestAlertsOf(t *testing.T) {
+	got := AlertsOf(template.Alerts{{
+		Status:      "firing",
+		Fingerprint: "abc",
+		Labels:      template.KV{"alertname": "HighLoad"},
+		Annotations: template.KV{"summary": "not kept"},
+	}})
+	assert.Equal(t, []Alert{{Fingerprint: "abc", Status: "firing", Labels: map[string]string{"alertname": "HighLoad"}}}, got)
+}
+
+func TestTruncateBody(t *testing.T) {
+	assert.Equal(t, "1", TruncateBody("1"))
+	long := strings.Repeat("x", MaxResponseBody+10)
+	assert.Equal(t, long[:MaxResponseBody]+"...", TruncateBody(long))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"maps"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+)
+
+// Verbs of the Action.Execute buttons of the cards.
+const (
+	VerbAcknowledge = "acknowledge"
+	VerbAssign      = "assign"
+)
+
+// ActionData is the data of the Action.Execute buttons of a card, sent back
+// with the invoke activity when a user presses one.
+type ActionData struct {
+	// Fingerprints are the fingerprints of the alerts of the card.
+	Fingerprints []string `json:"fingerprints"`
+	// Labels are the labels of the alerts by fingerprint, the matchers of
+	// their silences.
+	Labels map[string]map[string]string `json:"labels,omitempty"`
+	// Title names the alerts on the updated card.
+	Title string `json:"title,omitempty"`
+}
+
+// NewActionData returns the ActionData of the alerts of wm, or nil if none
+// of them has a fingerprint.
+func NewActionData(wm webhook.Message) *ActionData {
+	if wm.Data == nil {
+		return nil
+	}
+	var fingerprints []string
+	labels := map[string]map[string]string{}
+	for _, a := range distinctAlerts(wm.Alerts) {
+		if a.Fingerprint != "" {
+			fingerprints = append(fingerprints, a.Fingerprint)
+			labels[a.Fingerprint] = maps.Clone(a.Labels)
+		}
+	}
+	if len(fingerprints) == 0 {
+		return nil
+	}
+	title := wm.CommonAnnotations["summary"]
+	if title == "" {
+		title = wm.CommonLabels["alertname"]
+	}
+	return &ActionData{
+		Fingerprints: fingerprints,
+		Labels:       labels,
+		Title:        title,
+	}
+}
+
+// Actions returns the ActionSet of the Acknowledge and Assign to me buttons
+// of d, titled in the locale of l.
+func Actions(d *ActionData, l *Localizer) *adaptivecards.ActionSet {
+	if l == nil {
+		l = DefaultLocalizer()
+	}
+	return &adaptivecards.ActionSet{Actions: []adaptivecards.Action{
+		&adaptivecards.ActionExecute{
+			CommonActionProperties: &adaptivecards.CommonActionProperties{Title: l.Translate("action.acknowledge")},
+			Verb:                   VerbAcknowledge,
+			Data:                   d,
+		},
+		&adaptivecards.ActionExecute{
+			CommonActionProperties: &adaptivecards.CommonActionProperties{Title: l.Translate("action.assign")},
+			Verb:                   VerbAssign,
+			Data:                   d,
+		},
+	}}
+}
+
+// NewActionsMiddleware creates a Converter ending the cards of n with the
+// Actions of their alerts, which Teams sends to the callback endpoint of the
+// bot of the cards. The cards without alert fingerprints are left as is.
+func NewActionsMiddleware(l *Localizer, n Converter) Converter {
+	return actionsMiddleware{localizer: l, next: n}
+}
+
+type actionsMiddleware struct {
+	localizer *Localizer
+	next      Converter
+}
+
+func (m actionsMiddleware) Convert(ctx context.Context, a webhook.Message) (adaptivecards.WorkflowConnectorCard, error) {
+	c, err := m.next.Convert(ctx, a)
+	if err != nil || len(c.Attachments) == 0 {
+		return c, err
+	}
+	d := NewActionData(a)
+	if d == nil {
+		return c, nil
+	}
+	content := &c.Attachments[0].Content
+	content.Body = append(content.Body, Actions(d, m.localizer))
+	return c, nil
+}
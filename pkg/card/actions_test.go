@@ -0,0 +1,85 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionsMiddleware(t *testing.T) {
+	tmpl, err := ParseTemplateFile(testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"))
+	require.NoError(t, err)
+	l, err := NewLocalizer("de", "UTC")
+	require.NoError(t, err)
+	converter := NewActionsMiddleware(l, NewTemplatedCardCreator(tmpl, false, &utility.Logger{}))
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	wm := webhook.Message{Data: &template.Data{
+		Status: "firing",
+		Alerts: template.Alerts{
+			digestAlert("1", "firing", "HighLoad", "warning", start),
+			digestAlert("2", "firing", "HighLoad", "warning", start),
+		},
+		CommonLabels: template.KV{"alertname": "HighLoad", "severity": "warning"},
+	}}
+	wm.Alerts[1].Labels["instance"] = "b"
+	c, err := converter.Convert(context.Background(), wm)
+	require.NoError(t, err)
+
+	body := c.Attachments[0].Content.Body
+	set, ok := body[len(body)-1].(*adaptivecards.ActionSet)
+	require.True(t, ok)
+	require.Len(t, set.Actions, 2)
+	ack := set.Actions[0].(*adaptivecards.ActionExecute)
+	assert.Equal(t, "Bestätigen", ack.Title)
+	assert.Equal(t, VerbAcknowledge, ack.Verb)
+	assert.Equal(t, VerbAssign, set.Actions[1].(*adaptivecards.ActionExecute).Verb)
+
+	// The data goes through the JSON of the card.
+	b, err := json.Marshal(ack)
+	require.NoError(t, err)
+	var got struct {
+		Data ActionData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(b, &got))
+	assert.Equal(t, ActionData{
+		Fingerprints: []string{"1", "2"},
+		Labels: map[string]map[string]string{
+			"1": {"alertname": "HighLoad", "severity": "warning"},
+			"2": {"alertname": "HighLoad", "severity": "warning", "instance": "b"},
+		},
+		Title: "HighLoad",
+	}, got.Data)
+
+	// Without fingerprints, there is nothing to acknowledge.
+	wm.Alerts[0].Fingerprint, wm.Alerts[1].Fingerprint = "", ""
+	c, err = converter.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	body = c.Attachments[0].Content.Body
+	assert.IsType(t, &adaptivecards.Container{}, body[len(body)-1])
+}
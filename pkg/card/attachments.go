@@ -0,0 +1,134 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// DefaultMaxPayload is the maximum size in bytes of the cards with an
+// attachment per alert unless another one is given, the 28 KB limit of Teams
+// on the payloads of the webhooks.
+const DefaultMaxPayload = 28 << 10
+
+// ErrPayloadTooLarge is returned when the summary card alone exceeds the
+// maximum size of the payloads.
+var ErrPayloadTooLarge = errors.New("card payload too large")
+
+// NewAttachmentsMiddleware creates a Converter rendering with n a summary
+// card of the notification, followed by a card per alert when it has
+// several distinct alerts, as the attachments of one card shown with layout.
+// The cards of the alerts are added in order while the card fits in
+// maxPayload bytes, DefaultMaxPayload if not positive: the others are left
+// out and logged, as the summary covers all the alerts. It returns
+// ErrPayloadTooLarge if the summary card alone does not fit.
+func NewAttachmentsMiddleware(layout adaptivecards.AttachmentLayout, maxPayload int, logger *utility.Logger, n Converter) Converter {
+	if maxPayload <= 0 {
+		maxPayload = DefaultMaxPayload
+	}
+	return attachmentsMiddleware{
+		layout:     layout,
+		maxPayload: maxPayload,
+		logger:     logger.WithPrefix("package", "card", "component", "attachments"),
+		next:       n,
+	}
+}
+
+type attachmentsMiddleware struct {
+	layout     adaptivecards.AttachmentLayout
+	maxPayload int
+	logger     *utility.Logger
+	next       Converter
+}
+
+func (m attachmentsMiddleware) Convert(ctx context.Context, a webhook.Message) (adaptivecards.WorkflowConnectorCard, error) {
+	c, err := m.next.Convert(ctx, a)
+	if err != nil || a.Data == nil {
+		return c, err
+	}
+	alerts := distinctAlerts(a.Alerts)
+	if len(alerts) <= 1 {
+		return c, nil
+	}
+
+	c.AttachmentLayout = m.layout
+	b, err := json.Marshal(c)
+	if err != nil {
+		return c, err
+	}
+	if len(b) > m.maxPayload {
+		return c, fmt.Errorf("%w: the summary card has %d bytes, more than %d", ErrPayloadTooLarge, len(b), m.maxPayload)
+	}
+	for i, alert := range alerts {
+		ac, err := m.next.Convert(ctx, alertMessage(a, alert))
+		if err != nil {
+			return c, err
+		}
+		next := c
+		next.Attachments = append(slices.Clip(c.Attachments), ac.Attachments...)
+		b, err := json.Marshal(next)
+		if err != nil {
+			return c, err
+		}
+		if len(b) > m.maxPayload {
+			m.logger.Warn(
+				"message", "alert cards dropped, the card is over its payload limit",
+				"dropped", len(alerts)-i,
+				"alerts", len(alerts),
+				"max_bytes", m.maxPayload,
+			)
+			break
+		}
+		c = next
+	}
+	return c, nil
+}
+
+// distinctAlerts returns alerts without the repeated fingerprints.
+func distinctAlerts(alerts template.Alerts) template.Alerts {
+	seen := make(map[string]bool, len(alerts))
+	res := make(template.Alerts, 0, len(alerts))
+	for _, a := range alerts {
+		if a.Fingerprint != "" && seen[a.Fingerprint] {
+			continue
+		}
+		seen[a.Fingerprint] = true
+		res = append(res, a)
+	}
+	return res
+}
+
+// alertMessage returns the notification wm of the single alert a.
+func alertMessage(wm webhook.Message, a template.Alert) webhook.Message {
+	data := *wm.Data
+	data.Alerts = template.Alerts{a}
+	data.Status = a.Status
+	data.CommonLabels = maps.Clone(a.Labels)
+	data.CommonAnnotations = maps.Clone(a.Annotations)
+	wm.Data = &data
+	return wm
+}
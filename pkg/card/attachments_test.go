@@ -0,0 +1,85 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttachmentsMiddleware(t *testing.T) {
+	tmpl, err := ParseTemplateFile(testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"))
+	require.NoError(t, err)
+	converter := NewTemplatedCardCreator(tmpl, false, &utility.Logger{})
+	logger := utility.NewLogger(utility.LogFormatFmt, false)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	wm := webhook.Message{Data: &template.Data{
+		Status: "firing",
+		Alerts: template.Alerts{
+			digestAlert("1", "firing", "HighLoad", "warning", start),
+			digestAlert("2", "resolved", "DiskFull", "critical", start),
+			digestAlert("1", "firing", "HighLoad", "warning", start),
+		},
+		CommonLabels: template.KV{},
+	}}
+
+	c, err := NewAttachmentsMiddleware(adaptivecards.AttachmentLayoutCarousel, 0, logger, converter).Convert(context.Background(), wm)
+	require.NoError(t, err)
+	assert.Equal(t, adaptivecards.AttachmentLayoutCarousel, c.AttachmentLayout)
+	require.Len(t, c.Attachments, 3, "the summary and a card per distinct alert")
+	summary, err := converter.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	assert.Equal(t, summary.Attachments[0], c.Attachments[0])
+	for i, want := range []string{"HighLoad", "DiskFull"} {
+		body := c.Attachments[i+1].Content.Body
+		container := body[len(body)-1].(*adaptivecards.Container)
+		facts := container.Items[len(container.Items)-1].(*adaptivecards.FactSet).Facts
+		assert.Contains(t, facts, adaptivecards.Fact{Title: "alertname", Value: want})
+	}
+	heading := *c.Attachments[2].Content.Body[0].(*adaptivecards.TextBlock).Text
+	assert.Contains(t, heading, "Resolved", "the card of an alert has its status")
+
+	// The cards of the alerts not fitting in the payload are left out.
+	b, err := json.Marshal(summary)
+	require.NoError(t, err)
+	c, err = NewAttachmentsMiddleware(adaptivecards.AttachmentLayoutList, len(b)+200, logger, converter).Convert(context.Background(), wm)
+	require.NoError(t, err)
+	assert.Len(t, c.Attachments, 1)
+	assert.Equal(t, adaptivecards.AttachmentLayoutList, c.AttachmentLayout)
+
+	// The summary card must fit on its own.
+	_, err = NewAttachmentsMiddleware(adaptivecards.AttachmentLayoutList, len(b)-1, logger, converter).Convert(context.Background(), wm)
+	assert.ErrorIs(t, err, ErrPayloadTooLarge)
+
+	// A single alert gets the summary only.
+	wm.Alerts = wm.Alerts[:1]
+	c, err = NewAttachmentsMiddleware(adaptivecards.AttachmentLayoutCarousel, 0, logger, converter).Convert(context.Background(), wm)
+	require.NoError(t, err)
+	assert.Len(t, c.Attachments, 1)
+	assert.Empty(t, c.AttachmentLayout)
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/prometheus/alertmanager/notify/webhook"
 	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
 	"github.com/stakater/prometheus-msteams/pkg/utility"
 )
 
@@ -52,3 +53,22 @@ func (l loggingMiddleware) Convert(ctx context.Context, a webhook.Message) (c ad
 	}(time.Now())
 	return l.next.Convert(ctx, a)
 }
+
+type metricsMiddleware struct {
+	metrics  *metrics.Recorder
+	template string
+	next     Converter
+}
+
+// NewCreatorMetricsMiddleware creates a metricsMiddleware recording the cards
+// rendered by n with the given template name.
+func NewCreatorMetricsMiddleware(m *metrics.Recorder, template string, n Converter) Converter {
+	return metricsMiddleware{m, template, n}
+}
+
+func (m metricsMiddleware) Convert(ctx context.Context, a webhook.Message) (c adaptivecards.WorkflowConnectorCard, err error) {
+	defer func(begin time.Time) {
+		m.metrics.CardRendered(ctx, m.template, time.Since(begin), err)
+	}(time.Now())
+	return m.next.Convert(ctx, a)
+}
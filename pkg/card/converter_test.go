@@ -23,8 +23,12 @@ import (
 	"github.com/prometheus/alertmanager/notify/webhook"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
 	"github.com/stakater/prometheus-msteams/pkg/utility"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 )
 
 // mockConverter is a mock implementation of Converter
@@ -194,3 +198,21 @@ func TestLoggingMiddleware_Convert_MultipleAttachments(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 2, len(card.Attachments))
 }
+
+func TestMetricsMiddleware_Convert(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m, err := metrics.NewRecorder(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	ok := NewCreatorMetricsMiddleware(m, "card.tmpl", mockConverter{})
+	failing := NewCreatorMetricsMiddleware(m, "card.tmpl", mockConverter{convertErr: errors.New("bad template")})
+
+	_, err = ok.Convert(context.Background(), webhook.Message{})
+	require.NoError(t, err)
+	_, err = failing.Convert(context.Background(), webhook.Message{})
+	require.Error(t, err)
+
+	got := testutils.CollectMetrics(t, reader)
+	assert.Equal(t, map[string]float64{"template=card.tmpl": 1}, got["prometheus_msteams.cards.rendered"])
+	assert.Equal(t, map[string]float64{"template=card.tmpl": 1}, got["prometheus_msteams.card.render.failures"])
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+// Digest summarizes the notifications of a route buffered over a window. It
+// is the .Digest of the templates rendering a digest, and nil otherwise.
+type Digest struct {
+	// Start and End are the times of the first notification and of the
+	// flush of the digest.
+	Start time.Time
+	End   time.Time
+	// Notifications is the number of notifications summarized.
+	Notifications int
+	// Firing is the number of alerts firing at the end of the window.
+	Firing int
+	// Counts holds the number of firing alerts by alertname and severity,
+	// the largest first.
+	Counts []DigestCount
+	// Top holds the first firing alerts, the most severe and the most
+	// recent first.
+	Top template.Alerts
+	// Resolved holds the alerts resolved during the window.
+	Resolved template.Alerts
+}
+
+// DigestCount is the number of firing alerts with an alertname and severity.
+type DigestCount struct {
+	Alertname string
+	Severity  string
+	Count     int
+}
+
+// severityRanks orders the common severities, the most severe first. The
+// other severities rank after them.
+var severityRanks = map[string]int{
+	"critical": 0,
+	"error":    1,
+	"warning":  2,
+	"info":     3,
+}
+
+func severityRank(severity string) int {
+	if r, ok := severityRanks[severity]; ok {
+		return r
+	}
+	return len(severityRanks)
+}
+
+// NewDigest summarizes alerts, the last state of the alerts of notifications
+// received from start to end, keeping up to top firing alerts in Top.
+func NewDigest(start, end time.Time, notifications int, alerts template.Alerts, top int) *Digest {
+	d := &Digest{Start: start, End: end, Notifications: notifications}
+
+	counts := map[DigestCount]int{}
+	var firing template.Alerts
+	for _, a := range alerts {
+		if a.Status == "resolved" {
+			d.Resolved = append(d.Resolved, a)
+			continue
+		}
+		firing = append(firing, a)
+		counts[DigestCount{Alertname: a.Labels["alertname"], Severity: a.Labels["severity"]}]++
+	}
+	d.Firing = len(firing)
+
+	for c, n := range counts {
+		c.Count = n
+		d.Counts = append(d.Counts, c)
+	}
+	sort.Slice(d.Counts, func(i, j int) bool {
+		a, b := d.Counts[i], d.Counts[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Alertname != b.Alertname {
+			return a.Alertname < b.Alertname
+		}
+		return a.Severity < b.Severity
+	})
+
+	sort.SliceStable(firing, func(i, j int) bool {
+		a, b := severityRank(firing[i].Labels["severity"]), severityRank(firing[j].Labels["severity"])
+		if a != b {
+			return a < b
+		}
+		return firing[i].StartsAt.After(firing[j].StartsAt)
+	})
+	if len(firing) > top {
+		firing = firing[:top]
+	}
+	d.Top = firing
+	return d
+}
+
+type digestKey struct{}
+
+// ContextWithDigest returns a copy of ctx carrying d, rendered as .Digest by
+// the templated converters.
+func ContextWithDigest(ctx context.Context, d *Digest) context.Context {
+	return context.WithValue(ctx, digestKey{}, d)
+}
+
+// DigestFromContext returns the Digest set by ContextWithDigest, or nil.
+func DigestFromContext(ctx context.Context) *Digest {
+	d, _ := ctx.Value(digestKey{}).(*Digest)
+	return d
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func digestAlert(fingerprint, status, alertname, severity string, startsAt time.Time) template.Alert {
+	return template.Alert{
+		Status:      status,
+		Labels:      template.KV{"alertname": alertname, "severity": severity},
+		Annotations: template.KV{"description": alertname + " " + fingerprint},
+		StartsAt:    startsAt,
+		Fingerprint: fingerprint,
+	}
+}
+
+func TestNewDigest(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := template.Alerts{
+		digestAlert("1", "firing", "HighLoad", "warning", start),
+		digestAlert("2", "firing", "HighLoad", "warning", start.Add(time.Minute)),
+		digestAlert("3", "firing", "DiskFull", "critical", start),
+		digestAlert("4", "resolved", "HighLoad", "warning", start),
+		digestAlert("5", "firing", "Watchdog", "none", start.Add(2*time.Minute)),
+	}
+
+	d := NewDigest(start, start.Add(time.Hour), 7, alerts, 3)
+	assert.Equal(t, start, d.Start)
+	assert.Equal(t, start.Add(time.Hour), d.End)
+	assert.Equal(t, 7, d.Notifications)
+	assert.Equal(t, 4, d.Firing)
+	assert.Equal(t, []DigestCount{
+		{Alertname: "HighLoad", Severity: "warning", Count: 2},
+		{Alertname: "DiskFull", Severity: "critical", Count: 1},
+		{Alertname: "Watchdog", Severity: "none", Count: 1},
+	}, d.Counts)
+	assert.Equal(t, []string{"3", "2", "1"}, fingerprints(d.Top))
+	assert.Equal(t, []string{"4"}, fingerprints(d.Resolved))
+}
+
+func fingerprints(alerts template.Alerts) []string {
+	out := []string{}
+	for _, a := range alerts {
+		out = append(out, a.Fingerprint)
+	}
+	return out
+}
+
+func TestDigestContext(t *testing.T) {
+	assert.Nil(t, DigestFromContext(context.Background()))
+	d := &Digest{Notifications: 1}
+	assert.Same(t, d, DigestFromContext(ContextWithDigest(context.Background(), d)))
+}
+
+func Test_templatedCard_ConvertDigest(t *testing.T) {
+	tmpl, err := ParseTemplateFile(testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"))
+	require.NoError(t, err)
+	c := NewTemplatedCardCreator(tmpl, false, utility.NewLogger(utility.LogFormatFmt, false))
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := template.Alerts{
+		digestAlert("1", "firing", "HighLoad", "warning", start),
+		digestAlert("2", "resolved", "DiskFull", "critical", start),
+	}
+	wm := webhook.Message{Data: &template.Data{Status: "firing", Alerts: alerts, ExternalURL: "http://alertmanager"}}
+	ctx := ContextWithDigest(context.Background(), NewDigest(start, start.Add(time.Hour), 3, alerts, 10))
+
+	got, err := c.Convert(ctx, wm)
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	require.Len(t, body, 7)
+	assert.Equal(t, "Prometheus Alert - Digest (Firing)", *body[0].(*adaptivecards.TextBlock).Text)
+	assert.IsType(t, &adaptivecards.Badge{}, body[1])
+	assert.Equal(t, []adaptivecards.Fact{
+		{Title: "Notifications", Value: "3"},
+		{Title: "Firing", Value: "1"},
+		{Title: "Resolved", Value: "1"},
+		{Title: "HighLoad (warning)", Value: "1"},
+	}, body[3].(*adaptivecards.FactSet).Facts)
+	assert.Equal(t, "[HighLoad](http://alertmanager): HighLoad 1", *body[4].(*adaptivecards.TextBlock).Text)
+	assert.Equal(t, "Resolved", *body[5].(*adaptivecards.TextBlock).Text)
+	assert.Equal(t, "DiskFull: DiskFull 2", *body[6].(*adaptivecards.TextBlock).Text)
+
+	// Without a digest the alerts are rendered one by one.
+	got, err = c.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	assert.Equal(t, "Prometheus Alert (Firing)", *got.Attachments[0].Content.Body[0].(*adaptivecards.TextBlock).Text)
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+)
+
+// DefaultMarkdownChars are the markdown characters escaped by the templated
+// cards created with escapeUnderscores.
+const DefaultMarkdownChars = "_"
+
+// markdownURL matches the parts of a text which are never escaped: the
+// targets of the markdown links and the bare URLs, with the parentheses and
+// brackets they hold in pairs, as in a PromQL expression.
+var markdownURL = regexp.MustCompile(`\]\((?:[^()]|\([^()]*\))*\)|(?:https?|mailto):(?:[^\s()\[\]]|\([^\s()]*\)|\[[^\s\[\]]*\])+`)
+
+// EscapeMarkdown escapes with a backslash the characters of chars in the
+// texts of c which are rendered as markdown: the Text of the TextBlocks and
+// TextRuns and the Value of the Facts, in the body and the ShowCard actions
+// of all the cards. URLs, IDs and the characters already escaped are left
+// alone. c is modified in place.
+func EscapeMarkdown(c *adaptivecards.WorkflowConnectorCard, chars string) {
+	if chars == "" {
+		return
+	}
+	for i := range c.Attachments {
+		escapeCard(&c.Attachments[i].Content, chars)
+	}
+}
+
+func escapeCard(c *adaptivecards.AdaptiveCard, chars string) {
+	escapeElements(c.Body, chars)
+	escapeActions(c.Actions, chars)
+}
+
+func escapeElements(elements []adaptivecards.Element, chars string) {
+	for _, e := range elements {
+		switch e := e.(type) {
+		case *adaptivecards.TextBlock:
+			if e.Text != nil {
+				e.Text = adaptivecards.AsPtr(escapeMarkdownText(*e.Text, chars))
+			}
+		case *adaptivecards.FactSet:
+			for i := range e.Facts {
+				e.Facts[i].Value = escapeMarkdownText(e.Facts[i].Value, chars)
+			}
+		case *adaptivecards.RichTextBlock:
+			for _, in := range e.Inlines {
+				if r, ok := in.(*adaptivecards.TextRun); ok {
+					r.Text = escapeMarkdownText(r.Text, chars)
+				}
+			}
+		case *adaptivecards.Container:
+			escapeElements(e.Items, chars)
+		case *adaptivecards.ColumnSet:
+			for i := range e.Columns {
+				escapeElements(e.Columns[i].Items, chars)
+			}
+		case *adaptivecards.Column:
+			escapeElements(e.Items, chars)
+		case *adaptivecards.Table:
+			for _, row := range e.Rows {
+				for _, cell := range row.Cells {
+					escapeElements(cell.Items, chars)
+				}
+			}
+		case *adaptivecards.ActionSet:
+			escapeActions(e.Actions, chars)
+		}
+	}
+}
+
+func escapeActions(actions []adaptivecards.Action, chars string) {
+	for _, a := range actions {
+		if a, ok := a.(*adaptivecards.ActionShowCard); ok && a.Card != nil {
+			escapeCard(a.Card, chars)
+		}
+	}
+}
+
+// escapeMarkdownText escapes the characters of chars in s, outside of the
+// URLs and unless they are already escaped.
+func escapeMarkdownText(s, chars string) string {
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range markdownURL.FindAllStringIndex(s, -1) {
+		writeEscaped(&b, s[last:loc[0]], chars)
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	writeEscaped(&b, s[last:], chars)
+	return b.String()
+}
+
+func writeEscaped(b *strings.Builder, s, chars string) {
+	escaped := false
+	for _, r := range s {
+		if !escaped && strings.ContainsRune(chars, r) {
+			b.WriteByte('\\')
+		}
+		escaped = !escaped && r == '\\'
+		b.WriteRune(r)
+	}
+}
+
+// NewMarkdownEscapeMiddleware creates a Converter escaping the characters
+// of chars in the cards of n, see EscapeMarkdown.
+func NewMarkdownEscapeMiddleware(chars string, n Converter) Converter {
+	return markdownEscapeMiddleware{chars: chars, next: n}
+}
+
+type markdownEscapeMiddleware struct {
+	chars string
+	next  Converter
+}
+
+func (m markdownEscapeMiddleware) Convert(ctx context.Context, a webhook.Message) (adaptivecards.WorkflowConnectorCard, error) {
+	c, err := m.next.Convert(ctx, a)
+	if err != nil {
+		return c, err
+	}
+	EscapeMarkdown(&c, m.chars)
+	return c, nil
+}
+
+// jsonEscape returns s escaped to be written in a JSON string, the HTML
+// characters left as is.
+func jsonEscape(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return ""
+	}
+	// Trim the quotes and the newline of Encode.
+	return string(buf.Bytes()[1 : buf.Len()-2])
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEscapeMarkdownText(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		chars string
+		want  string
+	}{
+		{"plain", "high_memory_load", "_", `high\_memory\_load`},
+		{"nothing to escape", "high memory", "_", "high memory"},
+		{"already escaped", `high\_memory_load`, "_", `high\_memory\_load`},
+		{"escaped backslash", `a\\_b`, "_", `a\\\_b`},
+		{"link target", "[job_a](http://host/job_a?x=a_b)", "_", `[job\_a](http://host/job_a?x=a_b)`},
+		{"link target with parentheses", "[rate_5m](http://host/graph?g0.expr=rate(x_y[5m]))_z", "_", `[rate\_5m](http://host/graph?g0.expr=rate(x_y[5m]))\_z`},
+		{"bare url with parentheses", "see http://host/graph?g0.expr=rate(x_y[5m]) for x_y", "_", `see http://host/graph?g0.expr=rate(x_y[5m]) for x\_y`},
+		{"bare url", "see https://host/a_b for disk_full", "_", `see https://host/a_b for disk\_full`},
+		{"several chars", "*a*_b_~c~", "*_~", `\*a\*\_b\_\~c\~`},
+		{"no chars", "a_b", "", "a_b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, escapeMarkdownText(tt.in, tt.chars))
+		})
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	var c adaptivecards.WorkflowConnectorCard
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "type": "message",
+  "attachments": [{
+    "contentType": "application/vnd.microsoft.card.adaptive",
+    "content": {
+      "type": "AdaptiveCard",
+      "version": "1.5",
+      "body": [
+        {"type": "TextBlock", "id": "block_1", "text": "disk_full"},
+        {"type": "Container", "items": [
+          {"type": "FactSet", "facts": [{"title": "job_name", "value": "node_exporter"}]}
+        ]},
+        {"type": "ColumnSet", "columns": [{"type": "Column", "items": [
+          {"type": "RichTextBlock", "inlines": [{"type": "TextRun", "text": "in_column"}]}
+        ]}]},
+        {"type": "Image", "url": "http://host/a_b.png"}
+      ],
+      "actions": [
+        {"type": "Action.ShowCard", "title": "more_details", "card": {
+          "type": "AdaptiveCard",
+          "body": [{"type": "TextBlock", "text": "shown_card"}]
+        }},
+        {"type": "Action.OpenUrl", "title": "open_url", "url": "http://host/a_b"}
+      ]
+    }
+  }]
+}`), &c))
+
+	EscapeMarkdown(&c, "_")
+
+	content := c.Attachments[0].Content
+	text := content.Body[0].(*adaptivecards.TextBlock)
+	assert.Equal(t, `disk\_full`, *text.Text)
+	assert.Equal(t, "block_1", text.ID)
+	fact := content.Body[1].(*adaptivecards.Container).Items[0].(*adaptivecards.FactSet).Facts[0]
+	assert.Equal(t, adaptivecards.Fact{Title: "job_name", Value: `node\_exporter`}, fact)
+	rich := content.Body[2].(*adaptivecards.ColumnSet).Columns[0].Items[0].(*adaptivecards.RichTextBlock)
+	assert.Equal(t, `in\_column`, rich.Inlines[0].(*adaptivecards.TextRun).Text)
+	assert.Equal(t, "http://host/a_b.png", content.Body[3].(*adaptivecards.Image).URL)
+	show := content.Actions[0].(*adaptivecards.ActionShowCard)
+	assert.Equal(t, `shown\_card`, *show.Card.Body[0].(*adaptivecards.TextBlock).Text)
+	assert.Equal(t, "http://host/a_b", content.Actions[1].(*adaptivecards.ActionOpenURL).URL)
+}
+
+func TestTemplateEscapesActionsInStrings(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "card.tmpl")
+	require.NoError(t, os.WriteFile(f, []byte(`{{ define "teams.card" -}}
+{"text": "{{ .CommonAnnotations.description }}", "len": {{ len .CommonAnnotations.description }}, "labels": {{ toJson .CommonLabels }}, "title": "a \"{{ .CommonLabels.job }}\""}
+{{- end }}`), 0o600))
+	tmpl, err := ParseTemplateFile(f)
+	require.NoError(t, err)
+
+	description := "line 1\n\"line 2\" <b>"
+	data := &template.Data{
+		CommonLabels:      template.KV{"job": `node "exporter"`},
+		CommonAnnotations: template.KV{"description": description},
+	}
+	out, err := tmpl.Execute(context.Background(), DefaultEntryTemplate, data)
+	require.NoError(t, err)
+
+	// The functions get the values as is, and their output is escaped in
+	// the strings only.
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &got), out)
+	assert.Equal(t, description, got["text"])
+	assert.Equal(t, float64(len(description)), got["len"])
+	assert.Equal(t, map[string]any{"job": `node "exporter"`}, got["labels"])
+	assert.Equal(t, `a "node "exporter""`, got["title"])
+}
+
+func TestTemplateSkipsEscapedActions(t *testing.T) {
+	f := writeTemplate(t, t.TempDir(), "card.tmpl", `{{ define "teams.card" -}}
+{"js": "{{ .CommonLabels.job | js }}", "json": "{{ toJson .CommonLabels.job | trimPrefix "\"" | trimSuffix "\"" }}", "plain": "{{ .CommonLabels.job }}"}
+{{- end }}`)
+	tmpl, err := ParseTemplateFile(f)
+	require.NoError(t, err)
+
+	out, err := tmpl.Execute(context.Background(), DefaultEntryTemplate, &template.Data{CommonLabels: template.KV{"job": `node "exporter"`}})
+	require.NoError(t, err)
+
+	// The actions escaping their output with js or toJson are not escaped
+	// twice.
+	var got map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &got), out)
+	assert.Equal(t, map[string]any{"js": `node "exporter"`, "json": `node "exporter"`, "plain": `node "exporter"`}, got)
+}
+
+func Test_templatedCard_ConvertDoesNotModifyMessage(t *testing.T) {
+	tmpl, err := ParseTemplateFile(testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"))
+	require.NoError(t, err)
+	wm, err := testutils.ParseWebhookJSONFromFile(testutils.GetTestDataFilePath("prom_post_request_linebreak.json"))
+	require.NoError(t, err)
+	want, err := testutils.ParseWebhookJSONFromFile(testutils.GetTestDataFilePath("prom_post_request_linebreak.json"))
+	require.NoError(t, err)
+
+	for _, escape := range []bool{false, true} {
+		c := NewTemplatedCardCreator(tmpl, escape, utility.NewLogger(utility.LogFormatFmt, false))
+		_, err = c.Convert(context.Background(), wm)
+		require.NoError(t, err)
+		assert.Equal(t, want, wm)
+	}
+}
+
+func TestMarkdownEscapeMiddleware(t *testing.T) {
+	next := cardConverter{body: `{"type": "TextBlock", "text": "**a_b**"}`}
+
+	got, err := NewMarkdownEscapeMiddleware("*", next).Convert(context.Background(), webhook.Message{})
+	require.NoError(t, err)
+	assert.Equal(t, `\*\*a_b\*\*`, *got.Attachments[0].Content.Body[0].(*adaptivecards.TextBlock).Text)
+}
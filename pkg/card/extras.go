@@ -0,0 +1,100 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+
+	"github.com/prometheus/alertmanager/template"
+)
+
+// AlertExtras holds the fields of an alert which Alertmanager does not send,
+// but other sources, such as Grafana, do.
+type AlertExtras struct {
+	// Values are the values of the expressions of the alert rule, by refID.
+	Values       map[string]float64 `json:"values,omitempty"`
+	ValueString  string             `json:"valueString,omitempty"`
+	ImageURL     string             `json:"imageURL,omitempty"`
+	DashboardURL string             `json:"dashboardURL,omitempty"`
+	PanelURL     string             `json:"panelURL,omitempty"`
+	SilenceURL   string             `json:"silenceURL,omitempty"`
+}
+
+// Extras holds the fields of a notification which Alertmanager does not
+// send.
+type Extras struct {
+	Title   string
+	Message string
+	// Alerts holds the AlertExtras of the alerts by fingerprint.
+	Alerts map[string]AlertExtras
+}
+
+// Alert is an alert of the card templates: the Alertmanager alert and its
+// extra fields, zero if the source did not send them.
+type Alert struct {
+	template.Alert
+	AlertExtras
+}
+
+// Alerts is a list of Alert.
+type Alerts []Alert
+
+// Firing returns the subset of alerts that are firing.
+func (as Alerts) Firing() []Alert {
+	return as.withStatus("firing")
+}
+
+// Resolved returns the subset of alerts that are resolved.
+func (as Alerts) Resolved() []Alert {
+	return as.withStatus("resolved")
+}
+
+func (as Alerts) withStatus(status string) []Alert {
+	res := []Alert{}
+	for _, a := range as {
+		if a.Status == status {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// withExtras returns alerts with the extras of e, which may be nil.
+func withExtras(alerts template.Alerts, e *Extras) Alerts {
+	res := make(Alerts, len(alerts))
+	for i, a := range alerts {
+		res[i].Alert = a
+		if e != nil {
+			res[i].AlertExtras = e.Alerts[a.Fingerprint]
+		}
+	}
+	return res
+}
+
+type extrasKey struct{}
+
+// ContextWithExtras returns a copy of ctx carrying the extras of the
+// notification, to render in the cards.
+func ContextWithExtras(ctx context.Context, e *Extras) context.Context {
+	return context.WithValue(ctx, extrasKey{}, e)
+}
+
+// ExtrasFromContext returns the extras carried by ctx, or nil.
+func ExtrasFromContext(ctx context.Context) *Extras {
+	e, _ := ctx.Value(extrasKey{}).(*Extras)
+	return e
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtrasContext(t *testing.T) {
+	assert.Nil(t, ExtrasFromContext(context.Background()))
+	e := &Extras{Title: "title"}
+	assert.Same(t, e, ExtrasFromContext(ContextWithExtras(context.Background(), e)))
+}
+
+func TestAlerts(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alerts := withExtras(template.Alerts{
+		digestAlert("1", "firing", "HighLoad", "warning", start),
+		digestAlert("2", "resolved", "DiskFull", "critical", start),
+	}, &Extras{Alerts: map[string]AlertExtras{"2": {PanelURL: "http://grafana/panel"}}})
+
+	require.Len(t, alerts.Firing(), 1)
+	assert.Equal(t, "1", alerts.Firing()[0].Fingerprint)
+	require.Len(t, alerts.Resolved(), 1)
+	assert.Equal(t, "http://grafana/panel", alerts.Resolved()[0].PanelURL)
+	assert.Empty(t, withExtras(template.Alerts{digestAlert("1", "firing", "HighLoad", "warning", start)}, nil)[0].PanelURL)
+}
+
+func Test_templatedCard_ConvertExtras(t *testing.T) {
+	tmpl, err := ParseTemplateFile(testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"))
+	require.NoError(t, err)
+	c := NewTemplatedCardCreator(tmpl, false, utility.NewLogger(utility.LogFormatFmt, false))
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	wm := webhook.Message{Data: &template.Data{
+		Status: "firing",
+		Alerts: template.Alerts{digestAlert("1", "firing", "HighLoad", "warning", start)},
+	}}
+	ctx := ContextWithExtras(context.Background(), &Extras{
+		Alerts: map[string]AlertExtras{"1": {ImageURL: "http://grafana/render/1.png"}},
+	})
+
+	got, err := c.Convert(ctx, wm)
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	container, ok := body[len(body)-1].(*adaptivecards.Container)
+	require.True(t, ok)
+	image, ok := container.Items[len(container.Items)-1].(*adaptivecards.Image)
+	require.True(t, ok)
+	assert.Equal(t, "http://grafana/render/1.png", image.URL)
+
+	// Without extras there is no image.
+	got, err = c.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	body = got.Attachments[0].Content.Body
+	container = body[len(body)-1].(*adaptivecards.Container)
+	assert.IsType(t, &adaptivecards.FactSet{}, container.Items[len(container.Items)-1])
+}
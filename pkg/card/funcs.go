@@ -0,0 +1,250 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"strings"
+	tmpltext "text/template"
+
+	sprig "github.com/go-task/slim-sprig/v3"
+	"github.com/prometheus/alertmanager/template"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxSequence is the maximum number of items of the sequences generated by
+// the template functions until, untilStep and seq, which would otherwise
+// let a template allocate as much memory as it wants.
+const MaxSequence = 10000
+
+// sandboxedFuncs are the sprig functions reading the environment of the
+// server or reaching the network, which templates cannot use.
+var sandboxedFuncs = []string{"env", "expandenv", "getHostByName"}
+
+// FuncMap returns the functions of the card templates: the functions of the
+// Alertmanager templates, overridden by the slim-sprig functions except
+// 'env', 'expandenv' and 'getHostByName', and toToml, toYaml, fromYaml,
+// toJson, fromJson and counter. until, untilStep, seq and repeat fail
+// beyond MaxSequence items or DefaultMaxOutput bytes. It returns a new map
+// on every call.
+func FuncMap() tmpltext.FuncMap {
+	funcs := tmpltext.FuncMap(maps.Clone(template.DefaultFuncs))
+	maps.Copy(funcs, sprig.TxtFuncMap())
+	for _, name := range sandboxedFuncs {
+		delete(funcs, name)
+	}
+	maps.Copy(funcs, tmpltext.FuncMap{
+		"until":     until(funcs["until"].(func(int) []int)),
+		"untilStep": untilStep(funcs["untilStep"].(func(int, int, int) []int)),
+		"seq":       seq(funcs["seq"].(func(...int) string)),
+		"repeat":    repeat,
+		"toToml":    toToml,
+		"toYaml":    toYaml,
+		"fromYaml":  fromYaml,
+		"toJson":    toJSON,
+		"fromJson":  fromJSON,
+		"counter":   counter,
+	})
+	return funcs
+}
+
+// checkSequence returns an error if the sequence from start up to stop
+// excluded by step has more than MaxSequence items.
+func checkSequence(start, stop, step int) error {
+	if step == 0 {
+		return nil
+	}
+	if n := math.Ceil((float64(stop) - float64(start)) / float64(step)); n > MaxSequence {
+		return fmt.Errorf("sequence of %.0f items exceeds the limit of %d", n, MaxSequence)
+	}
+	return nil
+}
+
+// until returns the sprig function until failing beyond MaxSequence items.
+func until(f func(int) []int) func(int) ([]int, error) {
+	return func(count int) ([]int, error) {
+		step := 1
+		if count < 0 {
+			step = -1
+		}
+		if err := checkSequence(0, count, step); err != nil {
+			return nil, err
+		}
+		return f(count), nil
+	}
+}
+
+// untilStep returns the sprig function untilStep failing beyond MaxSequence
+// items.
+func untilStep(f func(int, int, int) []int) func(int, int, int) ([]int, error) {
+	return func(start, stop, step int) ([]int, error) {
+		if err := checkSequence(start, stop, step); err != nil {
+			return nil, err
+		}
+		return f(start, stop, step), nil
+	}
+}
+
+// seq returns the sprig function seq failing beyond MaxSequence items. Like
+// the sprig function, it takes the end, the start and the end, or the start,
+// the step and the end, the end being included.
+func seq(f func(...int) string) func(...int) (string, error) {
+	return func(params ...int) (string, error) {
+		var start, end, step int
+		switch len(params) {
+		case 1:
+			start, end, step = 1, params[0], 1
+		case 2:
+			start, end, step = params[0], params[1], 1
+		case 3:
+			start, end, step = params[0], params[2], params[1]
+		}
+		inc := 1
+		if end < start {
+			inc = -1
+			if len(params) < 3 {
+				step = -1
+			}
+		}
+		if err := checkSequence(start, end+inc, step); err != nil {
+			return "", err
+		}
+		return f(params...), nil
+	}
+}
+
+// repeat returns s repeated count times, or an error if the result exceeds
+// DefaultMaxOutput bytes.
+func repeat(count int, s string) (string, error) {
+	if count > 0 && len(s) > DefaultMaxOutput/count {
+		return "", fmt.Errorf("repeat of %d bytes exceeds the limit of %d", count*len(s), DefaultMaxOutput)
+	}
+	return strings.Repeat(s, max(count, 0)), nil
+}
+
+// jsonValue returns v as decoded from its JSON encoding, so that structs
+// are encoded with their JSON names, like Helm does. The numbers are int64
+// when they are integers, and float64 otherwise.
+func jsonValue(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var out any
+	if err := d.Decode(&out); err != nil {
+		return nil, err
+	}
+	return numbers(out), nil
+}
+
+// numbers replaces the json.Number values of v with int64 or float64.
+func numbers(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, e := range v {
+			v[k] = numbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = numbers(e)
+		}
+	}
+	return v
+}
+
+// toToml encodes v as TOML, or returns the error.
+func toToml(v any) string {
+	j, err := jsonValue(v)
+	if err != nil {
+		return err.Error()
+	}
+	m, ok := j.(map[string]any)
+	if !ok {
+		return "toml: top-level values must be maps or structs"
+	}
+	var b strings.Builder
+	encodeToml(&b, nil, m)
+	return b.String()
+}
+
+// toYaml encodes v as YAML indented with two spaces, or returns "" on error.
+func toYaml(v any) string {
+	j, err := jsonValue(v)
+	if err != nil {
+		return ""
+	}
+	var b bytes.Buffer
+	e := yaml.NewEncoder(&b)
+	e.SetIndent(2)
+	if err := e.Encode(j); err != nil {
+		return ""
+	}
+	if err := e.Close(); err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// fromYaml decodes the YAML mapping s. On error, the map has the error
+// under the key "Error".
+func fromYaml(s string) map[string]any {
+	m := map[string]any{}
+	if err := yaml.Unmarshal([]byte(s), &m); err != nil {
+		m["Error"] = err.Error()
+	}
+	return m
+}
+
+// toJSON encodes v as JSON, or returns "" on error.
+func toJSON(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// fromJSON decodes the JSON object s. On error, the map has the error
+// under the key "Error".
+func fromJSON(s string) map[string]any {
+	m := map[string]any{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		m["Error"] = err.Error()
+	}
+	return m
+}
+
+// counter returns a function returning 0, 1, 2... on its successive calls.
+func counter() func() int {
+	i := -1
+	return func() int {
+		i++
+		return i
+	}
+}
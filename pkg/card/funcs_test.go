@@ -0,0 +1,101 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFuncMap(t *testing.T) {
+	funcs := FuncMap()
+	v := map[string]any{"name": "api", "labels": map[string]any{"team": "sre"}}
+
+	assert.Equal(t, "labels:\n  team: sre\nname: api\n", funcs["toYaml"].(func(any) string)(v))
+	assert.Equal(t, `{"labels":{"team":"sre"},"name":"api"}`, funcs["toJson"].(func(any) string)(v))
+	assert.Equal(t, "name = \"api\"\n\n[labels]\nteam = \"sre\"\n", funcs["toToml"].(func(any) string)(v))
+
+	assert.Equal(t, v, funcs["fromYaml"].(func(string) map[string]any)("name: api\nlabels:\n  team: sre\n"))
+	assert.Equal(t, v, funcs["fromJson"].(func(string) map[string]any)(`{"name":"api","labels":{"team":"sre"}}`))
+	assert.Contains(t, funcs["fromYaml"].(func(string) map[string]any)("- a"), "Error")
+	assert.Contains(t, funcs["fromJson"].(func(string) map[string]any)("["), "Error")
+
+	next := funcs["counter"].(func() func() int)()
+	assert.Equal(t, []int{0, 1, 2}, []int{next(), next(), next()})
+
+	// sprig overrides the Alertmanager functions.
+	assert.Equal(t, "a,b", funcs["join"].(func(string, any) string)(",", []string{"a", "b"}))
+	for _, name := range sandboxedFuncs {
+		assert.NotContains(t, funcs, name)
+	}
+}
+
+func TestFuncMapStructs(t *testing.T) {
+	funcs := FuncMap()
+	v := struct {
+		Name   string            `json:"name"`
+		Count  int               `json:"count"`
+		Labels map[string]string `json:"labels,omitempty"`
+	}{Name: "api", Count: 3}
+
+	// Like Helm, the structs are encoded with their JSON names.
+	assert.Equal(t, "count: 3\nname: api\n", funcs["toYaml"].(func(any) string)(v))
+	assert.Equal(t, "count = 3\nname = \"api\"\n", funcs["toToml"].(func(any) string)(v))
+	assert.Equal(t, "toml: top-level values must be maps or structs", funcs["toToml"].(func(any) string)([]int{1}))
+}
+
+func TestFuncMapSequences(t *testing.T) {
+	funcs := FuncMap()
+	until := funcs["until"].(func(int) ([]int, error))
+	untilStep := funcs["untilStep"].(func(int, int, int) ([]int, error))
+	seq := funcs["seq"].(func(...int) (string, error))
+	repeat := funcs["repeat"].(func(int, string) (string, error))
+
+	ints, err := until(3)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, ints)
+	ints, err = untilStep(10, 0, -4)
+	require.NoError(t, err)
+	assert.Equal(t, []int{10, 6, 2}, ints)
+	s, err := seq(3, -1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "3 2 1", s)
+	s, err = seq(5, 1, 1)
+	require.NoError(t, err)
+	assert.Empty(t, s)
+	_, err = until(MaxSequence)
+	assert.NoError(t, err)
+	s, err = repeat(3, "ab")
+	require.NoError(t, err)
+	assert.Equal(t, "ababab", s)
+
+	_, err = until(MaxSequence + 1)
+	assert.ErrorContains(t, err, "exceeds the limit")
+	_, err = until(math.MinInt)
+	assert.ErrorContains(t, err, "exceeds the limit")
+	_, err = untilStep(0, math.MaxInt, 1)
+	assert.ErrorContains(t, err, "exceeds the limit")
+	_, err = seq(math.MaxInt - 1)
+	assert.ErrorContains(t, err, "exceeds the limit")
+	_, err = seq(-MaxSequence, 1, MaxSequence)
+	assert.ErrorContains(t, err, "exceeds the limit")
+	_, err = repeat(math.MaxInt, "ab")
+	assert.ErrorContains(t, err, "exceeds the limit")
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	tmpltext "text/template"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/common/model"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+)
+
+// MaxLinks is the maximum number of link buttons of a card, the number of
+// actions Teams shows.
+const MaxLinks = 6
+
+// maxURLLength is the length of the longest URL of a link button.
+const maxURLLength = 2048
+
+// LinkTemplate defines the link buttons of a card. Title and URL are
+// templates executed with each alert, as a LinkData.
+type LinkTemplate struct {
+	Title string
+	URL   string
+}
+
+// BuiltinLinks are the LinkTemplates available by name.
+var BuiltinLinks = map[string]LinkTemplate{
+	"runbook": {
+		Title: `{{ tr "link.runbook" }}`,
+		URL:   `{{ or .Annotations.runbook_url .Annotations.runbook }}`,
+	},
+	"dashboard": {
+		Title: `{{ tr "link.dashboard" }}`,
+		URL:   `{{ or .DashboardURL .Annotations.dashboard_url .Annotations.dashboard }}`,
+	},
+	"alertmanager": {
+		Title: `{{ tr "link.alertmanager" }}`,
+		URL:   `{{ .AlertmanagerURL }}`,
+	},
+	"graph": {
+		Title: `{{ tr "link.graph" }}`,
+		URL:   `{{ .GeneratorURL }}`,
+	},
+}
+
+// LinkData is the data of the LinkTemplates: an alert and its notification.
+type LinkData struct {
+	Alert
+	Receiver    string
+	GroupLabels template.KV
+	ExternalURL string
+	// AlertmanagerURL is the URL of the alerts of the group in the
+	// Alertmanager UI, if ExternalURL is set.
+	AlertmanagerURL string
+}
+
+// Link is a link button of a card.
+type Link struct {
+	Title string
+	URL   string
+}
+
+type linkTemplate struct {
+	title *tmpltext.Template
+	url   *tmpltext.Template
+}
+
+// Links resolves the link buttons of the cards.
+type Links struct {
+	templates []linkTemplate
+}
+
+// NewLinks parses templates with the functions of the card templates, see
+// FuncMap, and of l.
+func NewLinks(templates []LinkTemplate, l *Localizer) (*Links, error) {
+	if l == nil {
+		l = DefaultLocalizer()
+	}
+	funcs := FuncMap()
+	for k, v := range l.FuncMap() {
+		funcs[k] = v
+	}
+	links := &Links{}
+	for i, lt := range templates {
+		title, err := tmpltext.New("title").Option("missingkey=zero").Funcs(funcs).Parse(lt.Title)
+		if err != nil {
+			return nil, fmt.Errorf("link %d: invalid title: %w", i, err)
+		}
+		u, err := tmpltext.New("url").Option("missingkey=zero").Funcs(funcs).Parse(lt.URL)
+		if err != nil {
+			return nil, fmt.Errorf("link %d: invalid url: %w", i, err)
+		}
+		links.templates = append(links.templates, linkTemplate{title: title, url: u})
+	}
+	return links, nil
+}
+
+// Resolve returns the links of the alerts of wm, in the order of the
+// templates and then of the alerts, at most MaxLinks. The links without a
+// valid URL, or with the URL of a previous link, are skipped, and the titles
+// used by several links are numbered.
+func (ls *Links) Resolve(wm webhook.Message, extras *Extras) []Link {
+	if wm.Data == nil {
+		return nil
+	}
+	data := LinkData{
+		Receiver:        wm.Receiver,
+		GroupLabels:     wm.GroupLabels,
+		ExternalURL:     wm.ExternalURL,
+		AlertmanagerURL: alertmanagerURL(wm.Data),
+	}
+	var links []Link
+	seenURLs := map[string]bool{}
+	titles := map[string]int{}
+	for _, lt := range ls.templates {
+		for _, a := range withExtras(wm.Alerts, extras) {
+			data.Alert = a
+			var u, title strings.Builder
+			if lt.url.Execute(&u, data) != nil || lt.title.Execute(&title, data) != nil {
+				continue
+			}
+			link := Link{Title: strings.TrimSpace(title.String()), URL: strings.TrimSpace(u.String())}
+			if link.Title == "" || seenURLs[link.URL] || !validLinkURL(link.URL) {
+				continue
+			}
+			seenURLs[link.URL] = true
+			titles[link.Title]++
+			if n := titles[link.Title]; n > 1 {
+				link.Title += " (" + strconv.Itoa(n) + ")"
+			}
+			links = append(links, link)
+			if len(links) == MaxLinks {
+				return links
+			}
+		}
+	}
+	return links
+}
+
+// validLinkURL reports whether Teams accepts u as the URL of an
+// Action.OpenUrl: an absolute http or https URL of at most maxURLLength.
+func validLinkURL(u string) bool {
+	if u == "" || len(u) > maxURLLength {
+		return false
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// alertmanagerURL returns the URL of the alerts of the group of data in the
+// Alertmanager UI, or "" without ExternalURL.
+func alertmanagerURL(data *template.Data) string {
+	if data.ExternalURL == "" {
+		return ""
+	}
+	ls := model.LabelSet{}
+	for k, v := range data.GroupLabels {
+		ls[model.LabelName(k)] = model.LabelValue(v)
+	}
+	q := url.Values{"filter": {ls.String()}}
+	if data.Receiver != "" {
+		q.Set("receiver", data.Receiver)
+	}
+	return strings.TrimSuffix(data.ExternalURL, "/") + "/#/alerts?" + q.Encode()
+}
+
+type linksMiddleware struct {
+	links *Links
+	next  Converter
+}
+
+// NewLinksMiddleware creates a Converter adding to each attachment of the
+// cards of n the links of ls as an ActionSet, but those already in the
+// attachment, up to MaxLinks actions per attachment with those it has.
+// Within NewAttachmentsMiddleware, the card of each alert gets its own links.
+func NewLinksMiddleware(ls *Links, n Converter) Converter {
+	return linksMiddleware{ls, n}
+}
+
+func (m linksMiddleware) Convert(ctx context.Context, a webhook.Message) (adaptivecards.WorkflowConnectorCard, error) {
+	c, err := m.next.Convert(ctx, a)
+	if err != nil || len(c.Attachments) == 0 {
+		return c, err
+	}
+	links := m.links.Resolve(a, ExtrasFromContext(ctx))
+	if len(links) == 0 {
+		return c, nil
+	}
+	for i := range c.Attachments {
+		content := &c.Attachments[i].Content
+		existing := cardActions(content)
+		urls := map[string]bool{}
+		for _, u := range openURLs(existing) {
+			urls[u] = true
+		}
+		var actions []adaptivecards.Action
+		for _, l := range links {
+			if len(existing)+len(actions) >= MaxLinks {
+				break
+			}
+			if urls[l.URL] {
+				continue
+			}
+			actions = append(actions, &adaptivecards.ActionOpenURL{
+				CommonActionProperties: &adaptivecards.CommonActionProperties{Title: l.Title},
+				URL:                    l.URL,
+			})
+		}
+		if len(actions) > 0 {
+			content.Body = append(content.Body, &adaptivecards.ActionSet{Actions: actions})
+		}
+	}
+	return c, nil
+}
+
+// cardActions returns the actions of c and of the ActionSets of its body.
+func cardActions(c *adaptivecards.AdaptiveCard) []adaptivecards.Action {
+	actions := slices.Clone(c.Actions)
+	for _, e := range c.Body {
+		switch set := e.(type) {
+		case *adaptivecards.ActionSet:
+			actions = append(actions, set.Actions...)
+		case adaptivecards.ActionSet:
+			actions = append(actions, set.Actions...)
+		}
+	}
+	return actions
+}
+
+func openURLs(actions []adaptivecards.Action) []string {
+	var urls []string
+	for _, a := range actions {
+		switch a := a.(type) {
+		case *adaptivecards.ActionOpenURL:
+			urls = append(urls, a.URL)
+		case adaptivecards.ActionOpenURL:
+			urls = append(urls, a.URL)
+		}
+	}
+	return urls
+}
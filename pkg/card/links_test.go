@@ -0,0 +1,205 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func linksMessage(alerts ...template.Alert) webhook.Message {
+	return webhook.Message{Data: &template.Data{
+		Receiver:    "teams",
+		Status:      "firing",
+		Alerts:      alerts,
+		GroupLabels: template.KV{"alertname": "HighLoad"},
+		ExternalURL: "http://alertmanager:9093/",
+	}}
+}
+
+func linkAlert(fingerprint string, annotations template.KV, generatorURL string) template.Alert {
+	return template.Alert{
+		Status:       "firing",
+		Labels:       template.KV{"alertname": "HighLoad", "instance": fingerprint},
+		Annotations:  annotations,
+		GeneratorURL: generatorURL,
+		Fingerprint:  fingerprint,
+	}
+}
+
+func builtinLinks(names ...string) []LinkTemplate {
+	var lts []LinkTemplate
+	for _, name := range names {
+		lts = append(lts, BuiltinLinks[name])
+	}
+	return lts
+}
+
+func TestLinksResolve(t *testing.T) {
+	ls, err := NewLinks(append(builtinLinks("runbook", "dashboard", "alertmanager", "graph"), LinkTemplate{
+		Title: "Logs {{ .Labels.instance }}",
+		URL:   "https://logs.example.com/?q={{ .Labels.instance | urlquery }}",
+	}), nil)
+	require.NoError(t, err)
+
+	wm := linksMessage(
+		linkAlert("a", template.KV{"runbook_url": "https://runbooks.example.com/high-load"}, "javascript:alert(1)"),
+		linkAlert("b", template.KV{"runbook_url": "https://runbooks.example.com/high-load", "dashboard": "https://grafana.example.com/d/1"}, ""),
+		linkAlert("c", template.KV{"runbook": "https://runbooks.example.com/other"}, "http://prometheus:9090/graph?g0.expr=up"),
+	)
+	extras := &Extras{Alerts: map[string]AlertExtras{"a": {DashboardURL: "https://grafana.example.com/d/2"}}}
+
+	assert.Equal(t, []Link{
+		{Title: "Runbook", URL: "https://runbooks.example.com/high-load"},
+		{Title: "Runbook (2)", URL: "https://runbooks.example.com/other"},
+		{Title: "Dashboard", URL: "https://grafana.example.com/d/2"},
+		{Title: "Dashboard (2)", URL: "https://grafana.example.com/d/1"},
+		{Title: "Alertmanager", URL: "http://alertmanager:9093/#/alerts?filter=%7Balertname%3D%22HighLoad%22%7D&receiver=teams"},
+		{Title: "Graph", URL: "http://prometheus:9090/graph?g0.expr=up"},
+	}, ls.Resolve(wm, extras))
+
+	ls, err = NewLinks(builtinLinks("graph", "alertmanager"), nil)
+	require.NoError(t, err)
+	wm.ExternalURL = ""
+	assert.Empty(t, ls.Resolve(wm, nil)[1:])
+	assert.Nil(t, ls.Resolve(webhook.Message{}, nil))
+}
+
+func TestLinksResolveLimits(t *testing.T) {
+	ls, err := NewLinks([]LinkTemplate{{Title: "Host", URL: "https://{{ .Labels.instance }}.example.com"}}, nil)
+	require.NoError(t, err)
+
+	var alerts []template.Alert
+	for _, fp := range []string{"a", "b", "c", "d", "e", "f", "g", strings.Repeat("h", maxURLLength)} {
+		alerts = append(alerts, linkAlert(fp, nil, ""))
+	}
+	links := ls.Resolve(linksMessage(alerts[len(alerts)-1:]...), nil)
+	assert.Empty(t, links)
+	links = ls.Resolve(linksMessage(alerts...), nil)
+	assert.Len(t, links, MaxLinks)
+	assert.Equal(t, "Host (6)", links[5].Title)
+}
+
+func TestNewLinksInvalid(t *testing.T) {
+	_, err := NewLinks([]LinkTemplate{{Title: "{{ .Labels", URL: "https://a"}}, nil)
+	assert.ErrorContains(t, err, "link 0: invalid title")
+	_, err = NewLinks([]LinkTemplate{{Title: "a", URL: "{{ unknown }}"}}, nil)
+	assert.ErrorContains(t, err, "link 0: invalid url")
+}
+
+func TestLinksLocalized(t *testing.T) {
+	l, err := NewLocalizer("fr", "UTC")
+	require.NoError(t, err)
+	ls, err := NewLinks(builtinLinks("dashboard"), l)
+	require.NoError(t, err)
+	links := ls.Resolve(linksMessage(linkAlert("a", template.KV{"dashboard_url": "https://grafana.example.com/d/1"}, "")), nil)
+	assert.Equal(t, []Link{{Title: "Tableau de bord", URL: "https://grafana.example.com/d/1"}}, links)
+}
+
+// cardConverter returns a card with the actions of body.
+type cardConverter struct {
+	body string
+}
+
+func (c cardConverter) Convert(context.Context, webhook.Message) (adaptivecards.WorkflowConnectorCard, error) {
+	var card adaptivecards.WorkflowConnectorCard
+	err := json.Unmarshal([]byte(`{"type": "message", "attachments": [{"contentType": "application/vnd.microsoft.card.adaptive", "content": {
+		"type": "AdaptiveCard", "version": "1.2", "body": [`+c.body+`]}}]}`), &card)
+	return card, err
+}
+
+func TestLinksMiddleware(t *testing.T) {
+	ls, err := NewLinks(builtinLinks("runbook", "graph"), nil)
+	require.NoError(t, err)
+	wm := linksMessage(linkAlert("a", template.KV{"runbook_url": "https://runbooks.example.com/a"}, "http://prometheus:9090/graph?g0.expr=up"))
+
+	// The links already in the card are not added again.
+	c := NewLinksMiddleware(ls, cardConverter{body: `{"type": "ActionSet", "actions": [{"type": "Action.OpenUrl", "title": "Runbook", "url": "https://runbooks.example.com/a"}]}`})
+	got, err := c.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	require.Len(t, body, 2)
+	set, ok := body[1].(*adaptivecards.ActionSet)
+	require.True(t, ok)
+	require.Len(t, set.Actions, 1)
+	action := set.Actions[0].(*adaptivecards.ActionOpenURL)
+	assert.Equal(t, "Graph", action.Title)
+	assert.Equal(t, "http://prometheus:9090/graph?g0.expr=up", action.URL)
+
+	b, err := json.Marshal(set)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "ActionSet", "actions": [{"type": "Action.OpenUrl", "title": "Graph", "url": "http://prometheus:9090/graph?g0.expr=up"}]}`, string(b))
+
+	// Without links, the card is left as is.
+	c = NewLinksMiddleware(ls, cardConverter{body: `{"type": "TextBlock", "text": "a"}`})
+	got, err = c.Convert(context.Background(), linksMessage(linkAlert("b", nil, "")))
+	require.NoError(t, err)
+	assert.Len(t, got.Attachments[0].Content.Body, 1)
+}
+
+func TestLinksMiddlewareActionsBudget(t *testing.T) {
+	ls, err := NewLinks(builtinLinks("runbook", "graph"), nil)
+	require.NoError(t, err)
+	wm := linksMessage(linkAlert("a", template.KV{"runbook_url": "https://runbooks.example.com/a"}, "http://prometheus:9090/graph?g0.expr=up"))
+
+	// The card has room for one more action only.
+	var actions []string
+	for i := range MaxLinks - 1 {
+		actions = append(actions, fmt.Sprintf(`{"type": "Action.OpenUrl", "title": "%d", "url": "https://example.com/%d"}`, i, i))
+	}
+	c := NewLinksMiddleware(ls, cardConverter{body: `{"type": "ActionSet", "actions": [` + strings.Join(actions, ",") + `]}`})
+	got, err := c.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	require.Len(t, body, 2)
+	set := body[1].(*adaptivecards.ActionSet)
+	require.Len(t, set.Actions, 1)
+	assert.Equal(t, "https://runbooks.example.com/a", set.Actions[0].(*adaptivecards.ActionOpenURL).URL)
+}
+
+func TestLinksMiddlewareAttachments(t *testing.T) {
+	ls, err := NewLinks(builtinLinks("runbook"), nil)
+	require.NoError(t, err)
+	wm := linksMessage(
+		linkAlert("a", template.KV{"runbook_url": "https://runbooks.example.com/a"}, ""),
+		linkAlert("b", template.KV{"runbook_url": "https://runbooks.example.com/b"}, ""),
+	)
+
+	c := NewAttachmentsMiddleware(adaptivecards.AttachmentLayoutCarousel, 0, utility.NewLogger(utility.LogFormatFmt, false), NewLinksMiddleware(ls, cardConverter{body: `{"type": "TextBlock", "text": "a"}`}))
+	got, err := c.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	require.Len(t, got.Attachments, 3)
+	var urls [][]string
+	for _, a := range got.Attachments {
+		urls = append(urls, openURLs(cardActions(&a.Content)))
+	}
+	assert.Equal(t, [][]string{
+		{"https://runbooks.example.com/a", "https://runbooks.example.com/b"},
+		{"https://runbooks.example.com/a"},
+		{"https://runbooks.example.com/b"},
+	}, urls)
+}
@@ -0,0 +1,367 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+)
+
+const (
+	// DefaultLocale is the locale used when a connector does not configure one.
+	DefaultLocale = "en"
+	// DefaultTimezone is the timezone used when a connector does not configure one.
+	DefaultTimezone = "UTC"
+)
+
+// Catalog maps a locale (e.g. "en", "de") to its translated messages,
+// keyed by message key.
+type Catalog map[string]map[string]string
+
+// DefaultCatalog holds the strings used by the shipped default templates.
+// The "layout.datetime" key is a Go time layout and the "duration.*" keys are
+// fmt verbs used to render each duration unit.
+var DefaultCatalog = Catalog{
+	"en": {
+		"alert.title":          "Prometheus Alert",
+		"status.firing":        "Firing",
+		"status.resolved":      "Resolved",
+		"alert.firingFor":      "Firing for",
+		"alert.resolvedAfter":  "Resolved after",
+		"alert.startsAt":       "Started",
+		"alert.endsAt":         "Ended",
+		"layout.datetime":      "2006-01-02 15:04 MST",
+		"duration.day":         "%dd",
+		"duration.hour":        "%dh",
+		"duration.minute":      "%dm",
+		"duration.second":      "%ds",
+		"digest.title":         "Digest",
+		"digest.notifications": "Notifications",
+		"link.runbook":         "Runbook",
+		"link.dashboard":       "Dashboard",
+		"link.alertmanager":    "Alertmanager",
+		"link.graph":           "Graph",
+		"action.acknowledge":   "Acknowledge",
+		"action.assign":        "Assign to me",
+		"ack.acknowledgedBy":   "Acknowledged by",
+		"ack.assignedTo":       "Assigned to",
+	},
+	"de": {
+		"alert.title":          "Prometheus-Alarm",
+		"status.firing":        "Ausgelöst",
+		"status.resolved":      "Behoben",
+		"alert.firingFor":      "Aktiv seit",
+		"alert.resolvedAfter":  "Behoben nach",
+		"alert.startsAt":       "Beginn",
+		"alert.endsAt":         "Ende",
+		"layout.datetime":      "02.01.2006 15:04 MST",
+		"duration.day":         "%d T.",
+		"duration.hour":        "%d Std.",
+		"duration.minute":      "%d Min.",
+		"duration.second":      "%d Sek.",
+		"digest.title":         "Zusammenfassung",
+		"digest.notifications": "Benachrichtigungen",
+		"link.runbook":         "Runbook",
+		"link.dashboard":       "Dashboard",
+		"link.alertmanager":    "Alertmanager",
+		"link.graph":           "Diagramm",
+		"action.acknowledge":   "Bestätigen",
+		"action.assign":        "Mir zuweisen",
+		"ack.acknowledgedBy":   "Bestätigt von",
+		"ack.assignedTo":       "Zugewiesen an",
+	},
+	"fr": {
+		"alert.title":          "Alerte Prometheus",
+		"status.firing":        "Déclenchée",
+		"status.resolved":      "Résolue",
+		"alert.firingFor":      "Active depuis",
+		"alert.resolvedAfter":  "Résolue après",
+		"alert.startsAt":       "Début",
+		"alert.endsAt":         "Fin",
+		"layout.datetime":      "02/01/2006 15:04 MST",
+		"duration.day":         "%d j",
+		"duration.hour":        "%d h",
+		"duration.minute":      "%d min",
+		"duration.second":      "%d s",
+		"digest.title":         "Résumé",
+		"digest.notifications": "Notifications",
+		"link.runbook":         "Procédure",
+		"link.dashboard":       "Tableau de bord",
+		"link.alertmanager":    "Alertmanager",
+		"link.graph":           "Graphique",
+		"action.acknowledge":   "Acquitter",
+		"action.assign":        "M'assigner",
+		"ack.acknowledgedBy":   "Acquittée par",
+		"ack.assignedTo":       "Assignée à",
+	},
+	"es": {
+		"alert.title":          "Alerta de Prometheus",
+		"status.firing":        "Activa",
+		"status.resolved":      "Resuelta",
+		"alert.firingFor":      "Activa desde hace",
+		"alert.resolvedAfter":  "Resuelta tras",
+		"alert.startsAt":       "Inicio",
+		"alert.endsAt":         "Fin",
+		"layout.datetime":      "02/01/2006 15:04 MST",
+		"duration.day":         "%d d",
+		"duration.hour":        "%d h",
+		"duration.minute":      "%d min",
+		"duration.second":      "%d s",
+		"digest.title":         "Resumen",
+		"digest.notifications": "Notificaciones",
+		"link.runbook":         "Procedimiento",
+		"link.dashboard":       "Panel",
+		"link.alertmanager":    "Alertmanager",
+		"link.graph":           "Gráfico",
+		"action.acknowledge":   "Reconocer",
+		"action.assign":        "Asignármela",
+		"ack.acknowledgedBy":   "Reconocida por",
+		"ack.assignedTo":       "Asignada a",
+	},
+	"nl": {
+		"alert.title":          "Prometheus-melding",
+		"status.firing":        "Actief",
+		"status.resolved":      "Opgelost",
+		"alert.firingFor":      "Actief sinds",
+		"alert.resolvedAfter":  "Opgelost na",
+		"alert.startsAt":       "Begin",
+		"alert.endsAt":         "Einde",
+		"layout.datetime":      "02-01-2006 15:04 MST",
+		"duration.day":         "%dd",
+		"duration.hour":        "%du",
+		"duration.minute":      "%dm",
+		"duration.second":      "%ds",
+		"digest.title":         "Samenvatting",
+		"digest.notifications": "Meldingen",
+		"link.runbook":         "Runbook",
+		"link.dashboard":       "Dashboard",
+		"link.alertmanager":    "Alertmanager",
+		"link.graph":           "Grafiek",
+		"action.acknowledge":   "Bevestigen",
+		"action.assign":        "Aan mij toewijzen",
+		"ack.acknowledgedBy":   "Bevestigd door",
+		"ack.assignedTo":       "Toegewezen aan",
+	},
+}
+
+// Localizer renders strings, dates and durations for a single locale and
+// timezone. A Localizer is bound to a template when it is parsed, so every
+// connector can render its cards in its own language.
+type Localizer struct {
+	locale   string
+	location *time.Location
+	catalog  Catalog
+	now      func() time.Time
+}
+
+// NewLocalizer creates a Localizer for the given locale and IANA timezone
+// name using the DefaultCatalog. Empty values fall back to DefaultLocale and
+// DefaultTimezone.
+func NewLocalizer(locale, timezone string) (*Localizer, error) {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone '%s': %w", timezone, err)
+	}
+	if !DefaultCatalog.supports(locale) {
+		return nil, fmt.Errorf("unsupported locale '%s'", locale)
+	}
+	return &Localizer{
+		locale:   locale,
+		location: loc,
+		catalog:  DefaultCatalog,
+		now:      time.Now,
+	}, nil
+}
+
+// DefaultLocalizer returns the Localizer for DefaultLocale and DefaultTimezone.
+func DefaultLocalizer() *Localizer {
+	return &Localizer{
+		locale:   DefaultLocale,
+		location: time.UTC,
+		catalog:  DefaultCatalog,
+		now:      time.Now,
+	}
+}
+
+// Locale returns the locale of the Localizer.
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// Location returns the timezone of the Localizer.
+func (l *Localizer) Location() *time.Location {
+	return l.location
+}
+
+// lookup resolves key for locale, falling back from a regional locale
+// ("de-AT") to its language ("de") and finally to DefaultLocale.
+func (c Catalog) lookup(locale, key string) (string, bool) {
+	for _, candidate := range localeCandidates(locale) {
+		if msgs, ok := c[candidate]; ok {
+			if v, ok := msgs[key]; ok {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
+// supports reports whether the catalog has messages for locale or its
+// language, without falling back to DefaultLocale.
+func (c Catalog) supports(locale string) bool {
+	candidates := localeCandidates(locale)
+	for _, candidate := range candidates[:len(candidates)-1] {
+		if _, ok := c[candidate]; ok {
+			return true
+		}
+	}
+	return locale == DefaultLocale
+}
+
+func localeCandidates(locale string) []string {
+	candidates := []string{locale}
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	return append(candidates, DefaultLocale)
+}
+
+// Translate returns the localized message for key. Unknown keys are
+// returned as is, so a missing translation never breaks a card.
+func (l *Localizer) Translate(key string) string {
+	if v, ok := l.catalog.lookup(l.locale, key); ok {
+		return v
+	}
+	return key
+}
+
+// LocalTime converts t to the timezone of the Localizer.
+func (l *Localizer) LocalTime(t time.Time) time.Time {
+	return t.In(l.location)
+}
+
+// FormatDate formats t in the timezone of the Localizer using the
+// locale's "layout.datetime" layout.
+func (l *Localizer) FormatDate(t time.Time) string {
+	return l.LocalTime(t).Format(l.Translate("layout.datetime"))
+}
+
+// FormatDuration renders d using the two most significant units,
+// e.g. "2h 13m" or "3d 4h".
+func (l *Localizer) FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf(l.Translate("duration.second"), 0)
+	}
+	units := []struct {
+		key  string
+		size time.Duration
+	}{
+		{"duration.day", 24 * time.Hour},
+		{"duration.hour", time.Hour},
+		{"duration.minute", time.Minute},
+		{"duration.second", time.Second},
+	}
+	var parts []string
+	for _, u := range units {
+		if len(parts) == 2 {
+			break
+		}
+		n := int64(d / u.size)
+		if n == 0 {
+			// Stop after the most significant unit to avoid "2d 0h 5m".
+			if len(parts) > 0 {
+				break
+			}
+			continue
+		}
+		d -= time.Duration(n) * u.size
+		parts = append(parts, fmt.Sprintf(l.Translate(u.key), n))
+	}
+	return strings.Join(parts, " ")
+}
+
+// RelativeTime renders the time elapsed since t, e.g. "2h 13m".
+func (l *Localizer) RelativeTime(t time.Time) string {
+	return l.FormatDuration(l.now().Sub(t))
+}
+
+// Resources returns an adaptivecards.Resources carrying the given message
+// keys with their default value in the Localizer's locale and their
+// translations in every other locale of the catalog. When no keys are given
+// all keys of DefaultLocale are included.
+func (l *Localizer) Resources(keys ...string) adaptivecards.Resources {
+	if len(keys) == 0 {
+		for k := range l.catalog[DefaultLocale] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+	}
+
+	res := adaptivecards.Resources{}
+	for _, k := range keys {
+		sr := adaptivecards.StringResource{
+			Key:             k,
+			DefaultValue:    l.Translate(k),
+			LocalizedValues: map[string]string{},
+		}
+		for locale, msgs := range l.catalog {
+			if v, ok := msgs[k]; ok {
+				sr.LocalizedValues[locale] = v
+			}
+		}
+		res.StringResources = append(res.StringResources, sr)
+	}
+	return res
+}
+
+// FuncMap returns the localization template functions:
+//   - tr: translate a message key
+//   - localTime: convert a time to the connector timezone
+//   - localDate: format a time with the locale's date layout
+//   - relativeTime: time elapsed since a time, e.g. "2h 13m"
+//   - localDuration: duration between two times, e.g. "45m 10s"
+//   - stringResources: JSON encoded adaptivecards.Resources for message keys
+func (l *Localizer) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"tr":           l.Translate,
+		"localTime":    l.LocalTime,
+		"localDate":    l.FormatDate,
+		"relativeTime": l.RelativeTime,
+		"localDuration": func(start, end time.Time) string {
+			return l.FormatDuration(end.Sub(start))
+		},
+		"stringResources": func(keys ...string) (string, error) {
+			b, err := json.Marshal(l.Resources(keys...))
+			if err != nil {
+				return "", err
+			}
+			return string(b), nil
+		},
+	}
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package card
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLocalizer(t *testing.T) {
+	l, err := NewLocalizer("", "")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLocale, l.Locale())
+	assert.Equal(t, "UTC", l.Location().String())
+
+	_, err = NewLocalizer("de", "Not/AZone")
+	assert.Error(t, err)
+
+	_, err = NewLocalizer("xx", "UTC")
+	assert.Error(t, err)
+
+	// Regional locales fall back to their language.
+	l, err = NewLocalizer("de-AT", "Europe/Vienna")
+	require.NoError(t, err)
+	assert.Equal(t, "Behoben", l.Translate("status.resolved"))
+}
+
+func TestLocalizer_Translate(t *testing.T) {
+	l, err := NewLocalizer("fr", "UTC")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Alerte Prometheus", l.Translate("alert.title"))
+	assert.Equal(t, "unknown.key", l.Translate("unknown.key"))
+}
+
+func TestLocalizer_FormatDate(t *testing.T) {
+	ts := time.Date(2026, 3, 14, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale   string
+		timezone string
+		want     string
+	}{
+		{"en", "UTC", "2026-03-14 23:30 UTC"},
+		{"de", "Europe/Berlin", "15.03.2026 00:30 CET"},
+		{"nl", "Europe/Amsterdam", "15-03-2026 00:30 CET"},
+		{"es", "America/New_York", "14/03/2026 19:30 EDT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			l, err := NewLocalizer(tt.locale, tt.timezone)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, l.FormatDate(ts))
+		})
+	}
+}
+
+func TestLocalizer_FormatDuration(t *testing.T) {
+	en := DefaultLocalizer()
+	de, err := NewLocalizer("de", "UTC")
+	require.NoError(t, err)
+
+	assert.Equal(t, "0s", en.FormatDuration(-time.Minute))
+	assert.Equal(t, "45s", en.FormatDuration(45*time.Second))
+	assert.Equal(t, "2h 13m", en.FormatDuration(2*time.Hour+13*time.Minute+9*time.Second))
+	assert.Equal(t, "3d", en.FormatDuration(3*24*time.Hour+5*time.Minute))
+	assert.Equal(t, "2 Std. 13 Min.", de.FormatDuration(2*time.Hour+13*time.Minute))
+}
+
+func TestLocalizer_RelativeTime(t *testing.T) {
+	now := time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+	l := DefaultLocalizer()
+	l.now = func() time.Time { return now }
+
+	assert.Equal(t, "1h 30m", l.RelativeTime(now.Add(-90*time.Minute)))
+}
+
+func TestLocalizer_Resources(t *testing.T) {
+	l, err := NewLocalizer("de", "UTC")
+	require.NoError(t, err)
+
+	res := l.Resources("status.firing")
+	require.Len(t, res.StringResources, 1)
+	sr := res.StringResources[0]
+	assert.Equal(t, "status.firing", sr.Key)
+	assert.Equal(t, "Ausgelöst", sr.DefaultValue)
+	assert.Equal(t, "Firing", sr.LocalizedValues["en"])
+	assert.Equal(t, "Déclenchée", sr.LocalizedValues["fr"])
+
+	all := l.Resources()
+	assert.Len(t, all.StringResources, len(DefaultCatalog[DefaultLocale]))
+
+	b, err := json.Marshal(res)
+	require.NoError(t, err)
+	var decoded adaptivecards.Resources
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, res, decoded)
+}
+
+func TestParseTemplateFile_WithLocalizer(t *testing.T) {
+	l, err := NewLocalizer("de", "Europe/Berlin")
+	require.NoError(t, err)
+
+	tmpl, err := ParseTemplateFile(testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"), WithLocalizer(l))
+	require.NoError(t, err)
+
+	a, err := testutils.ParseWebhookJSONFromFile(testutils.GetTestDataFilePath("prom_post_request.json"))
+	require.NoError(t, err)
+
+	got, err := NewTemplatedCardCreator(tmpl, false, &utility.Logger{}).Convert(context.Background(), a)
+	require.NoError(t, err)
+	require.Len(t, got.Attachments, 1)
+	require.NotEmpty(t, got.Attachments[0].Content.Body)
+
+	heading, ok := got.Attachments[0].Content.Body[0].(*adaptivecards.TextBlock)
+	require.True(t, ok)
+	assert.Equal(t, "Prometheus-Alarm (Ausgelöst)", *heading.Text)
+
+	// The times of the resolved alerts are localized.
+	a.Status = "resolved"
+	a.Alerts[0].Status = "resolved"
+	a.Alerts[0].EndsAt = a.Alerts[0].StartsAt.Add(45*time.Minute + 10*time.Second)
+	got, err = NewTemplatedCardCreator(tmpl, false, &utility.Logger{}).Convert(context.Background(), a)
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	times := body[len(body)-1].(*adaptivecards.Container).Items[1].(*adaptivecards.TextBlock)
+	assert.Equal(t, "Beginn: 07.03.2018 12:33 CET | Ende: 07.03.2018 13:18 CET | Behoben nach 45 Min. 10 Sek.", *times.Text)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the format of the output of a card template.
+type Format string
+
+const (
+	// FormatJSON is the format of the templates writing the JSON of the
+	// cards, the default.
+	FormatJSON Format = "json"
+	// FormatYAML is the format of the templates writing the cards in YAML.
+	// The output of their actions is not escaped: the templates write the
+	// values with toJson, as JSON strings are YAML double-quoted strings.
+	FormatYAML Format = "yaml"
+)
+
+// ParseFormat returns the Format named s, FormatJSON if s is empty.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatYAML:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown template format %q (json|yaml)", s)
+	}
+}
+
+// OutputError is an output of a card template which is not a valid card.
+type OutputError struct {
+	Format Format
+	// Location is the location, as file:line:column, of the error in the
+	// templates, if known.
+	Location string
+	// Line and Column locate the error in the output, from 1, if known.
+	Line, Column int
+	// Context is the line of the output with the error.
+	Context string
+	Err     error
+}
+
+func (e *OutputError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid %s output", e.Format)
+	if e.Location != "" {
+		fmt.Fprintf(&b, " at %s", e.Location)
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, " (output line %d, column %d: %q)", e.Line, e.Column, e.Context)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *OutputError) Unwrap() error {
+	return e.Err
+}
+
+// decode decodes o, the output of t in format f, into v. The syntax errors
+// are located in the output and in the templates; the type errors have the
+// path of the invalid field instead.
+func (t *Template) decode(o *output, f Format, v any) error {
+	data := []byte(o.text)
+	if f == FormatYAML {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return t.outputError(o, f, yamlOffset(o.text, err), err)
+		}
+		var err error
+		if data, err = json.Marshal(doc); err != nil {
+			return &OutputError{Format: f, Err: err}
+		}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		var serr *json.SyntaxError
+		if f == FormatJSON && errors.As(err, &serr) {
+			// The error occurred after reading Offset bytes.
+			return t.outputError(o, f, int(serr.Offset)-1, err)
+		}
+		return &OutputError{Format: f, Err: err}
+	}
+	return nil
+}
+
+// outputError returns the OutputError err located at offset in o, if it is
+// not negative.
+func (t *Template) outputError(o *output, f Format, offset int, err error) *OutputError {
+	e := &OutputError{Format: f, Err: err}
+	if offset < 0 || offset >= len(o.text) {
+		return e
+	}
+	start := strings.LastIndexByte(o.text[:offset], '\n') + 1
+	end := strings.IndexByte(o.text[offset:], '\n')
+	if end < 0 {
+		end = len(o.text)
+	} else {
+		end += offset
+	}
+	e.Location = t.locate(o, offset)
+	e.Line = strings.Count(o.text[:offset], "\n") + 1
+	e.Column = offset - start + 1
+	e.Context = strings.TrimSpace(o.text[start:end])
+	return e
+}
+
+// yamlLine matches the line of the YAML syntax errors.
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+):`)
+
+// yamlOffset returns the offset in text of the first non-blank byte of the
+// line of the YAML error err, or -1.
+func yamlOffset(text string, err error) int {
+	m := yamlLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return -1
+	}
+	line, _ := strconv.Atoi(m[1])
+	offset := 0
+	for i := 1; i < line; i++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			return -1
+		}
+		offset += next + 1
+	}
+	for offset < len(text) && (text[offset] == ' ' || text[offset] == '\t') {
+		offset++
+	}
+	return offset
+}
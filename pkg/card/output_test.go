@@ -0,0 +1,165 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	for s, want := range map[string]Format{"": FormatJSON, "json": FormatJSON, "YAML": FormatYAML} {
+		f, err := ParseFormat(s)
+		require.NoError(t, err)
+		assert.Equal(t, want, f)
+	}
+	_, err := ParseFormat("toml")
+	assert.Error(t, err)
+}
+
+// yamlCard writes the card in YAML, with the partials in a flow sequence.
+const yamlCard = `{{ define "teams.yaml" }}
+type: message
+attachments:
+  - contentType: application/vnd.microsoft.card.adaptive
+    content:
+      type: AdaptiveCard
+      version: "1.5"
+      body: [
+{{ template "teams.header" . }},
+{{ template "teams.facts" (index .Alerts 0) }}
+      ]
+      actions:
+        - type: Action.OpenUrl
+          title: {{ toJson .CommonLabels.alertname }}
+          url: {{ toJson .ExternalURL }}
+{{ end }}`
+
+func TestTemplatedCard_ConvertYAML(t *testing.T) {
+	dir := t.TempDir()
+	tmpl, err := ParseTemplateFile(writeTemplate(t, dir, "card.tmpl", yamlCard))
+	require.NoError(t, err)
+	a, err := testutils.ParseWebhookJSONFromFile(testutils.GetTestDataFilePath("prom_post_request.json"))
+	require.NoError(t, err)
+
+	c, err := NewTemplatedCardCreator(tmpl, false, &utility.Logger{}, WithEntryTemplate("teams.yaml"), WithFormat(FormatYAML)).Convert(context.Background(), a)
+	require.NoError(t, err)
+	content := c.Attachments[0].Content
+	require.Len(t, content.Body, 4)
+	assert.IsType(t, &adaptivecards.Badge{}, content.Body[1])
+	assert.Equal(t, "Prometheus Test", *content.Body[2].(*adaptivecards.TextBlock).Text)
+	facts := content.Body[3].(*adaptivecards.FactSet).Facts
+	assert.Equal(t, adaptivecards.Fact{Title: "summary", Value: "Server High Memory usage"}, facts[0])
+	require.Len(t, content.Actions, 1)
+	action := content.Actions[0].(*adaptivecards.ActionOpenURL)
+	assert.Equal(t, "high_memory_load", action.Title)
+	assert.Equal(t, "http://docker.for.mac.host.internal:9093", action.URL)
+}
+
+func TestTemplatedCard_ConvertYAMLStrings(t *testing.T) {
+	f := writeTemplate(t, t.TempDir(), "card.tmpl", `{{ define "teams.yaml" }}
+type: message
+attachments:
+  - contentType: application/vnd.microsoft.card.adaptive
+    content:
+      type: AdaptiveCard
+      version: "1.5"
+      body:
+        - type: TextBlock
+          text: 'single "{{ .CommonLabels.job }}"'
+        - type: TextBlock
+          text: plain "{{ .CommonLabels.job }}"
+        - type: TextBlock
+          text: {{ toJson .CommonLabels.job }}
+{{ end }}`)
+	tmpl, err := ParseTemplateFile(f)
+	require.NoError(t, err)
+	m := webhook.Message{Data: &template.Data{CommonLabels: template.KV{"job": `node "exporter"`}}}
+
+	// The output of the actions is not escaped in the YAML strings.
+	c, err := NewTemplatedCardCreator(tmpl, false, &utility.Logger{}, WithEntryTemplate("teams.yaml"), WithFormat(FormatYAML)).Convert(context.Background(), m)
+	require.NoError(t, err)
+	body := c.Attachments[0].Content.Body
+	require.Len(t, body, 3)
+	assert.Equal(t, `single "node "exporter""`, *body[0].(*adaptivecards.TextBlock).Text)
+	assert.Equal(t, `plain "node "exporter""`, *body[1].(*adaptivecards.TextBlock).Text)
+	assert.Equal(t, `node "exporter"`, *body[2].(*adaptivecards.TextBlock).Text)
+}
+
+func TestTemplatedCard_ConvertOutputErrors(t *testing.T) {
+	a, err := testutils.ParseWebhookJSONFromFile(testutils.GetTestDataFilePath("prom_post_request.json"))
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		format   Format
+		text     string
+		location string
+		line     int
+		context  string
+	}{
+		{
+			name:     "json syntax error in a text",
+			format:   FormatJSON,
+			text:     "{{ define \"e\" }}{\n  \"type\": \"message\"\n  \"attachments\": []\n}{{ end }}",
+			location: "card.tmpl:3:2",
+			line:     3,
+			context:  `"attachments": []`,
+		},
+		{
+			name:     "json syntax error in an action",
+			format:   FormatJSON,
+			text:     "{{ define \"e\" }}{\n  \"type\": {{ .Status }}\n}{{ end }}",
+			location: "card.tmpl:2:10",
+			line:     2,
+			context:  `"type": firing`,
+		},
+		{
+			name:     "yaml syntax error",
+			format:   FormatYAML,
+			text:     "{{ define \"e\" }}\ntype: message\nattachments: [\n  - a\n{{ end }}",
+			location: "card.tmpl:3:0",
+			line:     3,
+			context:  "attachments: [",
+		},
+		{
+			name:   "type error",
+			format: FormatYAML,
+			text:   "{{ define \"e\" }}\ntype: message\nattachments: yes\n{{ end }}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplateFile(writeTemplate(t, t.TempDir(), "card.tmpl", tt.text))
+			require.NoError(t, err)
+			_, err = NewTemplatedCardCreator(tmpl, false, &utility.Logger{}, WithEntryTemplate("e"), WithFormat(tt.format)).Convert(context.Background(), a)
+			var oerr *OutputError
+			require.ErrorAs(t, err, &oerr)
+			assert.Equal(t, tt.format, oerr.Format)
+			assert.Equal(t, tt.location, oerr.Location)
+			assert.Equal(t, tt.line, oerr.Line)
+			assert.Equal(t, tt.context, oerr.Context)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+)
+
+// SampleMessage returns a webhook message holding one firing alert with the
+// labels and annotations commonly used by templates. It is rendered by the
+// readiness check to validate the templates before alerts arrive.
+func SampleMessage() webhook.Message {
+	labels := template.KV{
+		"alertname": "SampleAlert",
+		"instance":  "localhost:9090",
+		"job":       "prometheus",
+		"severity":  "warning",
+	}
+	annotations := template.KV{
+		"summary":     "Sample alert",
+		"description": "Sample alert rendered by prometheus-msteams to check its templates.",
+		"runbook_url": "https://example.com/runbooks/sample-alert",
+	}
+	return webhook.Message{
+		Data: &template.Data{
+			Receiver: "prometheus-msteams",
+			Status:   "firing",
+			Alerts: template.Alerts{{
+				Status:       "firing",
+				Labels:       labels,
+				Annotations:  annotations,
+				StartsAt:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+				GeneratorURL: "http://localhost:9090/graph",
+				Fingerprint:  "0123456789abcdef",
+			}},
+			GroupLabels:       template.KV{"alertname": "SampleAlert"},
+			CommonLabels:      labels,
+			CommonAnnotations: annotations,
+			ExternalURL:       "http://localhost:9093",
+		},
+		Version:  "4",
+		GroupKey: `{}:{alertname="SampleAlert"}`,
+	}
+}
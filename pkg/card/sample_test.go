@@ -0,0 +1,46 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampleMessage(t *testing.T) {
+	for _, f := range []string{
+		testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"),
+		"../../default-message-workflow-card.tmpl",
+		"../../chart/prometheus-msteams/data/cardWorkflow.tmpl",
+	} {
+		t.Run(filepath.Base(filepath.Dir(f))+"/"+filepath.Base(f), func(t *testing.T) {
+			tmpl, err := ParseTemplateFile(f)
+			require.NoError(t, err)
+			c := NewTemplatedCardCreator(tmpl, true, utility.NewLogger(utility.LogFormatFmt, false))
+
+			got, err := c.Convert(context.Background(), SampleMessage())
+			require.NoError(t, err)
+			assert.NotEmpty(t, got.Attachments)
+		})
+	}
+}
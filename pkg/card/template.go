@@ -0,0 +1,318 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	tmpltext "text/template"
+	"text/template/parse"
+	"time"
+)
+
+const (
+	// DefaultMaxOutput is the maximum size in bytes of the output of a
+	// template unless another one is passed with WithMaxOutput. Teams
+	// rejects cards far smaller than this.
+	DefaultMaxOutput = 1 << 20
+	// DefaultTimeout is the maximum duration of the execution of a template
+	// unless another one is passed with WithTimeout.
+	DefaultTimeout = 5 * time.Second
+)
+
+var (
+	// ErrOutputTooLarge is returned when the output of a template exceeds
+	// its maximum size.
+	ErrOutputTooLarge = errors.New("template output too large")
+	// ErrTimeout is returned when the execution of a template exceeds its
+	// timeout.
+	ErrTimeout = errors.New("template execution timed out")
+)
+
+// actionStart and actionEnd are written by the templates around the output
+// of their actions, see markNodes, so that it is escaped when it is
+// written in a JSON string of a FormatJSON template. textMark, followed by the index of a text and
+// a zero byte, is written before the texts so that they can be located.
+var (
+	actionStart = []byte("\x00prometheus-msteams:action\x00")
+	actionEnd   = []byte("\x00prometheus-msteams:end\x00")
+	textMark    = []byte("\x00prometheus-msteams:text:")
+)
+
+// Template is a parsed card template with the limits of its execution. It is
+// safe for concurrent use.
+type Template struct {
+	text *tmpltext.Template
+	// texts are the texts of the templates by their index in textMark.
+	texts     []textNode
+	maxOutput int
+	timeout   time.Duration
+}
+
+// Execute executes the template named name with data and returns its
+// output as a FormatJSON template: the output of the actions written in a
+// double-quoted string is escaped as a JSON string, the template functions
+// getting the values as is. The actions already escaping their output, with
+// toJson or js, are left as they are. It returns ErrOutputTooLarge once the output exceeds the maximum
+// size, and ErrTimeout when the execution exceeds the timeout.
+//
+// text/template cannot interrupt the execution of a template: after a
+// timeout, it goes on in the background until it writes its next output,
+// which fails, or ends. A function call, or a range writing nothing, runs
+// to its end. The sequences of until, untilStep and seq are capped to
+// MaxSequence items to bound such ranges, but not their nesting.
+func (t *Template) Execute(ctx context.Context, name string, data any) (string, error) {
+	o, err := t.execute(ctx, name, data, FormatJSON)
+	if err != nil {
+		return "", err
+	}
+	return o.text, nil
+}
+
+// output is the output of a template with the writes producing it.
+type output struct {
+	text   string
+	writes []write
+}
+
+// write is a write of n bytes of the output at offset. text is the index
+// of the text of the templates written, or -1 for the output of an action.
+type write struct {
+	offset, n int
+	text      int
+}
+
+// execute executes the template named name with data. The output of the
+// actions is escaped in the JSON strings when f is FormatJSON only, as
+// the strings of the other formats cannot be told apart as reliably.
+func (t *Template) execute(ctx context.Context, name string, data any, f Format) (*output, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	w := &limitedWriter{ctx: ctx, max: t.maxOutput, text: -1, escape: f == FormatJSON}
+	done := make(chan error, 1)
+	go func() {
+		done <- t.text.ExecuteTemplate(w, name, data)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, t.wrap(err)
+		}
+		return &output{text: w.buf.String(), writes: w.writes}, nil
+	case <-ctx.Done():
+		return nil, t.wrap(ctx.Err())
+	}
+}
+
+// locate returns the location in the templates, as file:line:column, of the
+// byte of o at offset, or "" if it is unknown. The texts of the templates
+// are located exactly, and the output of an action at the end of the text
+// preceding it.
+func (t *Template) locate(o *output, offset int) string {
+	loc := ""
+	for _, w := range o.writes {
+		if w.offset > offset {
+			break
+		}
+		if w.text < 0 || w.text >= len(t.texts) {
+			continue
+		}
+		text := t.texts[w.text]
+		if offset < w.offset+w.n {
+			return text.at(offset - w.offset)
+		}
+		loc = text.at(w.n)
+	}
+	return loc
+}
+
+// textNode is a text of a template with the tree it belongs to.
+type textNode struct {
+	tree *parse.Tree
+	node *parse.TextNode
+}
+
+// at returns the location of the byte of the text at i.
+func (n textNode) at(i int) string {
+	loc, _ := n.tree.ErrorContext(&parse.TextNode{NodeType: parse.NodeText, Pos: n.node.Pos + parse.Pos(i)})
+	return loc
+}
+
+// wrap returns ErrTimeout with the timeout for the deadline errors, and err
+// otherwise.
+func (t *Template) wrap(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s", ErrTimeout, t.timeout)
+	}
+	return err
+}
+
+// markNodes wraps the actions printing a value in the templates of t
+// between actionStart and actionEnd, but those escaping it already, and
+// precedes their texts with textMark and their index in the returned texts.
+func markNodes(t *tmpltext.Template) []textNode {
+	var texts []textNode
+	seen := map[*parse.ListNode]bool{}
+	var mark func(tree *parse.Tree, l *parse.ListNode)
+	mark = func(tree *parse.Tree, l *parse.ListNode) {
+		if l == nil || seen[l] {
+			return
+		}
+		seen[l] = true
+		nodes := make([]parse.Node, 0, len(l.Nodes))
+		for _, n := range l.Nodes {
+			switch n := n.(type) {
+			case *parse.ActionNode:
+				if len(n.Pipe.Decl) > 0 || escapes(n.Pipe) {
+					break
+				}
+				nodes = append(nodes, &parse.TextNode{NodeType: parse.NodeText, Pos: n.Pos, Text: actionStart}, n,
+					&parse.TextNode{NodeType: parse.NodeText, Pos: n.Pos, Text: actionEnd})
+				continue
+			case *parse.TextNode:
+				if len(n.Text) == 0 {
+					break
+				}
+				m := fmt.Appendf(slices.Clone(textMark), "%d\x00", len(texts))
+				texts = append(texts, textNode{tree: tree, node: n})
+				nodes = append(nodes, &parse.TextNode{NodeType: parse.NodeText, Pos: n.Pos, Text: m})
+			case *parse.IfNode:
+				mark(tree, n.List)
+				mark(tree, n.ElseList)
+			case *parse.RangeNode:
+				mark(tree, n.List)
+				mark(tree, n.ElseList)
+			case *parse.WithNode:
+				mark(tree, n.List)
+				mark(tree, n.ElseList)
+			}
+			nodes = append(nodes, n)
+		}
+		l.Nodes = nodes
+	}
+	for _, tmpl := range t.Templates() {
+		if tmpl.Tree != nil {
+			mark(tmpl.Tree, tmpl.Tree.Root)
+		}
+	}
+	return texts
+}
+
+// escapingFuncs are the template functions escaping their output for JSON
+// or JavaScript strings.
+var escapingFuncs = map[string]bool{"toJson": true, "toRawJson": true, "toPrettyJson": true, "js": true}
+
+// escapes reports whether a command of pipe calls one of escapingFuncs, such
+// as {{ .X | toJson }}, so that its output must not be escaped again.
+func escapes(pipe *parse.PipeNode) bool {
+	for _, cmd := range pipe.Cmds {
+		if len(cmd.Args) == 0 {
+			continue
+		}
+		if id, ok := cmd.Args[0].(*parse.IdentifierNode); ok && escapingFuncs[id.Ident] {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedWriter buffers the output of a template, and records its writes,
+// until it exceeds max bytes or ctx is done. With escape, it escapes the
+// output of the actions written in a JSON string.
+type limitedWriter struct {
+	ctx    context.Context
+	max    int
+	buf    bytes.Buffer
+	writes []write
+	// action buffers the output of the current action, nil outside one.
+	action []byte
+	// text is the index of the text written next, -1 if unknown.
+	text   int
+	escape bool
+	// inString and escaped are the state of the output as JSON: in a
+	// string, and after a backslash in it.
+	inString, escaped bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	switch {
+	case bytes.Equal(p, actionStart):
+		w.action = []byte{}
+		return len(p), nil
+	case bytes.Equal(p, actionEnd):
+		out := w.action
+		w.action = nil
+		if w.escape && w.inString {
+			out = []byte(jsonEscape(string(out)))
+		}
+		return len(p), w.write(out, -1)
+	case w.action != nil:
+		w.action = append(w.action, p...)
+		if len(w.action) > w.max {
+			return 0, fmt.Errorf("%w: more than %d bytes", ErrOutputTooLarge, w.max)
+		}
+		return len(p), nil
+	}
+	if i, ok := textIndex(p); ok {
+		w.text = i
+		return len(p), nil
+	}
+	text := w.text
+	w.text = -1
+	return len(p), w.write(p, text)
+}
+
+// textIndex returns the index of the text if p is a text mark.
+func textIndex(p []byte) (int, bool) {
+	rest, ok := bytes.CutPrefix(p, textMark)
+	if !ok || len(rest) < 2 || rest[len(rest)-1] != 0 {
+		return 0, false
+	}
+	i, err := strconv.Atoi(string(rest[:len(rest)-1]))
+	return i, err == nil
+}
+
+func (w *limitedWriter) write(p []byte, text int) error {
+	if w.buf.Len()+len(p) > w.max {
+		return fmt.Errorf("%w: more than %d bytes", ErrOutputTooLarge, w.max)
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	w.writes = append(w.writes, write{offset: w.buf.Len(), n: len(p), text: text})
+	for _, b := range p {
+		switch {
+		case w.escaped:
+			w.escaped = false
+		case w.inString && b == '\\':
+			w.escaped = true
+		case b == '"':
+			w.inString = !w.inString
+		}
+	}
+	w.buf.Write(p)
+	return nil
+}
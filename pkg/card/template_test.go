@@ -0,0 +1,139 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blocking is template data whose Wait method blocks until release is
+// closed.
+type blocking struct {
+	release chan struct{}
+}
+
+func (b blocking) Wait() string {
+	<-b.release
+	return ""
+}
+
+func TestTemplate_Execute(t *testing.T) {
+	dir := t.TempDir()
+	f := writeTemplate(t, dir, "limits.tmpl", `
+{{ define "small" }}{{ . }}{{ end }}
+{{ define "large" }}{{ range until 100 }}0123456789{{ end }}{{ end }}
+{{ define "slow" }}{{ .Wait }}{{ end }}`)
+
+	tmpl, err := ParseTemplateFile(f, WithMaxOutput(500), WithTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+
+	out, err := tmpl.Execute(context.Background(), "small", "ok")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out)
+
+	_, err = tmpl.Execute(context.Background(), "large", nil)
+	assert.ErrorIs(t, err, ErrOutputTooLarge)
+
+	b := blocking{release: make(chan struct{})}
+	defer close(b.release)
+	_, err = tmpl.Execute(context.Background(), "slow", b)
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	// The output fails once the execution is done, stopping it at its next
+	// write.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = (&limitedWriter{ctx: ctx, max: 500}).Write([]byte("a"))
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The defaults accept the large output.
+	tmpl, err = ParseTemplateFile(f)
+	require.NoError(t, err)
+	out, err = tmpl.Execute(context.Background(), "large", nil)
+	require.NoError(t, err)
+	assert.Len(t, out, 1000)
+}
+
+func TestTemplate_Locate(t *testing.T) {
+	dir := t.TempDir()
+	f := writeTemplate(t, dir, "locate.tmpl", `{{ define "e" }}[{{ template "x" }},
+{{ "x" }}, {{ template "x" }}]{{ end }}
+{{ define "x" }}"x"{{ end }}`)
+	tmpl, err := ParseTemplateFile(f)
+	require.NoError(t, err)
+
+	o, err := tmpl.execute(context.Background(), "e", nil, FormatJSON)
+	require.NoError(t, err)
+	require.Equal(t, "[\"x\",\nx, \"x\"]", o.text)
+
+	// The texts are located by the parse tree, the output of the actions at
+	// the end of the text preceding them.
+	assert.Equal(t, "locate.tmpl:1:16", tmpl.locate(o, 0))
+	assert.Equal(t, "locate.tmpl:3:17", tmpl.locate(o, 2))
+	assert.Equal(t, "locate.tmpl:2:0", tmpl.locate(o, 6))
+	assert.Equal(t, "locate.tmpl:2:10", tmpl.locate(o, 8))
+	assert.Equal(t, "locate.tmpl:3:17", tmpl.locate(o, 10))
+}
+
+func TestParseTemplateFile_Sandboxed(t *testing.T) {
+	defaults := len(template.DefaultFuncs)
+	dir := t.TempDir()
+
+	_, err := ParseTemplateFile(writeTemplate(t, dir, "yaml.tmpl", `{{ toYaml . }}`))
+	require.NoError(t, err)
+	assert.Len(t, template.DefaultFuncs, defaults, "the global functions are untouched")
+
+	for _, fn := range []string{"env", "expandenv", "getHostByName", "include", "tpl", "required"} {
+		_, err := ParseTemplateFile(writeTemplate(t, dir, fn+".tmpl", `{{ `+fn+` "x" }}`))
+		assert.ErrorContains(t, err, `function "`+fn+`" not defined`, fn)
+	}
+
+	// Templates parsed with different options do not share functions.
+	theme := DefaultTheme
+	theme.Resolved.Color = adaptivecards.ColorWarning
+	f := writeTemplate(t, dir, "color.tmpl", `{{ define "color" }}{{ severityColor "resolved" "" }}{{ end }}`)
+	themed, err := ParseTemplateFile(f, WithTheme(theme))
+	require.NoError(t, err)
+	plain, err := ParseTemplateFile(f)
+	require.NoError(t, err)
+	out, err := themed.Execute(context.Background(), "color", nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(adaptivecards.ColorWarning), strings.TrimSpace(out))
+	out, err = plain.Execute(context.Background(), "color", nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(DefaultTheme.Resolved.Color), strings.TrimSpace(out))
+}
+
+func TestParseTemplateFile_RequiredTemplate(t *testing.T) {
+	dir := t.TempDir()
+	f := writeTemplate(t, dir, "card.tmpl", `{{ define "my.card" }}{"type": "message"}{{ end }}`)
+
+	_, err := ParseTemplateFile(f, WithRequiredTemplate("my.card"))
+	require.NoError(t, err)
+	_, err = ParseTemplateFile(f, WithRequiredTemplate("other.card"))
+	assert.ErrorContains(t, err, `template "other.card" is not defined`)
+	_, err = ParseTemplateFile(f, WithRequiredTemplate(""))
+	assert.ErrorContains(t, err, `template "teams.card" is not defined`)
+}
@@ -19,151 +19,334 @@ limitations under the License.
 package card
 
 import (
-	"bytes"
+	"cmp"
 	"context"
-	"encoding/json"
+	"embed"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	tmpltext "text/template"
+	"time"
 
+	"github.com/prometheus/alertmanager/asset"
 	"github.com/prometheus/alertmanager/notify/webhook"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
 	"github.com/stakater/prometheus-msteams/pkg/utility"
-	"go.opencensus.io/trace"
-	"k8s.io/helm/pkg/engine"
+	"go.opentelemetry.io/otel"
 )
 
+// tracerName is the instrumentation scope of the spans started by this package.
+const tracerName = "github.com/stakater/prometheus-msteams/pkg/card"
+
+// DefaultEntryTemplate is the template rendering the cards unless another
+// one is passed with WithEntryTemplate.
+const DefaultEntryTemplate = "teams.card"
+
 // templatedCard implements Converter using Alert manager templating.
 type templatedCard struct {
 	logger   *utility.Logger
-	template *template.Template
-	// If true, replace all character `_` with `\\_` in the prometheus alert.
+	template *Template
+	// If true, escape the DefaultMarkdownChars in the texts of the cards.
 	escapeUnderscores bool
+	// entry is the name of the template rendering the cards.
+	entry string
+	// format is the format of the output of the template.
+	format Format
+}
+
+// TemplatedCardOption configures the Converter of NewTemplatedCardCreator.
+type TemplatedCardOption func(*templatedCard)
+
+// WithEntryTemplate renders the cards with the template named name instead
+// of DefaultEntryTemplate.
+func WithEntryTemplate(name string) TemplatedCardOption {
+	return func(m *templatedCard) {
+		if name != "" {
+			m.entry = name
+		}
+	}
+}
+
+// WithFormat decodes the output of the template in format f instead of
+// FormatJSON.
+func WithFormat(f Format) TemplatedCardOption {
+	return func(m *templatedCard) {
+		if f != "" {
+			m.format = f
+		}
+	}
 }
 
 // NewTemplatedCardCreator creates a templatedCard.
-func NewTemplatedCardCreator(template *template.Template, escapeUnderscores bool, logger *utility.Logger) Converter {
-	return &templatedCard{
+func NewTemplatedCardCreator(template *Template, escapeUnderscores bool, logger *utility.Logger, opts ...TemplatedCardOption) Converter {
+	m := &templatedCard{
 		logger:            logger.WithPrefix("package", "card", "component", "templatedCard"),
 		template:          template,
 		escapeUnderscores: escapeUnderscores,
+		entry:             DefaultEntryTemplate,
+		format:            FormatJSON,
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
 }
 
 func (m *templatedCard) Convert(ctx context.Context, promAlert webhook.Message) (adaptivecards.WorkflowConnectorCard, error) {
-	_, span := trace.StartSpan(ctx, "templatedCard.Convert")
+	_, span := otel.Tracer(tracerName).Start(ctx, "templatedCard.Convert")
 	defer span.End()
 
-	cardString, err := m.executeTemplate(promAlert)
+	out, err := m.executeTemplate(ctx, promAlert)
 	if err != nil {
 		return adaptivecards.WorkflowConnectorCard{}, err
 	}
 
 	var card adaptivecards.WorkflowConnectorCard
-	if err := json.Unmarshal([]byte(cardString), &card); err != nil {
+	if err := m.template.decode(out, m.format, &card); err != nil {
 		return adaptivecards.WorkflowConnectorCard{}, err
 	}
 
 	if card.Type != "message" {
 		return adaptivecards.WorkflowConnectorCard{}, errors.New("only message type is supported")
 	}
+	if m.escapeUnderscores {
+		EscapeMarkdown(&card, DefaultMarkdownChars)
+	}
 
 	return card, nil
 }
 
-func (m *templatedCard) executeTemplate(promAlert webhook.Message) (string, error) {
-	// TODO(bzon): Maybe we can escape underscores after the office 365 card is finally created?
-	// That approach would be simpler to read and probably a performance gain because
-	// we don't have to run json.NewEncoder(v).Encode() multiple times.
-	if m.escapeUnderscores {
-		promAlert = jsonEscapeMessage(promAlert)
-	}
+// cardData is the data of the card templates: the Alertmanager template data
+// with the extras of the alerts, the extras of the notification and its
+// Digest, if any.
+type cardData struct {
+	*template.Data
+	Alerts  Alerts
+	Title   string
+	Message string
+	Digest  *Digest
+}
 
-	data := &template.Data{
-		Receiver:          promAlert.Receiver,
-		Status:            promAlert.Status,
-		Alerts:            promAlert.Alerts,
-		GroupLabels:       promAlert.GroupLabels,
-		CommonLabels:      promAlert.CommonLabels,
-		CommonAnnotations: promAlert.CommonAnnotations,
-		ExternalURL:       promAlert.ExternalURL,
+func (m *templatedCard) executeTemplate(ctx context.Context, promAlert webhook.Message) (*output, error) {
+	data := cardData{
+		Data: &template.Data{
+			Receiver:          promAlert.Receiver,
+			Status:            promAlert.Status,
+			Alerts:            promAlert.Alerts,
+			GroupLabels:       promAlert.GroupLabels,
+			CommonLabels:      promAlert.CommonLabels,
+			CommonAnnotations: promAlert.CommonAnnotations,
+			ExternalURL:       promAlert.ExternalURL,
+		},
+		Digest: DigestFromContext(ctx),
 	}
-
-	cardString, err := m.template.ExecuteTextString(
-		`{{ template "teams.card" . }}`, data,
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to template alerts: %w", err)
+	extras := ExtrasFromContext(ctx)
+	data.Alerts = withExtras(promAlert.Alerts, extras)
+	if extras != nil {
+		data.Title = extras.Title
+		data.Message = extras.Message
 	}
 
-	return cardString, nil
-}
-
-func jsonEncode(str string) string {
-	var buf bytes.Buffer
-	enc := json.NewEncoder(&buf)
-	err := enc.Encode(str)
+	out, err := m.template.execute(ctx, m.entry, data, m.format)
 	if err != nil {
-		return ""
-	}
-	return string(buf.Bytes()[1 : len(buf.Bytes())-2])
-}
-
-// json escape all string values in kvData and also escape
-// '_' char so it does not get processed as markdown italic
-func jsonEncodeAlertmanagerKV(kvData template.KV) {
-	for k, v := range kvData {
-		kvData[k] = strings.ReplaceAll(jsonEncode(v), `_`, `\\_`)
+		return nil, fmt.Errorf("failed to template alerts: %w", err)
 	}
-}
 
-func jsonEscapeMessage(promAlert webhook.Message) webhook.Message {
-	retPromAlert := promAlert
-	jsonEncodeAlertmanagerKV(retPromAlert.GroupLabels)
-	jsonEncodeAlertmanagerKV(retPromAlert.CommonLabels)
-	jsonEncodeAlertmanagerKV(retPromAlert.CommonAnnotations)
-	for _, alert := range retPromAlert.Alerts {
-		jsonEncodeAlertmanagerKV(alert.Labels)
-		jsonEncodeAlertmanagerKV(alert.Annotations)
-	}
-	return retPromAlert
+	return out, nil
 }
 
 /*
-	ParseTemplateFile creates an alertmanager template from the given file.
+	ParseTemplateFile creates a card template from the given file.
+
+The template can use the built-in partials teams.header, teams.facts and
+teams.actions, see partials/teams.tmpl, the templates of Alertmanager, and
+the templates of the directories passed with WithTemplateDirs. The file
+redefines the templates of the directories, which redefine the partials. The
+file may be empty when a directory defines the entry template.
 
-The functions include all functions (except 'env' and 'expandenv' ) from sprig (http://masterminds.github.io/sprig/)
-and the following functions from HELM templating:
+The functions are those of FuncMap, built for every template: ParseTemplateFile
+does not change any global state, so templates can be parsed concurrently
+with different options. They include all functions from slim-sprig
+(https://go-task.github.io/slim-sprig/) except 'env', 'expandenv' and
+'getHostByName', and the following functions:
   - toToml
   - toYaml
   - fromYaml
   - toJson
   - fromJson
+  - counter
+
+toToml and toYaml encode structs with their JSON names, like Helm. until,
+untilStep and seq fail beyond MaxSequence items, and repeat beyond
+DefaultMaxOutput bytes.
+
+Cards are rendered in English and UTC unless a Localizer is passed with
+WithLocalizer, and with DefaultTheme unless a Theme is passed with WithTheme.
+See Localizer.FuncMap and Theme.FuncMap for their functions.
+
+The execution of the template fails once its output exceeds
+DefaultMaxOutput bytes or it runs longer than DefaultTimeout, see
+WithMaxOutput and WithTimeout.
 */
-func ParseTemplateFile(f string) (*template.Template, error) {
-	funcs := template.DefaultFuncs
-	for k, v := range engine.FuncMap() {
-		funcs[k] = v
-	}
-	funcs["counter"] = func() func() int {
-		i := -1
-		return func() int {
-			i++
-			return i
+func ParseTemplateFile(f string, opts ...TemplateOption) (*Template, error) {
+	o := templateOptions{
+		localizer: DefaultLocalizer(),
+		theme:     DefaultTheme,
+		maxOutput: DefaultMaxOutput,
+		timeout:   DefaultTimeout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	globs := make([]string, 0, len(o.dirs)+1)
+	for _, d := range o.dirs {
+		fi, err := os.Stat(d)
+		switch {
+		case err == nil && fi.IsDir():
+			d = filepath.Join(d, "*.tmpl")
+		case os.IsNotExist(err) && !strings.ContainsAny(d, `*?[\`):
+			return nil, fmt.Errorf("template dir %s does not exist", d)
 		}
+		globs = append(globs, d)
 	}
-	template.DefaultFuncs = funcs
+	if f != "" {
+		if _, err := os.Stat(f); os.IsNotExist(err) {
+			return nil, fmt.Errorf("template file %s does not exist", f)
+		}
+		globs = append(globs, f)
+	}
+
+	funcs := FuncMap()
+	maps.Copy(funcs, tmpltext.FuncMap(o.localizer.FuncMap()))
+	maps.Copy(funcs, tmpltext.FuncMap(o.theme.FuncMap()))
+	text := tmpltext.New("").Option("missingkey=zero").Funcs(funcs)
 
-	if _, err := os.Stat(f); os.IsNotExist(err) {
-		return nil, fmt.Errorf("template file %s does not exist", f)
+	if err := parseFS(text, asset.Assets, "/templates/default.tmpl", "/templates/email.tmpl"); err != nil {
+		return nil, fmt.Errorf("failed to parse the Alertmanager templates: %w", err)
 	}
+	if err := parsePartials(text); err != nil {
+		return nil, fmt.Errorf("failed to parse the partials: %w", err)
+	}
+	for _, g := range globs {
+		if _, err := text.ParseGlob(g); err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+	}
+
+	if o.required != "" {
+		if t := text.Lookup(o.required); t == nil || t.Tree == nil {
+			return nil, fmt.Errorf("template %q is not defined", o.required)
+		}
+	}
+
+	texts := markNodes(text)
+	return &Template{text: text, texts: texts, maxOutput: o.maxOutput, timeout: o.timeout}, nil
+}
+
+//go:embed partials/*.tmpl
+var partials embed.FS
 
-	tmpl, err := template.FromGlobs([]string{f})
+// parsePartials parses the built-in partials into t.
+func parsePartials(t *tmpltext.Template) error {
+	files, err := fs.Glob(partials, "partials/*.tmpl")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse template: %v: %v", err, err)
+		return err
 	}
+	return parseFS(t, http.FS(partials), files...)
+}
 
-	return tmpl, nil
+// parseFS parses the files of fsys into t.
+func parseFS(t *tmpltext.Template, fsys http.FileSystem, files ...string) error {
+	for _, name := range files {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		b, err := io.ReadAll(f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := t.New(name).Parse(string(b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TemplateOption configures how ParseTemplateFile parses a template.
+type TemplateOption func(*templateOptions)
+
+type templateOptions struct {
+	localizer *Localizer
+	theme     Theme
+	dirs      []string
+	maxOutput int
+	timeout   time.Duration
+	required  string
+}
+
+// WithRequiredTemplate makes ParseTemplateFile fail unless the template
+// named name, DefaultEntryTemplate if empty, is defined, so that a missing
+// entry template is reported before any card is rendered.
+func WithRequiredTemplate(name string) TemplateOption {
+	return func(o *templateOptions) {
+		o.required = cmp.Or(name, DefaultEntryTemplate)
+	}
+}
+
+// WithLocalizer binds the localization template functions to l.
+func WithLocalizer(l *Localizer) TemplateOption {
+	return func(o *templateOptions) {
+		if l != nil {
+			o.localizer = l
+		}
+	}
+}
+
+// WithTheme binds the theme template functions to t instead of
+// DefaultTheme.
+func WithTheme(t Theme) TemplateOption {
+	return func(o *templateOptions) {
+		o.theme = t
+	}
+}
+
+// WithTemplateDirs parses the templates of dirs before the template file so
+// that it can use them. A directory provides its *.tmpl files, and the
+// other entries are globs.
+func WithTemplateDirs(dirs ...string) TemplateOption {
+	return func(o *templateOptions) {
+		o.dirs = append(o.dirs, dirs...)
+	}
+}
+
+// WithMaxOutput limits the output of the template to n bytes instead of
+// DefaultMaxOutput. It is ignored when n is not positive.
+func WithMaxOutput(n int) TemplateOption {
+	return func(o *templateOptions) {
+		if n > 0 {
+			o.maxOutput = n
+		}
+	}
+}
+
+// WithTimeout limits the execution of the template to d instead of
+// DefaultTimeout. It is ignored when d is not positive.
+func WithTimeout(d time.Duration) TemplateOption {
+	return func(o *templateOptions) {
+		if d > 0 {
+			o.timeout = d
+		}
+	}
 }
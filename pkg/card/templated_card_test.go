@@ -17,7 +17,10 @@ package card
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
 	"github.com/stakater/prometheus-msteams/pkg/testutils"
@@ -50,39 +53,50 @@ func Test_templatedCard_Convert(t *testing.T) {
 							Body: []adaptivecards.Element{
 								&adaptivecards.TextBlock{
 									Text:   adaptivecards.AsPtr("Prometheus Alert (Firing)"),
-									Style:  adaptivecards.AsPtr(adaptivecards.TextBlockStyleHeading),
-									Size:   adaptivecards.AsPtr(adaptivecards.FontSize("medium")),
-									Weight: adaptivecards.AsPtr(adaptivecards.FontWeight("bolder")),
-									Color:  adaptivecards.AsPtr(adaptivecards.ColorNone),
+									Style:  adaptivecards.AsPtr(adaptivecards.TextBlockStyle("Heading")),
+									Size:   adaptivecards.AsPtr(adaptivecards.FontSize("Medium")),
+									Weight: adaptivecards.AsPtr(adaptivecards.FontWeight("Bolder")),
+									Color:  adaptivecards.AsPtr(adaptivecards.ColorWarning),
 								},
-								&adaptivecards.TextBlock{
-									Text: adaptivecards.AsPtr("Prometheus Test"),
-									Wrap: true,
+								&adaptivecards.Badge{
+									Text:       adaptivecards.AsPtr("Firing - warning"),
+									Icon:       adaptivecards.AsPtr(adaptivecards.SymbolWarning),
+									Style:      adaptivecards.AsPtr(adaptivecards.ColorWarning),
+									Appearance: adaptivecards.AsPtr(adaptivecards.AppearanceTint),
 								},
 								&adaptivecards.TextBlock{
-									Text: adaptivecards.AsPtr("[10.80.40.11 reported high memory usage with 23.28%.](http://docker.for.mac.host.internal:9093)"),
+									Text: adaptivecards.AsPtr("Prometheus Test"),
 									Wrap: true,
 								},
-								&adaptivecards.FactSet{
-									Facts: []adaptivecards.Fact{
-										{Title: "", Value: ""},
-										{Title: "summary", Value: "Server High Memory usage"},
-										{Title: "alertname", Value: "high_memory_load"},
-										{Title: "instance", Value: "instance-with-hyphen_and_underscore"},
-										{Title: "job", Value: "docker_nodes"},
-										{Title: "monitor", Value: "master"},
-										{Title: "severity", Value: "warning"},
+								&adaptivecards.Container{
+									Style: adaptivecards.AsPtr(adaptivecards.ContainerStyleWarning),
+									Items: []adaptivecards.Element{
+										&adaptivecards.TextBlock{
+											Text: adaptivecards.AsPtr("[10.80.40.11 reported high memory usage with 23.28%.](http://docker.for.mac.host.internal:9093)"),
+											Wrap: true,
+										},
+										&adaptivecards.TextBlock{
+											Text:     adaptivecards.AsPtr("Started: 2018-03-07 11:33 UTC | Firing for 2h 13m"),
+											IsSubtle: adaptivecards.AsPtr(true),
+											Wrap:     true,
+										},
+										&adaptivecards.FactSet{
+											Facts: []adaptivecards.Fact{
+												{Title: "summary", Value: "Server High Memory usage"},
+												{Title: "alertname", Value: "high_memory_load"},
+												{Title: "instance", Value: "instance-with-hyphen_and_underscore"},
+												{Title: "job", Value: "docker_nodes"},
+												{Title: "monitor", Value: "master"},
+												{Title: "severity", Value: "warning"},
+											},
+										},
 									},
 								},
 							},
 							MsTeams: &adaptivecards.TeamsCardProperties{
 								Width: adaptivecards.AsPtr(adaptivecards.TeamsCardWidthFull),
 							},
-							Version: "1.2",
-							BackgroundImage: &adaptivecards.BackgroundImage{
-								URL:      "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAAECAIAAADAusJtAAAAEklEQVR42mL4v5QBGQMCAAD//ziMBpHr7M3UAAAAAElFTkSuQmCC",
-								FillMode: adaptivecards.AsPtr(adaptivecards.ImageFillModeRepeatHorizontally),
-							},
+							Version: "1.5",
 						},
 					},
 				},
@@ -103,39 +117,50 @@ func Test_templatedCard_Convert(t *testing.T) {
 							Body: []adaptivecards.Element{
 								&adaptivecards.TextBlock{
 									Text:   adaptivecards.AsPtr("Prometheus Alert (Firing)"),
-									Style:  adaptivecards.AsPtr(adaptivecards.TextBlockStyleHeading),
-									Size:   adaptivecards.AsPtr(adaptivecards.FontSize("medium")),
-									Weight: adaptivecards.AsPtr(adaptivecards.FontWeight("bolder")),
-									Color:  adaptivecards.AsPtr(adaptivecards.ColorNone),
+									Style:  adaptivecards.AsPtr(adaptivecards.TextBlockStyle("Heading")),
+									Size:   adaptivecards.AsPtr(adaptivecards.FontSize("Medium")),
+									Weight: adaptivecards.AsPtr(adaptivecards.FontWeight("Bolder")),
+									Color:  adaptivecards.AsPtr(adaptivecards.ColorWarning),
 								},
-								&adaptivecards.TextBlock{
-									Text: adaptivecards.AsPtr("Prometheus Test"),
-									Wrap: true,
+								&adaptivecards.Badge{
+									Text:       adaptivecards.AsPtr("Firing - warning"),
+									Icon:       adaptivecards.AsPtr(adaptivecards.SymbolWarning),
+									Style:      adaptivecards.AsPtr(adaptivecards.ColorWarning),
+									Appearance: adaptivecards.AsPtr(adaptivecards.AppearanceTint),
 								},
 								&adaptivecards.TextBlock{
-									Text: adaptivecards.AsPtr("[10.80.40.11 reported high memory usage with 23.28%.](http://docker.for.mac.host.internal:9093)"),
+									Text: adaptivecards.AsPtr("Prometheus Test"),
 									Wrap: true,
 								},
-								&adaptivecards.FactSet{
-									Facts: []adaptivecards.Fact{
-										{Title: "", Value: ""},
-										{Title: "summary", Value: "Server High Memory usage"},
-										{Title: "alertname", Value: "high\\_memory\\_load"},
-										{Title: "instance", Value: "instance-with-hyphen\\_and\\_underscore"},
-										{Title: "job", Value: "docker\\_nodes"},
-										{Title: "monitor", Value: "master"},
-										{Title: "severity", Value: "warning"},
+								&adaptivecards.Container{
+									Style: adaptivecards.AsPtr(adaptivecards.ContainerStyleWarning),
+									Items: []adaptivecards.Element{
+										&adaptivecards.TextBlock{
+											Text: adaptivecards.AsPtr("[10.80.40.11 reported high memory usage with 23.28%.](http://docker.for.mac.host.internal:9093)"),
+											Wrap: true,
+										},
+										&adaptivecards.TextBlock{
+											Text:     adaptivecards.AsPtr("Started: 2018-03-07 11:33 UTC | Firing for 2h 13m"),
+											IsSubtle: adaptivecards.AsPtr(true),
+											Wrap:     true,
+										},
+										&adaptivecards.FactSet{
+											Facts: []adaptivecards.Fact{
+												{Title: "summary", Value: "Server High Memory usage"},
+												{Title: "alertname", Value: "high\\_memory\\_load"},
+												{Title: "instance", Value: "instance-with-hyphen\\_and\\_underscore"},
+												{Title: "job", Value: "docker\\_nodes"},
+												{Title: "monitor", Value: "master"},
+												{Title: "severity", Value: "warning"},
+											},
+										},
 									},
 								},
 							},
 							MsTeams: &adaptivecards.TeamsCardProperties{
 								Width: adaptivecards.AsPtr(adaptivecards.TeamsCardWidthFull),
 							},
-							Version: "1.2",
-							BackgroundImage: &adaptivecards.BackgroundImage{
-								URL:      "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAAECAIAAADAusJtAAAAEklEQVR42mL4v5QBGQMCAAD//ziMBpHr7M3UAAAAAElFTkSuQmCC",
-								FillMode: adaptivecards.AsPtr(adaptivecards.ImageFillModeRepeatHorizontally),
-							},
+							Version: "1.5",
 						},
 					},
 				},
@@ -155,7 +180,10 @@ func Test_templatedCard_Convert(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			templateFile := testutils.GetTestDataFilePath(tt.templateFile)
-			tmpl, err := ParseTemplateFile(templateFile)
+			// The alert fires for 2h 13m.
+			l := DefaultLocalizer()
+			l.now = func() time.Time { return time.Date(2018, 3, 7, 13, 46, 30, 0, time.UTC) }
+			tmpl, err := ParseTemplateFile(templateFile, WithLocalizer(l))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -178,3 +206,86 @@ func Test_templatedCard_Convert(t *testing.T) {
 		})
 	}
 }
+
+func Test_templatedCard_ConvertSpan(t *testing.T) {
+	exporter := testutils.RecordSpans(t)
+
+	tmpl, err := ParseTemplateFile(testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"))
+	require.NoError(t, err)
+	a, err := testutils.ParseWebhookJSONFromFile(testutils.GetTestDataFilePath("prom_post_request.json"))
+	require.NoError(t, err)
+
+	_, err = NewTemplatedCardCreator(tmpl, false, &utility.Logger{}).Convert(context.Background(), a)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"templatedCard.Convert"}, testutils.SpanNames(exporter.GetSpans()))
+}
+
+// writeTemplate writes a template file named name in dir.
+func writeTemplate(t *testing.T, dir, name, text string) string {
+	t.Helper()
+	f := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(f, []byte(text), 0o600))
+	return f
+}
+
+const compactCard = `{{ define "teams.compact" }}
+{"type": "message", "attachments": [{"contentType": "application/vnd.microsoft.card.adaptive", "content": {
+  "type": "AdaptiveCard", "version": "1.5",
+  "body": [{{ template "teams.header" . }}, {{ template "teams.facts" (index .Alerts 0) }}, {{ template "teams.actions" . }}]
+}}]}
+{{ end }}`
+
+func TestParseTemplateFile_TemplateDirs(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "compact.tmpl", compactCard)
+	writeTemplate(t, dir, "ignored.txt", `{{ define "teams.compact" }}not json{{ end }}`)
+	a, err := testutils.ParseWebhookJSONFromFile(testutils.GetTestDataFilePath("prom_post_request.json"))
+	require.NoError(t, err)
+
+	// The entry template is defined in the directory, without a file.
+	tmpl, err := ParseTemplateFile("", WithTemplateDirs(dir))
+	require.NoError(t, err)
+	got, err := NewTemplatedCardCreator(tmpl, false, &utility.Logger{}, WithEntryTemplate("teams.compact")).Convert(context.Background(), a)
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	require.Len(t, body, 5)
+	assert.Equal(t, "Prometheus Alert (Firing)", *body[0].(*adaptivecards.TextBlock).Text)
+	assert.IsType(t, &adaptivecards.Badge{}, body[1])
+	assert.Equal(t, "Prometheus Test", *body[2].(*adaptivecards.TextBlock).Text)
+	facts := body[3].(*adaptivecards.FactSet).Facts
+	assert.Equal(t, adaptivecards.Fact{Title: "summary", Value: "Server High Memory usage"}, facts[0])
+	assert.Equal(t, adaptivecards.Fact{Title: "alertname", Value: "high_memory_load"}, facts[1])
+	actions := body[4].(*adaptivecards.ActionSet).Actions
+	require.Len(t, actions, 1)
+	assert.Equal(t, "http://docker.for.mac.host.internal:9093", actions[0].(*adaptivecards.ActionOpenURL).URL)
+
+	// Without the entry template, teams.card is not defined.
+	_, err = NewTemplatedCardCreator(tmpl, false, &utility.Logger{}).Convert(context.Background(), a)
+	assert.ErrorContains(t, err, `no template "teams.card"`)
+
+	// Globs are accepted, and a missing directory is an error.
+	_, err = ParseTemplateFile("", WithTemplateDirs(filepath.Join(dir, "*.tmpl")))
+	require.NoError(t, err)
+	_, err = ParseTemplateFile("", WithTemplateDirs(filepath.Join(dir, "missing")))
+	assert.ErrorContains(t, err, "does not exist")
+}
+
+func TestParseTemplateFile_RedefinePartials(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "header.tmpl", `{{ define "teams.header" }}{"type": "TextBlock", "text": "shared header"}{{ end }}`)
+	f := writeTemplate(t, t.TempDir(), "card.tmpl", compactCard+`
+{{ define "teams.actions" }}{"type": "TextBlock", "text": "no actions"}{{ end }}`)
+	a, err := testutils.ParseWebhookJSONFromFile(testutils.GetTestDataFilePath("prom_post_request.json"))
+	require.NoError(t, err)
+
+	tmpl, err := ParseTemplateFile(f, WithTemplateDirs(dir))
+	require.NoError(t, err)
+	got, err := NewTemplatedCardCreator(tmpl, false, &utility.Logger{}, WithEntryTemplate("teams.compact")).Convert(context.Background(), a)
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	require.Len(t, body, 3)
+	assert.Equal(t, "shared header", *body[0].(*adaptivecards.TextBlock).Text)
+	assert.IsType(t, &adaptivecards.FactSet{}, body[1])
+	assert.Equal(t, "no actions", *body[2].(*adaptivecards.TextBlock).Text)
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+)
+
+// Style is the look of the alerts of a severity or status.
+type Style struct {
+	// Color is the color of the texts, such as the heading, and the style of
+	// the badges.
+	Color           adaptivecards.Colors         `yaml:"color"`
+	ContainerStyle  adaptivecards.ContainerStyle `yaml:"container_style"`
+	BadgeAppearance adaptivecards.Appearance     `yaml:"badge_appearance"`
+	Icon            adaptivecards.Symbol         `yaml:"icon"`
+}
+
+// merge returns s with the fields set in o.
+func (s Style) merge(o Style) Style {
+	if o.Color != "" {
+		s.Color = o.Color
+	}
+	if o.ContainerStyle != "" {
+		s.ContainerStyle = o.ContainerStyle
+	}
+	if o.BadgeAppearance != "" {
+		s.BadgeAppearance = o.BadgeAppearance
+	}
+	if o.Icon != "" {
+		s.Icon = o.Icon
+	}
+	return s
+}
+
+func (s Style) validate() error {
+	switch s.Color {
+	case adaptivecards.ColorNone, adaptivecards.ColorDefault, adaptivecards.ColorDark, adaptivecards.ColorLight,
+		adaptivecards.ColorAccent, adaptivecards.ColorGood, adaptivecards.ColorWarning,
+		adaptivecards.ColorAttention, adaptivecards.ColorInformative, adaptivecards.ColorSubtle:
+	default:
+		return fmt.Errorf("invalid color '%s'", s.Color)
+	}
+	switch s.ContainerStyle {
+	case "", adaptivecards.ContainerStyleDefault, adaptivecards.ContainerStyleEmphasis, adaptivecards.ContainerStyleGood,
+		adaptivecards.ContainerStyleAttention, adaptivecards.ContainerStyleWarning, adaptivecards.ContainerStyleAccent:
+	default:
+		return fmt.Errorf("invalid container_style '%s'", s.ContainerStyle)
+	}
+	switch s.BadgeAppearance {
+	case "", adaptivecards.AppearanceFilled, adaptivecards.AppearanceTint:
+	default:
+		return fmt.Errorf("invalid badge_appearance '%s'", s.BadgeAppearance)
+	}
+	if s.Icon != "" && !s.Icon.Valid() {
+		return fmt.Errorf("unknown icon '%s'", s.Icon)
+	}
+	return nil
+}
+
+// Theme maps the severities and statuses of the alerts to Styles.
+type Theme struct {
+	// Severities holds the styles of the firing alerts by the value of their
+	// severity label, lower-cased.
+	Severities map[string]Style `yaml:"severities"`
+	// Resolved is the style of the resolved alerts, whatever their severity.
+	Resolved Style `yaml:"resolved"`
+	// Default is the style of the firing alerts of the other severities.
+	Default Style `yaml:"default"`
+}
+
+// DefaultTheme is the theme of the cards unless overridden.
+var DefaultTheme = Theme{
+	Severities: map[string]Style{
+		"critical": {adaptivecards.ColorAttention, adaptivecards.ContainerStyleAttention, adaptivecards.AppearanceFilled, adaptivecards.SymbolErrorCircle},
+		"error":    {adaptivecards.ColorAttention, adaptivecards.ContainerStyleAttention, adaptivecards.AppearanceTint, adaptivecards.SymbolErrorCircle},
+		"warning":  {adaptivecards.ColorWarning, adaptivecards.ContainerStyleWarning, adaptivecards.AppearanceTint, adaptivecards.SymbolWarning},
+		"info":     {adaptivecards.ColorAccent, adaptivecards.ContainerStyleAccent, adaptivecards.AppearanceTint, adaptivecards.SymbolInfo},
+	},
+	Resolved: Style{adaptivecards.ColorGood, adaptivecards.ContainerStyleGood, adaptivecards.AppearanceTint, adaptivecards.SymbolCheckmarkCircle},
+	Default:  Style{adaptivecards.ColorDefault, adaptivecards.ContainerStyleEmphasis, adaptivecards.AppearanceTint, adaptivecards.SymbolAlert},
+}
+
+// Merge returns t overridden with the styles of o, field by field.
+func (t Theme) Merge(o Theme) Theme {
+	res := Theme{
+		Severities: make(map[string]Style, len(t.Severities)+len(o.Severities)),
+		Resolved:   t.Resolved.merge(o.Resolved),
+		Default:    t.Default.merge(o.Default),
+	}
+	for k, s := range t.Severities {
+		res.Severities[strings.ToLower(k)] = s
+	}
+	for k, s := range o.Severities {
+		k = strings.ToLower(k)
+		res.Severities[k] = res.Severities[k].merge(s)
+	}
+	return res
+}
+
+// Validate checks the colors, styles, appearances and icons of t.
+func (t Theme) Validate() error {
+	for k, s := range t.Severities {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("severity '%s': %w", k, err)
+		}
+	}
+	if err := t.Resolved.validate(); err != nil {
+		return fmt.Errorf("resolved: %w", err)
+	}
+	if err := t.Default.validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	return nil
+}
+
+// Style returns the style of the alerts of status and severity.
+func (t Theme) Style(status, severity string) Style {
+	if status == "resolved" {
+		return t.Resolved
+	}
+	if s, ok := t.Severities[strings.ToLower(severity)]; ok {
+		return s
+	}
+	return t.Default
+}
+
+// FuncMap returns the theme template functions, all taking the status and
+// the severity of the alerts:
+//   - severityColor returns the Colors of the texts and badges;
+//   - severityStyle returns the ContainerStyle;
+//   - severityAppearance returns the Appearance of the badges;
+//   - severityIcon returns the Symbol of the icons and badges.
+func (t Theme) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"severityColor": func(status, severity string) adaptivecards.Colors {
+			return t.Style(status, severity).Color
+		},
+		"severityStyle": func(status, severity string) adaptivecards.ContainerStyle {
+			return t.Style(status, severity).ContainerStyle
+		},
+		"severityAppearance": func(status, severity string) adaptivecards.Appearance {
+			return t.Style(status, severity).BadgeAppearance
+		},
+		"severityIcon": func(status, severity string) adaptivecards.Symbol {
+			return t.Style(status, severity).Icon
+		},
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestTheme_Style(t *testing.T) {
+	th := DefaultTheme
+	assert.Equal(t, adaptivecards.ColorAttention, th.Style("firing", "critical").Color)
+	assert.Equal(t, adaptivecards.AppearanceFilled, th.Style("firing", "Critical").BadgeAppearance)
+	assert.Equal(t, th.Resolved, th.Style("resolved", "critical"))
+	assert.Equal(t, th.Default, th.Style("firing", "page"))
+	assert.Equal(t, th.Default, th.Style("firing", ""))
+}
+
+func TestTheme_Merge(t *testing.T) {
+	var o Theme
+	require.NoError(t, yaml.Unmarshal([]byte(`
+severities:
+  Critical:
+    icon: Fire
+  page:
+    color: Attention
+    container_style: attention
+resolved:
+  badge_appearance: Filled
+`), &o))
+
+	th := DefaultTheme.Merge(o)
+	require.NoError(t, th.Validate())
+
+	critical := th.Style("firing", "critical")
+	assert.Equal(t, adaptivecards.Symbol("Fire"), critical.Icon)
+	assert.Equal(t, adaptivecards.ColorAttention, critical.Color, "the fields not set are kept")
+	assert.Equal(t, Style{Color: adaptivecards.ColorAttention, ContainerStyle: adaptivecards.ContainerStyleAttention}, th.Style("firing", "page"))
+	assert.Equal(t, adaptivecards.AppearanceFilled, th.Style("resolved", "").BadgeAppearance)
+	assert.Equal(t, adaptivecards.ColorGood, th.Style("resolved", "").Color)
+
+	// DefaultTheme is not modified.
+	assert.Equal(t, adaptivecards.SymbolErrorCircle, DefaultTheme.Style("firing", "critical").Icon)
+	assert.NotContains(t, DefaultTheme.Severities, "page")
+}
+
+func TestTheme_Validate(t *testing.T) {
+	require.NoError(t, DefaultTheme.Validate())
+
+	tests := []struct {
+		name  string
+		theme Theme
+		err   string
+	}{
+		{"color", Theme{Default: Style{Color: "Pink"}}, "default: invalid color 'Pink'"},
+		{"container style", Theme{Resolved: Style{ContainerStyle: "loud"}}, "resolved: invalid container_style 'loud'"},
+		{"appearance", Theme{Severities: map[string]Style{"info": {BadgeAppearance: "Outline"}}}, "severity 'info': invalid badge_appearance 'Outline'"},
+		{"icon", Theme{Severities: map[string]Style{"info": {Icon: "NoSuchIcon"}}}, "severity 'info': unknown icon 'NoSuchIcon'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualError(t, DefaultTheme.Merge(tt.theme).Validate(), tt.err)
+		})
+	}
+}
+
+func TestWithTheme(t *testing.T) {
+	th := DefaultTheme.Merge(Theme{Severities: map[string]Style{"warning": {Color: adaptivecards.ColorAccent, Icon: "Fire"}}})
+	tmpl, err := ParseTemplateFile(testutils.GetTestDataFilePath("default-message-workflow-card.tmpl"), WithTheme(th))
+	require.NoError(t, err)
+	c := NewTemplatedCardCreator(tmpl, false, utility.NewLogger(utility.LogFormatFmt, false))
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	wm := webhook.Message{Data: &template.Data{
+		Status:       "firing",
+		Alerts:       template.Alerts{digestAlert("1", "firing", "HighLoad", "warning", start)},
+		CommonLabels: template.KV{"severity": "warning"},
+	}}
+	got, err := c.Convert(context.Background(), wm)
+	require.NoError(t, err)
+	body := got.Attachments[0].Content.Body
+	assert.Equal(t, adaptivecards.ColorAccent, *body[0].(*adaptivecards.TextBlock).Color)
+	require.IsType(t, &adaptivecards.Badge{}, body[1])
+	badge := body[1].(*adaptivecards.Badge)
+	assert.Equal(t, adaptivecards.Symbol("Fire"), *badge.Icon)
+	assert.Equal(t, adaptivecards.ContainerStyleWarning, *body[len(body)-1].(*adaptivecards.Container).Style)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// bareKey matches the TOML keys which need no quotes.
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// encodeToml writes the table m at path to b: its keys with values, then
+// its tables and arrays of tables. m holds the values decoded from JSON by
+// jsonValue, and the null values are left out as TOML has none.
+func encodeToml(b *strings.Builder, path []string, m map[string]any) {
+	var tables, arrays []string
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		switch v := m[k].(type) {
+		case nil:
+		case map[string]any:
+			tables = append(tables, k)
+		case []any:
+			if isTomlTables(v) {
+				arrays = append(arrays, k)
+				continue
+			}
+			fmt.Fprintf(b, "%s = %s\n", tomlKey(k), tomlValue(v))
+		default:
+			fmt.Fprintf(b, "%s = %s\n", tomlKey(k), tomlValue(v))
+		}
+	}
+	for _, k := range tables {
+		p := append(slices.Clone(path), k)
+		tomlHeader(b, "[%s]\n", p)
+		encodeToml(b, p, m[k].(map[string]any))
+	}
+	for _, k := range arrays {
+		p := append(slices.Clone(path), k)
+		for _, t := range m[k].([]any) {
+			tomlHeader(b, "[[%s]]\n", p)
+			encodeToml(b, p, t.(map[string]any))
+		}
+	}
+}
+
+// isTomlTables reports whether the array a is an array of tables.
+func isTomlTables(a []any) bool {
+	if len(a) == 0 {
+		return false
+	}
+	for _, v := range a {
+		if _, ok := v.(map[string]any); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlHeader writes the header of the table at path to b with format,
+// after an empty line unless it is the first line.
+func tomlHeader(b *strings.Builder, format string, path []string) {
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	keys := make([]string, len(path))
+	for i, k := range path {
+		keys[i] = tomlKey(k)
+	}
+	fmt.Fprintf(b, format, strings.Join(keys, "."))
+}
+
+// tomlKey returns the key k, quoted unless it is a bare key.
+func tomlKey(k string) string {
+	if bareKey.MatchString(k) {
+		return k
+	}
+	return tomlString(k)
+}
+
+// tomlValue returns the inline TOML value of v.
+func tomlValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return tomlString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			if e != nil {
+				values = append(values, tomlValue(e))
+			}
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case map[string]any:
+		values := make([]string, 0, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			if v[k] != nil {
+				values = append(values, tomlKey(k)+" = "+tomlValue(v[k]))
+			}
+		}
+		return "{" + strings.Join(values, ", ") + "}"
+	}
+	return `""`
+}
+
+// tomlString returns s as a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
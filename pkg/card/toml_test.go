@@ -0,0 +1,57 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package card
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToToml(t *testing.T) {
+	v := map[string]any{
+		"name":    "api \"v2\"\n",
+		"enabled": true,
+		"ratio":   0.5,
+		"big":     1e21,
+		"none":    nil,
+		"tags":    []any{"a", 1, nil},
+		"empty":   []any{},
+		"a.b":     map[string]any{"c": map[string]any{"d": 1}, "inline": []any{map[string]any{"x": 1}, 2}},
+		"hosts":   []any{map[string]any{"host": "a"}, map[string]any{"host": "b"}},
+	}
+	assert.Equal(t, `big = 1e+21
+empty = []
+enabled = true
+name = "api \"v2\"\n"
+ratio = 0.5
+tags = ["a", 1]
+
+["a.b"]
+inline = [{x = 1}, 2]
+
+["a.b".c]
+d = 1
+
+[[hosts]]
+host = "a"
+
+[[hosts]]
+host = "b"
+`, toToml(v))
+	assert.Equal(t, `"\u0001é"`, tomlString("\x01é"))
+}
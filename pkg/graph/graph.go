@@ -0,0 +1,130 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package graph renders the graphs of the expressions of the alerts, queried
+// from Prometheus.
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// maxResponseBytes caps the size of the responses of Prometheus read.
+const maxResponseBytes = 4 << 20
+
+// ErrNoExpr is returned by Expr for the generator URLs without expression.
+var ErrNoExpr = errors.New("no expression in the generator URL")
+
+// Expr returns the expression of the generator URL of a Prometheus alert,
+// e.g. http://prometheus:9090/graph?g0.expr=up+%3D%3D+0&g0.tab=1.
+func Expr(generatorURL string) (string, error) {
+	u, err := url.Parse(generatorURL)
+	if err != nil {
+		return "", err
+	}
+	expr := u.Query().Get("g0.expr")
+	if expr == "" {
+		return "", ErrNoExpr
+	}
+	return expr, nil
+}
+
+// Client queries the HTTP API of Prometheus at URL.
+type Client struct {
+	URL  string
+	HTTP *http.Client
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string       `json:"resultType"`
+		Result     model.Matrix `json:"result"`
+	} `json:"data"`
+	Error string `json:"error"`
+}
+
+// QueryRange evaluates expr from start to end with a resolution of step.
+func (c Client) QueryRange(ctx context.Context, expr string, start, end time.Time, step time.Duration) (model.Matrix, error) {
+	q := url.Values{
+		"query": {expr},
+		"start": {formatTime(start)},
+		"end":   {formatTime(end)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.URL, "/")+"/api/v1/query_range?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var qr queryResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&qr); err != nil {
+		return nil, fmt.Errorf("query_range returned %d: %w", resp.StatusCode, err)
+	}
+	if qr.Status != "success" {
+		return nil, fmt.Errorf("query_range returned %d: %s", resp.StatusCode, qr.Error)
+	}
+	if qr.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("query_range returned a %s, expected a matrix", qr.Data.ResultType)
+	}
+	return qr.Data.Result, nil
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixMilli())/1000, 'f', -1, 64)
+}
+
+// Select returns the series of m whose labels are all labels of the alert,
+// or m if there is none.
+func Select(m model.Matrix, labels map[string]string) model.Matrix {
+	var selected model.Matrix
+	for _, ss := range m {
+		match := true
+		for name, v := range ss.Metric {
+			if labels[string(name)] != string(v) {
+				match = false
+				break
+			}
+		}
+		if match {
+			selected = append(selected, ss)
+		}
+	}
+	if len(selected) == 0 {
+		return m
+	}
+	return selected
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpr(t *testing.T) {
+	expr, err := Expr("http://prometheus:9090/graph?g0.expr=rate%28http_errors_total%5B5m%5D%29+%3E+1&g0.tab=1")
+	require.NoError(t, err)
+	assert.Equal(t, "rate(http_errors_total[5m]) > 1", expr)
+
+	_, err = Expr("https://grafana.example.com/alerting/grafana/abc/view")
+	assert.ErrorIs(t, err, ErrNoExpr)
+	_, err = Expr("://invalid")
+	assert.Error(t, err)
+}
+
+func TestQueryRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query_range", r.URL.Path)
+		q := r.URL.Query()
+		switch q.Get("query") {
+		case "up":
+			assert.Equal(t, "1767225600", q.Get("start"))
+			assert.Equal(t, "1767227400", q.Get("end"))
+			assert.Equal(t, "30", q.Get("step"))
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+				{"metric": {"job": "node"}, "values": [[1767225600, "1"], [1767225630, "0"]]}
+			]}}`))
+		case "scalar(1)":
+			_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "scalar", "result": []}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status": "error", "errorType": "bad_data", "error": "parse error"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := Client{URL: srv.URL + "/"}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m, err := c.QueryRange(context.Background(), "up", start, start.Add(30*time.Minute), 30*time.Second)
+	require.NoError(t, err)
+	require.Len(t, m, 1)
+	assert.Equal(t, model.Metric{"job": "node"}, m[0].Metric)
+	assert.Len(t, m[0].Values, 2)
+
+	_, err = c.QueryRange(context.Background(), "up{", start, start, time.Second)
+	assert.EqualError(t, err, "query_range returned 400: parse error")
+	_, err = c.QueryRange(context.Background(), "scalar(1)", start, start, time.Second)
+	assert.ErrorContains(t, err, "expected a matrix")
+}
+
+func TestSelect(t *testing.T) {
+	m := model.Matrix{
+		{Metric: model.Metric{"instance": "a"}},
+		{Metric: model.Metric{"instance": "b"}},
+	}
+	assert.Equal(t, m[1:], Select(m, map[string]string{"alertname": "Down", "instance": "b"}))
+	assert.Equal(t, m, Select(m, map[string]string{"alertname": "Down"}))
+}
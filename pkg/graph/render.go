@@ -0,0 +1,151 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+
+	"github.com/prometheus/common/model"
+)
+
+// MaxSeries is the maximum number of series drawn by Render.
+const MaxSeries = 10
+
+// ErrNoData is returned by Render for the matrices without any value.
+var ErrNoData = errors.New("no data to render")
+
+// palette holds the transparent background and the colors of the series.
+var palette = color.Palette{
+	color.Transparent,
+	color.RGBA{0x00, 0x78, 0xd4, 0xff},
+	color.RGBA{0xe8, 0x11, 0x23, 0xff},
+	color.RGBA{0x10, 0x7c, 0x10, 0xff},
+	color.RGBA{0xff, 0x8c, 0x00, 0xff},
+	color.RGBA{0x5c, 0x2d, 0x91, 0xff},
+}
+
+// padding is the space left around the lines, in pixels.
+const padding = 2
+
+// Render draws the series of m as a sparkline, a PNG of width by height
+// pixels with a transparent background, scaled to the values of m.
+func Render(m model.Matrix, width, height int) ([]byte, error) {
+	if len(m) > MaxSeries {
+		m = m[:MaxSeries]
+	}
+	minT, maxT := model.Latest, model.Earliest
+	minV, maxV := math.Inf(1), math.Inf(-1)
+	for _, ss := range m {
+		for _, p := range ss.Values {
+			v := float64(p.Value)
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			minT, maxT = min(minT, p.Timestamp), max(maxT, p.Timestamp)
+			minV, maxV = min(minV, v), max(maxV, v)
+		}
+	}
+	if minT > maxT {
+		return nil, ErrNoData
+	}
+	if minV == maxV {
+		// Draw a flat series in the middle.
+		minV, maxV = minV-1, maxV+1
+	}
+
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	x := func(t model.Time) int {
+		if minT == maxT {
+			return width / 2
+		}
+		return padding + int(math.Round(float64(t-minT)/float64(maxT-minT)*float64(width-2*padding-1)))
+	}
+	y := func(v float64) int {
+		return height - 1 - padding - int(math.Round((v-minV)/(maxV-minV)*float64(height-2*padding-2)))
+	}
+	for i, ss := range m {
+		c := uint8(1 + i%(len(palette)-1))
+		prevX, prevY, prev := 0, 0, false
+		for _, p := range ss.Values {
+			v := float64(p.Value)
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				// Leave a gap.
+				prev = false
+				continue
+			}
+			px, py := x(p.Timestamp), y(v)
+			if !prev {
+				prevX, prevY = px, py
+			}
+			line(img, prevX, prevY, px, py, c)
+			prevX, prevY, prev = px, py, true
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// line draws a line two pixels thick from (x0, y0) to (x1, y1) with the
+// color at index c of the palette of img.
+func line(img *image.Paletted, x0, y0, x1, y1 int, c uint8) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetColorIndex(x0, y0, c)
+		img.SetColorIndex(x0, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// DataURI returns the data URI of a PNG image.
+func DataURI(png []byte) string {
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+}
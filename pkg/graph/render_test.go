@@ -0,0 +1,78 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func series(values ...float64) *model.SampleStream {
+	ss := &model.SampleStream{}
+	for i, v := range values {
+		ss.Values = append(ss.Values, model.SamplePair{Timestamp: model.Time(i * 15000), Value: model.SampleValue(v)})
+	}
+	return ss
+}
+
+func TestRender(t *testing.T) {
+	b, err := Render(model.Matrix{series(1, 3, math.NaN(), 2, 5), series(4, 4)}, 120, 40)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 120, 40), img.Bounds())
+	// The background is transparent and the first value, the lowest, is at
+	// the bottom left.
+	_, _, _, a := img.At(60, 0).RGBA()
+	assert.Zero(t, a)
+	assert.Equal(t, palette[1], img.At(padding, 40-1-padding))
+	// The highest value is at the top right.
+	assert.Equal(t, palette[1], img.At(120-1-padding, padding+1))
+}
+
+func TestRenderFlat(t *testing.T) {
+	b, err := Render(model.Matrix{series(7)}, 40, 20)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, palette[1], img.At(20, 10))
+}
+
+func TestRenderNoData(t *testing.T) {
+	_, err := Render(model.Matrix{series(math.NaN())}, 40, 20)
+	assert.ErrorIs(t, err, ErrNoData)
+	_, err = Render(nil, 40, 20)
+	assert.ErrorIs(t, err, ErrNoData)
+}
+
+func TestDataURI(t *testing.T) {
+	uri := DataURI([]byte("png"))
+	require.True(t, strings.HasPrefix(uri, "data:image/png;base64,"))
+	b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/png;base64,"))
+	require.NoError(t, err)
+	assert.Equal(t, "png", string(b))
+}
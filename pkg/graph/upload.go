@@ -0,0 +1,70 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Uploader stores the images in an object store with HTTP PUT requests, e.g.
+// a bucket of S3, GCS or MinIO writable with the Headers.
+type Uploader struct {
+	// URL is the prefix of the URLs the images are put to.
+	URL string
+	// PublicURL is the prefix of the URLs the images are read from by Teams.
+	// Defaults to URL.
+	PublicURL string
+	Headers   map[string]string
+	HTTP      *http.Client
+}
+
+// Upload puts img, named by its SHA-256 hash, and returns its public URL.
+func (u Uploader) Upload(ctx context.Context, img []byte) (string, error) {
+	sum := sha256.Sum256(img)
+	name := hex.EncodeToString(sum[:]) + ".png"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSuffix(u.URL, "/")+"/"+name, bytes.NewReader(img))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "image/png")
+	for k, v := range u.Headers {
+		req.Header.Set(k, v)
+	}
+	client := u.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("upload returned %d", resp.StatusCode)
+	}
+	public := u.PublicURL
+	if public == "" {
+		public = u.URL
+	}
+	return strings.TrimSuffix(public, "/") + "/" + name, nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package graph
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpload(t *testing.T) {
+	var path, auth, contentType string
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		path, auth, contentType = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+		if strings.HasPrefix(r.URL.Path, "/forbidden/") {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	u := Uploader{URL: srv.URL + "/graphs/", PublicURL: "https://cdn.example.com/graphs", Headers: map[string]string{"Authorization": "Bearer token"}}
+	got, err := u.Upload(context.Background(), []byte("png"))
+	require.NoError(t, err)
+	assert.Regexp(t, `^/graphs/[0-9a-f]{64}\.png$`, path)
+	assert.Equal(t, "https://cdn.example.com"+path, got)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, "png", string(body))
+
+	u = Uploader{URL: srv.URL + "/graphs"}
+	got, err = u.Upload(context.Background(), []byte("png"))
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL+path, got)
+
+	_, err = Uploader{URL: srv.URL + "/forbidden"}.Upload(context.Background(), []byte("png"))
+	assert.EqualError(t, err, "upload returned 403")
+}
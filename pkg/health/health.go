@@ -0,0 +1,130 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package health runs the checks behind the liveness and readiness endpoints.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Statuses of a Report.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// ErrPending is the result of a check which has not completed yet.
+var ErrPending = errors.New("pending")
+
+// Check returns an error if the checked dependency is unavailable.
+type Check func(context.Context) error
+
+// Resolver resolves host names, e.g. net.DefaultResolver.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DNSCheck returns a Check resolving host with r.
+func DNSCheck(r Resolver, host string) Check {
+	return func(ctx context.Context) error {
+		addrs, err := r.LookupHost(ctx, host)
+		if err != nil {
+			return err
+		}
+		if len(addrs) == 0 {
+			return fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil
+	}
+}
+
+// Report is the outcome of the checks of a Checker.
+type Report struct {
+	Status string `json:"status"`
+	// Checks maps the name of each check to StatusOK or its error.
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// OK reports whether every check passed.
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker holds the results of one-off checks, such as loading the
+// configuration, and the checks run on every request. A Checker without
+// checks is always OK.
+type Checker struct {
+	mu      sync.RWMutex
+	results map[string]error
+	checks  []namedCheck
+}
+
+// NewChecker creates a Checker.
+func NewChecker() *Checker {
+	return &Checker{results: map[string]error{}}
+}
+
+// Set records the result of the one-off check name. Set it to ErrPending
+// until the check completes.
+func (c *Checker) Set(name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[name] = err
+}
+
+// Add adds a check run on every call to Run.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name, check})
+	sort.Slice(c.checks, func(i, j int) bool { return c.checks[i].name < c.checks[j].name })
+}
+
+// Run runs the checks and returns the Report of all results.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	results := make(map[string]error, len(c.results)+len(c.checks))
+	for name, err := range c.results {
+		results[name] = err
+	}
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	for _, nc := range checks {
+		results[nc.name] = nc.check(ctx)
+	}
+
+	r := Report{Status: StatusOK, Checks: make(map[string]string, len(results))}
+	for name, err := range results {
+		if err != nil {
+			r.Status = StatusFailed
+			r.Checks[name] = err.Error()
+			continue
+		}
+		r.Checks[name] = StatusOK
+	}
+	return r
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func TestDNSCheck(t *testing.T) {
+	r := fakeResolver{"teams.example.com": {"192.0.2.1"}, "empty.example.com": {}}
+	ctx := context.Background()
+
+	assert.NoError(t, DNSCheck(r, "teams.example.com")(ctx))
+	assert.EqualError(t, DNSCheck(r, "missing.example.com")(ctx), "no such host")
+	assert.EqualError(t, DNSCheck(r, "empty.example.com")(ctx), "no addresses found for empty.example.com")
+}
+
+func TestCheckerEmpty(t *testing.T) {
+	r := NewChecker().Run(context.Background())
+	assert.True(t, r.OK())
+	assert.Equal(t, Report{Status: StatusOK, Checks: map[string]string{}}, r)
+}
+
+func TestChecker(t *testing.T) {
+	c := NewChecker()
+	c.Set("config", ErrPending)
+	c.Add("dns", func(context.Context) error { return nil })
+
+	r := c.Run(context.Background())
+	assert.False(t, r.OK())
+	assert.Equal(t, map[string]string{"config": "pending", "dns": StatusOK}, r.Checks)
+
+	c.Set("config", nil)
+	assert.True(t, c.Run(context.Background()).OK())
+
+	calls := 0
+	c.Add("flaky", func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	r = c.Run(context.Background())
+	assert.Equal(t, StatusFailed, r.Status)
+	assert.Equal(t, "unavailable", r.Checks["flaky"])
+	assert.True(t, c.Run(context.Background()).OK())
+}
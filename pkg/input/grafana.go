@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+)
+
+// Grafana decodes the webhook messages of the Grafana unified alerting,
+// which extend those of Alertmanager.
+type Grafana struct{}
+
+type grafanaMessage struct {
+	webhook.Message
+	// Alerts shadows the alerts of the embedded template.Data.
+	Alerts []grafanaAlert `json:"alerts"`
+	Title  string         `json:"title"`
+	// Text is the message of the notification, as Message is embedded.
+	Text string `json:"message"`
+}
+
+type grafanaAlert struct {
+	template.Alert
+	card.AlertExtras
+}
+
+// Decode implements Decoder.
+func (Grafana) Decode(body []byte) (webhook.Message, *card.Extras, error) {
+	var gm grafanaMessage
+	if err := json.Unmarshal(body, &gm); err != nil {
+		return webhook.Message{}, nil, err
+	}
+	if gm.Data == nil || gm.GroupKey == "" {
+		return webhook.Message{}, nil, errors.New("the webhook message does not seem to be a valid Grafana webhook")
+	}
+	extras := &card.Extras{
+		Title:   gm.Title,
+		Message: gm.Text,
+		Alerts:  make(map[string]card.AlertExtras, len(gm.Alerts)),
+	}
+	gm.Data.Alerts = make(template.Alerts, len(gm.Alerts))
+	for i, a := range gm.Alerts {
+		gm.Data.Alerts[i] = a.Alert
+		extras.Alerts[a.Fingerprint] = a.AlertExtras
+	}
+	return gm.Message, extras, nil
+}
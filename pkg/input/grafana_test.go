@@ -0,0 +1,89 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const grafanaBody = `{
+  "receiver": "teams",
+  "status": "firing",
+  "orgId": 1,
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighCPU", "instance": "node-1"},
+      "annotations": {"summary": "CPU above 90%"},
+      "startsAt": "2026-01-01T00:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z",
+      "generatorURL": "https://grafana.example.com/alerting/grafana/abc/view",
+      "fingerprint": "57c6d9296de2ad39",
+      "silenceURL": "https://grafana.example.com/alerting/silence/new?alertmanager=grafana",
+      "dashboardURL": "https://grafana.example.com/d/dash",
+      "panelURL": "https://grafana.example.com/d/dash?viewPanel=1",
+      "values": {"B": 93.5, "C": 1},
+      "valueString": "[ var='B' labels={instance=node-1} value=93.5 ]",
+      "imageURL": "https://grafana.example.com/render/abc.png"
+    }
+  ],
+  "groupLabels": {"alertname": "HighCPU"},
+  "commonLabels": {"alertname": "HighCPU", "instance": "node-1"},
+  "commonAnnotations": {"summary": "CPU above 90%"},
+  "externalURL": "https://grafana.example.com/",
+  "version": "1",
+  "groupKey": "{}:{alertname=\"HighCPU\"}",
+  "truncatedAlerts": 0,
+  "title": "[FIRING:1] HighCPU",
+  "state": "alerting",
+  "message": "**Firing**\n\nValue: B=93.5"
+}`
+
+func TestGrafanaDecode(t *testing.T) {
+	wm, extras, err := Grafana{}.Decode([]byte(grafanaBody))
+	require.NoError(t, err)
+
+	assert.Equal(t, "{}:{alertname=\"HighCPU\"}", wm.GroupKey)
+	assert.Equal(t, "firing", wm.Status)
+	assert.Equal(t, "https://grafana.example.com/", wm.ExternalURL)
+	require.Len(t, wm.Alerts, 1)
+	a := wm.Alerts[0]
+	assert.Equal(t, "HighCPU", a.Labels["alertname"])
+	assert.Equal(t, "CPU above 90%", a.Annotations["summary"])
+	assert.Equal(t, "57c6d9296de2ad39", a.Fingerprint)
+
+	require.NotNil(t, extras)
+	assert.Equal(t, "[FIRING:1] HighCPU", extras.Title)
+	assert.Equal(t, "**Firing**\n\nValue: B=93.5", extras.Message)
+	e := extras.Alerts["57c6d9296de2ad39"]
+	assert.Equal(t, map[string]float64{"B": 93.5, "C": 1}, e.Values)
+	assert.Equal(t, "[ var='B' labels={instance=node-1} value=93.5 ]", e.ValueString)
+	assert.Equal(t, "https://grafana.example.com/render/abc.png", e.ImageURL)
+	assert.Equal(t, "https://grafana.example.com/d/dash", e.DashboardURL)
+	assert.Equal(t, "https://grafana.example.com/d/dash?viewPanel=1", e.PanelURL)
+	assert.Equal(t, "https://grafana.example.com/alerting/silence/new?alertmanager=grafana", e.SilenceURL)
+}
+
+func TestGrafanaDecodeInvalid(t *testing.T) {
+	_, _, err := Grafana{}.Decode([]byte(`{"status": "firing"}`))
+	assert.ErrorContains(t, err, "valid Grafana webhook")
+	_, _, err = Grafana{}.Decode([]byte(`[]`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package input decodes the requests sent by the alerting sources into the
+// notifications posted to Teams.
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+)
+
+// Formats of the requests.
+const (
+	FormatAlertmanager = "alertmanager"
+	FormatGrafana      = "grafana"
+	FormatJSON         = "json"
+)
+
+// ErrInvalidAlertmanager is returned for the requests which are not
+// Alertmanager webhook messages.
+var ErrInvalidAlertmanager = errors.New("the webhook message does not seem to be a valid Prometheus Alertmanager webhook. More information see https://prometheus.io/docs/alerting/latest/configuration/#webhook_config")
+
+// Decoder decodes the body of a request into a notification. The extras are
+// the fields the templates get besides those of Alertmanager, nil if none.
+type Decoder interface {
+	Decode(body []byte) (webhook.Message, *card.Extras, error)
+}
+
+// New returns the Decoder of format. The mapping is required by FormatJSON
+// and ignored by the others. An empty format means FormatAlertmanager.
+func New(format string, mapping *Mapping) (Decoder, error) {
+	switch format {
+	case "", FormatAlertmanager:
+		return Alertmanager{}, nil
+	case FormatGrafana:
+		return Grafana{}, nil
+	case FormatJSON:
+		if mapping == nil {
+			return nil, errors.New("the json input requires a mapping")
+		}
+		return NewJSON(*mapping)
+	default:
+		return nil, fmt.Errorf("unknown input format '%s', expected alertmanager, grafana or json", format)
+	}
+}
+
+// Alertmanager decodes the Alertmanager webhook messages.
+type Alertmanager struct{}
+
+// Decode implements Decoder.
+func (Alertmanager) Decode(body []byte) (webhook.Message, *card.Extras, error) {
+	var wm webhook.Message
+	if err := json.Unmarshal(body, &wm); err != nil {
+		return webhook.Message{}, nil, err
+	}
+	if wm.Data == nil || wm.Version == "" || wm.GroupKey == "" {
+		return webhook.Message{}, nil, ErrInvalidAlertmanager
+	}
+	return wm, nil, nil
+}
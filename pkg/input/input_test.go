@@ -0,0 +1,68 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	for _, format := range []string{"", FormatAlertmanager} {
+		d, err := New(format, nil)
+		require.NoError(t, err)
+		assert.Equal(t, Alertmanager{}, d)
+	}
+
+	d, err := New(FormatGrafana, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Grafana{}, d)
+
+	d, err = New(FormatJSON, &Mapping{LabelsFrom: "labels"})
+	require.NoError(t, err)
+	assert.IsType(t, &JSON{}, d)
+
+	_, err = New(FormatJSON, nil)
+	assert.ErrorContains(t, err, "requires a mapping")
+	_, err = New("zabbix", nil)
+	assert.EqualError(t, err, "unknown input format 'zabbix', expected alertmanager, grafana or json")
+}
+
+func TestAlertmanagerDecode(t *testing.T) {
+	body, err := json.Marshal(webhook.Message{
+		Data:     &template.Data{Status: "firing", Alerts: template.Alerts{{Status: "firing"}}},
+		Version:  "4",
+		GroupKey: "g",
+	})
+	require.NoError(t, err)
+
+	wm, extras, err := Alertmanager{}.Decode(body)
+	require.NoError(t, err)
+	assert.Nil(t, extras)
+	assert.Equal(t, "g", wm.GroupKey)
+	assert.Len(t, wm.Alerts, 1)
+
+	_, _, err = Alertmanager{}.Decode([]byte(`{"status": "firing"}`))
+	assert.ErrorIs(t, err, ErrInvalidAlertmanager)
+	_, _, err = Alertmanager{}.Decode([]byte(`not json`))
+	assert.Error(t, err)
+}
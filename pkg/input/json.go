@@ -0,0 +1,238 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/common/model"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/tidwall/gjson"
+)
+
+// DefaultResolvedValues are the values of the status of an alert meaning it
+// is resolved, compared case-insensitively.
+var DefaultResolvedValues = []string{"resolved", "ok", "inactive", "normal", "success"}
+
+// Mapping maps the fields of arbitrary JSON payloads to the alerts of a
+// notification. Its values are GJSON paths
+// (https://github.com/tidwall/gjson/blob/master/SYNTAX.md), not JSONPath,
+// relative to an alert unless documented otherwise.
+type Mapping struct {
+	// Alerts is the path of the alerts in the payload, an array or an
+	// object. Empty means the payload is the only alert.
+	Alerts string `yaml:"alerts"`
+	// Status is the path of the status of an alert. Its alerts are firing
+	// unless the value is one of ResolvedValues. Empty means firing.
+	Status         string   `yaml:"status"`
+	ResolvedValues []string `yaml:"resolved_values"`
+	// LabelsFrom and AnnotationsFrom are the paths of objects whose fields
+	// are the labels and the annotations of an alert.
+	LabelsFrom      string `yaml:"labels_from"`
+	AnnotationsFrom string `yaml:"annotations_from"`
+	// PayloadLabels and PayloadAnnotations are the paths, relative to the
+	// payload, of the labels and the annotations of every alert by name.
+	// They override those of LabelsFrom and AnnotationsFrom.
+	PayloadLabels      map[string]string `yaml:"payload_labels"`
+	PayloadAnnotations map[string]string `yaml:"payload_annotations"`
+	// Labels and Annotations are the paths of the labels and the annotations
+	// of an alert by name. They override all the others.
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+	// StartsAt and EndsAt are the paths of RFC 3339 dates or of Unix times
+	// in seconds.
+	StartsAt     string `yaml:"starts_at"`
+	EndsAt       string `yaml:"ends_at"`
+	GeneratorURL string `yaml:"generator_url"`
+	// Fingerprint defaults to the fingerprint of the labels.
+	Fingerprint string `yaml:"fingerprint"`
+	// GroupKey is the path of the group key of the notification, relative to
+	// the payload. It defaults to the labels common to the alerts. A payload
+	// without a value at the path is rejected.
+	GroupKey string `yaml:"group_key"`
+}
+
+// JSON decodes arbitrary JSON payloads with a Mapping.
+type JSON struct {
+	mapping  Mapping
+	resolved map[string]bool
+}
+
+// NewJSON creates the JSON decoder of m.
+func NewJSON(m Mapping) (*JSON, error) {
+	if m.LabelsFrom == "" && len(m.Labels) == 0 && len(m.PayloadLabels) == 0 {
+		return nil, errors.New("the json mapping requires labels_from, payload_labels or labels")
+	}
+	values := m.ResolvedValues
+	if len(values) == 0 {
+		values = DefaultResolvedValues
+	}
+	resolved := make(map[string]bool, len(values))
+	for _, v := range values {
+		resolved[strings.ToLower(v)] = true
+	}
+	return &JSON{mapping: m, resolved: resolved}, nil
+}
+
+// Decode implements Decoder.
+func (j *JSON) Decode(body []byte) (webhook.Message, *card.Extras, error) {
+	if !gjson.ValidBytes(body) {
+		return webhook.Message{}, nil, errors.New("the payload is not valid JSON")
+	}
+	root := gjson.ParseBytes(body)
+	items := []gjson.Result{root}
+	if j.mapping.Alerts != "" {
+		res := root.Get(j.mapping.Alerts)
+		switch {
+		case res.IsArray():
+			items = res.Array()
+		case res.IsObject():
+			items = []gjson.Result{res}
+		default:
+			return webhook.Message{}, nil, fmt.Errorf("no alerts at path '%s'", j.mapping.Alerts)
+		}
+	}
+	if len(items) == 0 {
+		return webhook.Message{}, nil, errors.New("the payload has no alert")
+	}
+
+	alerts := make(template.Alerts, 0, len(items))
+	for i, item := range items {
+		a, err := j.alert(root, item)
+		if err != nil {
+			return webhook.Message{}, nil, fmt.Errorf("alert %d: %w", i, err)
+		}
+		alerts = append(alerts, a)
+	}
+
+	data := &template.Data{
+		Status:            "resolved",
+		Alerts:            alerts,
+		GroupLabels:       template.KV{},
+		CommonLabels:      commonKV(alerts, func(a template.Alert) template.KV { return a.Labels }),
+		CommonAnnotations: commonKV(alerts, func(a template.Alert) template.KV { return a.Annotations }),
+	}
+	if len(alerts.Firing()) > 0 {
+		data.Status = "firing"
+	}
+	groupKey := "{}:" + labelSet(data.CommonLabels).String()
+	if j.mapping.GroupKey != "" {
+		groupKey = get(root, j.mapping.GroupKey).String()
+		if groupKey == "" {
+			return webhook.Message{}, nil, fmt.Errorf("no group key at path '%s'", j.mapping.GroupKey)
+		}
+	}
+	return webhook.Message{Data: data, Version: "4", GroupKey: groupKey}, nil, nil
+}
+
+func (j *JSON) alert(root, item gjson.Result) (template.Alert, error) {
+	m := j.mapping
+	a := template.Alert{
+		Status:       "firing",
+		Labels:       kv(get(item, m.LabelsFrom)),
+		Annotations:  kv(get(item, m.AnnotationsFrom)),
+		GeneratorURL: get(item, m.GeneratorURL).String(),
+		Fingerprint:  get(item, m.Fingerprint).String(),
+	}
+	setKV(a.Labels, root, m.PayloadLabels)
+	setKV(a.Annotations, root, m.PayloadAnnotations)
+	setKV(a.Labels, item, m.Labels)
+	setKV(a.Annotations, item, m.Annotations)
+	if len(a.Labels) == 0 {
+		return template.Alert{}, errors.New("no label")
+	}
+	if status := get(item, m.Status); status.Exists() && j.resolved[strings.ToLower(status.String())] {
+		a.Status = "resolved"
+	}
+	var err error
+	if a.StartsAt, err = parseTime(get(item, m.StartsAt)); err != nil {
+		return template.Alert{}, fmt.Errorf("starts_at: %w", err)
+	}
+	if a.EndsAt, err = parseTime(get(item, m.EndsAt)); err != nil {
+		return template.Alert{}, fmt.Errorf("ends_at: %w", err)
+	}
+	if a.Fingerprint == "" {
+		a.Fingerprint = labelSet(a.Labels).Fingerprint().String()
+	}
+	return a, nil
+}
+
+// get returns the value at path in v. An empty path does not exist.
+func get(v gjson.Result, path string) gjson.Result {
+	if path == "" {
+		return gjson.Result{}
+	}
+	return v.Get(path)
+}
+
+// setKV sets in out the values in v at the paths of paths, by name.
+func setKV(out template.KV, v gjson.Result, paths map[string]string) {
+	for name, path := range paths {
+		if r := get(v, path); r.Exists() {
+			out[name] = r.String()
+		}
+	}
+}
+
+// kv returns the fields of the object v as strings.
+func kv(v gjson.Result) template.KV {
+	res := template.KV{}
+	v.ForEach(func(key, value gjson.Result) bool {
+		res[key.String()] = value.String()
+		return true
+	})
+	return res
+}
+
+func parseTime(v gjson.Result) (time.Time, error) {
+	switch v.Type {
+	case gjson.Null:
+		return time.Time{}, nil
+	case gjson.Number:
+		sec := v.Float()
+		return time.Unix(0, int64(sec*float64(time.Second))).UTC(), nil
+	default:
+		return time.Parse(time.RFC3339Nano, v.String())
+	}
+}
+
+func commonKV(alerts template.Alerts, field func(template.Alert) template.KV) template.KV {
+	common := maps.Clone(field(alerts[0]))
+	for _, a := range alerts[1:] {
+		kv := field(a)
+		for k, v := range common {
+			if kv[k] != v {
+				delete(common, k)
+			}
+		}
+	}
+	return common
+}
+
+func labelSet(kv template.KV) model.LabelSet {
+	ls := make(model.LabelSet, len(kv))
+	for k, v := range kv {
+		ls[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return ls
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package input
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONDecode(t *testing.T) {
+	d, err := NewJSON(Mapping{
+		Alerts:        "checks",
+		Status:        "state",
+		LabelsFrom:    "tags",
+		Labels:        map[string]string{"alertname": "name"},
+		PayloadLabels: map[string]string{"service": "service"},
+		Annotations:   map[string]string{"description": "output"},
+		StartsAt:      "since",
+		GeneratorURL:  "link",
+		GroupKey:      "id",
+	})
+	require.NoError(t, err)
+
+	wm, extras, err := d.Decode([]byte(`{
+  "id": "incident-42",
+  "service": "checkout",
+  "checks": [
+    {"name": "Latency", "state": "CRITICAL", "tags": {"env": "prod"}, "output": "p99 2.3s", "since": "2026-01-01T00:00:00Z", "link": "https://status.example.com/42"},
+    {"name": "Errors", "state": "OK", "tags": {"env": "prod"}, "since": 1767225600}
+  ]
+}`))
+	require.NoError(t, err)
+	assert.Nil(t, extras)
+	assert.Equal(t, "incident-42", wm.GroupKey)
+	assert.Equal(t, "4", wm.Version)
+	assert.Equal(t, "firing", wm.Status)
+	assert.Equal(t, template.KV{"env": "prod", "service": "checkout"}, wm.CommonLabels)
+
+	require.Len(t, wm.Alerts, 2)
+	latency, errs := wm.Alerts[0], wm.Alerts[1]
+	assert.Equal(t, "firing", latency.Status)
+	assert.Equal(t, template.KV{"alertname": "Latency", "env": "prod", "service": "checkout"}, latency.Labels)
+	assert.Equal(t, template.KV{"description": "p99 2.3s"}, latency.Annotations)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), latency.StartsAt)
+	assert.Equal(t, "https://status.example.com/42", latency.GeneratorURL)
+	assert.NotEmpty(t, latency.Fingerprint)
+	assert.Equal(t, "resolved", errs.Status)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), errs.StartsAt)
+	assert.NotEqual(t, latency.Fingerprint, errs.Fingerprint)
+}
+
+func TestJSONDecodeSingleAlert(t *testing.T) {
+	d, err := NewJSON(Mapping{
+		Labels:         map[string]string{"alertname": "event", "host": "host.name"},
+		Status:         "resolved",
+		ResolvedValues: []string{"true"},
+	})
+	require.NoError(t, err)
+
+	wm, _, err := d.Decode([]byte(`{"event": "DiskFull", "host": {"name": "db-1"}, "resolved": true}`))
+	require.NoError(t, err)
+	require.Len(t, wm.Alerts, 1)
+	assert.Equal(t, "resolved", wm.Status)
+	assert.Equal(t, "resolved", wm.Alerts[0].Status)
+	assert.Equal(t, `{}:{alertname="DiskFull", host="db-1"}`, wm.GroupKey)
+}
+
+func TestJSONDecodeErrors(t *testing.T) {
+	_, err := NewJSON(Mapping{})
+	assert.ErrorContains(t, err, "requires labels_from, payload_labels or labels")
+
+	d, err := NewJSON(Mapping{Alerts: "items", Labels: map[string]string{"alertname": "name"}, StartsAt: "at"})
+	require.NoError(t, err)
+	for body, msg := range map[string]string{
+		`not json`:               "not valid JSON",
+		`{"other": []}`:          "no alerts at path 'items'",
+		`{"items": []}`:          "no alert",
+		`{"items": [{"id": 1}]}`: "alert 0: no label",
+		`{"items": [{"name": "A", "at": "now"}]}`: "alert 0: starts_at",
+	} {
+		_, _, err := d.Decode([]byte(body))
+		assert.ErrorContains(t, err, msg, body)
+	}
+
+	d, err = NewJSON(Mapping{Labels: map[string]string{"alertname": "name"}, GroupKey: "id"})
+	require.NoError(t, err)
+	_, _, err = d.Decode([]byte(`{"name": "A", "id": ""}`))
+	assert.ErrorContains(t, err, "no group key at path 'id'")
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics records the alert, card and delivery metrics of
+// prometheus-msteams.
+//
+// Metric attributes are kept bounded: deliveries are labeled with the request
+// path pattern of the route, never with the webhook URL, and cards with the
+// base name of their template file.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ScopeName is the instrumentation scope of the metrics.
+const ScopeName = "github.com/stakater/prometheus-msteams/pkg/metrics"
+
+// UnknownRoute is the route label of requests without a route in their
+// context.
+const UnknownRoute = "unknown"
+
+// StatusClassError is the status class of deliveries which got no HTTP
+// response.
+const StatusClassError = "error"
+
+type routeKey struct{}
+
+// ContextWithRoute returns a copy of ctx carrying the request path pattern
+// of the route serving the request.
+func ContextWithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// RouteFromContext returns the route set by ContextWithRoute, or
+// UnknownRoute.
+func RouteFromContext(ctx context.Context) string {
+	if route, ok := ctx.Value(routeKey{}).(string); ok && route != "" {
+		return route
+	}
+	return UnknownRoute
+}
+
+// Recorder records the metrics. A nil *Recorder records nothing.
+type Recorder struct {
+	alertsReceived metric.Int64Counter
+	cardsRendered  metric.Int64Counter
+	renderFailures metric.Int64Counter
+	renderDuration metric.Float64Histogram
+	cardSize       metric.Int64Histogram
+	attempts       metric.Int64Counter
+	retries        metric.Int64Counter
+	deliveries     metric.Int64Counter
+	deliveryDelay  metric.Float64Histogram
+	queueRejected  metric.Int64Counter
+	queueWait      metric.Float64Histogram
+
+	mu     sync.Mutex
+	queues map[string]QueueStats
+}
+
+// QueueStats returns the number of notifications waiting in a queue and the
+// time the oldest one has been waiting.
+type QueueStats func() (depth int, age time.Duration)
+
+// NewRecorder creates the instruments of a Recorder with mp.
+func NewRecorder(mp metric.MeterProvider) (*Recorder, error) {
+	m := mp.Meter(ScopeName)
+	r := &Recorder{queues: map[string]QueueStats{}}
+	var err error
+	if r.alertsReceived, err = m.Int64Counter("prometheus_msteams.alerts.received",
+		metric.WithDescription("Alerts received from Alertmanager, by route and alert status."),
+		metric.WithUnit("{alert}")); err != nil {
+		return nil, err
+	}
+	if r.cardsRendered, err = m.Int64Counter("prometheus_msteams.cards.rendered",
+		metric.WithDescription("Cards rendered, by template."),
+		metric.WithUnit("{card}")); err != nil {
+		return nil, err
+	}
+	if r.renderFailures, err = m.Int64Counter("prometheus_msteams.card.render.failures",
+		metric.WithDescription("Cards which failed to render, by template."),
+		metric.WithUnit("{card}")); err != nil {
+		return nil, err
+	}
+	if r.renderDuration, err = m.Float64Histogram("prometheus_msteams.card.render.duration",
+		metric.WithDescription("Time to render a card, by template."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1)); err != nil {
+		return nil, err
+	}
+	if r.cardSize, err = m.Int64Histogram("prometheus_msteams.card.size",
+		metric.WithDescription("Size of the card payloads sent to Teams, by route."),
+		metric.WithUnit("By"),
+		metric.WithExplicitBucketBoundaries(1024, 2048, 4096, 8192, 16384, 28672, 65536, 262144)); err != nil {
+		return nil, err
+	}
+	if r.attempts, err = m.Int64Counter("prometheus_msteams.delivery.attempts",
+		metric.WithDescription("HTTP requests sent to Teams including retries, by route."),
+		metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	if r.retries, err = m.Int64Counter("prometheus_msteams.delivery.retries",
+		metric.WithDescription("HTTP requests sent to Teams again after a failed attempt, by route."),
+		metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	if r.deliveries, err = m.Int64Counter("prometheus_msteams.deliveries",
+		metric.WithDescription("Cards delivered to Teams after retries, by route and HTTP status class of the last attempt."),
+		metric.WithUnit("{card}")); err != nil {
+		return nil, err
+	}
+	if r.deliveryDelay, err = m.Float64Histogram("prometheus_msteams.alert.delivery.delay",
+		metric.WithDescription("Time from the start of an alert to its successful delivery to Teams, by route and alert status."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200, 21600, 86400)); err != nil {
+		return nil, err
+	}
+	if r.queueRejected, err = m.Int64Counter("prometheus_msteams.queue.rejected",
+		metric.WithDescription("Notifications rejected because the delivery queue was full, by route."),
+		metric.WithUnit("{notification}")); err != nil {
+		return nil, err
+	}
+	if r.queueWait, err = m.Float64Histogram("prometheus_msteams.queue.wait.duration",
+		metric.WithDescription("Time notifications waited in the delivery queue, by route."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300)); err != nil {
+		return nil, err
+	}
+	queueDepth, err := m.Int64ObservableGauge("prometheus_msteams.queue.depth",
+		metric.WithDescription("Notifications waiting in the delivery queue, by route."),
+		metric.WithUnit("{notification}"))
+	if err != nil {
+		return nil, err
+	}
+	queueAge, err := m.Float64ObservableGauge("prometheus_msteams.queue.oldest.age",
+		metric.WithDescription("Time the oldest notification of the delivery queue has been waiting, by route."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for route, stats := range r.queues {
+			depth, age := stats()
+			attrs := metric.WithAttributes(attribute.String("route", route))
+			o.ObserveInt64(queueDepth, int64(depth), attrs)
+			o.ObserveFloat64(queueAge, age.Seconds(), attrs)
+		}
+		return nil
+	}, queueDepth, queueAge); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// AlertsReceived records the alerts of a webhook message.
+func (r *Recorder) AlertsReceived(ctx context.Context, route string, alerts template.Alerts) {
+	if r == nil {
+		return
+	}
+	for _, a := range alerts {
+		r.alertsReceived.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("route", route),
+			attribute.String("status", alertStatus(a)),
+		))
+	}
+}
+
+// CardRendered records the rendering of a card with tmpl, which failed if
+// err is not nil.
+func (r *Recorder) CardRendered(ctx context.Context, tmpl string, d time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	attrs := metric.WithAttributes(attribute.String("template", tmpl))
+	r.renderDuration.Record(ctx, d.Seconds(), attrs)
+	if err != nil {
+		r.renderFailures.Add(ctx, 1, attrs)
+		return
+	}
+	r.cardsRendered.Add(ctx, 1, attrs)
+}
+
+// CardSize records the size in bytes of a card payload.
+func (r *Recorder) CardSize(ctx context.Context, route string, size int) {
+	if r == nil {
+		return
+	}
+	r.cardSize.Record(ctx, int64(size), metric.WithAttributes(attribute.String("route", route)))
+}
+
+// DeliveryAttempt records an HTTP request to Teams; attempt is 0 for the
+// first request of a delivery and counts the retries after it.
+func (r *Recorder) DeliveryAttempt(ctx context.Context, route string, attempt int) {
+	if r == nil {
+		return
+	}
+	attrs := metric.WithAttributes(attribute.String("route", route))
+	r.attempts.Add(ctx, 1, attrs)
+	if attempt > 0 {
+		r.retries.Add(ctx, 1, attrs)
+	}
+}
+
+// Delivered records the outcome of the delivery of a card holding alerts.
+// status is the HTTP status of the last attempt, or 0 if it got no response.
+// On success the time since the start of each alert is recorded.
+func (r *Recorder) Delivered(ctx context.Context, route string, status int, alerts template.Alerts, now time.Time) {
+	if r == nil {
+		return
+	}
+	class := StatusClass(status)
+	r.deliveries.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("route", route),
+		attribute.String("status_class", class),
+	))
+	if class != "2xx" {
+		return
+	}
+	for _, a := range alerts {
+		if a.StartsAt.IsZero() {
+			continue
+		}
+		r.deliveryDelay.Record(ctx, now.Sub(a.StartsAt).Seconds(), metric.WithAttributes(
+			attribute.String("route", route),
+			attribute.String("status", alertStatus(a)),
+		))
+	}
+}
+
+// ObserveQueue reports the depth and age of the delivery queue of route
+// from stats.
+func (r *Recorder) ObserveQueue(route string, stats QueueStats) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queues[route] = stats
+}
+
+// QueueRejected records a notification rejected by the full delivery queue
+// of route.
+func (r *Recorder) QueueRejected(ctx context.Context, route string) {
+	if r == nil {
+		return
+	}
+	r.queueRejected.Add(ctx, 1, metric.WithAttributes(attribute.String("route", route)))
+}
+
+// QueueWait records the time a notification waited in the delivery queue of
+// route.
+func (r *Recorder) QueueWait(ctx context.Context, route string, d time.Duration) {
+	if r == nil {
+		return
+	}
+	r.queueWait.Record(ctx, d.Seconds(), metric.WithAttributes(attribute.String("route", route)))
+}
+
+// StatusClass returns the class of an HTTP status, e.g. "2xx", or
+// StatusClassError for 0.
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return StatusClassError
+	}
+	return fmt.Sprintf("%dxx", status/100)
+}
+
+// alertStatus bounds the status label to the statuses sent by Alertmanager.
+func alertStatus(a template.Alert) string {
+	switch a.Status {
+	case "firing", "resolved":
+		return a.Status
+	default:
+		return "unknown"
+	}
+}
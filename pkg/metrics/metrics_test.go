@@ -0,0 +1,153 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func newTestRecorder(t *testing.T) (*Recorder, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	r, err := NewRecorder(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+	return r, reader
+}
+
+func TestRouteFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, UnknownRoute, RouteFromContext(ctx))
+	assert.Equal(t, UnknownRoute, RouteFromContext(ContextWithRoute(ctx, "")))
+	assert.Equal(t, "/alertmanager", RouteFromContext(ContextWithRoute(ctx, "/alertmanager")))
+}
+
+func TestStatusClass(t *testing.T) {
+	for status, want := range map[int]string{
+		0:   StatusClassError,
+		200: "2xx",
+		202: "2xx",
+		301: "3xx",
+		429: "4xx",
+		503: "5xx",
+		999: StatusClassError,
+	} {
+		assert.Equal(t, want, StatusClass(status), status)
+	}
+}
+
+func TestRecorderAlertsReceived(t *testing.T) {
+	r, reader := newTestRecorder(t)
+
+	r.AlertsReceived(context.Background(), "/alertmanager", template.Alerts{
+		{Status: "firing"}, {Status: "firing"}, {Status: "resolved"}, {Status: "bogus"},
+	})
+
+	assert.Equal(t, map[string]float64{
+		"route=/alertmanager,status=firing":   2,
+		"route=/alertmanager,status=resolved": 1,
+		"route=/alertmanager,status=unknown":  1,
+	}, testutils.CollectMetrics(t, reader)["prometheus_msteams.alerts.received"])
+}
+
+func TestRecorderCardRendered(t *testing.T) {
+	r, reader := newTestRecorder(t)
+	ctx := context.Background()
+
+	r.CardRendered(ctx, "card.tmpl", time.Millisecond, nil)
+	r.CardRendered(ctx, "card.tmpl", time.Millisecond, nil)
+	r.CardRendered(ctx, "card.tmpl", time.Millisecond, errors.New("bad template"))
+
+	got := testutils.CollectMetrics(t, reader)
+	assert.Equal(t, map[string]float64{"template=card.tmpl": 2}, got["prometheus_msteams.cards.rendered"])
+	assert.Equal(t, map[string]float64{"template=card.tmpl": 1}, got["prometheus_msteams.card.render.failures"])
+	assert.Equal(t, map[string]float64{"template=card.tmpl": 3}, got["prometheus_msteams.card.render.duration"])
+}
+
+func TestRecorderDeliveries(t *testing.T) {
+	r, reader := newTestRecorder(t)
+	ctx := context.Background()
+	now := time.Now()
+	alerts := template.Alerts{
+		{Status: "firing", StartsAt: now.Add(-time.Minute)},
+		{Status: "resolved", StartsAt: now.Add(-time.Hour)},
+		{Status: "firing"},
+	}
+
+	r.CardSize(ctx, "/a", 2048)
+	r.DeliveryAttempt(ctx, "/a", 0)
+	r.DeliveryAttempt(ctx, "/a", 1)
+	r.DeliveryAttempt(ctx, "/a", 2)
+	r.Delivered(ctx, "/a", 202, alerts, now)
+	r.Delivered(ctx, "/b", 503, alerts, now)
+	r.Delivered(ctx, "/b", 0, alerts, now)
+
+	got := testutils.CollectMetrics(t, reader)
+	assert.Equal(t, map[string]float64{"route=/a": 1}, got["prometheus_msteams.card.size"])
+	assert.Equal(t, map[string]float64{"route=/a": 3}, got["prometheus_msteams.delivery.attempts"])
+	assert.Equal(t, map[string]float64{"route=/a": 2}, got["prometheus_msteams.delivery.retries"])
+	assert.Equal(t, map[string]float64{
+		"route=/a,status_class=2xx":   1,
+		"route=/b,status_class=5xx":   1,
+		"route=/b,status_class=error": 1,
+	}, got["prometheus_msteams.deliveries"])
+	// Only successful deliveries of alerts with a start time are observed.
+	assert.Equal(t, map[string]float64{
+		"route=/a,status=firing":   1,
+		"route=/a,status=resolved": 1,
+	}, got["prometheus_msteams.alert.delivery.delay"])
+}
+
+func TestRecorderQueues(t *testing.T) {
+	r, reader := newTestRecorder(t)
+	ctx := context.Background()
+
+	r.ObserveQueue("/a", func() (int, time.Duration) { return 3, 2 * time.Second })
+	r.ObserveQueue("/b", func() (int, time.Duration) { return 0, 0 })
+	r.QueueRejected(ctx, "/a")
+	r.QueueWait(ctx, "/a", time.Second)
+	r.QueueWait(ctx, "/b", time.Second)
+
+	got := testutils.CollectMetrics(t, reader)
+	assert.Equal(t, map[string]float64{"route=/a": 3, "route=/b": 0}, got["prometheus_msteams.queue.depth"])
+	assert.Equal(t, map[string]float64{"route=/a": 2, "route=/b": 0}, got["prometheus_msteams.queue.oldest.age"])
+	assert.Equal(t, map[string]float64{"route=/a": 1}, got["prometheus_msteams.queue.rejected"])
+	assert.Equal(t, map[string]float64{"route=/a": 1, "route=/b": 1}, got["prometheus_msteams.queue.wait.duration"])
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	ctx := context.Background()
+
+	assert.NotPanics(t, func() {
+		r.AlertsReceived(ctx, "/a", template.Alerts{{Status: "firing"}})
+		r.CardRendered(ctx, "card.tmpl", time.Millisecond, nil)
+		r.CardSize(ctx, "/a", 1)
+		r.DeliveryAttempt(ctx, "/a", 1)
+		r.Delivered(ctx, "/a", 200, nil, time.Now())
+		r.ObserveQueue("/a", func() (int, time.Duration) { return 0, 0 })
+		r.QueueRejected(ctx, "/a")
+		r.QueueWait(ctx, "/a", time.Second)
+	})
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preview renders Adaptive Cards to static HTML, so templates can be
+// reviewed without posting to a Microsoft Teams channel.
+package preview
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+)
+
+// HostConfig describes how a host renders cards. The field names follow the
+// Adaptive Cards host config format, with MaxVersion added to report cards
+// that are newer than the host supports.
+type HostConfig struct {
+	MaxVersion      string                          `json:"maxVersion"`
+	FontFamily      string                          `json:"fontFamily"`
+	FontSizes       FontSizesConfig                 `json:"fontSizes"`
+	FontWeights     FontWeightsConfig               `json:"fontWeights"`
+	Spacing         SpacingConfig                   `json:"spacing"`
+	Separator       SeparatorConfig                 `json:"separator"`
+	ImageSizes      ImageSizesConfig                `json:"imageSizes"`
+	ContainerStyles map[string]ContainerStyleConfig `json:"containerStyles"`
+	Actions         ActionsConfig                   `json:"actions"`
+	CardWidth       int                             `json:"cardWidth"`
+}
+
+// FontSizesConfig holds font sizes in pixels.
+type FontSizesConfig struct {
+	Small      int `json:"small"`
+	Default    int `json:"default"`
+	Medium     int `json:"medium"`
+	Large      int `json:"large"`
+	ExtraLarge int `json:"extraLarge"`
+}
+
+// FontWeightsConfig holds CSS font weights.
+type FontWeightsConfig struct {
+	Lighter int `json:"lighter"`
+	Default int `json:"default"`
+	Bolder  int `json:"bolder"`
+}
+
+// SpacingConfig holds spacings in pixels.
+type SpacingConfig struct {
+	Small      int `json:"small"`
+	Default    int `json:"default"`
+	Medium     int `json:"medium"`
+	Large      int `json:"large"`
+	ExtraLarge int `json:"extraLarge"`
+	Padding    int `json:"padding"`
+}
+
+// SeparatorConfig configures the line drawn by "separator": true.
+type SeparatorConfig struct {
+	LineThickness int    `json:"lineThickness"`
+	LineColor     string `json:"lineColor"`
+}
+
+// ImageSizesConfig holds image widths in pixels.
+type ImageSizesConfig struct {
+	Small  int `json:"small"`
+	Medium int `json:"medium"`
+	Large  int `json:"large"`
+}
+
+// ContainerStyleConfig holds the colors of a container style.
+type ContainerStyleConfig struct {
+	BackgroundColor  string                            `json:"backgroundColor"`
+	ForegroundColors map[string]ForegroundColorsConfig `json:"foregroundColors"`
+}
+
+// ForegroundColorsConfig holds the regular and subtle variant of a color.
+type ForegroundColorsConfig struct {
+	Default string `json:"default"`
+	Subtle  string `json:"subtle"`
+}
+
+// ActionsConfig configures how actions are rendered.
+type ActionsConfig struct {
+	MaxActions    int `json:"maxActions"`
+	ButtonSpacing int `json:"buttonSpacing"`
+}
+
+// TeamsHostConfig returns a host config resembling the Microsoft Teams light
+// theme.
+func TeamsHostConfig() HostConfig {
+	foreground := map[string]ForegroundColorsConfig{
+		"default":   {Default: "#242424", Subtle: "#616161"},
+		"dark":      {Default: "#242424", Subtle: "#424242"},
+		"light":     {Default: "#FFFFFF", Subtle: "#F5F5F5"},
+		"accent":    {Default: "#5B5FC7", Subtle: "#7F85F5"},
+		"good":      {Default: "#237B4B", Subtle: "#4CA76E"},
+		"warning":   {Default: "#835C00", Subtle: "#C19C00"},
+		"attention": {Default: "#C4314B", Subtle: "#D36F81"},
+	}
+	style := func(bg string) ContainerStyleConfig {
+		return ContainerStyleConfig{BackgroundColor: bg, ForegroundColors: foreground}
+	}
+	return HostConfig{
+		MaxVersion: "1.5",
+		FontFamily: `"Segoe UI", system-ui, -apple-system, sans-serif`,
+		FontSizes: FontSizesConfig{
+			Small: 12, Default: 14, Medium: 14, Large: 18, ExtraLarge: 24,
+		},
+		FontWeights: FontWeightsConfig{Lighter: 200, Default: 400, Bolder: 600},
+		Spacing: SpacingConfig{
+			Small: 8, Default: 12, Medium: 16, Large: 20, ExtraLarge: 24, Padding: 16,
+		},
+		Separator:  SeparatorConfig{LineThickness: 1, LineColor: "#E0E0E0"},
+		ImageSizes: ImageSizesConfig{Small: 32, Medium: 52, Large: 100},
+		ContainerStyles: map[string]ContainerStyleConfig{
+			"default":   style("#FFFFFF"),
+			"emphasis":  style("#F5F5F5"),
+			"good":      style("#E7F2DA"),
+			"attention": style("#FCF4F6"),
+			"warning":   style("#FBF6D9"),
+			"accent":    style("#E8EBFA"),
+		},
+		Actions:   ActionsConfig{MaxActions: 6, ButtonSpacing: 8},
+		CardWidth: 500,
+	}
+}
+
+// LoadHostConfig reads a host config from a JSON file. Settings missing from
+// the file keep their TeamsHostConfig value.
+func LoadHostConfig(f string) (HostConfig, error) {
+	hc := TeamsHostConfig()
+	b, err := os.ReadFile(f) //nolint:gosec
+	if err != nil {
+		return HostConfig{}, err
+	}
+	if err := json.Unmarshal(b, &hc); err != nil {
+		return HostConfig{}, fmt.Errorf("host config %s: %w", f, err)
+	}
+	if _, err := adaptivecards.ParseVersion(hc.MaxVersion); err != nil {
+		return HostConfig{}, fmt.Errorf("host config %s: maxVersion: %w", f, err)
+	}
+	return hc, nil
+}
+
+// containerStyle returns the config of a container style, falling back to
+// the default style.
+func (hc HostConfig) containerStyle(s *adaptivecards.ContainerStyle) ContainerStyleConfig {
+	if s != nil {
+		if cs, ok := hc.ContainerStyles[string(*s)]; ok {
+			return cs
+		}
+	}
+	return hc.ContainerStyles["default"]
+}
+
+// spacing returns the spacing in pixels for s, which defaults to "default".
+func (hc HostConfig) spacing(s *adaptivecards.Spacing) int {
+	if s == nil {
+		return hc.Spacing.Default
+	}
+	switch adaptivecards.Spacing(strings.ToLower(string(*s))) {
+	case "none":
+		return 0
+	case "small":
+		return hc.Spacing.Small
+	case "medium":
+		return hc.Spacing.Medium
+	case "large":
+		return hc.Spacing.Large
+	case "extralarge":
+		return hc.Spacing.ExtraLarge
+	case "padding":
+		return hc.Spacing.Padding
+	}
+	return hc.Spacing.Default
+}
+
+func (hc HostConfig) fontSize(s *adaptivecards.FontSize) int {
+	if s == nil {
+		return hc.FontSizes.Default
+	}
+	switch strings.ToLower(string(*s)) {
+	case "small":
+		return hc.FontSizes.Small
+	case "medium":
+		return hc.FontSizes.Medium
+	case "large":
+		return hc.FontSizes.Large
+	case "extralarge":
+		return hc.FontSizes.ExtraLarge
+	}
+	return hc.FontSizes.Default
+}
+
+func (hc HostConfig) fontWeight(w *adaptivecards.FontWeight) int {
+	if w == nil {
+		return hc.FontWeights.Default
+	}
+	switch strings.ToLower(string(*w)) {
+	case "lighter":
+		return hc.FontWeights.Lighter
+	case "bolder":
+		return hc.FontWeights.Bolder
+	}
+	return hc.FontWeights.Default
+}
+
+// foreground returns the text color for c in the given container style.
+func (hc HostConfig) foreground(style ContainerStyleConfig, c *adaptivecards.Colors, subtle bool) string {
+	name := "default"
+	if c != nil && *c != "" {
+		name = strings.ToLower(string(*c))
+	}
+	fc, ok := style.ForegroundColors[name]
+	if !ok {
+		fc = style.ForegroundColors["default"]
+	}
+	if subtle {
+		return fc.Subtle
+	}
+	return fc.Default
+}
+
+func (hc HostConfig) imageSize(s *adaptivecards.ImageSize) string {
+	if s == nil {
+		return "auto"
+	}
+	switch strings.ToLower(string(*s)) {
+	case "small":
+		return fmt.Sprintf("%dpx", hc.ImageSizes.Small)
+	case "medium":
+		return fmt.Sprintf("%dpx", hc.ImageSizes.Medium)
+	case "large":
+		return fmt.Sprintf("%dpx", hc.ImageSizes.Large)
+	case "stretch":
+		return "100%"
+	}
+	return "auto"
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadHostConfig(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "host-config.json")
+	require.NoError(t, os.WriteFile(f, []byte(`{
+		"maxVersion": "1.4",
+		"fontSizes": {"default": 16},
+		"containerStyles": {"default": {"backgroundColor": "#292929"}}
+	}`), 0o600))
+
+	hc, err := LoadHostConfig(f)
+	require.NoError(t, err)
+	assert.Equal(t, "1.4", hc.MaxVersion)
+	assert.Equal(t, 16, hc.FontSizes.Default)
+	assert.Equal(t, "#292929", hc.ContainerStyles["default"].BackgroundColor)
+	// Settings missing from the file keep the Teams defaults.
+	assert.Equal(t, TeamsHostConfig().Spacing, hc.Spacing)
+	assert.Equal(t, TeamsHostConfig().ContainerStyles["good"], hc.ContainerStyles["good"])
+}
+
+func TestLoadHostConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := LoadHostConfig(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte("{"), 0o600))
+	_, err = LoadHostConfig(invalid)
+	assert.ErrorContains(t, err, "invalid.json")
+
+	badVersion := filepath.Join(dir, "version.json")
+	require.NoError(t, os.WriteFile(badVersion, []byte(`{"maxVersion": "latest"}`), 0o600))
+	_, err = LoadHostConfig(badVersion)
+	assert.ErrorContains(t, err, "maxVersion")
+}
+
+func TestHostConfigLookups(t *testing.T) {
+	hc := TeamsHostConfig()
+
+	assert.Equal(t, hc.Spacing.Default, hc.spacing(nil))
+	assert.Equal(t, 0, hc.spacing(adaptivecards.AsPtr(adaptivecards.SpacingNone)))
+	assert.Equal(t, hc.Spacing.Large, hc.spacing(adaptivecards.AsPtr(adaptivecards.Spacing("large"))))
+
+	assert.Equal(t, hc.FontSizes.ExtraLarge, hc.fontSize(adaptivecards.AsPtr(adaptivecards.FontSizeExtraLarge)))
+	assert.Equal(t, hc.FontWeights.Bolder, hc.fontWeight(adaptivecards.AsPtr(adaptivecards.FontWeightBolder)))
+
+	style := hc.containerStyle(adaptivecards.AsPtr(adaptivecards.ContainerStyleAttention))
+	assert.Equal(t, "#FCF4F6", style.BackgroundColor)
+	assert.Equal(t, hc.ContainerStyles["default"], hc.containerStyle(adaptivecards.AsPtr(adaptivecards.ContainerStyle("unknown"))))
+
+	assert.Equal(t, "#C4314B", hc.foreground(style, adaptivecards.AsPtr(adaptivecards.ColorAttention), false))
+	assert.Equal(t, "#616161", hc.foreground(style, nil, true))
+
+	assert.Equal(t, "32px", hc.imageSize(adaptivecards.AsPtr(adaptivecards.ImageSizeSmall)))
+	assert.Equal(t, "100%", hc.imageSize(adaptivecards.AsPtr(adaptivecards.ImageSizeStretch)))
+	assert.Equal(t, "auto", hc.imageSize(nil))
+}
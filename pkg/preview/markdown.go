@@ -0,0 +1,104 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	mdLink       = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBold       = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdItalicStar = regexp.MustCompile(`\*([^*]+)\*`)
+	mdItalic     = regexp.MustCompile(`\b_(.+?)_\b`)
+	mdOrdered    = regexp.MustCompile(`^\d+\.\s+`)
+)
+
+// escapedUnderscore stands in for "\_" while italics are replaced, so
+// underscores escaped by -auto-escape-underscores render as plain "_".
+const escapedUnderscore = "\x00"
+
+// markdown renders the Markdown subset supported by TextBlock: bold, italic,
+// links, bulleted and numbered lists and line breaks. Everything else is
+// HTML escaped.
+func markdown(s string) string {
+	var out strings.Builder
+	list := ""
+	closeList := func() {
+		if list != "" {
+			out.WriteString("</" + list + ">")
+			list = ""
+		}
+	}
+	text := false
+	for _, line := range strings.Split(s, "\n") {
+		tag := ""
+		switch {
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "):
+			tag, line = "ul", line[2:]
+		case mdOrdered.MatchString(line):
+			tag, line = "ol", mdOrdered.ReplaceAllString(line, "")
+		}
+		if tag != list {
+			closeList()
+			if tag != "" {
+				out.WriteString("<" + tag + ">")
+				list = tag
+			}
+		}
+		if list != "" {
+			out.WriteString("<li>" + inlineMarkdown(line) + "</li>")
+			text = false
+			continue
+		}
+		if text {
+			out.WriteString("<br>")
+		}
+		out.WriteString(inlineMarkdown(line))
+		text = true
+	}
+	closeList()
+	return out.String()
+}
+
+func inlineMarkdown(s string) string {
+	s = html.EscapeString(strings.ReplaceAll(s, `\_`, escapedUnderscore))
+	s = mdLink.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdLink.FindStringSubmatch(m)
+		href := html.UnescapeString(parts[2])
+		if !safeURL(href, false) {
+			return parts[1]
+		}
+		return `<a href="` + html.EscapeString(href) + `" target="_blank" rel="noopener">` + parts[1] + `</a>`
+	})
+	s = mdBold.ReplaceAllString(s, "<strong>$1</strong>")
+	s = mdItalicStar.ReplaceAllString(s, "<em>$1</em>")
+	s = mdItalic.ReplaceAllString(s, "<em>$1</em>")
+	return strings.ReplaceAll(s, escapedUnderscore, "_")
+}
+
+// safeURL reports whether u may be used as a link or, when image is true,
+// as an image source.
+func safeURL(u string, image bool) bool {
+	l := strings.ToLower(strings.TrimSpace(u))
+	if strings.HasPrefix(l, "https://") || strings.HasPrefix(l, "http://") {
+		return true
+	}
+	return image && strings.HasPrefix(l, "data:image/")
+}
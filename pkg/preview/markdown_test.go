@@ -0,0 +1,51 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkdown(t *testing.T) {
+	tests := map[string]string{
+		"plain":                                 "plain",
+		"**bold** and _italic_":                 "<strong>bold</strong> and <em>italic</em>",
+		"*italic*":                              "<em>italic</em>",
+		"node_exporter_up":                      "node_exporter_up",
+		`job\_name`:                             "job_name",
+		"line 1\nline 2":                        "line 1<br>line 2",
+		"- one\n- two":                          "<ul><li>one</li><li>two</li></ul>",
+		"intro\n1. one\n2. two\nend":            "intro<ol><li>one</li><li>two</li></ol>end",
+		"[runbook](https://example.com/?a=1&b)": `<a href="https://example.com/?a=1&amp;b" target="_blank" rel="noopener">runbook</a>`,
+		"[bad](javascript:alert(1))":            "bad)",
+		"<script>alert(1)</script>":             "&lt;script&gt;alert(1)&lt;/script&gt;",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, markdown(in), in)
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	assert.True(t, safeURL("https://example.com", false))
+	assert.True(t, safeURL("HTTP://example.com", false))
+	assert.False(t, safeURL("javascript:alert(1)", false))
+	assert.False(t, safeURL("data:image/png;base64,AAAA", false))
+	assert.True(t, safeURL("data:image/png;base64,AAAA", true))
+	assert.False(t, safeURL("data:text/html,<b>", true))
+}
@@ -0,0 +1,577 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+)
+
+// IssueKind classifies an Issue.
+type IssueKind string
+
+// IssueKind values.
+const (
+	// IssueUnsupported is an element or action the preview cannot render.
+	IssueUnsupported IssueKind = "unsupported"
+	// IssueVersion is a property, element or card that needs a newer
+	// version than the card or the host declares.
+	IssueVersion IssueKind = "version"
+	// IssueInvalid is a value the host would reject, e.g. an unsafe URL.
+	IssueInvalid IssueKind = "invalid"
+)
+
+// Issue is a problem found while rendering a card. Issues are highlighted
+// in the preview and listed above the card.
+type Issue struct {
+	Kind    IssueKind `json:"kind"`
+	Path    string    `json:"path"`
+	Message string    `json:"message"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s (%s)", i.Path, i.Message, i.Kind)
+}
+
+// elementVersions holds the version that introduced element types whose
+// fields do not carry a version tag of their own.
+var elementVersions = map[string]string{
+	"ActionSet":     "1.2",
+	"Badge":         "1.5",
+	"CodeBlock":     "1.5",
+	"Media":         "1.1",
+	"RichTextBlock": "1.2",
+	"Table":         "1.5",
+}
+
+// Renderer renders cards to a static HTML preview for a host config.
+type Renderer struct {
+	hc HostConfig
+}
+
+// NewRenderer creates a Renderer for hc.
+func NewRenderer(hc HostConfig) *Renderer {
+	return &Renderer{hc: hc}
+}
+
+// Render writes an HTML document previewing card to w and returns the
+// issues found while rendering it.
+func (r *Renderer) Render(w io.Writer, card adaptivecards.AdaptiveCard) ([]Issue, error) {
+	s := r.newState()
+	s.card("card", card)
+	return s.issues, s.document(w)
+}
+
+// RenderWorkflowCard writes an HTML document previewing every Adaptive Card
+// attached to a workflow message.
+func (r *Renderer) RenderWorkflowCard(w io.Writer, c adaptivecards.WorkflowConnectorCard) ([]Issue, error) {
+	s := r.newState()
+	for i, a := range c.Attachments {
+		s.card(fmt.Sprintf("attachments[%d].content", i), a.Content)
+	}
+	return s.issues, s.document(w)
+}
+
+// state holds the output and the issues of a single render.
+type state struct {
+	hc     HostConfig
+	out    strings.Builder
+	issues []Issue
+	// version is the version of the card being rendered, or "" when the
+	// card version is invalid.
+	version string
+}
+
+func (r *Renderer) newState() *state {
+	return &state{hc: r.hc}
+}
+
+func (s *state) issue(kind IssueKind, path, format string, args ...any) Issue {
+	i := Issue{Kind: kind, Path: path, Message: fmt.Sprintf(format, args...)}
+	s.issues = append(s.issues, i)
+	return i
+}
+
+func (s *state) printf(format string, args ...any) {
+	fmt.Fprintf(&s.out, format, args...)
+}
+
+// document writes the rendered cards into a standalone HTML page.
+func (s *state) document(w io.Writer) error {
+	var doc strings.Builder
+	doc.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Adaptive Card preview</title>\n<style>\n")
+	fmt.Fprintf(&doc, stylesheet, s.hc.FontFamily, s.hc.FontSizes.Default, s.hc.CardWidth, s.hc.Spacing.Padding, s.hc.Separator.LineThickness, s.hc.Separator.LineColor, s.hc.FontWeights.Bolder)
+	doc.WriteString("</style>\n</head>\n<body>\n")
+	if len(s.issues) > 0 {
+		fmt.Fprintf(&doc, "<div class=\"ac-issues\"><strong>%d issue(s)</strong><ul>", len(s.issues))
+		for _, i := range s.issues {
+			fmt.Fprintf(&doc, "<li class=\"ac-issue-%s\"><code>%s</code> %s</li>", i.Kind, html.EscapeString(i.Path), html.EscapeString(i.Message))
+		}
+		doc.WriteString("</ul></div>\n")
+	}
+	doc.WriteString(s.out.String())
+	doc.WriteString("\n</body>\n</html>\n")
+	_, err := io.WriteString(w, doc.String())
+	return err
+}
+
+const stylesheet = `body { background: #F0F0F0; font-family: %s; font-size: %dpx; margin: 24px; }
+.ac-card { width: %dpx; padding: %dpx; border-radius: 8px; box-shadow: 0 1px 3px rgba(0,0,0,.2); margin-bottom: 24px; box-sizing: border-box; }
+.ac-separator { border-top: %dpx solid %s; }
+.ac-textblock p { margin: 0; }
+.ac-textblock ul, .ac-textblock ol { margin: 0; padding-left: 20px; }
+.ac-factset { border-collapse: collapse; }
+.ac-factset td { padding: 0 12px 4px 0; vertical-align: top; }
+.ac-columnset { display: flex; gap: 8px; }
+.ac-table { border-collapse: collapse; width: 100%%; }
+.ac-table td { border: 1px solid #E0E0E0; padding: 6px; vertical-align: top; }
+.ac-table-header .ac-textblock { font-weight: %d !important; }
+.ac-badge { display: inline-block; padding: 2px 8px; border-radius: 12px; font-size: 12px; }
+.ac-actions { display: flex; flex-wrap: wrap; }
+.ac-action { display: inline-block; padding: 5px 12px; border: 1px solid #D1D1D1; border-radius: 4px; background: #FFFFFF; color: #242424; text-decoration: none; font: inherit; }
+.ac-action-positive { background: #5B5FC7; color: #FFFFFF; border-color: #5B5FC7; }
+.ac-action-destructive { color: #C4314B; }
+.ac-showcard summary { list-style: none; cursor: pointer; }
+.ac-hidden { opacity: .45; outline: 1px dashed #888888; }
+.ac-issue { outline: 2px dashed #C4314B; outline-offset: 2px; position: relative; }
+.ac-issue-label { display: block; font-size: 11px; color: #C4314B; }
+.ac-issues { background: #FCF4F6; border: 1px solid #C4314B; border-radius: 4px; padding: 8px 12px; margin-bottom: 16px; max-width: 600px; }
+.ac-issues code { color: #C4314B; }
+`
+
+// card renders a card, including its version checks.
+func (s *state) card(path string, card adaptivecards.AdaptiveCard) {
+	prevVersion := s.version
+	defer func() { s.version = prevVersion }()
+
+	s.version = ""
+	if cv, err := adaptivecards.ParseVersion(card.Version); err != nil {
+		s.issue(IssueVersion, path+".version", "invalid card version %q", card.Version)
+	} else {
+		s.version = card.Version
+		for _, e := range adaptivecards.ValidateVersion(card, card.Version) {
+			s.issue(IssueVersion, path+"."+e.FieldName, "%s", e.Error())
+		}
+		if hv, err := adaptivecards.ParseVersion(s.hc.MaxVersion); err == nil && !hv.SupportsVersion(cv) {
+			s.issue(IssueVersion, path+".version", "card version %s is newer than the host supports (%s)", cv, hv)
+		}
+	}
+
+	style := s.hc.containerStyle(card.Style)
+	s.printf(`<div class="ac-card" style="background:%s;color:%s">`, cssValue(style.BackgroundColor), cssValue(s.hc.foreground(style, nil, false)))
+	s.elements(path+".body", card.Body, style)
+	s.actions(path+".actions", card.Actions, true)
+	s.out.WriteString("</div>\n")
+}
+
+func (s *state) elements(path string, elements []adaptivecards.Element, style ContainerStyleConfig) {
+	for i, el := range elements {
+		s.element(fmt.Sprintf("%s[%d]", path, i), el, style, i == 0)
+	}
+}
+
+// element renders a single element wrapped in a div carrying its spacing,
+// separator and visibility, and highlights it when it has issues.
+func (s *state) element(path string, el adaptivecards.Element, style ContainerStyleConfig, first bool) {
+	if el == nil {
+		return
+	}
+	common := commonOf(el)
+	typeName := typeNameOf(el)
+
+	var issues []Issue
+	if s.version != "" {
+		issues = append(issues, s.versionIssues(path, typeName, el)...)
+	}
+
+	var classes []string
+	var css []string
+	if common != nil {
+		if !first {
+			css = append(css, fmt.Sprintf("margin-top:%dpx", s.hc.spacing(common.Spacing)))
+			if common.Separator {
+				classes = append(classes, "ac-separator")
+				css = append(css, fmt.Sprintf("padding-top:%dpx", s.hc.spacing(common.Spacing)))
+			}
+		}
+		if common.HorizontalAlignment != nil {
+			css = append(css, "text-align:"+cssValue(strings.ToLower(string(*common.HorizontalAlignment))))
+		}
+		if common.IsVisible != nil && !*common.IsVisible {
+			classes = append(classes, "ac-hidden")
+		}
+	} else if !first {
+		css = append(css, fmt.Sprintf("margin-top:%dpx", s.hc.Spacing.Default))
+	}
+
+	// Render the element first, so nested issues are listed after its own.
+	outer := s.out
+	s.out = strings.Builder{}
+	if !s.renderElement(path, el, style) {
+		issues = append(issues, s.issue(IssueUnsupported, path, "%s is not supported by the preview", typeName))
+	}
+	inner := s.out.String()
+	s.out = outer
+
+	if len(issues) > 0 {
+		classes = append(classes, "ac-issue")
+	}
+	s.printf(`<div class="%s" style="%s" data-path="%s">`, strings.Join(append([]string{"ac-element"}, classes...), " "), strings.Join(css, ";"), html.EscapeString(path))
+	for _, i := range issues {
+		s.printf(`<span class="ac-issue-label">%s</span>`, html.EscapeString(i.Message))
+	}
+	s.out.WriteString(inner)
+	s.out.WriteString("</div>")
+}
+
+// versionIssues reports the fields of el, and el itself, that need a newer
+// version than the card declares.
+func (s *state) versionIssues(path, typeName string, el any) []Issue {
+	var issues []Issue
+	cv := adaptivecards.MustParseVersion(s.version)
+	if v, ok := elementVersions[typeName]; ok {
+		if required := adaptivecards.MustParseVersion(v); !cv.SupportsVersion(required) {
+			issues = append(issues, s.issue(IssueVersion, path, "%s requires version %s but card version is %s", typeName, required, cv))
+		}
+	}
+	for _, e := range adaptivecards.ValidateVersion(el, s.version) {
+		issues = append(issues, s.issue(IssueVersion, path+"."+e.FieldName, "%s", e.Error()))
+	}
+	return issues
+}
+
+// renderElement writes the HTML of el and reports whether its type is
+// supported.
+func (s *state) renderElement(path string, el adaptivecards.Element, style ContainerStyleConfig) bool {
+	switch e := indirect(el).(type) {
+	case adaptivecards.TextBlock:
+		s.textBlock(e, style)
+	case adaptivecards.FactSet:
+		s.factSet(e)
+	case adaptivecards.ColumnSet:
+		s.columnSet(path, e, style)
+	case adaptivecards.Container:
+		s.container(path, e, style)
+	case adaptivecards.Table:
+		s.table(path, e, style)
+	case adaptivecards.Image:
+		s.image(path, e)
+	case adaptivecards.Badge:
+		s.badge(e, style)
+	case adaptivecards.ActionSet:
+		s.actions(path+".actions", e.Actions, false)
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *state) textBlock(t adaptivecards.TextBlock, style ContainerStyleConfig) {
+	size, weight := t.Size, t.Weight
+	if t.Style != nil && strings.ToLower(string(*t.Style)) == "heading" {
+		if size == nil {
+			large := adaptivecards.FontSizeLarge
+			size = &large
+		}
+		if weight == nil {
+			bolder := adaptivecards.FontWeightBolder
+			weight = &bolder
+		}
+	}
+	css := []string{
+		fmt.Sprintf("font-size:%dpx", s.hc.fontSize(size)),
+		fmt.Sprintf("font-weight:%d", s.hc.fontWeight(weight)),
+		"color:" + cssValue(s.hc.foreground(style, t.Color, t.IsSubtle != nil && *t.IsSubtle)),
+	}
+	if !t.Wrap {
+		css = append(css, "white-space:nowrap", "overflow:hidden", "text-overflow:ellipsis")
+	}
+	text := ""
+	if t.Text != nil {
+		text = *t.Text
+	}
+	s.printf(`<div class="ac-textblock" style="%s">%s</div>`, strings.Join(css, ";"), markdown(text))
+}
+
+func (s *state) factSet(f adaptivecards.FactSet) {
+	s.out.WriteString(`<table class="ac-factset">`)
+	for _, fact := range f.Facts {
+		s.printf(`<tr><td style="font-weight:%d">%s</td><td>%s</td></tr>`,
+			s.hc.FontWeights.Bolder, markdown(fact.Title), markdown(fact.Value))
+	}
+	s.out.WriteString("</table>")
+}
+
+func (s *state) columnSet(path string, cs adaptivecards.ColumnSet, style ContainerStyleConfig) {
+	s.out.WriteString(`<div class="ac-columnset">`)
+	for i, c := range cs.Columns {
+		colStyle := style
+		css := []string{"flex:" + columnFlex(c.Width), "min-width:0"}
+		if c.Style != nil {
+			colStyle = s.hc.containerStyle(c.Style)
+			css = append(css, "background:"+cssValue(colStyle.BackgroundColor), fmt.Sprintf("padding:%dpx", s.hc.Spacing.Small))
+		}
+		// The version of the column fields is checked with the ColumnSet.
+		s.printf(`<div class="ac-column" style="%s">`, strings.Join(css, ";"))
+		s.elements(fmt.Sprintf("%s.columns[%d].items", path, i), c.Items, colStyle)
+		s.out.WriteString("</div>")
+	}
+	s.out.WriteString("</div>")
+}
+
+// columnFlex maps a column width ("auto", "stretch", a weight or "50px")
+// to a CSS flex value.
+func columnFlex(width any) string {
+	switch w := width.(type) {
+	case float64:
+		return fmt.Sprintf("%g 1 0", w)
+	case int:
+		return fmt.Sprintf("%d 1 0", w)
+	case string:
+		switch {
+		case strings.ToLower(w) == "auto":
+			return "0 0 auto"
+		case strings.HasSuffix(w, "px"):
+			return "0 0 " + cssValue(w)
+		case w != "" && strings.ToLower(w) != "stretch":
+			return cssValue(w) + " 1 0"
+		}
+	}
+	return "1 1 0"
+}
+
+func (s *state) container(path string, c adaptivecards.Container, style ContainerStyleConfig) {
+	if c.Style != nil {
+		style = s.hc.containerStyle(c.Style)
+		s.printf(`<div class="ac-container" style="background:%s;padding:%dpx">`, cssValue(style.BackgroundColor), s.hc.Spacing.Padding)
+	} else {
+		s.out.WriteString(`<div class="ac-container">`)
+	}
+	s.elements(path+".items", c.Items, style)
+	s.out.WriteString("</div>")
+}
+
+func (s *state) table(path string, t adaptivecards.Table, style ContainerStyleConfig) {
+	s.out.WriteString(`<table class="ac-table">`)
+	for i, row := range t.Rows {
+		rowStyle := style
+		if row.Style != nil {
+			rowStyle = s.hc.containerStyle(row.Style)
+		}
+		s.printf(`<tr style="background:%s">`, cssValue(rowStyle.BackgroundColor))
+		for j, cell := range row.Cells {
+			cellStyle := rowStyle
+			if cell.Style != nil {
+				cellStyle = s.hc.containerStyle(cell.Style)
+			}
+			class := ""
+			if i == 0 && t.FirstRowAsHeader {
+				class = "ac-table-header"
+			}
+			s.printf(`<td class="%s" style="background:%s">`, class, cssValue(cellStyle.BackgroundColor))
+			s.elements(fmt.Sprintf("%s.rows[%d].cells[%d].items", path, i, j), cell.Items, cellStyle)
+			s.out.WriteString("</td>")
+		}
+		s.out.WriteString("</tr>")
+	}
+	s.out.WriteString("</table>")
+}
+
+func (s *state) image(path string, img adaptivecards.Image) {
+	if !safeURL(img.URL, true) {
+		s.issue(IssueInvalid, path+".url", "image url %q is not an http(s) or data URL", img.URL)
+		s.printf(`<div class="ac-image-missing">[image: %s]</div>`, html.EscapeString(img.AltText))
+		return
+	}
+	width := s.hc.imageSize(img.Size)
+	if img.Width != "" {
+		width = img.Width
+	}
+	css := []string{"max-width:100%", "width:" + cssValue(width)}
+	if img.Style != nil && strings.ToLower(string(*img.Style)) == "person" {
+		css = append(css, "border-radius:50%")
+	}
+	if img.BackgroundColor != "" {
+		css = append(css, "background:"+cssValue(img.BackgroundColor))
+	}
+	s.printf(`<img src="%s" alt="%s" style="%s">`, html.EscapeString(img.URL), html.EscapeString(img.AltText), strings.Join(css, ";"))
+}
+
+func (s *state) badge(b adaptivecards.Badge, style ContainerStyleConfig) {
+	color := s.hc.foreground(style, b.Style, false)
+	text := ""
+	if b.Text != nil {
+		text = *b.Text
+	}
+	title := ""
+	if b.Tooltip != nil {
+		title = *b.Tooltip
+	}
+	if b.Appearance != nil && strings.ToLower(string(*b.Appearance)) == "tint" {
+		s.printf(`<span class="ac-badge" title="%s" style="color:%s;border:1px solid %s">%s</span>`, html.EscapeString(title), cssValue(color), cssValue(color), html.EscapeString(text))
+		return
+	}
+	s.printf(`<span class="ac-badge" title="%s" style="background:%s;color:#FFFFFF">%s</span>`, html.EscapeString(title), cssValue(color), html.EscapeString(text))
+}
+
+// actions renders a row of buttons. Only the card level actions are
+// limited to the host's maxActions.
+func (s *state) actions(path string, actions []adaptivecards.Action, cardLevel bool) {
+	if len(actions) == 0 {
+		return
+	}
+	if cardLevel && s.hc.Actions.MaxActions > 0 && len(actions) > s.hc.Actions.MaxActions {
+		s.issue(IssueUnsupported, path, "%d actions, the host only shows the first %d", len(actions), s.hc.Actions.MaxActions)
+		actions = actions[:s.hc.Actions.MaxActions]
+	}
+	s.printf(`<div class="ac-actions" style="gap:%dpx;margin-top:%dpx">`, s.hc.Actions.ButtonSpacing, s.hc.Spacing.Default)
+	type showCard struct {
+		path   string
+		action adaptivecards.ActionShowCard
+	}
+	var showCards []showCard
+	for i, a := range actions {
+		actionPath := fmt.Sprintf("%s[%d]", path, i)
+		s.action(actionPath, a)
+		if sc, ok := indirect(a).(adaptivecards.ActionShowCard); ok && sc.Card != nil {
+			showCards = append(showCards, showCard{actionPath, sc})
+		}
+	}
+	s.out.WriteString("</div>")
+	for _, sc := range showCards {
+		s.printf(`<details class="ac-showcard"><summary class="ac-action">%s</summary>`, html.EscapeString(titleOf(sc.action.CommonActionProperties)))
+		s.card(sc.path+".card", *sc.action.Card)
+		s.out.WriteString("</details>")
+	}
+}
+
+func (s *state) action(path string, a adaptivecards.Action) {
+	if a == nil {
+		return
+	}
+	if s.version != "" {
+		for _, e := range adaptivecards.ValidateVersion(a, s.version) {
+			s.issue(IssueVersion, path+"."+e.FieldName, "%s", e.Error())
+		}
+	}
+
+	props := actionPropsOf(a)
+	class := "ac-action"
+	if props != nil && props.Style != nil && *props.Style != adaptivecards.ActionStyleDefault {
+		class += " ac-action-" + cssValue(strings.ToLower(string(*props.Style)))
+	}
+	title := html.EscapeString(titleOf(props))
+
+	switch act := indirect(a).(type) {
+	case adaptivecards.ActionOpenURL:
+		if !safeURL(act.URL, false) {
+			s.issue(IssueInvalid, path+".url", "url %q is not an http(s) URL", act.URL)
+			s.printf(`<span class="%s ac-issue">%s</span>`, class, title)
+			return
+		}
+		s.printf(`<a class="%s" href="%s" target="_blank" rel="noopener">%s</a>`, class, html.EscapeString(act.URL), title)
+	case adaptivecards.ActionShowCard:
+		// Rendered as a collapsible card below the action row.
+	case adaptivecards.ActionSubmit, adaptivecards.ActionExecute, adaptivecards.ActionToggleVisibility:
+		s.printf(`<button class="%s" disabled>%s</button>`, class, title)
+	default:
+		s.issue(IssueUnsupported, path, "%s is not supported by the preview", typeNameOf(a))
+		s.printf(`<button class="%s ac-issue" disabled>%s</button>`, class, title)
+	}
+}
+
+// indirect returns the value v points to, as SmartUnmarshalJSON stores
+// elements and actions as pointers.
+func indirect(v any) any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		return rv.Elem().Interface()
+	}
+	return v
+}
+
+// commonOf returns the embedded *Common of an element, if any.
+func commonOf(el any) *adaptivecards.Common {
+	v := reflect.ValueOf(el)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.FieldByName("Common")
+	if !f.IsValid() {
+		return nil
+	}
+	c, _ := f.Interface().(*adaptivecards.Common)
+	return c
+}
+
+// actionPropsOf returns the embedded *CommonActionProperties of an action,
+// if any.
+func actionPropsOf(a any) *adaptivecards.CommonActionProperties {
+	v := reflect.ValueOf(a)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	f := v.FieldByName("CommonActionProperties")
+	if !f.IsValid() {
+		return nil
+	}
+	p, _ := f.Interface().(*adaptivecards.CommonActionProperties)
+	return p
+}
+
+func titleOf(p *adaptivecards.CommonActionProperties) string {
+	if p == nil || p.Title == "" {
+		return "Action"
+	}
+	return p.Title
+}
+
+// typeNameOf returns the "type" an element or action is marshaled with.
+func typeNameOf(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%T", v)
+	}
+	var t adaptivecards.AdaptiveCardType
+	if err := json.Unmarshal(b, &t); err != nil || t.Type == "" {
+		return fmt.Sprintf("%T", v)
+	}
+	return t.Type
+}
+
+// cssValue makes s safe to use as a value in an inline style attribute.
+func cssValue(s string) string {
+	return html.EscapeString(strings.NewReplacer(";", "", "{", "", "}", "", "\"", "", "'", "").Replace(s))
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ac "github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func render(t *testing.T, card ac.AdaptiveCard) (string, []Issue) {
+	t.Helper()
+	var buf bytes.Buffer
+	issues, err := NewRenderer(TeamsHostConfig()).Render(&buf, card)
+	require.NoError(t, err)
+	return buf.String(), issues
+}
+
+func TestRenderSupportedElements(t *testing.T) {
+	card := ac.AdaptiveCard{
+		Version: "1.5",
+		Body: []ac.Element{
+			ac.TextBlock{Text: ac.AsPtr("**Disk full**"), Size: ac.AsPtr(ac.FontSizeLarge), Color: ac.AsPtr(ac.ColorAttention)},
+			ac.FactSet{Facts: []ac.Fact{{Title: "severity", Value: "critical"}}},
+			ac.ColumnSet{Columns: []ac.Column{
+				{Width: "auto", Items: []ac.Element{ac.TextBlock{Text: ac.AsPtr("left")}}},
+				{Width: float64(2), Items: []ac.Element{ac.TextBlock{Text: ac.AsPtr("right")}}},
+			}},
+			ac.Container{Style: ac.AsPtr(ac.ContainerStyleGood), Items: []ac.Element{ac.TextBlock{Text: ac.AsPtr("inside")}}},
+			ac.Table{FirstRowAsHeader: true, Rows: []ac.TableRow{
+				{Cells: []ac.TableCell{{Items: []ac.Element{ac.TextBlock{Text: ac.AsPtr("instance")}}}}},
+				{Cells: []ac.TableCell{{Items: []ac.Element{ac.TextBlock{Text: ac.AsPtr("node-1")}}}}},
+			}},
+			ac.Image{URL: "https://example.com/graph.png", AltText: "graph", Size: ac.AsPtr(ac.ImageSizeSmall)},
+			ac.Badge{Text: ac.AsPtr("firing"), Style: ac.AsPtr(ac.ColorAttention)},
+			ac.ActionSet{Actions: []ac.Action{ac.ActionSubmit{CommonActionProperties: &ac.CommonActionProperties{Title: "Ack"}}}},
+		},
+		Actions: []ac.Action{
+			ac.ActionOpenURL{CommonActionProperties: &ac.CommonActionProperties{Title: "Runbook"}, URL: "https://example.com/runbook"},
+			ac.ActionShowCard{
+				CommonActionProperties: &ac.CommonActionProperties{Title: "Details"},
+				Card:                   &ac.AdaptiveCard{Version: "1.5", Body: []ac.Element{ac.TextBlock{Text: ac.AsPtr("more")}}},
+			},
+		},
+	}
+
+	out, issues := render(t, card)
+	assert.Empty(t, issues)
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<strong>Disk full</strong>",
+		"font-size:18px",
+		"color:#C4314B",
+		`<table class="ac-factset">`,
+		"flex:0 0 auto",
+		"flex:2 1 0",
+		"background:#E7F2DA",
+		`<td class="ac-table-header"`,
+		`<img src="https://example.com/graph.png" alt="graph" style="max-width:100%;width:32px">`,
+		`<span class="ac-badge"`,
+		`<button class="ac-action" disabled>Ack</button>`,
+		`<a class="ac-action" href="https://example.com/runbook"`,
+		`<summary class="ac-action">Details</summary>`,
+		`data-path="card.actions[1].card.body[0]"`,
+	} {
+		assert.Contains(t, out, want)
+	}
+	assert.NotContains(t, out, `<div class="ac-issues">`)
+}
+
+func TestRenderUnsupportedElement(t *testing.T) {
+	out, issues := render(t, ac.AdaptiveCard{
+		Version: "1.5",
+		Body:    []ac.Element{ac.CodeBlock{CodeSnippet: "up == 0"}},
+		Actions: []ac.Action{ac.ActionResetInputs{CommonActionProperties: &ac.CommonActionProperties{Title: "Reset"}}},
+	})
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, Issue{Kind: IssueUnsupported, Path: "card.body[0]", Message: "CodeBlock is not supported by the preview"}, issues[0])
+	assert.Equal(t, IssueUnsupported, issues[1].Kind)
+	assert.Equal(t, "card.actions[0]", issues[1].Path)
+	assert.Contains(t, out, `class="ac-element ac-issue"`)
+	assert.Contains(t, out, "<strong>2 issue(s)</strong>")
+}
+
+func TestRenderVersionIssues(t *testing.T) {
+	_, issues := render(t, ac.AdaptiveCard{
+		Version: "1.2",
+		Body: []ac.Element{
+			ac.Badge{Text: ac.AsPtr("new")},
+			ac.TextBlock{Text: ac.AsPtr("x"), LabelFor: "input"},
+		},
+	})
+	var paths []string
+	for _, i := range issues {
+		assert.Equal(t, IssueVersion, i.Kind)
+		paths = append(paths, i.Path)
+	}
+	assert.Equal(t, []string{"card.body[0]", "card.body[0].Text", "card.body[1].LabelFor"}, paths)
+
+	_, issues = render(t, ac.AdaptiveCard{Version: "1.6"})
+	require.Len(t, issues, 1)
+	assert.Equal(t, "card version 1.6 is newer than the host supports (1.5)", issues[0].Message)
+
+	_, issues = render(t, ac.AdaptiveCard{Version: "latest"})
+	require.Len(t, issues, 1)
+	assert.Equal(t, "card.version", issues[0].Path)
+}
+
+func TestRenderSpacingSeparatorAndVisibility(t *testing.T) {
+	out, _ := render(t, ac.AdaptiveCard{
+		Version: "1.5",
+		Body: []ac.Element{
+			ac.TextBlock{Text: ac.AsPtr("first")},
+			ac.TextBlock{Common: &ac.Common{Separator: true, Spacing: ac.AsPtr(ac.SpacingLarge)}, Text: ac.AsPtr("second")},
+			ac.TextBlock{Common: &ac.Common{IsVisible: ac.AsPtr(false)}, Text: ac.AsPtr("hidden")},
+		},
+	})
+	assert.Contains(t, out, `class="ac-element ac-separator" style="margin-top:20px;padding-top:20px"`)
+	assert.Contains(t, out, `class="ac-element ac-hidden"`)
+}
+
+func TestRenderUnsafeValues(t *testing.T) {
+	out, issues := render(t, ac.AdaptiveCard{
+		Version: "1.5",
+		Body: []ac.Element{
+			ac.Image{URL: "javascript:alert(1)", AltText: "<b>"},
+			ac.Image{URL: "https://example.com/a.png", Width: "10px;background:url(x)"},
+		},
+		Actions: []ac.Action{
+			ac.ActionOpenURL{URL: "javascript:alert(1)"},
+		},
+	})
+	require.Len(t, issues, 2)
+	assert.Equal(t, IssueInvalid, issues[0].Kind)
+	assert.Equal(t, IssueInvalid, issues[1].Kind)
+	assert.NotContains(t, out, "javascript:alert(1)\"")
+	assert.Contains(t, out, "[image: &lt;b&gt;]")
+	assert.Contains(t, out, "width:10pxbackground:url(x)")
+}
+
+func TestRenderMaxActions(t *testing.T) {
+	hc := TeamsHostConfig()
+	hc.Actions.MaxActions = 1
+
+	var buf bytes.Buffer
+	issues, err := NewRenderer(hc).Render(&buf, ac.AdaptiveCard{
+		Version: "1.5",
+		Actions: []ac.Action{
+			ac.ActionSubmit{CommonActionProperties: &ac.CommonActionProperties{Title: "one"}},
+			ac.ActionSubmit{CommonActionProperties: &ac.CommonActionProperties{Title: "two"}},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "2 actions, the host only shows the first 1", issues[0].Message)
+	assert.Contains(t, buf.String(), ">one</button>")
+	assert.NotContains(t, buf.String(), ">two</button>")
+}
+
+// TestRenderSamples renders the round-trip corpus, which is decoded into
+// pointers by SmartUnmarshalJSON.
+func TestRenderSamples(t *testing.T) {
+	files, err := filepath.Glob("../../test/data/samples/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	r := NewRenderer(TeamsHostConfig())
+	for _, f := range files {
+		t.Run(filepath.Base(f), func(t *testing.T) {
+			b, err := os.ReadFile(f) //nolint:gosec
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			var typ ac.AdaptiveCardType
+			require.NoError(t, json.Unmarshal(b, &typ))
+			if typ.Type == "message" {
+				var wc ac.WorkflowConnectorCard
+				require.NoError(t, json.Unmarshal(b, &wc))
+				_, err = r.RenderWorkflowCard(&buf, wc)
+				require.NoError(t, err)
+				assert.Contains(t, buf.String(), "attachments[0].content.body[0]")
+				return
+			}
+			var card ac.AdaptiveCard
+			require.NoError(t, json.Unmarshal(b, &card))
+			_, err = r.Render(&buf, card)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), `data-path="card.body[0]"`)
+		})
+	}
+}
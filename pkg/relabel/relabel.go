@@ -0,0 +1,233 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package relabel rewrites label sets with the semantics of the Prometheus
+// relabel_configs.
+package relabel
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action is the action of a Config.
+type Action string
+
+const (
+	// Replace sets TargetLabel to Replacement, expanded with the groups of
+	// Regex, if Regex matches the concatenated SourceLabels.
+	Replace Action = "replace"
+	// Keep drops the label sets for which Regex does not match the
+	// concatenated SourceLabels.
+	Keep Action = "keep"
+	// Drop drops the label sets for which Regex matches the concatenated
+	// SourceLabels.
+	Drop Action = "drop"
+	// LabelDrop removes the labels whose name matches Regex.
+	LabelDrop Action = "labeldrop"
+	// LabelKeep removes the labels whose name does not match Regex.
+	LabelKeep Action = "labelkeep"
+	// LabelMap copies the labels whose name matches Regex to the label
+	// named Replacement, expanded with the groups of Regex.
+	LabelMap Action = "labelmap"
+)
+
+// Defaults of Config.
+const (
+	DefaultSeparator   = ";"
+	DefaultRegex       = "(.*)"
+	DefaultReplacement = "$1"
+)
+
+// Regexp is a regular expression anchored at both ends.
+type Regexp struct {
+	*regexp.Regexp
+	original string
+}
+
+// NewRegexp compiles s anchored at both ends.
+func NewRegexp(s string) (Regexp, error) {
+	r, err := regexp.Compile("^(?:" + s + ")$")
+	return Regexp{Regexp: r, original: s}, err
+}
+
+// MustNewRegexp is like NewRegexp but panics if s does not compile.
+func MustNewRegexp(s string) Regexp {
+	r, err := NewRegexp(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// String returns the regular expression without its anchors.
+func (r Regexp) String() string {
+	return r.original
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (r *Regexp) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return err
+	}
+	re, err := NewRegexp(s)
+	if err != nil {
+		return err
+	}
+	*r = re
+	return nil
+}
+
+// Config is a relabeling step.
+type Config struct {
+	SourceLabels []string `yaml:"source_labels"`
+	Separator    string   `yaml:"separator"`
+	Regex        Regexp   `yaml:"regex"`
+	TargetLabel  string   `yaml:"target_label"`
+	Replacement  string   `yaml:"replacement"`
+	Action       Action   `yaml:"action"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, setting the defaults of the
+// fields not set and validating the config.
+func (c *Config) UnmarshalYAML(value *yaml.Node) error {
+	type plain Config
+	*c = Config{
+		Separator:   DefaultSeparator,
+		Regex:       MustNewRegexp(DefaultRegex),
+		Replacement: DefaultReplacement,
+		Action:      Replace,
+	}
+	if err := value.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	return c.Validate()
+}
+
+// Validate checks that c has the fields required by its action.
+func (c *Config) Validate() error {
+	if c.Regex.Regexp == nil {
+		return fmt.Errorf("relabel action %s requires a regex", c.Action)
+	}
+	switch c.Action {
+	case Replace:
+		if c.TargetLabel == "" {
+			return fmt.Errorf("relabel action %s requires a target_label", c.Action)
+		}
+	case Keep, Drop:
+		if len(c.SourceLabels) == 0 {
+			return fmt.Errorf("relabel action %s requires source_labels", c.Action)
+		}
+	case LabelDrop, LabelKeep:
+		if len(c.SourceLabels) > 0 || c.TargetLabel != "" {
+			return fmt.Errorf("relabel action %s only uses the regex", c.Action)
+		}
+	case LabelMap:
+		if c.Replacement == "" {
+			return fmt.Errorf("relabel action %s requires a replacement", c.Action)
+		}
+	default:
+		return fmt.Errorf("unknown relabel action %q", c.Action)
+	}
+	return nil
+}
+
+// Configs is a list of relabeling steps. Unlike a []*Config, it rejects
+// the empty items of a YAML list, which would be nil steps.
+type Configs []*Config
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (cs *Configs) UnmarshalYAML(value *yaml.Node) error {
+	var cfgs []*Config
+	if err := value.Decode(&cfgs); err != nil {
+		return err
+	}
+	for i, c := range cfgs {
+		if c == nil {
+			return fmt.Errorf("relabel config %d is empty", i)
+		}
+	}
+	*cs = cfgs
+	return nil
+}
+
+// Process applies cfgs to ls in order. It returns false if ls is dropped
+// by a Keep or Drop step, and otherwise ls, modified in place.
+func Process(ls map[string]string, cfgs ...*Config) (map[string]string, bool) {
+	for _, c := range cfgs {
+		if !apply(ls, c) {
+			return nil, false
+		}
+	}
+	return ls, true
+}
+
+func apply(ls map[string]string, c *Config) bool {
+	values := make([]string, 0, len(c.SourceLabels))
+	for _, name := range c.SourceLabels {
+		values = append(values, ls[name])
+	}
+	val := strings.Join(values, c.Separator)
+
+	switch c.Action {
+	case Keep:
+		return c.Regex.MatchString(val)
+	case Drop:
+		return !c.Regex.MatchString(val)
+	case Replace:
+		indexes := c.Regex.FindStringSubmatchIndex(val)
+		if indexes == nil {
+			return true
+		}
+		target := string(c.Regex.ExpandString(nil, c.TargetLabel, val, indexes))
+		if target == "" {
+			return true
+		}
+		res := string(c.Regex.ExpandString(nil, c.Replacement, val, indexes))
+		if res == "" {
+			delete(ls, target)
+		} else {
+			ls[target] = res
+		}
+	case LabelDrop:
+		for name := range ls {
+			if c.Regex.MatchString(name) {
+				delete(ls, name)
+			}
+		}
+	case LabelKeep:
+		for name := range ls {
+			if !c.Regex.MatchString(name) {
+				delete(ls, name)
+			}
+		}
+	case LabelMap:
+		mapped := map[string]string{}
+		for name, v := range ls {
+			if c.Regex.MatchString(name) {
+				mapped[c.Regex.ReplaceAllString(name, c.Replacement)] = v
+			}
+		}
+		for name, v := range mapped {
+			ls[name] = v
+		}
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package relabel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func parseConfigs(t *testing.T, s string) []*Config {
+	t.Helper()
+	var cfgs []*Config
+	require.NoError(t, yaml.Unmarshal([]byte(s), &cfgs))
+	return cfgs
+}
+
+func TestConfigDefaults(t *testing.T) {
+	cfgs := parseConfigs(t, `[{target_label: team}]`)
+	require.Len(t, cfgs, 1)
+	assert.Equal(t, Replace, cfgs[0].Action)
+	assert.Equal(t, DefaultSeparator, cfgs[0].Separator)
+	assert.Equal(t, DefaultRegex, cfgs[0].Regex.String())
+	assert.Equal(t, DefaultReplacement, cfgs[0].Replacement)
+}
+
+func TestConfigInvalid(t *testing.T) {
+	for _, s := range []string{
+		`[{action: replace}]`,
+		`[{action: keep}]`,
+		`[{action: labeldrop, source_labels: [a]}]`,
+		`[{action: labelmap, replacement: ""}]`,
+		`[{action: hashmod}]`,
+		`[{target_label: a, regex: "("}]`,
+	} {
+		var cfgs []*Config
+		assert.Error(t, yaml.Unmarshal([]byte(s), &cfgs), s)
+	}
+}
+
+func TestConfigsEmptyItem(t *testing.T) {
+	var cfgs Configs
+	require.NoError(t, yaml.Unmarshal([]byte(`[{target_label: team}]`), &cfgs))
+	assert.Len(t, cfgs, 1)
+
+	// An empty item would be a nil step.
+	for _, s := range []string{"[null]", "- target_label: team\n-\n"} {
+		assert.ErrorContains(t, yaml.Unmarshal([]byte(s), &cfgs), "is empty", s)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs string
+		in      map[string]string
+		want    map[string]string
+		keep    bool
+	}{
+		{
+			name:    "drop",
+			configs: `[{action: drop, source_labels: [alertname], regex: Watchdog|InfoInhibitor}]`,
+			in:      map[string]string{"alertname": "Watchdog"},
+		},
+		{
+			name:    "drop no match",
+			configs: `[{action: drop, source_labels: [alertname], regex: Watchdog}]`,
+			in:      map[string]string{"alertname": "WatchdogDown"},
+			want:    map[string]string{"alertname": "WatchdogDown"},
+			keep:    true,
+		},
+		{
+			name:    "keep",
+			configs: `[{action: keep, source_labels: [severity, team], regex: "critical;.+"}]`,
+			in:      map[string]string{"severity": "critical", "team": "platform"},
+			want:    map[string]string{"severity": "critical", "team": "platform"},
+			keep:    true,
+		},
+		{
+			name:    "keep no match",
+			configs: `[{action: keep, source_labels: [severity, team], regex: "critical;.+"}]`,
+			in:      map[string]string{"severity": "critical"},
+		},
+		{
+			name: "replace",
+			configs: `
+- source_labels: [kubernetes_namespace]
+  regex: (.+)
+  target_label: namespace
+- action: labeldrop
+  regex: kubernetes_namespace
+`,
+			in:   map[string]string{"kubernetes_namespace": "monitoring"},
+			want: map[string]string{"namespace": "monitoring"},
+			keep: true,
+		},
+		{
+			name:    "replace no match",
+			configs: `[{source_labels: [kubernetes_namespace], regex: (.+), target_label: namespace}]`,
+			in:      map[string]string{"job": "node"},
+			want:    map[string]string{"job": "node"},
+			keep:    true,
+		},
+		{
+			name:    "replace empty removes",
+			configs: `[{target_label: job, replacement: ""}]`,
+			in:      map[string]string{"job": "node"},
+			want:    map[string]string{},
+			keep:    true,
+		},
+		{
+			name:    "labelkeep",
+			configs: `[{action: labelkeep, regex: "alertname|severity"}]`,
+			in:      map[string]string{"alertname": "A", "severity": "info", "pod": "p"},
+			want:    map[string]string{"alertname": "A", "severity": "info"},
+			keep:    true,
+		},
+		{
+			name:    "labelmap",
+			configs: `[{action: labelmap, regex: "kubernetes_(.+)"}]`,
+			in:      map[string]string{"kubernetes_pod": "p", "job": "node"},
+			want:    map[string]string{"kubernetes_pod": "p", "pod": "p", "job": "node"},
+			keep:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, keep := Process(tt.in, parseConfigs(t, tt.configs)...)
+			assert.Equal(t, tt.keep, keep)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// DefaultDigestTop is the number of alerts listed in the Top of a digest
+// when DigestConfig.Top is not set.
+const DefaultDigestTop = 10
+
+// DigestConfig configures a DigestService.
+type DigestConfig struct {
+	// Window is the time the notifications are buffered, from the first one.
+	Window time.Duration
+	// MaxNotifications flushes the digest once it holds that many
+	// notifications. Zero means no limit.
+	MaxNotifications int
+	// Top is the number of firing alerts listed in the digest.
+	Top int
+	// Bypass selects the alerts posted right away instead of being
+	// buffered: an alert bypasses the digest if its labels match all the
+	// matchers of one of the entries.
+	Bypass [][]*labels.Matcher
+}
+
+// digestBuffer holds the notifications buffered by a DigestService.
+type digestBuffer struct {
+	start         time.Time
+	notifications int
+	// alerts holds the last state of each alert, in the order they were
+	// first received.
+	alerts      map[string]int
+	alertsOrder template.Alerts
+	last        webhook.Message
+	values      context.Context
+	timer       *time.Timer
+}
+
+// DigestService is a middleware for Service buffering the notifications of a
+// route and posting them as one digest notification, whose card is rendered
+// with the card.Digest of the notifications.
+type DigestService struct {
+	ctx     context.Context
+	route   string
+	cfg     DigestConfig
+	next    Service
+	logger  *utility.Logger
+	tracker *Tracker
+
+	mu  sync.Mutex
+	buf *digestBuffer
+}
+
+// DigestOption configures a DigestService.
+type DigestOption func(*DigestService)
+
+// WithDigestTracker adds the digests posted at the end of their window to t,
+// and flushes the buffered notifications when t is flushed.
+func WithDigestTracker(t *Tracker) DigestOption {
+	return func(d *DigestService) {
+		d.tracker = t
+	}
+}
+
+// NewDigestService creates the DigestService of route posting the digests with
+// next. The digests posted once their window is over are canceled with ctx.
+func NewDigestService(ctx context.Context, route string, cfg DigestConfig, next Service, logger *utility.Logger, opts ...DigestOption) (*DigestService, error) {
+	if cfg.Window <= 0 {
+		return nil, fmt.Errorf("digest window must be positive, got %s", cfg.Window)
+	}
+	return newDigestService(ctx, route, cfg, next, logger, opts...)
+}
+
+// newDigestService creates a DigestService without a window when cfg.Window
+// is zero: it buffers the notifications until they are flushed.
+func newDigestService(ctx context.Context, route string, cfg DigestConfig, next Service, logger *utility.Logger, opts ...DigestOption) (*DigestService, error) {
+	if cfg.MaxNotifications < 0 {
+		return nil, fmt.Errorf("digest max notifications must not be negative, got %d", cfg.MaxNotifications)
+	}
+	if cfg.Top <= 0 {
+		cfg.Top = DefaultDigestTop
+	}
+	d := &DigestService{
+		ctx:    ctx,
+		route:  route,
+		cfg:    cfg,
+		next:   next,
+		logger: logger.WithPrefix("package", "service", "component", "digest", "route", route),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.tracker != nil {
+		d.tracker.addFlusher(d)
+	}
+	return d, nil
+}
+
+// Post posts the alerts of wm bypassing the digest and buffers the others.
+// It posts the digest when it reaches cfg.MaxNotifications.
+func (d *DigestService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	if wm.Data == nil {
+		return d.next.Post(ctx, wm)
+	}
+	bypass, buffered := d.split(wm.Alerts)
+	var prs []PostResponse
+	if len(bypass) > 0 {
+		var err error
+		if prs, err = d.next.Post(ctx, withAlerts(wm, bypass)); err != nil {
+			return prs, err
+		}
+	}
+	if len(buffered) == 0 {
+		return prs, nil
+	}
+
+	if b := d.add(ctx, wm, buffered); b != nil {
+		dprs, err := d.post(ctx, b)
+		return append(prs, dprs...), err
+	}
+	return prs, nil
+}
+
+// Flush posts the buffered notifications, if any.
+func (d *DigestService) Flush(ctx context.Context) error {
+	b := d.take(nil)
+	if b == nil {
+		return nil
+	}
+	_, err := d.post(queueContext{Context: ctx, values: b.values}, b)
+	return err
+}
+
+func (d *DigestService) split(alerts template.Alerts) (bypass, buffered template.Alerts) {
+	for _, a := range alerts {
+		if matchesAny(d.cfg.Bypass, a.Labels) {
+			bypass = append(bypass, a)
+		} else {
+			buffered = append(buffered, a)
+		}
+	}
+	return bypass, buffered
+}
+
+// matchesAny reports whether ls matches all the matchers of one of sets.
+func matchesAny(sets [][]*labels.Matcher, ls template.KV) bool {
+	for _, matchers := range sets {
+		matched := len(matchers) > 0
+		for _, m := range matchers {
+			if !m.Matches(ls[m.Name]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// add buffers the alerts of wm and returns the buffer to post if it is full.
+func (d *DigestService) add(ctx context.Context, wm webhook.Message, alerts template.Alerts) *digestBuffer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	b := d.buf
+	if b == nil {
+		b = &digestBuffer{start: time.Now(), alerts: map[string]int{}}
+		if d.cfg.Window > 0 {
+			b.timer = time.AfterFunc(d.cfg.Window, func() { d.flushWindow(b) })
+		}
+		d.buf = b
+	}
+	b.notifications++
+	b.last = wm
+	b.values = ctx
+	for _, a := range alerts {
+		if i, ok := b.alerts[a.Fingerprint]; ok {
+			b.alertsOrder[i] = a
+			continue
+		}
+		b.alerts[a.Fingerprint] = len(b.alertsOrder)
+		b.alertsOrder = append(b.alertsOrder, a)
+	}
+	if d.cfg.MaxNotifications > 0 && b.notifications >= d.cfg.MaxNotifications {
+		return d.takeLocked(b)
+	}
+	return nil
+}
+
+// take removes the buffer from d if it is b, or any buffer if b is nil.
+func (d *DigestService) take(b *digestBuffer) *digestBuffer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.takeLocked(b)
+}
+
+func (d *DigestService) takeLocked(b *digestBuffer) *digestBuffer {
+	if d.buf == nil || (b != nil && d.buf != b) {
+		return nil
+	}
+	b, d.buf = d.buf, nil
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return b
+}
+
+// pending returns the number of notifications buffered.
+func (d *DigestService) pending() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.buf == nil {
+		return 0
+	}
+	return d.buf.notifications
+}
+
+func (d *DigestService) flushWindow(b *digestBuffer) {
+	if b = d.take(b); b == nil {
+		return
+	}
+	ctx := queueContext{Context: d.ctx, values: b.values}
+	if d.tracker != nil {
+		defer d.tracker.start(newInFlight(ctx, d.message(b)))()
+	}
+	if _, err := d.post(ctx, b); err != nil {
+		d.logger.Err(err, "message", "failed to post digest")
+	}
+}
+
+func (d *DigestService) post(ctx context.Context, b *digestBuffer) ([]PostResponse, error) {
+	wm := d.message(b)
+	digest := card.NewDigest(b.start, time.Now(), b.notifications, b.alertsOrder, d.cfg.Top)
+	return d.next.Post(card.ContextWithDigest(ctx, digest), wm)
+}
+
+// withAlerts returns a copy of wm holding alerts.
+func withAlerts(wm webhook.Message, alerts template.Alerts) webhook.Message {
+	data := *wm.Data
+	data.Alerts = alerts
+	wm.Data = &data
+	return wm
+}
+
+// message merges the buffered notifications: it holds the last state of their
+// alerts and the labels and annotations common to all of them.
+func (d *DigestService) message(b *digestBuffer) webhook.Message {
+	wm := webhook.Message{
+		Data: &template.Data{
+			Receiver:    b.last.Receiver,
+			Alerts:      b.alertsOrder,
+			GroupLabels: template.KV{},
+			ExternalURL: b.last.ExternalURL,
+		},
+		Version:  b.last.Version,
+		GroupKey: "digest:" + d.route,
+	}
+	recomputeCommon(&wm)
+	return wm
+}
+
+// recomputeCommon sets the status of wm and its labels and annotations
+// common to all its alerts, e.g. once some of them were dropped or changed.
+func recomputeCommon(wm *webhook.Message) {
+	wm.Status = "resolved"
+	wm.CommonLabels = template.KV{}
+	wm.CommonAnnotations = template.KV{}
+	for i, a := range wm.Alerts {
+		if a.Status == "firing" {
+			wm.Status = "firing"
+		}
+		if i == 0 {
+			wm.CommonLabels = copyKV(a.Labels)
+			wm.CommonAnnotations = copyKV(a.Annotations)
+			continue
+		}
+		intersectKV(wm.CommonLabels, a.Labels)
+		intersectKV(wm.CommonAnnotations, a.Annotations)
+	}
+}
+
+func copyKV(kv template.KV) template.KV {
+	out := make(template.KV, len(kv))
+	for k, v := range kv {
+		out[k] = v
+	}
+	return out
+}
+
+// intersectKV removes from kv the pairs not in other.
+func intersectKV(kv, other template.KV) {
+	for k, v := range kv {
+		if w, ok := other[k]; !ok || w != v {
+			delete(kv, k)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// digestRecorder records the notifications posted and their digest.
+type digestRecorder struct {
+	mu      sync.Mutex
+	posted  []webhook.Message
+	digests []*card.Digest
+	routes  []string
+}
+
+func (s *digestRecorder) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posted = append(s.posted, wm)
+	s.digests = append(s.digests, card.DigestFromContext(ctx))
+	s.routes = append(s.routes, metrics.RouteFromContext(ctx))
+	return nil, nil
+}
+
+func (s *digestRecorder) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.posted)
+}
+
+func alertsMessage(groupKey string, alerts ...template.Alert) webhook.Message {
+	return webhook.Message{
+		Data:     &template.Data{Receiver: "teams", Status: "firing", Alerts: alerts},
+		Version:  "4",
+		GroupKey: groupKey,
+	}
+}
+
+func alert(fingerprint, status, severity string) template.Alert {
+	return template.Alert{
+		Status:      status,
+		Labels:      template.KV{"alertname": "HighLoad", "severity": severity, "instance": fingerprint},
+		Fingerprint: fingerprint,
+	}
+}
+
+func TestNewDigestServiceInvalid(t *testing.T) {
+	_, err := NewDigestService(context.Background(), "/a", DigestConfig{}, &digestRecorder{}, queueLogger)
+	assert.Error(t, err)
+	_, err = NewDigestService(context.Background(), "/a", DigestConfig{Window: time.Minute, MaxNotifications: -1}, &digestRecorder{}, queueLogger)
+	assert.Error(t, err)
+}
+
+func TestDigestServiceWithoutWindow(t *testing.T) {
+	next := &digestRecorder{}
+	d, err := newDigestService(context.Background(), "/alertmanager", DigestConfig{}, next, queueLogger)
+	require.NoError(t, err)
+
+	_, err = d.Post(context.Background(), alertsMessage("a", alert("1", "firing", "warning")))
+	require.NoError(t, err)
+	assert.Zero(t, next.len())
+	assert.Equal(t, 1, d.pending())
+
+	require.NoError(t, d.Flush(context.Background()))
+	assert.Equal(t, 1, next.len())
+	assert.Zero(t, d.pending())
+}
+
+func TestDigestService_MaxNotifications(t *testing.T) {
+	next := &digestRecorder{}
+	d, err := NewDigestService(context.Background(), "/alertmanager", DigestConfig{Window: time.Hour, MaxNotifications: 2}, next, queueLogger)
+	require.NoError(t, err)
+	ctx := metrics.ContextWithRoute(context.Background(), "/alertmanager")
+
+	_, err = d.Post(ctx, alertsMessage("a", alert("1", "firing", "warning"), alert("2", "firing", "warning")))
+	require.NoError(t, err)
+	assert.Zero(t, next.len())
+	_, err = d.Post(ctx, alertsMessage("b", alert("1", "resolved", "warning")))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, next.len())
+	wm := next.posted[0]
+	assert.Equal(t, "digest:/alertmanager", wm.GroupKey)
+	assert.Equal(t, "firing", wm.Status)
+	assert.Equal(t, "teams", wm.Receiver)
+	assert.Equal(t, template.KV{"alertname": "HighLoad", "severity": "warning"}, wm.CommonLabels)
+	require.Len(t, wm.Alerts, 2)
+	assert.Equal(t, "resolved", wm.Alerts[0].Status)
+	assert.Equal(t, "/alertmanager", next.routes[0])
+
+	digest := next.digests[0]
+	require.NotNil(t, digest)
+	assert.Equal(t, 2, digest.Notifications)
+	assert.Equal(t, 1, digest.Firing)
+	assert.Len(t, digest.Resolved, 1)
+
+	// The next notification starts a new digest.
+	_, err = d.Post(ctx, alertsMessage("c", alert("3", "firing", "warning")))
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.len())
+}
+
+func TestDigestService_Window(t *testing.T) {
+	tracker := NewTracker()
+	next := &digestRecorder{}
+	d, err := NewDigestService(context.Background(), "/alertmanager", DigestConfig{Window: 10 * time.Millisecond}, next, queueLogger, WithDigestTracker(tracker))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(metrics.ContextWithRoute(context.Background(), "/alertmanager"))
+	_, err = d.Post(ctx, alertsMessage("a", alert("1", "firing", "warning")))
+	require.NoError(t, err)
+	cancel()
+
+	assert.Eventually(t, func() bool { return next.len() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, tracker.Wait(context.Background()))
+	assert.Equal(t, "/alertmanager", next.routes[0])
+	assert.Equal(t, 1, next.digests[0].Notifications)
+}
+
+func TestDigestService_Bypass(t *testing.T) {
+	next := &digestRecorder{}
+	bypass, err := labels.ParseMatchers(`{severity="critical"}`)
+	require.NoError(t, err)
+	d, err := NewDigestService(context.Background(), "/alertmanager", DigestConfig{Window: time.Hour, Bypass: [][]*labels.Matcher{bypass}}, next, queueLogger)
+	require.NoError(t, err)
+
+	_, err = d.Post(context.Background(), alertsMessage("a", alert("1", "firing", "critical"), alert("2", "firing", "warning")))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, next.len())
+	assert.Equal(t, "a", next.posted[0].GroupKey)
+	require.Len(t, next.posted[0].Alerts, 1)
+	assert.Equal(t, "1", next.posted[0].Alerts[0].Fingerprint)
+	assert.Nil(t, next.digests[0])
+
+	require.NoError(t, d.Flush(context.Background()))
+	require.Equal(t, 2, next.len())
+	assert.Equal(t, "2", next.posted[1].Alerts[0].Fingerprint)
+}
+
+func TestTracker_Flush(t *testing.T) {
+	tracker := NewTracker()
+	next := &digestRecorder{}
+	_, err := NewDigestService(context.Background(), "/alertmanager", DigestConfig{Window: time.Hour}, next, queueLogger, WithDigestTracker(tracker))
+	require.NoError(t, err)
+	d, err := NewDigestService(context.Background(), "/other", DigestConfig{Window: time.Hour}, next, queueLogger, WithDigestTracker(tracker))
+	require.NoError(t, err)
+
+	_, err = d.Post(context.Background(), alertsMessage("a", alert("1", "firing", "warning")))
+	require.NoError(t, err)
+	require.NoError(t, tracker.Flush(context.Background()))
+	require.Equal(t, 1, next.len())
+	assert.Equal(t, "digest:/other", next.posted[0].GroupKey)
+
+	// Nothing is left to flush.
+	require.NoError(t, tracker.Flush(context.Background()))
+	assert.Equal(t, 1, next.len())
+}
+
+func TestRecomputeCommon(t *testing.T) {
+	a, b := alert("1", "resolved", "warning"), alert("2", "firing", "critical")
+	a.Annotations = template.KV{"summary": "High load"}
+	b.Annotations = template.KV{"summary": "High load", "runbook": "x"}
+	wm := alertsMessage("a", a, b)
+	wm.Status = "resolved"
+	wm.CommonLabels = template.KV{"stale": "true"}
+
+	recomputeCommon(&wm)
+
+	assert.Equal(t, "firing", wm.Status)
+	assert.Equal(t, template.KV{"alertname": "HighLoad"}, wm.CommonLabels)
+	assert.Equal(t, template.KV{"summary": "High load"}, wm.CommonAnnotations)
+}
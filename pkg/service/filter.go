@@ -0,0 +1,168 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// filterService is a middleware for Service posting only the alerts matching
+// one of its include entries, if any, and none of its exclude entries.
+type filterService struct {
+	include [][]*labels.Matcher
+	exclude [][]*labels.Matcher
+	logger  *utility.Logger
+	next    Service
+}
+
+func newFilterMiddleware(env MiddlewareEnv) (Middleware, error) {
+	var cfg struct {
+		Include []string `yaml:"include"`
+		Exclude []string `yaml:"exclude"`
+	}
+	if err := env.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Include) == 0 && len(cfg.Exclude) == 0 {
+		return nil, errors.New("include or exclude is required")
+	}
+	include, err := parseMatcherSets(cfg.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := parseMatcherSets(cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return func(next Service) Service {
+		return filterService{include: include, exclude: exclude, logger: env.Logger, next: next}
+	}, nil
+}
+
+func parseMatcherSets(in []string) ([][]*labels.Matcher, error) {
+	var sets [][]*labels.Matcher
+	for _, s := range in {
+		matchers, err := labels.ParseMatchers(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matchers '%s': %w", s, err)
+		}
+		sets = append(sets, matchers)
+	}
+	return sets, nil
+}
+
+func (s filterService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	if wm.Data == nil {
+		return s.next.Post(ctx, wm)
+	}
+	var alerts template.Alerts
+	for _, a := range wm.Alerts {
+		if (len(s.include) == 0 || matchesAny(s.include, a.Labels)) && !matchesAny(s.exclude, a.Labels) {
+			alerts = append(alerts, a)
+		}
+	}
+	if len(alerts) == 0 {
+		s.logger.Debug("message", "notification filtered out", "group_key", wm.GroupKey)
+		return nil, nil
+	}
+	if len(alerts) < len(wm.Alerts) {
+		wm = withAlerts(wm, alerts)
+	}
+	return s.next.Post(ctx, wm)
+}
+
+// enrichService is a middleware for Service adding labels and annotations to
+// the alerts. The values of the alerts are kept unless override is set.
+type enrichService struct {
+	labels      template.KV
+	annotations template.KV
+	override    bool
+	next        Service
+}
+
+func newEnrichMiddleware(env MiddlewareEnv) (Middleware, error) {
+	var cfg struct {
+		Labels      map[string]string `yaml:"labels"`
+		Annotations map[string]string `yaml:"annotations"`
+		Override    bool              `yaml:"override"`
+	}
+	if err := env.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.Labels) == 0 && len(cfg.Annotations) == 0 {
+		return nil, errors.New("labels or annotations is required")
+	}
+	return func(next Service) Service {
+		return enrichService{labels: cfg.Labels, annotations: cfg.Annotations, override: cfg.Override, next: next}
+	}, nil
+}
+
+func (s enrichService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	if wm.Data == nil {
+		return s.next.Post(ctx, wm)
+	}
+	alerts := make(template.Alerts, len(wm.Alerts))
+	for i, a := range wm.Alerts {
+		a.Labels = s.enrich(a.Labels, s.labels)
+		a.Annotations = s.enrich(a.Annotations, s.annotations)
+		alerts[i] = a
+	}
+	wm = withAlerts(wm, alerts)
+	wm.CommonLabels = commonKV(wm.CommonLabels, alerts, s.labels, func(a template.Alert) template.KV { return a.Labels })
+	wm.CommonAnnotations = commonKV(wm.CommonAnnotations, alerts, s.annotations, func(a template.Alert) template.KV { return a.Annotations })
+	return s.next.Post(ctx, wm)
+}
+
+func (s enrichService) enrich(kv, add template.KV) template.KV {
+	out := copyKV(kv)
+	for k, v := range add {
+		if _, ok := out[k]; !ok || s.override {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// commonKV adds to common the keys of added having the same value in all
+// the alerts.
+func commonKV(common template.KV, alerts template.Alerts, added template.KV, kv func(template.Alert) template.KV) template.KV {
+	out := copyKV(common)
+	for k := range added {
+		delete(out, k)
+		if len(alerts) == 0 {
+			continue
+		}
+		v, same := kv(alerts[0])[k], true
+		for _, a := range alerts[1:] {
+			if kv(a)[k] != v {
+				same = false
+				break
+			}
+		}
+		if same {
+			out[k] = v
+		}
+	}
+	return out
+}
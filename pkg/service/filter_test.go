@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterService(t *testing.T) {
+	next := &digestRecorder{}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, `
+include: ['{severity=~"critical|warning"}']
+exclude: ['{instance="2"}']
+`)
+	m, err := newFilterMiddleware(env)
+	require.NoError(t, err)
+	s := m(next)
+
+	_, err = s.Post(context.Background(), alertsMessage("a", alert("1", "firing", "warning"), alert("2", "firing", "critical"), alert("3", "firing", "info")))
+	require.NoError(t, err)
+	_, err = s.Post(context.Background(), alertsMessage("b", alert("4", "firing", "info")))
+	require.NoError(t, err)
+
+	require.Equal(t, 1, next.len())
+	require.Len(t, next.posted[0].Alerts, 1)
+	assert.Equal(t, "1", next.posted[0].Alerts[0].Fingerprint)
+
+	for _, cfg := range []string{"{}", "include: ['{severity}']"} {
+		env.Decode = yamlConfig(t, cfg)
+		_, err = newFilterMiddleware(env)
+		assert.Error(t, err, cfg)
+	}
+}
+
+func TestEnrichService(t *testing.T) {
+	next := &digestRecorder{}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, `
+labels: {team: platform, severity: info}
+annotations: {runbook: https://runbooks.example.com}
+`)
+	m, err := newEnrichMiddleware(env)
+	require.NoError(t, err)
+	s := m(next)
+
+	wm := alertsMessage("a", alert("1", "firing", "warning"), alert("2", "firing", "critical"))
+	_, err = s.Post(context.Background(), wm)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, next.len())
+	got := next.posted[0]
+	assert.Equal(t, "platform", got.Alerts[0].Labels["team"])
+	assert.Equal(t, "warning", got.Alerts[0].Labels["severity"])
+	assert.Equal(t, "https://runbooks.example.com", got.Alerts[1].Annotations["runbook"])
+	assert.Equal(t, template.KV{"team": "platform"}, got.CommonLabels)
+	assert.Equal(t, template.KV{"runbook": "https://runbooks.example.com"}, got.CommonAnnotations)
+	// The alerts of the request are not changed.
+	assert.NotContains(t, wm.Alerts[0].Labels, "team")
+
+	env.Decode = yamlConfig(t, "{labels: {severity: info}, override: true}")
+	m, err = newEnrichMiddleware(env)
+	require.NoError(t, err)
+	_, err = m(next).Post(context.Background(), wm)
+	require.NoError(t, err)
+	assert.Equal(t, template.KV{"severity": "info"}, next.posted[1].CommonLabels)
+
+	env.Decode = yamlConfig(t, "{}")
+	_, err = newEnrichMiddleware(env)
+	assert.Error(t, err)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/common/model"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/graph"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// Defaults of the graph middleware.
+const (
+	DefaultGraphRange     = 30 * time.Minute
+	DefaultGraphWidth     = 320
+	DefaultGraphHeight    = 80
+	DefaultGraphTimeout   = 3 * time.Second
+	DefaultGraphMaxAlerts = 5
+	// DefaultGraphMaxBytes is the size limit of an embedded image.
+	DefaultGraphMaxBytes = 8 << 10
+	// DefaultGraphMaxTotalBytes is the size limit of all the images embedded
+	// in a card, keeping it well under the 28 KB limit of Teams.
+	DefaultGraphMaxTotalBytes = 16 << 10
+)
+
+// graphService is a middleware for Service adding to the firing alerts the
+// graph of their expression, as the image of their extras. The alerts with
+// an image already, or without expression, are left as is. The graphs not
+// rendered before the timeout are skipped, so that they never hold the
+// delivery longer.
+type graphService struct {
+	prometheus graph.Client
+	uploader   *graph.Uploader
+	rng        time.Duration
+	step       time.Duration
+	width      int
+	height     int
+	timeout    time.Duration
+	maxAlerts  int
+	maxBytes   int
+	maxTotal   int
+	now        func() time.Time
+	logger     *utility.Logger
+	next       Service
+}
+
+func newGraphMiddleware(env MiddlewareEnv) (Middleware, error) {
+	cfg := struct {
+		PrometheusURL string        `yaml:"prometheus_url"`
+		Range         time.Duration `yaml:"range"`
+		Step          time.Duration `yaml:"step"`
+		Width         int           `yaml:"width"`
+		Height        int           `yaml:"height"`
+		Timeout       time.Duration `yaml:"timeout"`
+		MaxAlerts     int           `yaml:"max_alerts"`
+		MaxBytes      int           `yaml:"max_bytes"`
+		MaxTotalBytes int           `yaml:"max_total_bytes"`
+		Upload        *struct {
+			URL       string            `yaml:"url"`
+			PublicURL string            `yaml:"public_url"`
+			Headers   map[string]string `yaml:"headers"`
+		} `yaml:"upload"`
+	}{
+		Range:         DefaultGraphRange,
+		Width:         DefaultGraphWidth,
+		Height:        DefaultGraphHeight,
+		Timeout:       DefaultGraphTimeout,
+		MaxAlerts:     DefaultGraphMaxAlerts,
+		MaxBytes:      DefaultGraphMaxBytes,
+		MaxTotalBytes: DefaultGraphMaxTotalBytes,
+	}
+	if err := env.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.PrometheusURL == "" {
+		return nil, errors.New("prometheus_url is required")
+	}
+	if cfg.Range <= 0 || cfg.Timeout <= 0 || cfg.MaxAlerts <= 0 || cfg.MaxBytes <= 0 || cfg.MaxTotalBytes <= 0 {
+		return nil, errors.New("range, timeout, max_alerts, max_bytes and max_total_bytes must be positive")
+	}
+	if cfg.Width < 16 || cfg.Height < 16 {
+		return nil, errors.New("width and height must be at least 16")
+	}
+	if cfg.Step <= 0 {
+		cfg.Step = max(cfg.Range/time.Duration(cfg.Width/4), time.Second)
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	s := graphService{
+		prometheus: graph.Client{URL: cfg.PrometheusURL, HTTP: client},
+		rng:        cfg.Range,
+		step:       cfg.Step,
+		width:      cfg.Width,
+		height:     cfg.Height,
+		timeout:    cfg.Timeout,
+		maxAlerts:  cfg.MaxAlerts,
+		maxBytes:   cfg.MaxBytes,
+		maxTotal:   cfg.MaxTotalBytes,
+		now:        time.Now,
+		logger:     env.Logger,
+	}
+	if cfg.Upload != nil {
+		if cfg.Upload.URL == "" {
+			return nil, errors.New("upload url is required")
+		}
+		s.uploader = &graph.Uploader{URL: cfg.Upload.URL, PublicURL: cfg.Upload.PublicURL, Headers: cfg.Upload.Headers, HTTP: client}
+	}
+	return func(next Service) Service {
+		s.next = next
+		return s
+	}, nil
+}
+
+func (s graphService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	if wm.Data == nil {
+		return s.next.Post(ctx, wm)
+	}
+	if extras := s.graphs(ctx, wm); extras != nil {
+		ctx = card.ContextWithExtras(ctx, extras)
+	}
+	return s.next.Post(ctx, wm)
+}
+
+// graphs returns the extras of ctx with the images of the alerts of wm
+// graphed, or nil if none is.
+func (s graphService) graphs(ctx context.Context, wm webhook.Message) *card.Extras {
+	prev := card.ExtrasFromContext(ctx)
+	var exprs []string
+	alerts := map[string][]int{}
+	graphed := 0
+	for i, a := range wm.Alerts {
+		if graphed == s.maxAlerts {
+			break
+		}
+		if a.Status != "firing" || (prev != nil && prev.Alerts[a.Fingerprint].ImageURL != "") {
+			continue
+		}
+		expr, err := graph.Expr(a.GeneratorURL)
+		if err != nil {
+			continue
+		}
+		if _, ok := alerts[expr]; !ok {
+			exprs = append(exprs, expr)
+		}
+		alerts[expr] = append(alerts[expr], i)
+		graphed++
+	}
+	if graphed == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	end := s.now()
+	images := make([]string, len(wm.Alerts))
+	var wg sync.WaitGroup
+	for _, expr := range exprs {
+		wg.Go(func() {
+			m, err := s.prometheus.QueryRange(ctx, expr, end.Add(-s.rng), end, s.step)
+			if err != nil {
+				s.logger.Warn("message", "graph query failed", "expr", expr, "err", err)
+				return
+			}
+			for _, i := range alerts[expr] {
+				images[i] = s.image(ctx, graph.Select(m, wm.Alerts[i].Labels))
+			}
+		})
+	}
+	wg.Wait()
+
+	extras := &card.Extras{Alerts: map[string]card.AlertExtras{}}
+	if prev != nil {
+		*extras = *prev
+		extras.Alerts = maps.Clone(prev.Alerts)
+		if extras.Alerts == nil {
+			extras.Alerts = map[string]card.AlertExtras{}
+		}
+	}
+	added := false
+	total := 0
+	for i, img := range images {
+		if img == "" {
+			continue
+		}
+		// The images are embedded in the order of the alerts while they fit
+		// in the budget of the card.
+		if total+len(img) > s.maxTotal {
+			s.logger.Warn("message", "graph dropped, the card is over its image budget", "alert", wm.Alerts[i].Fingerprint, "bytes", len(img), "max_total_bytes", s.maxTotal)
+			continue
+		}
+		total += len(img)
+		fp := wm.Alerts[i].Fingerprint
+		e := extras.Alerts[fp]
+		e.ImageURL = img
+		extras.Alerts[fp] = e
+		added = true
+	}
+	if !added {
+		return nil
+	}
+	return extras
+}
+
+// image returns the URL of the graph of m, a data URI unless it is
+// uploaded, or "" if it could not be rendered within the limits.
+func (s graphService) image(ctx context.Context, m model.Matrix) string {
+	png, err := graph.Render(m, s.width, s.height)
+	if err != nil {
+		s.logger.Debug("message", "graph not rendered", "err", err)
+		return ""
+	}
+	if s.uploader != nil {
+		u, err := s.uploader.Upload(ctx, png)
+		if err != nil {
+			s.logger.Warn("message", "graph upload failed", "err", err)
+			return ""
+		}
+		return u
+	}
+	uri := graph.DataURI(png)
+	if len(uri) > s.maxBytes {
+		s.logger.Warn("message", "graph too large to embed", "bytes", len(uri), "max_bytes", s.maxBytes)
+		return ""
+	}
+	return uri
+}
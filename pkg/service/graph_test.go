@@ -0,0 +1,191 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// extrasRecorder records the extras of the notifications posted.
+type extrasRecorder struct {
+	extras []*card.Extras
+}
+
+func (s *extrasRecorder) Post(ctx context.Context, _ webhook.Message) ([]PostResponse, error) {
+	s.extras = append(s.extras, card.ExtrasFromContext(ctx))
+	return nil, nil
+}
+
+func graphAlert(fingerprint, status, expr string) template.Alert {
+	a := alert(fingerprint, status, "warning")
+	if expr != "" {
+		a.GeneratorURL = "http://prometheus:9090/graph?g0.tab=1&g0.expr=" + expr
+	}
+	return a
+}
+
+func TestGraphService(t *testing.T) {
+	var queries atomic.Int32
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries.Add(1)
+		if r.URL.Query().Get("query") == "slow" {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+			{"metric": {"instance": "1"}, "values": [[1767225600, "1"], [1767225660, "3"], [1767225720, "2"]]},
+			{"metric": {"instance": "2"}, "values": [[1767225600, "5"], [1767225660, "4"]]}
+		]}}`))
+	}))
+	defer prometheus.Close()
+
+	next := &extrasRecorder{}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, "prometheus_url: "+prometheus.URL+"\ntimeout: 200ms\nmax_alerts: 3")
+	m, err := newGraphMiddleware(env)
+	require.NoError(t, err)
+	s := m(next)
+
+	ctx := card.ContextWithExtras(context.Background(), &card.Extras{
+		Title:  "title",
+		Alerts: map[string]card.AlertExtras{"4": {ImageURL: "https://grafana/4.png"}},
+	})
+	start := time.Now()
+	_, err = s.Post(ctx, alertsMessage("a",
+		graphAlert("1", "firing", "up"),
+		graphAlert("2", "firing", "up"),
+		graphAlert("3", "resolved", "up"),
+		graphAlert("4", "firing", "up"),
+		graphAlert("5", "firing", ""),
+		graphAlert("6", "firing", "slow"),
+		graphAlert("7", "firing", "up"),
+	))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// The alerts sharing an expression are graphed with one query, and the
+	// slow query is given up at the timeout.
+	assert.Equal(t, int32(2), queries.Load())
+	require.Len(t, next.extras, 1)
+	extras := next.extras[0]
+	require.NotNil(t, extras)
+	assert.Equal(t, "title", extras.Title)
+	for _, fp := range []string{"1", "2"} {
+		assert.True(t, strings.HasPrefix(extras.Alerts[fp].ImageURL, "data:image/png;base64,"), fp)
+	}
+	assert.NotEqual(t, extras.Alerts["1"].ImageURL, extras.Alerts["2"].ImageURL)
+	assert.Equal(t, "https://grafana/4.png", extras.Alerts["4"].ImageURL)
+	for _, fp := range []string{"3", "5", "6", "7"} {
+		assert.Empty(t, extras.Alerts[fp].ImageURL, fp)
+	}
+
+	// Without a graph, the extras are left as is.
+	_, err = s.Post(context.Background(), alertsMessage("b", graphAlert("5", "firing", "")))
+	require.NoError(t, err)
+	assert.Nil(t, next.extras[1])
+}
+
+func TestGraphServiceLimits(t *testing.T) {
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+			{"metric": {}, "values": [[1767225600, "1"], [1767225660, "3"]]}
+		]}}`))
+	}))
+	defer prometheus.Close()
+
+	next := &extrasRecorder{}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, "prometheus_url: "+prometheus.URL+"\nmax_bytes: 10")
+	m, err := newGraphMiddleware(env)
+	require.NoError(t, err)
+	_, err = m(next).Post(context.Background(), alertsMessage("a", graphAlert("1", "firing", "up")))
+	require.NoError(t, err)
+	assert.Nil(t, next.extras[0])
+
+	// The images are embedded while they fit in the budget of the card.
+	env.Decode = yamlConfig(t, "prometheus_url: "+prometheus.URL)
+	m, err = newGraphMiddleware(env)
+	require.NoError(t, err)
+	_, err = m(next).Post(context.Background(), alertsMessage("b", graphAlert("1", "firing", "up")))
+	require.NoError(t, err)
+	size := len(next.extras[1].Alerts["1"].ImageURL)
+
+	env.Decode = yamlConfig(t, fmt.Sprintf("prometheus_url: %s\nmax_total_bytes: %d", prometheus.URL, 2*size))
+	m, err = newGraphMiddleware(env)
+	require.NoError(t, err)
+	_, err = m(next).Post(context.Background(), alertsMessage("c",
+		graphAlert("1", "firing", "up"),
+		graphAlert("2", "firing", "up"),
+		graphAlert("3", "firing", "up"),
+	))
+	require.NoError(t, err)
+	extras := next.extras[2]
+	assert.NotEmpty(t, extras.Alerts["1"].ImageURL)
+	assert.NotEmpty(t, extras.Alerts["2"].ImageURL)
+	assert.Empty(t, extras.Alerts["3"].ImageURL)
+}
+
+func TestGraphServiceUpload(t *testing.T) {
+	prometheus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "matrix", "result": [
+			{"metric": {}, "values": [[1767225600, "1"], [1767225660, "3"]]}
+		]}}`))
+	}))
+	defer prometheus.Close()
+	var uploaded atomic.Int32
+	store := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		uploaded.Add(1)
+	}))
+	defer store.Close()
+
+	next := &extrasRecorder{}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, "prometheus_url: "+prometheus.URL+"\nupload:\n  url: "+store.URL+"\n  public_url: https://cdn.example.com")
+	m, err := newGraphMiddleware(env)
+	require.NoError(t, err)
+	_, err = m(next).Post(context.Background(), alertsMessage("a", graphAlert("1", "firing", "up")))
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), uploaded.Load())
+	assert.Regexp(t, `^https://cdn.example.com/[0-9a-f]{64}\.png$`, next.extras[0].Alerts["1"].ImageURL)
+}
+
+func TestNewGraphMiddlewareInvalid(t *testing.T) {
+	env := middlewareEnv()
+	for _, cfg := range []string{
+		"{}",
+		"prometheus_url: http://prometheus\ntimeout: -1s",
+		"prometheus_url: http://prometheus\nwidth: 8",
+		"prometheus_url: http://prometheus\nupload: {public_url: https://cdn}",
+	} {
+		env.Decode = yamlConfig(t, cfg)
+		_, err := newGraphMiddleware(env)
+		assert.Error(t, err, cfg)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+)
+
+// InFlight is a notification being posted.
+type InFlight struct {
+	Route    string
+	Receiver string
+	GroupKey string
+	// Alerts holds the fingerprints of the alerts of the notification.
+	Alerts []string
+	Since  time.Time
+}
+
+// Tracker keeps the notifications being posted by the services created with
+// NewTrackingService.
+type Tracker struct {
+	mu       sync.Mutex
+	next     uint64
+	inFlight map[uint64]InFlight
+	// idle is closed when no notification is in flight.
+	idle chan struct{}
+	// flushers hold the notifications buffered until Flush.
+	flushers []Flusher
+}
+
+// Flusher is implemented by the services buffering notifications.
+type Flusher interface {
+	// Flush posts the buffered notifications.
+	Flush(ctx context.Context) error
+}
+
+// NewTracker creates a Tracker.
+func NewTracker() *Tracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &Tracker{inFlight: map[uint64]InFlight{}, idle: idle}
+}
+
+// start adds n to the notifications in flight and returns the function
+// removing it.
+func (t *Tracker) start(n InFlight) func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.inFlight) == 0 {
+		t.idle = make(chan struct{})
+	}
+	id := t.next
+	t.next++
+	t.inFlight[id] = n
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.inFlight, id)
+		if len(t.inFlight) == 0 {
+			close(t.idle)
+		}
+	}
+}
+
+func (t *Tracker) addFlusher(f Flusher) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.flushers = append(t.flushers, f)
+}
+
+// Flush posts the notifications buffered by the services added to t, such
+// as the DigestService created with WithDigestTracker.
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	flushers := append([]Flusher(nil), t.flushers...)
+	t.mu.Unlock()
+	var errs []error
+	for _, f := range flushers {
+		if err := f.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// InFlight returns the notifications in flight, the oldest first.
+func (t *Tracker) InFlight() []InFlight {
+	t.mu.Lock()
+	out := make([]InFlight, 0, len(t.inFlight))
+	for _, n := range t.inFlight {
+		out = append(out, n)
+	}
+	t.mu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Since.Before(out[j].Since) })
+	return out
+}
+
+// Wait waits until no notification is in flight or ctx is done.
+func (t *Tracker) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	idle := t.idle
+	t.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// trackingService is a middleware for Service adding the notifications
+// being posted to a Tracker.
+type trackingService struct {
+	tracker *Tracker
+	next    Service
+}
+
+// NewTrackingService creates a trackingService.
+func NewTrackingService(t *Tracker, next Service) Service {
+	return trackingService{t, next}
+}
+
+func (s trackingService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	defer s.tracker.start(newInFlight(ctx, wm))()
+	return s.next.Post(ctx, wm)
+}
+
+func newInFlight(ctx context.Context, wm webhook.Message) InFlight {
+	n := InFlight{
+		Route:    metrics.RouteFromContext(ctx),
+		GroupKey: wm.GroupKey,
+		Since:    time.Now(),
+	}
+	if wm.Data != nil {
+		n.Receiver = wm.Receiver
+		for _, a := range wm.Alerts {
+			n.Alerts = append(n.Alerts, a.Fingerprint)
+		}
+	}
+	return n
+}
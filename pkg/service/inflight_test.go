@@ -0,0 +1,92 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingService blocks its Post calls until release is closed.
+type blockingService struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s blockingService) Post(_ context.Context, _ webhook.Message) ([]PostResponse, error) {
+	s.started <- struct{}{}
+	<-s.release
+	return nil, nil
+}
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker()
+	require.NoError(t, tr.Wait(context.Background()))
+
+	now := time.Now()
+	doneB := tr.start(InFlight{GroupKey: "b", Since: now.Add(time.Second)})
+	doneA := tr.start(InFlight{GroupKey: "a", Since: now})
+	assert.Equal(t, []InFlight{{GroupKey: "a", Since: now}, {GroupKey: "b", Since: now.Add(time.Second)}}, tr.InFlight())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, tr.Wait(ctx), context.DeadlineExceeded)
+
+	doneA()
+	doneB()
+	assert.Empty(t, tr.InFlight())
+	assert.NoError(t, tr.Wait(context.Background()))
+}
+
+func TestTrackingService_Post(t *testing.T) {
+	tr := NewTracker()
+	next := blockingService{started: make(chan struct{}), release: make(chan struct{})}
+	svc := NewTrackingService(tr, next)
+
+	ctx := metrics.ContextWithRoute(context.Background(), "/alertmanager")
+	wm := webhook.Message{
+		Data: &template.Data{
+			Receiver: "teams",
+			Alerts:   template.Alerts{{Fingerprint: "abc"}, {Fingerprint: "def"}},
+		},
+		GroupKey: "{}:{alertname=\"HighLoad\"}",
+	}
+	posted := make(chan struct{})
+	go func() {
+		_, _ = svc.Post(ctx, wm)
+		close(posted)
+	}()
+	<-next.started
+
+	inFlight := tr.InFlight()
+	require.Len(t, inFlight, 1)
+	assert.Equal(t, "/alertmanager", inFlight[0].Route)
+	assert.Equal(t, "teams", inFlight[0].Receiver)
+	assert.Equal(t, wm.GroupKey, inFlight[0].GroupKey)
+	assert.Equal(t, []string{"abc", "def"}, inFlight[0].Alerts)
+
+	close(next.release)
+	<-posted
+	assert.Empty(t, tr.InFlight())
+}
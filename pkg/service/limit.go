@@ -0,0 +1,170 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"golang.org/x/time/rate"
+)
+
+// defaultDedupWindow is the window of the dedup middleware when not set.
+const defaultDedupWindow = 5 * time.Minute
+
+// ErrRateLimited is returned by the rate_limit middleware when the connector
+// posted its maximum number of notifications.
+var ErrRateLimited = errors.New("rate limit of the connector exceeded")
+
+// dedupService is a middleware for Service skipping the notifications posted
+// within a window, such as those Alertmanager sends again after a timeout.
+type dedupService struct {
+	window time.Duration
+	logger *utility.Logger
+	next   Service
+	now    func() time.Time
+
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	swept time.Time
+}
+
+func newDedupMiddleware(env MiddlewareEnv) (Middleware, error) {
+	var cfg struct {
+		Window time.Duration `yaml:"window"`
+	}
+	if err := env.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Window < 0 {
+		return nil, fmt.Errorf("window must not be negative, got %s", cfg.Window)
+	}
+	if cfg.Window == 0 {
+		cfg.Window = defaultDedupWindow
+	}
+	return func(next Service) Service {
+		return &dedupService{window: cfg.Window, logger: env.Logger, next: next, now: time.Now, seen: map[string]time.Time{}}
+	}, nil
+}
+
+func (s *dedupService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	key := dedupKey(wm)
+	now := s.now()
+	s.mu.Lock()
+	// Expired keys are swept at most once per window, so the map holds
+	// the keys of the last two windows at most.
+	if now.Sub(s.swept) >= s.window {
+		for k, t := range s.seen {
+			if now.Sub(t) >= s.window {
+				delete(s.seen, k)
+			}
+		}
+		s.swept = now
+	}
+	t, dup := s.seen[key]
+	dup = dup && now.Sub(t) < s.window
+	if !dup {
+		// Reserve the key before posting, so that a copy arriving while
+		// the notification is in flight is skipped too.
+		s.seen[key] = now
+	}
+	s.mu.Unlock()
+	if dup {
+		s.logger.Debug("message", "duplicate notification skipped", "group_key", wm.GroupKey)
+		return nil, nil
+	}
+
+	prs, err := s.next.Post(ctx, wm)
+	if err != nil {
+		// Release the key so that Alertmanager's retry is posted.
+		s.mu.Lock()
+		if s.seen[key] == now {
+			delete(s.seen, key)
+		}
+		s.mu.Unlock()
+	}
+	return prs, err
+}
+
+// dedupKey identifies a notification by its group key, status and the
+// status of its alerts.
+func dedupKey(wm webhook.Message) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00", wm.GroupKey)
+	if wm.Data != nil {
+		alerts := make([]string, 0, len(wm.Alerts))
+		for _, a := range wm.Alerts {
+			alerts = append(alerts, a.Fingerprint+":"+a.Status)
+		}
+		sort.Strings(alerts)
+		_, _ = fmt.Fprintf(h, "%s\x00%v", wm.Status, alerts)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// rateLimitService is a middleware for Service failing the notifications
+// beyond a rate, so that Alertmanager retries them later.
+type rateLimitService struct {
+	limiter *rate.Limiter
+	logger  *utility.Logger
+	next    Service
+}
+
+func newRateLimitMiddleware(env MiddlewareEnv) (Middleware, error) {
+	var cfg struct {
+		// Limit notifications are posted per Interval, one minute by default.
+		Limit    int           `yaml:"limit"`
+		Interval time.Duration `yaml:"interval"`
+		// Burst defaults to Limit.
+		Burst int `yaml:"burst"`
+	}
+	if err := env.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", cfg.Limit)
+	}
+	if cfg.Interval < 0 || cfg.Burst < 0 {
+		return nil, errors.New("interval and burst must not be negative")
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.Burst == 0 {
+		cfg.Burst = cfg.Limit
+	}
+	return func(next Service) Service {
+		limiter := rate.NewLimiter(rate.Every(cfg.Interval/time.Duration(cfg.Limit)), cfg.Burst)
+		return &rateLimitService{limiter: limiter, logger: env.Logger, next: next}
+	}, nil
+}
+
+func (s *rateLimitService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	if !s.limiter.Allow() {
+		s.logger.Warn("message", "notification rate limited", "group_key", wm.GroupKey)
+		return nil, ErrRateLimited
+	}
+	return s.next.Post(ctx, wm)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupService(t *testing.T) {
+	next := &digestRecorder{}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, "window: 1m")
+	m, err := newDedupMiddleware(env)
+	require.NoError(t, err)
+	s := m(next).(*dedupService)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	post := func(groupKey, status string) {
+		_, err := s.Post(context.Background(), alertsMessage(groupKey, alert("1", status, "warning")))
+		require.NoError(t, err)
+	}
+	post("a", "firing")
+	post("a", "firing")
+	post("a", "resolved")
+	post("b", "firing")
+	assert.Equal(t, 3, next.len())
+
+	now = now.Add(time.Minute)
+	post("a", "firing")
+	assert.Equal(t, 4, next.len())
+
+	// Expired keys are swept once a window has passed.
+	now = now.Add(2 * time.Minute)
+	post("c", "firing")
+	s.mu.Lock()
+	assert.Len(t, s.seen, 1)
+	s.mu.Unlock()
+
+	env.Decode = yamlConfig(t, "window: -1m")
+	_, err = newDedupMiddleware(env)
+	assert.Error(t, err)
+}
+
+func TestDedupServiceReservesKey(t *testing.T) {
+	next := blockingService{started: make(chan struct{}), release: make(chan struct{})}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, "window: 1m")
+	m, err := newDedupMiddleware(env)
+	require.NoError(t, err)
+	s := m(next)
+
+	// A copy arriving while the first post is in flight is skipped.
+	done := make(chan error)
+	go func() {
+		_, err := s.Post(context.Background(), alertsMessage("a"))
+		done <- err
+	}()
+	<-next.started
+	_, err = s.Post(context.Background(), alertsMessage("a"))
+	require.NoError(t, err)
+	close(next.release)
+	require.NoError(t, <-done)
+
+	// A failed post releases the key, so the retry is posted.
+	errFailed := errors.New("failed")
+	s = m(failingService{err: errFailed})
+	for i := 0; i < 2; i++ {
+		_, err = s.Post(context.Background(), alertsMessage("a"))
+		assert.ErrorIs(t, err, errFailed)
+	}
+}
+
+func TestRateLimitService(t *testing.T) {
+	next := &digestRecorder{}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, "{limit: 2, interval: 1h}")
+	m, err := newRateLimitMiddleware(env)
+	require.NoError(t, err)
+	s := m(next)
+
+	for i := 0; i < 2; i++ {
+		_, err := s.Post(context.Background(), alertsMessage("a"))
+		require.NoError(t, err)
+	}
+	_, err = s.Post(context.Background(), alertsMessage("a"))
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, 2, next.len())
+
+	for _, cfg := range []string{"limit: 0", "{limit: 1, burst: -1}"} {
+		env.Decode = yamlConfig(t, cfg)
+		_, err = newRateLimitMiddleware(env)
+		assert.Error(t, err, cfg)
+	}
+}
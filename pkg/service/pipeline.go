@@ -0,0 +1,124 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// Middleware wraps a Service.
+type Middleware func(next Service) Service
+
+// MiddlewareEnv is what a MiddlewareFactory gets to create a Middleware.
+type MiddlewareEnv struct {
+	// Route is the request path of the connector.
+	Route  string
+	Logger *utility.Logger
+	// Decode decodes the config of the middleware into v.
+	Decode func(v any) error
+	// Webhook returns the Service posting to a Teams webhook URL with the
+	// converter of the connector.
+	Webhook func(webhookURL string) (Service, error)
+}
+
+// MiddlewareFactory creates a Middleware from its config.
+type MiddlewareFactory func(env MiddlewareEnv) (Middleware, error)
+
+// MiddlewareSpec is a middleware of a pipeline.
+type MiddlewareSpec struct {
+	Name string
+	// Decode decodes the config of the middleware. Nil means no config.
+	Decode func(v any) error
+}
+
+var (
+	middlewaresMu sync.RWMutex
+	middlewares   = map[string]MiddlewareFactory{}
+)
+
+// RegisterMiddleware makes a middleware available in the pipelines under
+// name. It panics if name is empty or already registered.
+func RegisterMiddleware(name string, f MiddlewareFactory) {
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+	if name == "" || f == nil {
+		panic("service: RegisterMiddleware with an empty name or a nil factory")
+	}
+	if _, ok := middlewares[name]; ok {
+		panic(fmt.Sprintf("service: RegisterMiddleware called twice for %q", name))
+	}
+	middlewares[name] = f
+}
+
+// Middlewares returns the names of the registered middlewares, sorted.
+func Middlewares() []string {
+	middlewaresMu.RLock()
+	defer middlewaresMu.RUnlock()
+	names := make([]string, 0, len(middlewares))
+	for name := range middlewares {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewPipeline wraps next with the middlewares of specs, the first one being
+// the outermost. env is passed to each factory with the Decode of its spec.
+func NewPipeline(specs []MiddlewareSpec, next Service, env MiddlewareEnv) (Service, error) {
+	middlewaresMu.RLock()
+	defer middlewaresMu.RUnlock()
+	wraps := make([]Middleware, len(specs))
+	for i, spec := range specs {
+		f, ok := middlewares[spec.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown middleware '%s'", spec.Name)
+		}
+		e := env
+		e.Logger = env.Logger.With("middleware", spec.Name)
+		e.Decode = spec.Decode
+		if e.Decode == nil {
+			e.Decode = func(any) error { return nil }
+		}
+		m, err := f(e)
+		if err != nil {
+			return nil, fmt.Errorf("middleware '%s': %w", spec.Name, err)
+		}
+		wraps[i] = m
+	}
+	s := next
+	for i := len(wraps) - 1; i >= 0; i-- {
+		s = wraps[i](s)
+	}
+	return s, nil
+}
+
+func init() {
+	RegisterMiddleware("logging", func(env MiddlewareEnv) (Middleware, error) {
+		return func(next Service) Service { return NewLoggingService(env.Logger, next) }, nil
+	})
+	RegisterMiddleware("dedup", newDedupMiddleware)
+	RegisterMiddleware("rate_limit", newRateLimitMiddleware)
+	RegisterMiddleware("filter", newFilterMiddleware)
+	RegisterMiddleware("enrich", newEnrichMiddleware)
+	RegisterMiddleware("split", newSplitMiddleware)
+	RegisterMiddleware("fanout", newFanoutMiddleware)
+	RegisterMiddleware("graph", newGraphMiddleware)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlConfig returns the Decode of a middleware config written in YAML.
+func yamlConfig(t *testing.T, s string) func(any) error {
+	t.Helper()
+	var n yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(s), &n))
+	return n.Decode
+}
+
+func middlewareEnv() MiddlewareEnv {
+	return MiddlewareEnv{Route: "/alertmanager", Logger: queueLogger}
+}
+
+// tagService appends its tag to the group key of the notifications.
+type tagService struct {
+	tag  string
+	next Service
+}
+
+func (s tagService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	wm.GroupKey += s.tag
+	return s.next.Post(ctx, wm)
+}
+
+// registerTagOnce registers test_tag once, whatever the -count of the tests.
+var registerTagOnce sync.Once
+
+func TestRegisterMiddleware(t *testing.T) {
+	registerTagOnce.Do(func() {
+		RegisterMiddleware("test_tag", func(env MiddlewareEnv) (Middleware, error) {
+			var cfg struct {
+				Tag string `yaml:"tag"`
+			}
+			if err := env.Decode(&cfg); err != nil {
+				return nil, err
+			}
+			return func(next Service) Service { return tagService{tag: cfg.Tag, next: next} }, nil
+		})
+	})
+	assert.Panics(t, func() { RegisterMiddleware("test_tag", newDedupMiddleware) })
+	assert.Panics(t, func() { RegisterMiddleware("", newDedupMiddleware) })
+	assert.Subset(t, Middlewares(), []string{"dedup", "enrich", "fanout", "filter", "graph", "logging", "rate_limit", "split", "test_tag"})
+
+	next := &digestRecorder{}
+	s, err := NewPipeline([]MiddlewareSpec{
+		{Name: "test_tag", Decode: yamlConfig(t, "tag: -outer")},
+		{Name: "logging"},
+		{Name: "test_tag", Decode: yamlConfig(t, "tag: -inner")},
+	}, next, middlewareEnv())
+	require.NoError(t, err)
+	_, err = s.Post(context.Background(), alertsMessage("a"))
+	require.NoError(t, err)
+	assert.Equal(t, "a-outer-inner", next.posted[0].GroupKey)
+}
+
+func TestNewPipelineInvalid(t *testing.T) {
+	_, err := NewPipeline([]MiddlewareSpec{{Name: "unknown"}}, &digestRecorder{}, middlewareEnv())
+	assert.EqualError(t, err, "unknown middleware 'unknown'")
+
+	_, err = NewPipeline([]MiddlewareSpec{{Name: "rate_limit"}}, &digestRecorder{}, middlewareEnv())
+	assert.ErrorContains(t, err, "middleware 'rate_limit': limit must be positive")
+
+	s, err := NewPipeline(nil, &digestRecorder{}, middlewareEnv())
+	require.NoError(t, err)
+	assert.IsType(t, &digestRecorder{}, s)
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+var (
+	// ErrQueueFull is returned by Queue.Enqueue when the queue holds its
+	// maximum number of notifications.
+	ErrQueueFull = errors.New("delivery queue is full")
+	// ErrQueueClosed is returned by Queue.Enqueue once the queue stopped.
+	ErrQueueClosed = errors.New("delivery queue is closed")
+)
+
+// queueContext is the context of a delivery, canceled with the queue and
+// holding the values of the request.
+type queueContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c queueContext) Value(key any) any {
+	return c.values.Value(key)
+}
+
+type queued struct {
+	ctx      context.Context
+	service  Service
+	wm       webhook.Message
+	enqueued time.Time
+	done     func()
+}
+
+// Queue posts notifications asynchronously with a pool of workers. The
+// notifications of a group, identified by their group key, are posted by the
+// same worker in the order they were enqueued, so a resolved notification
+// never overtakes the firing one.
+type Queue struct {
+	ctx     context.Context
+	route   string
+	size    int
+	logger  *utility.Logger
+	metrics *metrics.Recorder
+	tracker *Tracker
+
+	mu sync.Mutex
+	// pending holds the notifications waiting for each worker.
+	pending [][]queued
+	depth   int
+	wake    []chan struct{}
+}
+
+// QueueOption configures a Queue.
+type QueueOption func(*Queue)
+
+// WithQueueMetrics records the depth, age and rejections of the queue with m.
+func WithQueueMetrics(m *metrics.Recorder) QueueOption {
+	return func(q *Queue) {
+		q.metrics = m
+	}
+}
+
+// WithQueueTracker adds the notifications to t from the time they are
+// enqueued until they are posted.
+func WithQueueTracker(t *Tracker) QueueOption {
+	return func(q *Queue) {
+		q.tracker = t
+	}
+}
+
+// NewQueue creates the Queue of route holding up to size notifications and
+// starts its workers. Once ctx is done, the queue rejects new notifications,
+// the deliveries are canceled and the workers stop when the queue is empty.
+func NewQueue(ctx context.Context, route string, size, workers int, logger *utility.Logger, opts ...QueueOption) (*Queue, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("queue size must be positive, got %d", size)
+	}
+	if workers <= 0 {
+		return nil, fmt.Errorf("queue workers must be positive, got %d", workers)
+	}
+	q := &Queue{
+		ctx:     ctx,
+		route:   route,
+		size:    size,
+		logger:  logger.WithPrefix("package", "service", "component", "queue", "route", route),
+		pending: make([][]queued, workers),
+		wake:    make([]chan struct{}, workers),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	q.metrics.ObserveQueue(route, q.Stats)
+	for i := range q.wake {
+		q.wake[i] = make(chan struct{}, 1)
+		go q.work(i)
+	}
+	return q, nil
+}
+
+// Enqueue adds wm to the queue, to be posted with s. The delivery keeps the
+// values of ctx, such as the route and the span, but not its cancellation.
+func (q *Queue) Enqueue(ctx context.Context, s Service, wm webhook.Message) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.ctx.Err() != nil {
+		return ErrQueueClosed
+	}
+	if q.depth >= q.size {
+		q.metrics.QueueRejected(ctx, q.route)
+		return ErrQueueFull
+	}
+
+	done := func() {}
+	if q.tracker != nil {
+		done = q.tracker.start(newInFlight(ctx, wm))
+	}
+	item := queued{
+		ctx:      queueContext{Context: q.ctx, values: ctx},
+		service:  s,
+		wm:       wm,
+		enqueued: time.Now(),
+		done:     done,
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(wm.GroupKey))
+	i := int(h.Sum32() % uint32(len(q.pending)))
+	q.pending[i] = append(q.pending[i], item)
+	q.depth++
+	select {
+	case q.wake[i] <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Stats returns the number of notifications waiting in the queue and the
+// time the oldest one has been waiting.
+func (q *Queue) Stats() (int, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var oldest time.Time
+	for _, p := range q.pending {
+		if len(p) > 0 && (oldest.IsZero() || p[0].enqueued.Before(oldest)) {
+			oldest = p[0].enqueued
+		}
+	}
+	if oldest.IsZero() {
+		return q.depth, 0
+	}
+	return q.depth, time.Since(oldest)
+}
+
+func (q *Queue) pop(i int) (queued, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending[i]) == 0 {
+		return queued{}, false
+	}
+	item := q.pending[i][0]
+	q.pending[i][0] = queued{}
+	q.pending[i] = q.pending[i][1:]
+	q.depth--
+	return item, true
+}
+
+func (q *Queue) work(i int) {
+	for {
+		if item, ok := q.pop(i); ok {
+			q.post(item)
+			continue
+		}
+		select {
+		case <-q.wake[i]:
+		case <-q.ctx.Done():
+			// Enqueue rejects notifications from now on, post those
+			// enqueued before.
+			for item, ok := q.pop(i); ok; item, ok = q.pop(i) {
+				q.post(item)
+			}
+			return
+		}
+	}
+}
+
+func (q *Queue) post(item queued) {
+	defer item.done()
+	q.metrics.QueueWait(item.ctx, q.route, time.Since(item.enqueued))
+	if _, err := item.service.Post(item.ctx, item.wm); err != nil {
+		q.logger.Err(err, "group_key", item.wm.GroupKey)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+var queueLogger = utility.NewLogger(utility.LogFormatFmt, false)
+
+// recordingService records the group key and status of the notifications
+// posted, and the route and error of their context.
+type recordingService struct {
+	mu     sync.Mutex
+	posted []string
+	routes []string
+	errs   []error
+}
+
+func (s *recordingService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.posted = append(s.posted, wm.GroupKey+":"+wm.Status)
+	s.routes = append(s.routes, metrics.RouteFromContext(ctx))
+	s.errs = append(s.errs, ctx.Err())
+	return nil, nil
+}
+
+func (s *recordingService) get() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.posted...)
+}
+
+func notification(groupKey, status string) webhook.Message {
+	return webhook.Message{Data: &template.Data{Status: status}, GroupKey: groupKey}
+}
+
+func TestNewQueueInvalid(t *testing.T) {
+	_, err := NewQueue(context.Background(), "/a", 0, 1, queueLogger)
+	assert.Error(t, err)
+	_, err = NewQueue(context.Background(), "/a", 1, 0, queueLogger)
+	assert.Error(t, err)
+}
+
+func TestQueue_Order(t *testing.T) {
+	tracker := NewTracker()
+	q, err := NewQueue(context.Background(), "/alertmanager", 100, 4, queueLogger, WithQueueTracker(tracker))
+	require.NoError(t, err)
+	s := &recordingService{}
+
+	for i := 0; i < 10; i++ {
+		for _, status := range []string{"firing", "resolved"} {
+			require.NoError(t, q.Enqueue(context.Background(), s, notification(fmt.Sprint(i), status)))
+		}
+	}
+	require.NoError(t, tracker.Wait(context.Background()))
+
+	posted := s.get()
+	require.Len(t, posted, 20)
+	index := map[string]int{}
+	for i, p := range posted {
+		index[p] = i
+	}
+	for i := 0; i < 10; i++ {
+		assert.Less(t, index[fmt.Sprint(i)+":firing"], index[fmt.Sprint(i)+":resolved"])
+	}
+}
+
+func TestQueue_DetachesRequestContext(t *testing.T) {
+	tracker := NewTracker()
+	q, err := NewQueue(context.Background(), "/alertmanager", 1, 1, queueLogger, WithQueueTracker(tracker))
+	require.NoError(t, err)
+	s := &recordingService{}
+
+	ctx, cancel := context.WithCancel(metrics.ContextWithRoute(context.Background(), "/alertmanager"))
+	require.NoError(t, q.Enqueue(ctx, s, notification("a", "firing")))
+	cancel()
+	require.NoError(t, tracker.Wait(context.Background()))
+
+	assert.Equal(t, []string{"/alertmanager"}, s.routes)
+	assert.Equal(t, []error{nil}, s.errs)
+}
+
+func TestQueue_Full(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m, err := metrics.NewRecorder(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+	q, err := NewQueue(context.Background(), "/alertmanager", 2, 1, queueLogger, WithQueueMetrics(m))
+	require.NoError(t, err)
+	s := blockingService{started: make(chan struct{}), release: make(chan struct{})}
+
+	require.NoError(t, q.Enqueue(context.Background(), s, notification("a", "firing")))
+	<-s.started
+	require.NoError(t, q.Enqueue(context.Background(), s, notification("b", "firing")))
+	require.NoError(t, q.Enqueue(context.Background(), s, notification("c", "firing")))
+	assert.ErrorIs(t, q.Enqueue(context.Background(), s, notification("d", "firing")), ErrQueueFull)
+
+	depth, age := q.Stats()
+	assert.Equal(t, 2, depth)
+	assert.Positive(t, age)
+
+	got := testutils.CollectMetrics(t, reader)
+	assert.Equal(t, map[string]float64{"route=/alertmanager": 2}, got["prometheus_msteams.queue.depth"])
+	assert.Equal(t, map[string]float64{"route=/alertmanager": 1}, got["prometheus_msteams.queue.rejected"])
+	assert.Equal(t, map[string]float64{"route=/alertmanager": 1}, got["prometheus_msteams.queue.wait.duration"])
+
+	close(s.release)
+	go func() {
+		for range s.started {
+		}
+	}()
+	assert.Eventually(t, func() bool {
+		depth, _ := q.Stats()
+		return depth == 0
+	}, time.Second, time.Millisecond)
+}
+
+// cancelableService blocks its Post calls until their context is canceled.
+type cancelableService struct {
+	started chan struct{}
+}
+
+func (s cancelableService) Post(ctx context.Context, _ webhook.Message) ([]PostResponse, error) {
+	s.started <- struct{}{}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	tracker := NewTracker()
+	ctx, cancel := context.WithCancel(context.Background())
+	q, err := NewQueue(ctx, "/alertmanager", 10, 1, queueLogger, WithQueueTracker(tracker))
+	require.NoError(t, err)
+	blocking := cancelableService{started: make(chan struct{})}
+	s := &recordingService{}
+
+	require.NoError(t, q.Enqueue(context.Background(), blocking, notification("a", "firing")))
+	<-blocking.started
+	require.NoError(t, q.Enqueue(context.Background(), s, notification("b", "firing")))
+	assert.Len(t, tracker.InFlight(), 2)
+
+	cancel()
+	require.NoError(t, tracker.Wait(context.Background()))
+	assert.ErrorIs(t, q.Enqueue(context.Background(), s, notification("c", "firing")), ErrQueueClosed)
+	// The notification queued before the cancellation is posted with a
+	// canceled context.
+	assert.Equal(t, []string{"b:firing"}, s.get())
+	assert.Equal(t, []error{context.Canceled}, s.errs)
+}
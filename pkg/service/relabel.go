@@ -0,0 +1,110 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/relabel"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// LabelMetaPrefix prefixes the labels of an alert in its annotations while
+// they are relabeled, e.g. __label_alertname.
+const LabelMetaPrefix = "__label_"
+
+// relabelService is a middleware for Service relabeling the alerts.
+type relabelService struct {
+	labels      []*relabel.Config
+	annotations []*relabel.Config
+	logger      *utility.Logger
+	next        Service
+}
+
+// NewRelabelService creates a relabelService. The labels of each alert are
+// relabeled with labelConfigs, then its annotations with annotationConfigs,
+// the labels being available as LabelMetaPrefix annotations. The alerts
+// dropped by either are removed, and the labels and annotations starting with
+// "__" are removed after relabeling.
+//
+// The group labels are relabeled with the labelConfigs not dropping alerts,
+// and the common labels and annotations and the status are recomputed from
+// the alerts.
+func NewRelabelService(labelConfigs, annotationConfigs []*relabel.Config, logger *utility.Logger, next Service) Service {
+	return relabelService{labels: labelConfigs, annotations: annotationConfigs, logger: logger, next: next}
+}
+
+func (s relabelService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	if wm.Data == nil {
+		return s.next.Post(ctx, wm)
+	}
+	var alerts template.Alerts
+	for _, a := range wm.Alerts {
+		if a, ok := s.relabel(a); ok {
+			alerts = append(alerts, a)
+		}
+	}
+	if len(alerts) == 0 {
+		s.logger.Debug("message", "all alerts dropped by relabeling", "group_key", wm.GroupKey)
+		return nil, nil
+	}
+
+	wm = withAlerts(wm, alerts)
+	recomputeCommon(&wm)
+	groupLabels := copyKV(wm.GroupLabels)
+	for _, c := range s.labels {
+		if c.Action != relabel.Keep && c.Action != relabel.Drop {
+			relabel.Process(groupLabels, c)
+		}
+	}
+	wm.GroupLabels = withoutMeta(groupLabels)
+	return s.next.Post(ctx, wm)
+}
+
+func (s relabelService) relabel(a template.Alert) (template.Alert, bool) {
+	ls, ok := relabel.Process(copyKV(a.Labels), s.labels...)
+	if !ok {
+		return a, false
+	}
+	ls = withoutMeta(ls)
+
+	annotations := copyKV(a.Annotations)
+	if len(s.annotations) > 0 {
+		for k, v := range ls {
+			annotations[LabelMetaPrefix+k] = v
+		}
+		if annotations, ok = relabel.Process(annotations, s.annotations...); !ok {
+			return a, false
+		}
+	}
+	a.Labels = ls
+	a.Annotations = withoutMeta(annotations)
+	return a, true
+}
+
+// withoutMeta removes the keys starting with "__" from kv.
+func withoutMeta(kv map[string]string) template.KV {
+	for k := range kv {
+		if strings.HasPrefix(k, "__") {
+			delete(kv, k)
+		}
+	}
+	return kv
+}
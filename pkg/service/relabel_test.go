@@ -0,0 +1,84 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/relabel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func relabelConfigs(t *testing.T, s string) []*relabel.Config {
+	t.Helper()
+	var cfgs []*relabel.Config
+	require.NoError(t, yaml.Unmarshal([]byte(s), &cfgs))
+	return cfgs
+}
+
+func TestRelabelService(t *testing.T) {
+	next := &digestRecorder{}
+	s := NewRelabelService(
+		relabelConfigs(t, `
+- action: drop
+  source_labels: [alertname]
+  regex: Watchdog
+- source_labels: [kubernetes_namespace]
+  regex: (.+)
+  target_label: namespace
+- action: labeldrop
+  regex: kubernetes_namespace|prometheus_replica
+`),
+		relabelConfigs(t, `
+- source_labels: [__label_alertname]
+  target_label: runbook
+  replacement: https://runbooks.example.com/$1
+`),
+		queueLogger, next,
+	)
+
+	alerts := template.Alerts{
+		{Status: "firing", Labels: template.KV{"alertname": "Watchdog"}},
+		{Status: "firing", Labels: template.KV{"alertname": "HighLoad", "kubernetes_namespace": "monitoring", "prometheus_replica": "0"}, Annotations: template.KV{"summary": "a"}},
+		{Status: "resolved", Labels: template.KV{"alertname": "HighLoad", "kubernetes_namespace": "default"}, Annotations: template.KV{"summary": "b"}},
+	}
+	wm := alertsMessage("a", alerts...)
+	wm.GroupLabels = template.KV{"alertname": "HighLoad", "kubernetes_namespace": "monitoring"}
+	_, err := s.Post(context.Background(), wm)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, next.len())
+	got := next.posted[0]
+	require.Len(t, got.Alerts, 2)
+	assert.Equal(t, template.KV{"alertname": "HighLoad", "namespace": "monitoring"}, got.Alerts[0].Labels)
+	assert.Equal(t, template.KV{"summary": "a", "runbook": "https://runbooks.example.com/HighLoad"}, got.Alerts[0].Annotations)
+	assert.Equal(t, "firing", got.Status)
+	assert.Equal(t, template.KV{"alertname": "HighLoad"}, got.CommonLabels)
+	assert.Equal(t, template.KV{"runbook": "https://runbooks.example.com/HighLoad"}, got.CommonAnnotations)
+	assert.Equal(t, template.KV{"alertname": "HighLoad", "namespace": "monitoring"}, got.GroupLabels)
+	// The alerts of the request are not changed.
+	assert.Contains(t, alerts[1].Labels, "kubernetes_namespace")
+
+	// A notification without alerts left is not posted.
+	_, err = s.Post(context.Background(), alertsMessage("b", alerts[0]))
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.len())
+}
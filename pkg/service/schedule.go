@@ -0,0 +1,378 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
+)
+
+// scheduleTick is the interval at which a ScheduleService releases the
+// notifications held by the schedules which ended.
+const scheduleTick = 15 * time.Second
+
+// ScheduleAction is what a Schedule does with the notifications it mutes.
+type ScheduleAction string
+
+const (
+	// ScheduleDrop discards the muted notifications.
+	ScheduleDrop ScheduleAction = "drop"
+	// ScheduleDelay posts the muted notifications once the schedule ends,
+	// the last one of each group only.
+	ScheduleDelay ScheduleAction = "delay"
+	// ScheduleDigest posts the muted notifications as one digest once the
+	// schedule ends.
+	ScheduleDigest ScheduleAction = "digest"
+)
+
+// Schedule mutes the notifications of a route during its time intervals.
+type Schedule struct {
+	Name   string
+	Action ScheduleAction
+	// Intervals are the Alertmanager time intervals during which the
+	// schedule is active.
+	Intervals []timeinterval.TimeInterval
+	// Matchers selects the alerts muted: an alert is muted if its labels
+	// match all the matchers of one of the entries. All the alerts are muted
+	// if there is no entry.
+	Matchers [][]*labels.Matcher
+}
+
+// Active reports whether t is in one of the intervals of s.
+func (s Schedule) Active(t time.Time) bool {
+	for _, ti := range s.Intervals {
+		if ti.ContainsTime(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s Schedule) mutes(a template.Alert) bool {
+	return len(s.Matchers) == 0 || matchesAny(s.Matchers, a.Labels)
+}
+
+// ScheduleState is the state of a Schedule of a route.
+type ScheduleState struct {
+	Route  string         `json:"route"`
+	Name   string         `json:"name"`
+	Action ScheduleAction `json:"action"`
+	Active bool           `json:"active"`
+	// Held is the number of notifications delayed or buffered until the
+	// schedule ends.
+	Held int `json:"held"`
+}
+
+type heldNotification struct {
+	ctx context.Context
+	wm  webhook.Message
+}
+
+// ScheduleService is a middleware for Service muting the alerts of a route
+// while one of its schedules is active. The alerts not muted are posted
+// right away.
+type ScheduleService struct {
+	ctx       context.Context
+	route     string
+	schedules []Schedule
+	next      Service
+	logger    *utility.Logger
+	tracker   *Tracker
+	now       func() time.Time
+	// digests buffer the notifications muted by the ScheduleDigest
+	// schedules, by schedule name.
+	digests map[string]*DigestService
+
+	mu sync.Mutex
+	// delayed holds the notifications muted by the ScheduleDelay
+	// schedules, by schedule name, in the order they were received.
+	delayed map[string][]heldNotification
+}
+
+// ScheduleOption configures a ScheduleService.
+type ScheduleOption func(*ScheduleService)
+
+// WithScheduleTracker adds the notifications posted once their schedule ends
+// to t, and posts the held notifications when t is flushed.
+func WithScheduleTracker(t *Tracker) ScheduleOption {
+	return func(s *ScheduleService) {
+		s.tracker = t
+	}
+}
+
+// NewScheduleService creates the ScheduleService of route posting with next
+// and starts releasing the notifications held by the schedules which ended.
+// Once ctx is done, it stops releasing them, and the deliveries are canceled.
+func NewScheduleService(ctx context.Context, route string, schedules []Schedule, next Service, logger *utility.Logger, opts ...ScheduleOption) (*ScheduleService, error) {
+	s := &ScheduleService{
+		ctx:       ctx,
+		route:     route,
+		schedules: schedules,
+		next:      next,
+		logger:    logger.WithPrefix("package", "service", "component", "schedule", "route", route),
+		now:       time.Now,
+		digests:   map[string]*DigestService{},
+		delayed:   map[string][]heldNotification{},
+	}
+	names := map[string]bool{}
+	for _, sc := range schedules {
+		if sc.Name == "" {
+			return nil, errors.New("schedule name is required")
+		}
+		if names[sc.Name] {
+			return nil, fmt.Errorf("duplicate schedule '%s'", sc.Name)
+		}
+		names[sc.Name] = true
+		if len(sc.Intervals) == 0 {
+			return nil, fmt.Errorf("schedule '%s' has no time interval", sc.Name)
+		}
+		switch sc.Action {
+		case ScheduleDrop, ScheduleDelay:
+		case ScheduleDigest:
+			d, err := newDigestService(ctx, route, scheduleDigestConfig(next), scheduleDigestNext(next), logger)
+			if err != nil {
+				return nil, err
+			}
+			s.digests[sc.Name] = d
+		default:
+			return nil, fmt.Errorf("schedule '%s' has an invalid action '%s', expected drop, delay or digest", sc.Name, sc.Action)
+		}
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.tracker != nil {
+		s.tracker.addFlusher(s)
+	}
+	go s.run()
+	return s, nil
+}
+
+// scheduleDigestNext returns the service posting the digests of the
+// schedules: the one of next when next is already a DigestService, so that
+// the muted notifications are not digested twice.
+func scheduleDigestNext(next Service) Service {
+	if d, ok := next.(*DigestService); ok {
+		return d.next
+	}
+	return next
+}
+
+// scheduleDigestConfig returns the config of the digests of the schedules,
+// without a window as they are posted once the schedule ends. They list
+// as many alerts as the digest of next, if any.
+func scheduleDigestConfig(next Service) DigestConfig {
+	if d, ok := next.(*DigestService); ok {
+		return DigestConfig{Top: d.cfg.Top}
+	}
+	return DigestConfig{}
+}
+
+// Route returns the route of s.
+func (s *ScheduleService) Route() string {
+	return s.route
+}
+
+// Post mutes the alerts of wm matched by an active schedule, the first one
+// in the order of the schedules, and posts the others. The response has an
+// entry with status 202 for each schedule which muted alerts.
+func (s *ScheduleService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	if wm.Data == nil {
+		return s.next.Post(ctx, wm)
+	}
+	now := s.now()
+	active := make([]bool, len(s.schedules))
+	for i, sc := range s.schedules {
+		active[i] = sc.Active(now)
+	}
+
+	muted := make([]template.Alerts, len(s.schedules))
+	var posted template.Alerts
+	for _, a := range wm.Alerts {
+		i := s.muting(active, a)
+		if i < 0 {
+			posted = append(posted, a)
+			continue
+		}
+		muted[i] = append(muted[i], a)
+	}
+
+	var prs []PostResponse
+	for i, alerts := range muted {
+		if len(alerts) > 0 {
+			s.mute(ctx, s.schedules[i], withAlerts(wm, alerts))
+			prs = append(prs, mutedResponse(s.schedules[i], len(alerts)))
+		}
+	}
+	if len(posted) == 0 {
+		return prs, nil
+	}
+	if len(posted) < len(wm.Alerts) {
+		wm = withAlerts(wm, posted)
+	}
+	nprs, err := s.next.Post(ctx, wm)
+	return append(prs, nprs...), err
+}
+
+// mutedResponse is the PostResponse of n alerts muted by sc.
+func mutedResponse(sc Schedule, n int) PostResponse {
+	verb := "held"
+	if sc.Action == ScheduleDrop {
+		verb = "dropped"
+	}
+	return PostResponse{
+		Status:  http.StatusAccepted,
+		Message: fmt.Sprintf("%d alerts %s by schedule '%s'", n, verb, sc.Name),
+	}
+}
+
+// Flush posts the notifications held by all the schedules.
+func (s *ScheduleService) Flush(ctx context.Context) error {
+	var errs []error
+	for _, sc := range s.schedules {
+		if err := s.releaseSchedule(ctx, sc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// States returns the state of the schedules at t.
+func (s *ScheduleService) States(t time.Time) []ScheduleState {
+	states := make([]ScheduleState, 0, len(s.schedules))
+	for _, sc := range s.schedules {
+		state := ScheduleState{Route: s.route, Name: sc.Name, Action: sc.Action, Active: sc.Active(t)}
+		switch sc.Action {
+		case ScheduleDelay:
+			s.mu.Lock()
+			state.Held = len(s.delayed[sc.Name])
+			s.mu.Unlock()
+		case ScheduleDigest:
+			state.Held = s.digests[sc.Name].pending()
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// muting returns the index of the first active schedule muting a, or -1.
+func (s *ScheduleService) muting(active []bool, a template.Alert) int {
+	for i, sc := range s.schedules {
+		if active[i] && sc.mutes(a) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *ScheduleService) mute(ctx context.Context, sc Schedule, wm webhook.Message) {
+	s.logger.Debug(
+		"message", "notification muted",
+		"schedule", sc.Name,
+		"action", sc.Action,
+		"group_key", wm.GroupKey,
+		"alerts", len(wm.Alerts),
+	)
+	switch sc.Action {
+	case ScheduleDelay:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		// The last notification of a group holds the state of all its
+		// alerts, so it replaces the previous ones.
+		held := s.delayed[sc.Name][:0]
+		for _, h := range s.delayed[sc.Name] {
+			if h.wm.GroupKey != wm.GroupKey {
+				held = append(held, h)
+			}
+		}
+		s.delayed[sc.Name] = append(held, heldNotification{ctx: ctx, wm: wm})
+	case ScheduleDigest:
+		// Without a window or a maximum, the digest only buffers.
+		_, _ = s.digests[sc.Name].Post(ctx, wm)
+	}
+}
+
+func (s *ScheduleService) run() {
+	ticker := time.NewTicker(scheduleTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.release(s.now())
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// release posts the notifications held by the schedules not active at t.
+func (s *ScheduleService) release(t time.Time) {
+	for _, sc := range s.schedules {
+		if sc.Active(t) {
+			continue
+		}
+		if err := s.releaseSchedule(s.ctx, sc); err != nil {
+			s.logger.Err(err, "schedule", sc.Name)
+		}
+	}
+}
+
+// releaseSchedule posts the notifications held by sc, canceled with ctx.
+func (s *ScheduleService) releaseSchedule(ctx context.Context, sc Schedule) error {
+	switch sc.Action {
+	case ScheduleDelay:
+		s.mu.Lock()
+		held := s.delayed[sc.Name]
+		delete(s.delayed, sc.Name)
+		s.mu.Unlock()
+		var errs []error
+		for _, h := range held {
+			if err := s.post(queueContext{Context: ctx, values: h.ctx}, h.wm); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	case ScheduleDigest:
+		d := s.digests[sc.Name]
+		if d.pending() == 0 {
+			return nil
+		}
+		if s.tracker != nil {
+			defer s.tracker.start(InFlight{Route: s.route, GroupKey: "digest:" + s.route, Since: time.Now()})()
+		}
+		return d.Flush(ctx)
+	}
+	return nil
+}
+
+func (s *ScheduleService) post(ctx context.Context, wm webhook.Message) error {
+	if s.tracker != nil {
+		defer s.tracker.start(newInFlight(ctx, wm))()
+	}
+	_, err := s.next.Post(ctx, wm)
+	return err
+}
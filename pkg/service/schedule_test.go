@@ -0,0 +1,170 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	// mornings is active from 00:00 to 12:00 UTC.
+	mornings = []timeinterval.TimeInterval{{Times: []timeinterval.TimeRange{{StartMinute: 0, EndMinute: 12 * 60}}}}
+	morning  = time.Date(2026, 1, 1, 6, 0, 0, 0, time.UTC)
+	noon     = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func newScheduleService(t *testing.T, next Service, schedules ...Schedule) *ScheduleService {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s, err := NewScheduleService(ctx, "/alertmanager", schedules, next, queueLogger)
+	require.NoError(t, err)
+	s.now = func() time.Time { return morning }
+	return s
+}
+
+func TestNewScheduleServiceInvalid(t *testing.T) {
+	tests := map[string][]Schedule{
+		"no name":        {{Action: ScheduleDrop, Intervals: mornings}},
+		"duplicate name": {{Name: "a", Action: ScheduleDrop, Intervals: mornings}, {Name: "a", Action: ScheduleDrop, Intervals: mornings}},
+		"no interval":    {{Name: "a", Action: ScheduleDrop}},
+		"invalid action": {{Name: "a", Action: "mute", Intervals: mornings}},
+	}
+	for name, schedules := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewScheduleService(context.Background(), "/a", schedules, &digestRecorder{}, queueLogger)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSchedule_Active(t *testing.T) {
+	s := Schedule{Intervals: mornings}
+	assert.True(t, s.Active(morning))
+	assert.False(t, s.Active(noon))
+}
+
+func TestScheduleService_Drop(t *testing.T) {
+	next := &digestRecorder{}
+	warnings, err := labels.ParseMatchers(`{severity="warning"}`)
+	require.NoError(t, err)
+	s := newScheduleService(t, next, Schedule{Name: "nights", Action: ScheduleDrop, Intervals: mornings, Matchers: [][]*labels.Matcher{warnings}})
+
+	_, err = s.Post(context.Background(), alertsMessage("a", alert("1", "firing", "warning"), alert("2", "firing", "critical")))
+	require.NoError(t, err)
+	prs, err := s.Post(context.Background(), alertsMessage("b", alert("3", "firing", "warning")))
+	require.NoError(t, err)
+	assert.Equal(t, []PostResponse{{Status: http.StatusAccepted, Message: "1 alerts dropped by schedule 'nights'"}}, prs)
+
+	require.Equal(t, 1, next.len())
+	require.Len(t, next.posted[0].Alerts, 1)
+	assert.Equal(t, "2", next.posted[0].Alerts[0].Fingerprint)
+
+	// The alerts are posted once the schedule is over.
+	s.now = func() time.Time { return noon }
+	_, err = s.Post(context.Background(), alertsMessage("b", alert("3", "firing", "warning")))
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.len())
+}
+
+func TestScheduleService_Delay(t *testing.T) {
+	next := &digestRecorder{}
+	s := newScheduleService(t, next, Schedule{Name: "nights", Action: ScheduleDelay, Intervals: mornings})
+
+	for _, wm := range []struct{ groupKey, status string }{{"a", "firing"}, {"b", "firing"}, {"a", "resolved"}} {
+		_, err := s.Post(context.Background(), alertsMessage(wm.groupKey, alert(wm.groupKey, wm.status, "warning")))
+		require.NoError(t, err)
+	}
+	assert.Zero(t, next.len())
+	assert.Equal(t, []ScheduleState{{Route: "/alertmanager", Name: "nights", Action: ScheduleDelay, Active: true, Held: 2}}, s.States(morning))
+
+	s.release(morning)
+	assert.Zero(t, next.len())
+
+	s.release(noon)
+	require.Equal(t, 2, next.len())
+	assert.Equal(t, "b", next.posted[0].GroupKey)
+	assert.Equal(t, "a", next.posted[1].GroupKey)
+	assert.Equal(t, "resolved", next.posted[1].Alerts[0].Status)
+	assert.Equal(t, []ScheduleState{{Route: "/alertmanager", Name: "nights", Action: ScheduleDelay}}, s.States(noon))
+}
+
+func TestScheduleService_Digest(t *testing.T) {
+	next := &digestRecorder{}
+	s := newScheduleService(t, next, Schedule{Name: "nights", Action: ScheduleDigest, Intervals: mornings})
+
+	for _, groupKey := range []string{"a", "b"} {
+		_, err := s.Post(context.Background(), alertsMessage(groupKey, alert(groupKey, "firing", "warning")))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, s.States(morning)[0].Held)
+
+	s.release(noon)
+	require.Equal(t, 1, next.len())
+	assert.Equal(t, "digest:/alertmanager", next.posted[0].GroupKey)
+	require.NotNil(t, next.digests[0])
+	assert.Equal(t, 2, next.digests[0].Notifications)
+	assert.Zero(t, s.States(noon)[0].Held)
+}
+
+func TestScheduleService_DigestOfDigest(t *testing.T) {
+	next := &digestRecorder{}
+	d, err := NewDigestService(context.Background(), "/alertmanager", DigestConfig{Window: time.Hour, Top: 3}, next, queueLogger)
+	require.NoError(t, err)
+	s := newScheduleService(t, d, Schedule{Name: "nights", Action: ScheduleDigest, Intervals: mornings})
+
+	prs, err := s.Post(context.Background(), alertsMessage("a", alert("a", "firing", "warning")))
+	require.NoError(t, err)
+	assert.Equal(t, []PostResponse{{Status: http.StatusAccepted, Message: "1 alerts held by schedule 'nights'"}}, prs)
+
+	// The digest of the schedule is posted once, not buffered by the digest
+	// of the route.
+	s.release(noon)
+	require.Equal(t, 1, next.len())
+	require.NotNil(t, next.digests[0])
+	assert.Equal(t, 1, next.digests[0].Notifications)
+	assert.Zero(t, d.pending())
+}
+
+func TestScheduleService_Flush(t *testing.T) {
+	tracker := NewTracker()
+	next := &digestRecorder{}
+	s, err := NewScheduleService(context.Background(), "/alertmanager", []Schedule{
+		{Name: "delay", Action: ScheduleDelay, Intervals: mornings},
+		{Name: "digest", Action: ScheduleDigest, Intervals: mornings},
+	}, next, queueLogger, WithScheduleTracker(tracker))
+	require.NoError(t, err)
+	s.now = func() time.Time { return morning }
+
+	// The first active schedule mutes the alerts.
+	_, err = s.Post(context.Background(), alertsMessage("a", alert("1", "firing", "warning")))
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 0}, []int{s.States(morning)[0].Held, s.States(morning)[1].Held})
+
+	require.NoError(t, tracker.Flush(context.Background()))
+	require.Equal(t, 1, next.len())
+	assert.Equal(t, "a", next.posted[0].GroupKey)
+	assert.Empty(t, tracker.InFlight())
+}
@@ -21,16 +21,31 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/audit"
 	"github.com/stakater/prometheus-msteams/pkg/card"
-	"go.opencensus.io/trace"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracerName is the instrumentation scope of the spans started by this package.
+const tracerName = "github.com/stakater/prometheus-msteams/pkg/service"
+
 // WebhookType is the type of Microsoft Teams webhook.
 type WebhookType string
 
@@ -60,25 +75,61 @@ type simpleService struct {
 	client      *http.Client
 	webhookURL  string
 	webhookType WebhookType
+	metrics     *metrics.Recorder
+	audit       *audit.Log
+}
+
+// Option configures a simpleService.
+type Option func(*simpleService)
+
+// WithMetrics records the alerts received and the deliveries of the
+// service with m.
+func WithMetrics(m *metrics.Recorder) Option {
+	return func(s *simpleService) {
+		s.metrics = m
+	}
+}
+
+// WithAudit records the deliveries of the service in l.
+func WithAudit(l *audit.Log) Option {
+	return func(s *simpleService) {
+		s.audit = l
+	}
 }
 
 // NewSimpleService creates a simpleService.
-func NewSimpleService(converter card.Converter, client *http.Client, webhookURL string, webhookType WebhookType) Service {
-	return simpleService{converter, client, webhookURL, webhookType}
+func NewSimpleService(converter card.Converter, client *http.Client, webhookURL string, webhookType WebhookType, opts ...Option) Service {
+	s := simpleService{converter: converter, client: client, webhookURL: webhookURL, webhookType: webhookType}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
 }
 
 func (s simpleService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
-	ctx, span := trace.StartSpan(ctx, "simpleService.Post")
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "simpleService.Post")
 	defer span.End()
 
 	prs := []PostResponse{}
 
+	route := metrics.RouteFromContext(ctx)
+	if wm.Data != nil {
+		s.metrics.AlertsReceived(ctx, route, wm.Alerts)
+	}
+
+	begin := time.Now()
 	c, err := s.converter.Convert(ctx, wm)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse webhook message: %w", err)
+		err = fmt.Errorf("failed to parse webhook message: %w", err)
+		s.recordAudit(ctx, route, wm, PostResponse{}, "", err, begin)
+		return nil, err
 	}
 
-	_, err = s.post(ctx, c, s.webhookURL)
+	pr, hash, err := s.send(ctx, c)
+	if wm.Data != nil {
+		s.metrics.Delivered(ctx, route, pr.Status, wm.Alerts, time.Now())
+	}
+	s.recordAudit(ctx, route, wm, pr, hash, err, begin)
 	if err != nil {
 		return prs, err
 	}
@@ -86,19 +137,78 @@ func (s simpleService) Post(ctx context.Context, wm webhook.Message) ([]PostResp
 	return prs, nil
 }
 
-func (s simpleService) post(ctx context.Context, c interface{}, url string) (PostResponse, error) {
-	ctx, span := trace.StartSpan(ctx, "simpleService.post")
-	defer span.End()
-
-	pr := PostResponse{WebhookURL: url}
+// send posts the card c and returns the response and the SHA-256 of the
+// card payload.
+func (s simpleService) send(ctx context.Context, c adaptivecards.WorkflowConnectorCard) (PostResponse, string, error) {
+	pr := PostResponse{WebhookURL: s.webhookURL}
 
 	b, err := json.Marshal(c)
 	if err != nil {
-		err = fmt.Errorf("failed to decoding JSON card: %w", err)
-		return pr, err
+		return pr, "", fmt.Errorf("failed to decoding JSON card: %w", err)
+	}
+	s.metrics.CardSize(ctx, metrics.RouteFromContext(ctx), len(b))
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+
+	pr, err = s.post(ctx, b, s.webhookURL)
+	return pr, hash, err
+}
+
+func (s simpleService) recordAudit(ctx context.Context, route string, wm webhook.Message, pr PostResponse, hash string, err error, begin time.Time) {
+	if s.audit == nil {
+		return
+	}
+	now := time.Now()
+	e := audit.Entry{
+		Time:         now,
+		Route:        route,
+		GroupKey:     wm.GroupKey,
+		CardHash:     hash,
+		ResponseCode: pr.Status,
+		ResponseBody: audit.TruncateBody(pr.Message),
+		Duration:     now.Sub(begin),
+	}
+	if wm.Data != nil {
+		e.Receiver = wm.Receiver
+		e.Status = wm.Status
+		e.Alerts = audit.AlertsOf(wm.Alerts)
 	}
+	if err != nil {
+		e.Error = redactError(err)
+	}
+	if err := s.audit.Record(e); err != nil {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("audit_error", err.Error()))
+	}
+}
+
+// urlRe matches the URLs in error messages.
+var urlRe = regexp.MustCompile(`https?://[^\s"']+`)
+
+// redactError returns the message of err without the paths and the queries
+// of the URLs, which hold the secrets of the webhooks, e.g. the sig of a
+// workflow URL. The URL of a *url.Error is dropped, keeping its Op and Err.
+func redactError(err error) string {
+	msg := err.Error()
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		msg = strings.Replace(msg, ue.Error(), ue.Op+": "+ue.Err.Error(), 1)
+	}
+	return urlRe.ReplaceAllStringFunc(msg, func(s string) string {
+		u, err := url.Parse(s)
+		if err != nil || u.Host == "" {
+			return "<redacted>"
+		}
+		return u.Scheme + "://" + u.Host
+	})
+}
+
+func (s simpleService) post(ctx context.Context, b []byte, url string) (PostResponse, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "simpleService.post")
+	defer span.End()
+
+	pr := PostResponse{WebhookURL: url}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", s.webhookURL, bytes.NewBuffer(b))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(b))
 	if err != nil {
 		err = fmt.Errorf("failed to creating a request: %w", err)
 		return pr, err
@@ -112,7 +222,7 @@ func (s simpleService) post(ctx context.Context, c interface{}, url string) (Pos
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			span.AddAttributes(trace.StringAttribute("close_body_error", fmt.Sprintf("failed to close response body: %v", err)))
+			span.SetAttributes(attribute.String("close_body_error", fmt.Sprintf("failed to close response body: %v", err)))
 		}
 	}()
 
@@ -18,6 +18,8 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -26,8 +28,12 @@ import (
 	"github.com/prometheus/alertmanager/notify/webhook"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/stakater/prometheus-msteams/pkg/adaptivecards"
+	"github.com/stakater/prometheus-msteams/pkg/audit"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
+	"github.com/stakater/prometheus-msteams/pkg/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 )
 
 // mockConverter implements card.Converter for testing
@@ -166,3 +172,118 @@ func TestSimpleService_PostWorkflowWebhook_ConverterError(t *testing.T) {
 	assert.Nil(t, responses)
 	assert.Contains(t, err.Error(), "failed to parse webhook message")
 }
+
+func TestSimpleService_Post_Spans(t *testing.T) {
+	exporter := testutils.RecordSpans(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	svc := NewSimpleService(mockConverter{}, server.Client(), server.URL, Workflow)
+	_, err := svc.Post(context.Background(), webhook.Message{Data: &template.Data{}})
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Equal(t, []string{"simpleService.post", "simpleService.Post"}, testutils.SpanNames(spans))
+	assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+}
+
+func TestSimpleService_Post_Metrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m, err := metrics.NewRecorder(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	svc := NewSimpleService(mockConverter{}, server.Client(), server.URL, Workflow, WithMetrics(m))
+	ctx := metrics.ContextWithRoute(context.Background(), "/alertmanager")
+	_, err = svc.Post(ctx, webhook.Message{Data: &template.Data{Alerts: template.Alerts{{Status: "firing"}}}})
+	require.NoError(t, err)
+
+	got := testutils.CollectMetrics(t, reader)
+	assert.Equal(t, map[string]float64{"route=/alertmanager,status=firing": 1}, got["prometheus_msteams.alerts.received"])
+	assert.Equal(t, map[string]float64{"route=/alertmanager": 1}, got["prometheus_msteams.card.size"])
+	assert.Equal(t, map[string]float64{"route=/alertmanager,status_class=4xx": 1}, got["prometheus_msteams.deliveries"])
+}
+
+func TestSimpleService_Post_Audit(t *testing.T) {
+	l, err := audit.New(10, "", nil)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte("throttled"))
+	}))
+	defer server.Close()
+
+	svc := NewSimpleService(mockConverter{}, server.Client(), server.URL, Workflow, WithAudit(l))
+	ctx := metrics.ContextWithRoute(context.Background(), "/alertmanager")
+	wm := webhook.Message{
+		Data: &template.Data{
+			Receiver: "teams",
+			Status:   "firing",
+			Alerts:   template.Alerts{{Status: "firing", Fingerprint: "abc", Labels: template.KV{"alertname": "HighLoad"}}},
+		},
+		GroupKey: "{}:{alertname=\"HighLoad\"}",
+	}
+	_, err = svc.Post(ctx, wm)
+	require.NoError(t, err)
+
+	svc = NewSimpleService(mockConverter{err: fmt.Errorf("bad template")}, server.Client(), server.URL, Workflow, WithAudit(l))
+	_, err = svc.Post(ctx, wm)
+	require.Error(t, err)
+
+	entries := l.Query(audit.Filter{})
+	require.Len(t, entries, 2)
+
+	failed, sent := entries[0], entries[1]
+	assert.Contains(t, failed.Error, "bad template")
+	assert.Empty(t, failed.CardHash)
+	assert.Zero(t, failed.ResponseCode)
+
+	assert.Equal(t, "/alertmanager", sent.Route)
+	assert.Equal(t, "teams", sent.Receiver)
+	assert.Equal(t, "firing", sent.Status)
+	assert.Equal(t, wm.GroupKey, sent.GroupKey)
+	assert.Equal(t, []audit.Alert{{Fingerprint: "abc", Status: "firing", Labels: map[string]string{"alertname": "HighLoad"}}}, sent.Alerts)
+	assert.Len(t, sent.CardHash, 64)
+	assert.Equal(t, http.StatusTooManyRequests, sent.ResponseCode)
+	assert.Equal(t, "throttled", sent.ResponseBody)
+	assert.False(t, sent.Delivered())
+
+	b, err := json.Marshal(entries)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), server.URL)
+}
+
+func TestSimpleService_Post_AuditRedactsWebhookURL(t *testing.T) {
+	l, err := audit.New(10, "", nil)
+	require.NoError(t, err)
+
+	// Nothing listens on the closed server, so the delivery fails with a
+	// *url.Error holding the webhook URL.
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	webhookURL := server.URL + "/powerautomate/automations/direct/workflows/0a1b/triggers/manual/paths/invoke?api-version=1&sp=%2Ftriggers%2Fmanual%2Frun&sv=1.0&sig=s3cr3t"
+
+	svc := NewSimpleService(mockConverter{}, server.Client(), webhookURL, Workflow, WithAudit(l))
+	_, err = svc.Post(context.Background(), webhook.Message{Data: &template.Data{Status: "firing"}})
+	require.Error(t, err)
+
+	entries := l.Query(audit.Filter{})
+	require.Len(t, entries, 1)
+	assert.NotEmpty(t, entries[0].Error)
+	assert.NotContains(t, entries[0].Error, "sig=")
+	assert.NotContains(t, entries[0].Error, "/workflows/")
+	assert.Contains(t, entries[0].Error, "Post: ")
+}
+
+func TestRedactError(t *testing.T) {
+	err := fmt.Errorf("giving up: %w", errors.New(`POST https://example.webhook.office.com/webhookb2/a@b/IncomingWebhook/c/d?x=1 failed`))
+	assert.Equal(t, "giving up: POST https://example.webhook.office.com failed", redactError(err))
+}
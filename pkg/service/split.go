@@ -0,0 +1,132 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/common/model"
+)
+
+// splitService is a middleware for Service posting a notification per
+// value of the by labels of the alerts, or per alert without labels.
+type splitService struct {
+	by   []string
+	next Service
+}
+
+func newSplitMiddleware(env MiddlewareEnv) (Middleware, error) {
+	var cfg struct {
+		By []string `yaml:"by"`
+	}
+	if err := env.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	return func(next Service) Service {
+		return splitService{by: cfg.By, next: next}
+	}, nil
+}
+
+func (s splitService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	if wm.Data == nil {
+		return s.next.Post(ctx, wm)
+	}
+	var keys []string
+	groups := map[string]template.Alerts{}
+	for _, a := range wm.Alerts {
+		key := s.key(a)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], a)
+	}
+	if len(keys) <= 1 {
+		return s.next.Post(ctx, wm)
+	}
+
+	var prs []PostResponse
+	var errs []error
+	for _, key := range keys {
+		m := withAlerts(wm, groups[key])
+		m.GroupKey = wm.GroupKey + ":" + key
+		recomputeCommon(&m)
+		p, err := s.next.Post(ctx, m)
+		prs = append(prs, p...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return prs, errors.Join(errs...)
+}
+
+func (s splitService) key(a template.Alert) string {
+	if len(s.by) == 0 {
+		return a.Fingerprint
+	}
+	ls := model.LabelSet{}
+	for _, name := range s.by {
+		ls[model.LabelName(name)] = model.LabelValue(a.Labels[name])
+	}
+	return ls.String()
+}
+
+// fanoutService is a middleware for Service posting the notifications to
+// more webhooks.
+type fanoutService struct {
+	webhooks []Service
+	next     Service
+}
+
+func newFanoutMiddleware(env MiddlewareEnv) (Middleware, error) {
+	var cfg struct {
+		WebhookURLs []string `yaml:"webhook_urls"`
+	}
+	if err := env.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if len(cfg.WebhookURLs) == 0 {
+		return nil, errors.New("webhook_urls is required")
+	}
+	if env.Webhook == nil {
+		return nil, errors.New("webhooks are not available")
+	}
+	webhooks := make([]Service, 0, len(cfg.WebhookURLs))
+	for _, u := range cfg.WebhookURLs {
+		s, err := env.Webhook(u)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, s)
+	}
+	return func(next Service) Service {
+		return fanoutService{webhooks: webhooks, next: next}
+	}, nil
+}
+
+func (s fanoutService) Post(ctx context.Context, wm webhook.Message) ([]PostResponse, error) {
+	prs, err := s.next.Post(ctx, wm)
+	errs := []error{err}
+	for _, w := range s.webhooks {
+		p, err := w.Post(ctx, wm)
+		prs = append(prs, p...)
+		errs = append(errs, err)
+	}
+	return prs, errors.Join(errs...)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2026 Stakater.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitService(t *testing.T) {
+	next := &digestRecorder{}
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, "by: [severity]")
+	m, err := newSplitMiddleware(env)
+	require.NoError(t, err)
+	s := m(next)
+
+	_, err = s.Post(context.Background(), alertsMessage("a", alert("1", "firing", "warning"), alert("2", "resolved", "critical"), alert("3", "firing", "warning")))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, next.len())
+	assert.Equal(t, `a:{severity="warning"}`, next.posted[0].GroupKey)
+	assert.Equal(t, "firing", next.posted[0].Status)
+	assert.Len(t, next.posted[0].Alerts, 2)
+	assert.Equal(t, template.KV{"alertname": "HighLoad", "severity": "warning"}, next.posted[0].CommonLabels)
+	assert.Equal(t, `a:{severity="critical"}`, next.posted[1].GroupKey)
+	assert.Equal(t, "resolved", next.posted[1].Status)
+
+	// A notification with a single group is posted as is.
+	_, err = s.Post(context.Background(), alertsMessage("b", alert("4", "firing", "info")))
+	require.NoError(t, err)
+	assert.Equal(t, "b", next.posted[2].GroupKey)
+
+	env.Decode = yamlConfig(t, "{}")
+	m, err = newSplitMiddleware(env)
+	require.NoError(t, err)
+	_, err = m(next).Post(context.Background(), alertsMessage("c", alert("5", "firing", "info"), alert("6", "firing", "info")))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c:5", "c:6"}, []string{next.posted[3].GroupKey, next.posted[4].GroupKey})
+}
+
+// failingService fails its Post calls with err.
+type failingService struct {
+	err error
+}
+
+func (s failingService) Post(context.Context, webhook.Message) ([]PostResponse, error) {
+	return []PostResponse{{Status: 500}}, s.err
+}
+
+func TestFanoutService(t *testing.T) {
+	next := &digestRecorder{}
+	extra := &digestRecorder{}
+	errFailed := errors.New("failed")
+	env := middlewareEnv()
+	env.Decode = yamlConfig(t, "webhook_urls: [https://a, https://b]")
+	env.Webhook = func(u string) (Service, error) {
+		if u == "https://b" {
+			return failingService{err: errFailed}, nil
+		}
+		return extra, nil
+	}
+	m, err := newFanoutMiddleware(env)
+	require.NoError(t, err)
+
+	prs, err := m(next).Post(context.Background(), alertsMessage("a"))
+	assert.ErrorIs(t, err, errFailed)
+	assert.Len(t, prs, 1)
+	assert.Equal(t, 1, next.len())
+	assert.Equal(t, 1, extra.len())
+
+	env.Decode = yamlConfig(t, "{}")
+	_, err = newFanoutMiddleware(env)
+	assert.Error(t, err)
+
+	env.Decode = yamlConfig(t, "webhook_urls: [https://a]")
+	env.Webhook = nil
+	_, err = newFanoutMiddleware(env)
+	assert.Error(t, err)
+}
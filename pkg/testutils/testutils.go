@@ -15,15 +15,27 @@ limitations under the License.
 */
 
 // Package testutils provides utility functions for testing, such as comparing
-// JSON output to golden files and parsing test data from JSON files.
+// JSON output to golden files, parsing test data from JSON files and
+// recording spans and metrics.
 package testutils
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
 
 	"github.com/prometheus/alertmanager/notify/webhook"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 // GetTestDataFilePath returns the path to a test data file given its filename.
@@ -44,3 +56,86 @@ func ParseWebhookJSONFromFile(f string) (webhook.Message, error) {
 	}
 	return w, nil
 }
+
+// RecordSpans installs a global tracer provider exporting the ended spans to
+// the returned in-memory exporter, and the W3C trace context propagator. Both
+// are restored when the test ends.
+func RecordSpans(t testing.TB) *tracetest.InMemoryExporter {
+	t.Helper()
+	tp, prop := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(prop)
+	})
+	return exporter
+}
+
+// SpanNames returns the names of the spans in the order they ended.
+func SpanNames(spans tracetest.SpanStubs) []string {
+	names := make([]string, 0, len(spans))
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+// CollectMetrics reads the metrics of r and returns the value of each data
+// point, keyed by metric name and then by the data point attributes formatted
+// as sorted "key=value" pairs joined by ",". Histograms report their count.
+func CollectMetrics(t testing.TB, r sdkmetric.Reader) map[string]map[string]float64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := r.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]map[string]float64{}
+	add := func(name string, attrs attribute.Set, v float64) {
+		if out[name] == nil {
+			out[name] = map[string]float64{}
+		}
+		out[name][formatAttributes(attrs)] += v
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, float64(dp.Value))
+				}
+			case metricdata.Sum[float64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, dp.Value)
+				}
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, float64(dp.Value))
+				}
+			case metricdata.Gauge[float64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, dp.Value)
+				}
+			case metricdata.Histogram[int64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, float64(dp.Count))
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					add(m.Name, dp.Attributes, float64(dp.Count))
+				}
+			}
+		}
+	}
+	return out
+}
+
+func formatAttributes(set attribute.Set) string {
+	pairs := make([]string, 0, set.Len())
+	for _, kv := range set.ToSlice() {
+		pairs = append(pairs, string(kv.Key)+"="+kv.Value.Emit())
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
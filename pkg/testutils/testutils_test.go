@@ -16,6 +16,7 @@ limitations under the License.
 package testutils
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -24,6 +25,11 @@ import (
 	"github.com/prometheus/alertmanager/notify/webhook"
 	"github.com/prometheus/alertmanager/template"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 )
 
 func TestGetTestDataFilePath(t *testing.T) {
@@ -120,3 +126,39 @@ func TestGetTestDataFilePath_EmptyFilename(t *testing.T) {
 	expected := filepath.Join("..", "..", "test", "data", "")
 	assert.Equal(t, expected, result)
 }
+
+func TestRecordSpans(t *testing.T) {
+	exporter := RecordSpans(t)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "first")
+	span.End()
+	_, span = otel.Tracer("test").Start(context.Background(), "second")
+	span.End()
+
+	assert.Equal(t, []string{"first", "second"}, SpanNames(exporter.GetSpans()))
+}
+
+func TestCollectMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	counter, err := meter.Int64Counter("requests")
+	require.NoError(t, err)
+	histogram, err := meter.Float64Histogram("duration")
+	require.NoError(t, err)
+	gauge, err := meter.Int64Gauge("depth")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	counter.Add(ctx, 2, metric.WithAttributes(attribute.String("route", "/a"), attribute.String("code", "2xx")))
+	counter.Add(ctx, 1, metric.WithAttributes(attribute.String("route", "/b")))
+	histogram.Record(ctx, 0.5)
+	histogram.Record(ctx, 1.5)
+	gauge.Record(ctx, 3)
+
+	assert.Equal(t, map[string]map[string]float64{
+		"requests": {"code=2xx,route=/a": 2, "route=/b": 1},
+		"duration": {"": 2},
+		"depth":    {"": 3},
+	}, CollectMetrics(t, reader))
+}
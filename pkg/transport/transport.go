@@ -18,7 +18,6 @@ limitations under the License.
 package transport
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,24 +25,35 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
-	"github.com/prometheus/alertmanager/notify/webhook"
-	"go.opencensus.io/plugin/ochttp"
-	"go.opencensus.io/trace"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/labstack/echo/v5"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/input"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
 	"github.com/stakater/prometheus-msteams/pkg/service"
 )
 
 // Route holds the Service implementation and the Request path to serve the Service.
+// With a Queue, the Service posts the notifications asynchronously. The
+// Decoder decodes the requests, Alertmanager webhook messages if nil.
 type Route struct {
 	Service     service.Service
 	RequestPath string
+	Queue       *service.Queue
+	Decoder     input.Decoder
 }
 
 // DynamicRoute holds the Request path to generate the service based on request (e.g. path)
+// With a Queue, the services post the notifications asynchronously.
 type DynamicRoute struct {
 	ServiceGenerator ServiceGenerator
 	RequestPath      string
+	Queue            *service.Queue
 }
 
 // ServiceGenerator creates a service on data from request (echo.Context)
@@ -54,23 +64,31 @@ func NewServer(logger log.Logger, routes []Route, dRoutes []DynamicRoute) *echo.
 	e := echo.New()
 	for _, r := range routes {
 		_ = level.Debug(logger).Log("request_path_added", r.RequestPath)
-		addRoute(e, r.RequestPath, r.Service, logger)
+		addRoute(e, r.RequestPath, r.Service, r.Queue, r.Decoder, logger)
 	}
 	for _, r := range dRoutes {
 		_ = level.Debug(logger).Log("request_path_added", r.RequestPath)
-		addContextAwareRoute(e, r.RequestPath, r.ServiceGenerator, logger)
+		addContextAwareRoute(e, r.RequestPath, r.ServiceGenerator, r.Queue, logger)
 	}
 	return e
 }
 
-func opencensusMiddleware() echo.MiddlewareFunc {
-	return echo.WrapMiddleware(func(h http.Handler) http.Handler {
-		return &ochttp.Handler{
-			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				h.ServeHTTP(w, r)
-			}),
-		}
-	})
+// tracerName is the instrumentation scope of the spans started by this package.
+const tracerName = "github.com/stakater/prometheus-msteams/pkg/transport"
+
+// otelMiddleware records the HTTP server span and metrics of a route. The
+// W3C trace context sent by Alertmanager, if any, becomes the parent span.
+func otelMiddleware() echo.MiddlewareFunc {
+	return echo.WrapMiddleware(otelhttp.NewMiddleware("alertmanager",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + r.Pattern
+		}),
+		// Label metrics with the route pattern rather than the path, which
+		// holds the webhook URL on /_dynamicwebhook/.
+		otelhttp.WithMetricAttributesFn(func(r *http.Request) []attribute.KeyValue {
+			return []attribute.KeyValue{attribute.String("http.route", r.Pattern)}
+		}),
+	))
 }
 
 func kitLoggerMiddleware(logger log.Logger) echo.MiddlewareFunc {
@@ -96,16 +114,19 @@ func kitLoggerMiddleware(logger log.Logger) echo.MiddlewareFunc {
 	}
 }
 
-func addRoute(e *echo.Echo, p string, s service.Service, logger log.Logger) {
+func addRoute(e *echo.Echo, p string, s service.Service, q *service.Queue, d input.Decoder, logger log.Logger) {
+	if d == nil {
+		d = input.Alertmanager{}
+	}
 	e.POST(p, func(c *echo.Context) error {
-		return handleRoute(c, s, logger)
+		return handleRoute(c, s, q, d, logger)
 	},
 		kitLoggerMiddleware(logger),
-		opencensusMiddleware(),
+		otelMiddleware(),
 	)
 }
 
-func addContextAwareRoute(e *echo.Echo, p string, w ServiceGenerator, logger log.Logger) {
+func addContextAwareRoute(e *echo.Echo, p string, w ServiceGenerator, q *service.Queue, logger log.Logger) {
 	e.POST(p, func(c *echo.Context) error {
 		s, err := w(c)
 		if err != nil {
@@ -114,46 +135,59 @@ func addContextAwareRoute(e *echo.Echo, p string, w ServiceGenerator, logger log
 		if s == nil {
 			return fmt.Errorf("invalid request. No service was returned")
 		}
-		return handleRoute(c, s, logger)
+		return handleRoute(c, s, q, input.Alertmanager{}, logger)
 	},
 		kitLoggerMiddleware(logger),
-		opencensusMiddleware(),
+		otelMiddleware(),
 	)
 }
 
-func handleRoute(c *echo.Context, s service.Service, logger log.Logger) error {
-	ctx, span := trace.StartSpan(c.Request().Context(), "alertmanager-handler")
+// handleRoute posts the notification of the request, decoded with d, with s,
+// or enqueues it in q if not nil and answers 202, or 503 if q is full.
+func handleRoute(c *echo.Context, s service.Service, q *service.Queue, d input.Decoder, logger log.Logger) error {
+	ctx, span := otel.Tracer(tracerName).Start(c.Request().Context(), "alertmanager-handler")
 	defer span.End()
+	ctx = metrics.ContextWithRoute(ctx, c.Path())
 
 	b, err := io.ReadAll(c.Request().Body)
 	if err != nil {
 		_ = logger.Log("err", err)
-		span.SetStatus(trace.Status{Code: 500, Message: err.Error()})
+		setSpanError(span, err)
 		return c.String(500, err.Error())
 	}
 
-	span.AddAttributes(trace.StringAttribute("alert", string(b)))
+	span.SetAttributes(attribute.String("alert", string(b)))
 
-	var wm webhook.Message
-	if err := json.Unmarshal(b, &wm); err != nil {
+	wm, extras, err := d.Decode(b)
+	if err != nil {
 		_ = logger.Log("err", err)
-		span.SetStatus(trace.Status{Code: 500, Message: err.Error()})
+		setSpanError(span, err)
 		return c.String(500, err.Error())
 	}
+	if extras != nil {
+		ctx = card.ContextWithExtras(ctx, extras)
+	}
 
-	if wm.Data == nil || wm.Version == "" || wm.GroupKey == "" {
-		err = fmt.Errorf("the webhook message does not seem to be a valid Prometheus Alertmanager webhook. More information see https://prometheus.io/docs/alerting/latest/configuration/#webhook_config")
-		_ = logger.Log("err", err)
-		span.SetStatus(trace.Status{Code: 500, Message: err.Error()})
-		return c.String(500, err.Error())
+	if q != nil {
+		if err := q.Enqueue(ctx, s, wm); err != nil {
+			_ = logger.Log("err", err)
+			setSpanError(span, err)
+			return c.String(http.StatusServiceUnavailable, err.Error())
+		}
+		return c.NoContent(http.StatusAccepted)
 	}
 
 	prs, err := s.Post(ctx, wm)
 	if err != nil {
 		_ = logger.Log("err", err)
-		span.SetStatus(trace.Status{Code: 500, Message: err.Error()})
+		setSpanError(span, err)
 		return c.String(500, err.Error())
 	}
 
 	return c.JSON(200, prs)
 }
+
+func setSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
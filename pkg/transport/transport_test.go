@@ -23,15 +23,22 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/go-kit/log"
 	"github.com/labstack/echo/v5"
 	"github.com/prometheus/alertmanager/notify/webhook"
 	"github.com/prometheus/alertmanager/template"
+	"github.com/stakater/prometheus-msteams/pkg/card"
+	"github.com/stakater/prometheus-msteams/pkg/input"
+	"github.com/stakater/prometheus-msteams/pkg/metrics"
 	"github.com/stakater/prometheus-msteams/pkg/service"
 	"github.com/stakater/prometheus-msteams/pkg/testutils"
+	"github.com/stakater/prometheus-msteams/pkg/utility"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // mockService is a mock implementation of service.Service
@@ -91,7 +98,7 @@ func TestAddRoute_Success(t *testing.T) {
 	}
 
 	e := echo.New()
-	addRoute(e, "/test", mockSvc, logger)
+	addRoute(e, "/test", mockSvc, nil, nil, logger)
 
 	// Create a valid Prometheus AlertManager webhook message
 	wm := webhook.Message{
@@ -116,7 +123,7 @@ func TestAddRoute_InvalidJSON(t *testing.T) {
 	mockSvc := mockService{}
 
 	e := echo.New()
-	addRoute(e, "/test", mockSvc, logger)
+	addRoute(e, "/test", mockSvc, nil, nil, logger)
 
 	// Send invalid JSON
 	req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader([]byte("invalid json")))
@@ -134,7 +141,7 @@ func TestAddRoute_InvalidWebhookMessage(t *testing.T) {
 	mockSvc := mockService{}
 
 	e := echo.New()
-	addRoute(e, "/test", mockSvc, logger)
+	addRoute(e, "/test", mockSvc, nil, nil, logger)
 
 	// Create an invalid webhook message (missing required fields)
 	wm := webhook.Message{
@@ -161,7 +168,7 @@ func TestAddRoute_ServiceError(t *testing.T) {
 	}
 
 	e := echo.New()
-	addRoute(e, "/test", mockSvc, logger)
+	addRoute(e, "/test", mockSvc, nil, nil, logger)
 
 	wm := webhook.Message{
 		Data:     &template.Data{},
@@ -215,7 +222,7 @@ func TestTransport_Payload(t *testing.T) {
 			}
 
 			e := echo.New()
-			addRoute(e, "/test", mockSvc, logger)
+			addRoute(e, "/test", mockSvc, nil, nil, logger)
 
 			// Create a valid Prometheus AlertManager webhook message
 			promAlertFile := testutils.GetTestDataFilePath(tt.promAlertFile)
@@ -252,7 +259,7 @@ func TestAddContextAwareRoute_Success(t *testing.T) {
 	}
 
 	e := echo.New()
-	addContextAwareRoute(e, "/dynamic", generator, logger)
+	addContextAwareRoute(e, "/dynamic", generator, nil, logger)
 
 	wm := webhook.Message{
 		Version:  "4",
@@ -278,7 +285,7 @@ func TestAddContextAwareRoute_GeneratorError(t *testing.T) {
 	}
 
 	e := echo.New()
-	addContextAwareRoute(e, "/dynamic", generator, logger)
+	addContextAwareRoute(e, "/dynamic", generator, nil, logger)
 
 	wm := webhook.Message{
 		Version:  "4",
@@ -304,7 +311,7 @@ func TestAddContextAwareRoute_NilService(t *testing.T) {
 	}
 
 	e := echo.New()
-	addContextAwareRoute(e, "/dynamic", generator, logger)
+	addContextAwareRoute(e, "/dynamic", generator, nil, logger)
 
 	wm := webhook.Message{
 		Version:  "4",
@@ -361,8 +368,8 @@ func TestKitLoggerMiddleware(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func TestOpencensusMiddleware(t *testing.T) {
-	middleware := opencensusMiddleware()
+func TestOtelMiddleware(t *testing.T) {
+	middleware := otelMiddleware()
 	assert.NotNil(t, middleware)
 
 	e := echo.New()
@@ -378,6 +385,44 @@ func TestOpencensusMiddleware(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestHandleRoute_TraceContext(t *testing.T) {
+	exporter := testutils.RecordSpans(t)
+
+	e := echo.New()
+	addRoute(e, "/alertmanager", mockService{}, nil, nil, log.NewNopLogger())
+
+	body, _ := json.Marshal(webhook.Message{Data: &template.Data{}, Version: "4", GroupKey: "g"})
+	req := httptest.NewRequest(http.MethodPost, "/alertmanager", bytes.NewReader(body))
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	spans := exporter.GetSpans()
+	require.Equal(t, []string{"alertmanager-handler", "POST /alertmanager"}, testutils.SpanNames(spans))
+	handler, server := spans[0], spans[1]
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.Equal(t, server.SpanContext.SpanID(), handler.Parent.SpanID())
+}
+
+func TestHandleRoute_SpanError(t *testing.T) {
+	exporter := testutils.RecordSpans(t)
+
+	e := echo.New()
+	addRoute(e, "/alertmanager", mockService{err: errors.New("teams is down")}, nil, nil, log.NewNopLogger())
+
+	body, _ := json.Marshal(webhook.Message{Data: &template.Data{}, Version: "4", GroupKey: "g"})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/alertmanager", bytes.NewReader(body)))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	span := exporter.GetSpans()[0]
+	assert.Equal(t, "alertmanager-handler", span.Name)
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Equal(t, "teams is down", span.Status.Description)
+}
+
 func TestRoute_CompleteWebhookValidation(t *testing.T) {
 	logger := log.NewNopLogger()
 
@@ -429,7 +474,7 @@ func TestRoute_CompleteWebhookValidation(t *testing.T) {
 			}
 
 			e := echo.New()
-			addRoute(e, "/test", mockSvc, logger)
+			addRoute(e, "/test", mockSvc, nil, nil, logger)
 
 			body, _ := json.Marshal(tt.message)
 			req := httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(body))
@@ -446,3 +491,99 @@ func TestRoute_CompleteWebhookValidation(t *testing.T) {
 		})
 	}
 }
+
+// routeService records the route found in the context of Post.
+type routeService struct {
+	route *string
+}
+
+func (s routeService) Post(ctx context.Context, _ webhook.Message) ([]service.PostResponse, error) {
+	*s.route = metrics.RouteFromContext(ctx)
+	return nil, nil
+}
+
+func TestHandleRoute_MetricsRoute(t *testing.T) {
+	var route string
+	e := NewServer(log.NewNopLogger(), nil, []DynamicRoute{{
+		RequestPath: "/_dynamicwebhook/*",
+		ServiceGenerator: func(_ *echo.Context) (service.Service, error) {
+			return routeService{&route}, nil
+		},
+	}})
+
+	body, _ := json.Marshal(webhook.Message{Data: &template.Data{}, Version: "4", GroupKey: "g"})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/_dynamicwebhook/example.webhook.office.com/webhookb2/secret", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// The route pattern is used, never the webhook URL in the path.
+	assert.Equal(t, "/_dynamicwebhook/*", route)
+}
+
+// postedService sends the route of each notification posted to it.
+type postedService struct {
+	posted chan string
+	block  chan struct{}
+}
+
+func (s postedService) Post(ctx context.Context, _ webhook.Message) ([]service.PostResponse, error) {
+	s.posted <- metrics.RouteFromContext(ctx)
+	<-s.block
+	return nil, nil
+}
+
+func TestHandleRoute_Queue(t *testing.T) {
+	q, err := service.NewQueue(context.Background(), "/_dynamicwebhook/*", 1, 1, utility.NewLogger(utility.LogFormatFmt, false))
+	require.NoError(t, err)
+	svc := postedService{posted: make(chan string, 1), block: make(chan struct{})}
+	defer close(svc.block)
+	e := NewServer(log.NewNopLogger(), nil, []DynamicRoute{{
+		RequestPath: "/_dynamicwebhook/*",
+		ServiceGenerator: func(_ *echo.Context) (service.Service, error) {
+			return svc, nil
+		},
+		Queue: q,
+	}})
+	post := func() int {
+		body, _ := json.Marshal(webhook.Message{Data: &template.Data{}, Version: "4", GroupKey: "g"})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/_dynamicwebhook/example.webhook.office.com/webhookb2/secret", bytes.NewReader(body)))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, post())
+	assert.Equal(t, "/_dynamicwebhook/*", <-svc.posted)
+	// The first notification is being posted, the second waits in the queue.
+	assert.Equal(t, http.StatusAccepted, post())
+	assert.Equal(t, http.StatusServiceUnavailable, post())
+}
+
+type captureService struct {
+	ctx *context.Context
+	wm  *webhook.Message
+}
+
+func (s captureService) Post(ctx context.Context, wm webhook.Message) ([]service.PostResponse, error) {
+	*s.ctx, *s.wm = ctx, wm
+	return nil, nil
+}
+
+func TestHandleRoute_Decoder(t *testing.T) {
+	var ctx context.Context
+	var wm webhook.Message
+	e := echo.New()
+	addRoute(e, "/grafana", captureService{ctx: &ctx, wm: &wm}, nil, input.Grafana{}, log.NewNopLogger())
+
+	body := `{"version": "1", "groupKey": "g", "status": "firing", "title": "[FIRING:1] HighCPU",
+		"alerts": [{"status": "firing", "labels": {"alertname": "HighCPU"}, "fingerprint": "abc", "imageURL": "https://grafana/image.png"}]}`
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/grafana", strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	require.Len(t, wm.Alerts, 1)
+	assert.Equal(t, "HighCPU", wm.Alerts[0].Labels["alertname"])
+	extras := card.ExtrasFromContext(ctx)
+	require.NotNil(t, extras)
+	assert.Equal(t, "[FIRING:1] HighCPU", extras.Title)
+	assert.Equal(t, "https://grafana/image.png", extras.Alerts["abc"].ImageURL)
+}
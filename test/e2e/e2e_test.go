@@ -26,7 +26,6 @@ import (
 
 	. "github.com/onsi/ginkgo/v2" //nolint:revive
 	. "github.com/onsi/gomega"    //nolint:revive
-	"github.com/prometheus/alertmanager/template"
 
 	"github.com/stakater/prometheus-msteams/pkg/card"
 	"github.com/stakater/prometheus-msteams/pkg/service"
@@ -89,7 +88,7 @@ var _ = Describe("Server E2E Tests", func() {
 	Context("Templated Card Service", func() {
 		var (
 			templatePath = "../../default-message-workflow-card.tmpl"
-			tmpl         *template.Template
+			tmpl         *card.Template
 			cardCreator  card.Converter
 
 			routes []transport.Route